@@ -0,0 +1,264 @@
+package rbac
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidAccessRequest  = errors.New("invalid access request")
+	ErrAccessRequestNotFound = errors.New("access request not found")
+	ErrAccessRequestClosed   = errors.New("access request already decided")
+
+	_ AccessRequestStore = (*MemoryAccessRequestStore)(nil)
+)
+
+const (
+	ActionApproveRole       = "approve:role:"
+	ActionApprovePermission = "approve:permission:"
+)
+
+type AccessRequestStatus string
+
+const (
+	AccessRequestPending  AccessRequestStatus = "pending"
+	AccessRequestApproved AccessRequestStatus = "approved"
+	AccessRequestDenied   AccessRequestStatus = "denied"
+)
+
+// AccessRequest asks for either a role or a single permission for a limited
+// duration.
+type AccessRequest struct {
+	ID         string              `json:"id"`
+	Subject    string              `json:"subject"`
+	Role       string              `json:"role,omitempty"`
+	Permission string              `json:"permission,omitempty"`
+	Reason     string              `json:"reason,omitempty"`
+	Duration   time.Duration       `json:"duration"`
+	Status     AccessRequestStatus `json:"status"`
+	CreatedAt  time.Time           `json:"createdAt"`
+	DecidedAt  time.Time           `json:"decidedAt,omitzero"`
+	DecidedBy  string              `json:"decidedBy,omitempty"`
+	Note       string              `json:"note,omitempty"`
+	// ValidUntil is when the granted access expires.
+	ValidUntil time.Time `json:"validUntil,omitzero"`
+}
+
+// Action is the action an approver has to be authorized for. It is checked
+// with Target.Anchored, so a grant for "approve:role:admin" does not cover
+// "approve:role:admin-super".
+func (r AccessRequest) Action() string {
+	if r.Role != "" {
+		return ActionApproveRole + r.Role
+	}
+	return ActionApprovePermission + r.Permission
+}
+
+type AccessRequestStore interface {
+	Create(ctx context.Context, req AccessRequest) error
+	Get(ctx context.Context, id string) (AccessRequest, error)
+	// Update stores req only if the stored request is still in status from,
+	// failing with ErrAccessRequestClosed otherwise, so that concurrent
+	// decisions cannot both succeed.
+	Update(ctx context.Context, req AccessRequest, from AccessRequestStatus) error
+	Pending(ctx context.Context) ([]AccessRequest, error)
+}
+
+// AccessGranter turns an approved request into a time-bounded assignment.
+type AccessGranter interface {
+	Grant(ctx context.Context, req AccessRequest) error
+}
+
+type AccessGranterFunc func(ctx context.Context, req AccessRequest) error
+
+func (f AccessGranterFunc) Grant(ctx context.Context, req AccessRequest) error {
+	return f(ctx, req)
+}
+
+// AccessWorkflow lets subjects request access for themselves that approvers,
+// authorized for the request's Action, approve or deny. Subjects cannot
+// decide their own requests.
+type AccessWorkflow struct {
+	authorizer  Authorizer
+	store       AccessRequestStore
+	granter     AccessGranter
+	maxDuration time.Duration
+	onChange    func(ctx context.Context, req AccessRequest)
+	now         func() time.Time
+}
+
+func NewAccessWorkflow(authorizer Authorizer, store AccessRequestStore, granter AccessGranter) *AccessWorkflow {
+	return &AccessWorkflow{authorizer: authorizer, store: store, granter: granter, now: time.Now}
+}
+
+// SetMaxDuration caps the requested duration; zero means no cap.
+func (w *AccessWorkflow) SetMaxDuration(d time.Duration) *AccessWorkflow {
+	w.maxDuration = d
+	return w
+}
+
+// OnChange registers a notification hook called after a request is created,
+// approved or denied.
+func (w *AccessWorkflow) OnChange(fn func(ctx context.Context, req AccessRequest)) *AccessWorkflow {
+	w.onChange = fn
+	return w
+}
+
+// Request files req for the requester, whose subject req.Subject defaults
+// to and must match.
+func (w *AccessWorkflow) Request(ctx context.Context, requester *Claims, req AccessRequest) (AccessRequest, error) {
+	if requester == nil || requester.Subject == nil || SubjectID(requester.Subject) == "" {
+		return req, NewAuthzError(AuthzUnauthenticated, req.Action())
+	}
+	if req.Subject == "" {
+		req.Subject = SubjectID(requester.Subject)
+	}
+	if req.Subject != SubjectID(requester.Subject) {
+		return req, NewAuthzError(AuthzForbidden, req.Action())
+	}
+
+	switch {
+	case (req.Role == "") == (req.Permission == ""):
+		return req, fmt.Errorf("%w: exactly one of role and permission is required", ErrInvalidAccessRequest)
+	case req.Duration <= 0:
+		return req, fmt.Errorf("%w: duration must be positive", ErrInvalidAccessRequest)
+	case w.maxDuration > 0 && req.Duration > w.maxDuration:
+		return req, fmt.Errorf("%w: duration exceeds %s", ErrInvalidAccessRequest, w.maxDuration)
+	}
+
+	req.ID = rand.Text()
+	req.Status = AccessRequestPending
+	req.CreatedAt = w.now()
+	req.DecidedAt, req.DecidedBy, req.Note, req.ValidUntil = time.Time{}, "", "", time.Time{}
+
+	if err := w.store.Create(ctx, req); err != nil {
+		return req, err
+	}
+	w.notify(ctx, req)
+	return req, nil
+}
+
+func (w *AccessWorkflow) Approve(ctx context.Context, approver *Claims, id, note string) (AccessRequest, error) {
+	return w.decide(ctx, approver, id, note, AccessRequestApproved)
+}
+
+func (w *AccessWorkflow) Deny(ctx context.Context, approver *Claims, id, note string) (AccessRequest, error) {
+	return w.decide(ctx, approver, id, note, AccessRequestDenied)
+}
+
+func (w *AccessWorkflow) decide(ctx context.Context, approver *Claims, id, note string, status AccessRequestStatus) (AccessRequest, error) {
+	req, err := w.store.Get(ctx, id)
+	if err != nil {
+		return req, err
+	}
+	if req.Status != AccessRequestPending {
+		return req, fmt.Errorf(`%w: "%s" is %s`, ErrAccessRequestClosed, id, req.Status)
+	}
+
+	if approver == nil || approver.Subject == nil {
+		return req, NewAuthzError(AuthzUnauthenticated, req.Action())
+	}
+	by := SubjectID(approver.Subject)
+	if by == "" || by == req.Subject {
+		return req, NewAuthzError(AuthzForbidden, req.Action())
+	}
+	if d := w.authorizer.Authorize(ctx, approver, &Target{Action: req.Action(), Anchored: true}); !d.Allowed() {
+		return req, NewAuthzError(AuthzForbidden, req.Action())
+	}
+
+	pending := req
+	req.Status, req.DecidedBy, req.Note = status, by, note
+	req.DecidedAt = w.now()
+	if status == AccessRequestApproved {
+		req.ValidUntil = req.DecidedAt.Add(req.Duration)
+	}
+	if err = w.store.Update(ctx, req, AccessRequestPending); err != nil {
+		return pending, err
+	}
+
+	if status == AccessRequestApproved {
+		if err = w.granter.Grant(ctx, req); err != nil {
+			// reopen the request so that it can be approved again
+			return pending, errors.Join(err, w.store.Update(ctx, pending, AccessRequestApproved))
+		}
+	}
+	w.notify(ctx, req)
+	return req, nil
+}
+
+func (w *AccessWorkflow) Pending(ctx context.Context) ([]AccessRequest, error) {
+	return w.store.Pending(ctx)
+}
+
+func (w *AccessWorkflow) notify(ctx context.Context, req AccessRequest) {
+	if w.onChange != nil {
+		w.onChange(ctx, req)
+	}
+}
+
+type MemoryAccessRequestStore struct {
+	mu       sync.RWMutex
+	requests map[string]AccessRequest
+}
+
+func NewMemoryAccessRequestStore() *MemoryAccessRequestStore {
+	return &MemoryAccessRequestStore{requests: map[string]AccessRequest{}}
+}
+
+func (s *MemoryAccessRequestStore) Create(_ context.Context, req AccessRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[req.ID] = req
+	return nil
+}
+
+func (s *MemoryAccessRequestStore) Get(_ context.Context, id string) (AccessRequest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	req, ok := s.requests[id]
+	if !ok {
+		return req, fmt.Errorf(`%w: "%s"`, ErrAccessRequestNotFound, id)
+	}
+	return req, nil
+}
+
+func (s *MemoryAccessRequestStore) Update(_ context.Context, req AccessRequest, from AccessRequestStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.requests[req.ID]
+	if !ok {
+		return fmt.Errorf(`%w: "%s"`, ErrAccessRequestNotFound, req.ID)
+	}
+	if current.Status != from {
+		return fmt.Errorf(`%w: "%s" is %s`, ErrAccessRequestClosed, req.ID, current.Status)
+	}
+	s.requests[req.ID] = req
+	return nil
+}
+
+func (s *MemoryAccessRequestStore) Pending(_ context.Context) ([]AccessRequest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var pending []AccessRequest
+	for _, req := range s.requests {
+		if req.Status == AccessRequestPending {
+			pending = append(pending, req)
+		}
+	}
+	slices.SortFunc(pending, func(a, b AccessRequest) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return pending, nil
+}
@@ -0,0 +1,183 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAccessWorkflow(t *testing.T, granted *[]AccessRequest) *AccessWorkflow {
+	rbac := New()
+	approver := NewRole("approver")
+	require.NoError(t, approver.AddPermissionsE("approve:role:.*"))
+	require.NoError(t, rbac.AddRole(approver))
+	require.NoError(t, rbac.AddRole("user"))
+
+	return NewAccessWorkflow(NewDefaultAuthorizer(rbac), NewMemoryAccessRequestStore(), AccessGranterFunc(func(_ context.Context, req AccessRequest) error {
+		*granted = append(*granted, req)
+		return nil
+	}))
+}
+
+func TestAccessWorkflow_Approve(t *testing.T) {
+	ctx := context.Background()
+	var granted, notified []AccessRequest
+	w := newAccessWorkflow(t, &granted).OnChange(func(_ context.Context, req AccessRequest) {
+		notified = append(notified, req)
+	})
+	u1 := &Claims{Subject: NewSubject("u1", "user")}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return now }
+
+	req, err := w.Request(ctx, u1, AccessRequest{Role: "oncall", Reason: "incident", Duration: time.Hour})
+	require.NoError(t, err)
+	assert.NotEmpty(t, req.ID)
+	assert.Equal(t, AccessRequestPending, req.Status)
+	assert.Equal(t, "approve:role:oncall", req.Action())
+
+	pending, err := w.Pending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []AccessRequest{req}, pending)
+
+	_, err = w.Approve(ctx, &Claims{Subject: NewSubject("u2", "user")}, req.ID, "")
+	assert.ErrorIs(t, err, ErrDeny)
+	_, err = w.Approve(ctx, &Claims{Subject: NewSubject("u1", "approver")}, req.ID, "")
+	assert.ErrorIs(t, err, ErrDeny)
+	_, err = w.Approve(ctx, nil, req.ID, "")
+	var authzErr *AuthzError
+	require.ErrorAs(t, err, &authzErr)
+	assert.Equal(t, AuthzUnauthenticated, authzErr.Kind)
+	assert.Empty(t, granted)
+
+	req, err = w.Approve(ctx, &Claims{Subject: NewSubject("boss", "approver")}, req.ID, "ok")
+	require.NoError(t, err)
+	assert.Equal(t, AccessRequestApproved, req.Status)
+	assert.Equal(t, "boss", req.DecidedBy)
+	assert.Equal(t, now.Add(time.Hour), req.ValidUntil)
+	assert.Equal(t, []AccessRequest{req}, granted)
+	assert.Len(t, notified, 2)
+
+	_, err = w.Deny(ctx, &Claims{Subject: NewSubject("boss", "approver")}, req.ID, "")
+	assert.ErrorIs(t, err, ErrAccessRequestClosed)
+
+	pending, err = w.Pending(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestAccessWorkflow_ApproveAnchored(t *testing.T) {
+	ctx := context.Background()
+	rbac := New()
+	approver := NewRole("admin-approver")
+	require.NoError(t, approver.AddPermissionsE("approve:role:admin"))
+	require.NoError(t, rbac.AddRole(approver))
+	require.NoError(t, rbac.AddRole("user"))
+
+	var granted []AccessRequest
+	w := NewAccessWorkflow(NewDefaultAuthorizer(rbac), NewMemoryAccessRequestStore(), AccessGranterFunc(func(_ context.Context, req AccessRequest) error {
+		granted = append(granted, req)
+		return nil
+	}))
+
+	super, err := w.Request(ctx, &Claims{Subject: NewSubject("u1", "user")}, AccessRequest{Role: "admin-super", Duration: time.Hour})
+	require.NoError(t, err)
+	_, err = w.Approve(ctx, &Claims{Subject: NewSubject("boss", "admin-approver")}, super.ID, "")
+	assert.ErrorIs(t, err, ErrDeny)
+	assert.Empty(t, granted)
+
+	admin, err := w.Request(ctx, &Claims{Subject: NewSubject("u1", "user")}, AccessRequest{Role: "admin", Duration: time.Hour})
+	require.NoError(t, err)
+	_, err = w.Approve(ctx, &Claims{Subject: NewSubject("boss", "admin-approver")}, admin.ID, "")
+	require.NoError(t, err)
+	assert.Len(t, granted, 1)
+}
+
+func TestAccessWorkflow_Deny(t *testing.T) {
+	ctx := context.Background()
+	var granted []AccessRequest
+	w := newAccessWorkflow(t, &granted)
+	u1 := &Claims{Subject: NewSubject("u1", "user")}
+
+	req, err := w.Request(ctx, u1, AccessRequest{Subject: "u1", Role: "admin", Duration: time.Hour})
+	require.NoError(t, err)
+
+	req, err = w.Deny(ctx, &Claims{Subject: NewSubject("boss", "approver")}, req.ID, "no")
+	require.NoError(t, err)
+	assert.Equal(t, AccessRequestDenied, req.Status)
+	assert.True(t, req.ValidUntil.IsZero())
+	assert.Empty(t, granted)
+
+	_, err = w.Deny(ctx, &Claims{Subject: NewSubject("boss", "approver")}, "missing", "")
+	assert.ErrorIs(t, err, ErrAccessRequestNotFound)
+}
+
+func TestAccessWorkflow_Request(t *testing.T) {
+	var granted []AccessRequest
+	w := newAccessWorkflow(t, &granted).SetMaxDuration(8 * time.Hour)
+
+	u1 := &Claims{Subject: NewSubject("u1", "user")}
+
+	_, err := w.Request(context.Background(), nil, AccessRequest{Role: "admin", Duration: time.Hour})
+	var authzErr *AuthzError
+	require.ErrorAs(t, err, &authzErr)
+	assert.Equal(t, AuthzUnauthenticated, authzErr.Kind)
+	_, err = w.Request(context.Background(), u1, AccessRequest{Subject: "u2", Role: "admin", Duration: time.Hour})
+	require.ErrorAs(t, err, &authzErr)
+	assert.Equal(t, AuthzForbidden, authzErr.Kind)
+
+	for _, req := range []AccessRequest{
+		{Subject: "u1", Duration: time.Hour},
+		{Subject: "u1", Role: "admin", Permission: "read", Duration: time.Hour},
+		{Subject: "u1", Role: "admin"},
+		{Subject: "u1", Role: "admin", Duration: 24 * time.Hour},
+	} {
+		_, err := w.Request(context.Background(), u1, req)
+		assert.ErrorIs(t, err, ErrInvalidAccessRequest)
+	}
+
+	req, err := w.Request(context.Background(), u1, AccessRequest{Permission: "reports:export", Duration: time.Hour})
+	require.NoError(t, err)
+	assert.Equal(t, "approve:permission:reports:export", req.Action())
+
+	w.granter = AccessGranterFunc(func(context.Context, AccessRequest) error { return errors.New("store down") })
+	w.authorizer = &mockAuthorizer{decision: DecisionAllow}
+	_, err = w.Approve(context.Background(), &Claims{Subject: NewSubject("boss")}, req.ID, "")
+	assert.ErrorContains(t, err, "store down")
+
+	current, err := w.store.Get(context.Background(), req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, AccessRequestPending, current.Status)
+}
+
+func TestAccessWorkflow_ConcurrentDecisions(t *testing.T) {
+	ctx := context.Background()
+	var granted []AccessRequest
+	w := newAccessWorkflow(t, &granted)
+	w.granter = AccessGranterFunc(func(_ context.Context, req AccessRequest) error {
+		current, err := w.store.Get(ctx, req.ID)
+		require.NoError(t, err)
+		assert.Equal(t, AccessRequestApproved, current.Status)
+		granted = append(granted, req)
+		return nil
+	})
+
+	req, err := w.Request(ctx, &Claims{Subject: NewSubject("u1")}, AccessRequest{Role: "oncall", Duration: time.Hour})
+	require.NoError(t, err)
+	stale := req
+
+	_, err = w.Deny(ctx, &Claims{Subject: NewSubject("boss", "approver")}, req.ID, "")
+	require.NoError(t, err)
+
+	// a decision racing with the denial still sees the pending request
+	stale.Status = AccessRequestApproved
+	err = w.store.Update(ctx, stale, AccessRequestPending)
+	assert.ErrorIs(t, err, ErrAccessRequestClosed)
+
+	_, err = w.Approve(ctx, &Claims{Subject: NewSubject("boss", "approver")}, req.ID, "")
+	assert.ErrorIs(t, err, ErrAccessRequestClosed)
+	assert.Empty(t, granted)
+}
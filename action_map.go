@@ -0,0 +1,124 @@
+package rbac
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// actionRoutes caches the compiled routes of ActionMap entries.
+var actionRoutes = new(sync.Map)
+
+// ActionMap maps "METHOD /path/{param}" routes onto action names. When
+// several routes match, the most specific one wins as with RouteMatcher,
+// then the lowest route.
+type ActionMap map[string]string
+
+func (m ActionMap) Action(method, path string) (string, bool) {
+	if action, ok := m[method+" "+path]; ok {
+		return action, true
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	var (
+		best   *compiledRoute
+		action string
+	)
+	for route, a := range m {
+		routeMethod, _, ok := strings.Cut(route, " ")
+		if !ok || routeMethod != method {
+			continue
+		}
+		c := actionRoute(route)
+		if c == nil {
+			continue
+		}
+		if _, ok = c.match(path); !ok {
+			continue
+		}
+		if best != nil {
+			if n := c.compare(best); n > 0 || n == 0 && route > best.Pattern {
+				continue
+			}
+		}
+		best, action = c, a
+	}
+	return action, best != nil
+}
+
+// actionRoute compiles the route once, invalid routes are cached as nil.
+func actionRoute(route string) *compiledRoute {
+	if value, ok := actionRoutes.Load(route); ok {
+		return value.(*compiledRoute)
+	}
+	var c *compiledRoute
+	if compiled, err := compileRoute(Route{Pattern: route}); err == nil {
+		c = &compiled
+	}
+	value, _ := actionRoutes.LoadOrStore(route, c)
+	return value.(*compiledRoute)
+}
+
+// RouteMatcher returns a matcher with a route per entry, in route order,
+// whose permission is the action. It matches like the map, compiling the
+// routes once.
+func (m ActionMap) RouteMatcher() (*RouteMatcher, error) {
+	routes := make([]Route, 0, len(m))
+	for _, route := range sortedKeys(m) {
+		routes = append(routes, Route{Pattern: route, Permissions: []string{m[route]}})
+	}
+	return NewRouteMatcher(routes...)
+}
+
+func (m ActionMap) Actions(r *http.Request) []string {
+	if action, ok := m.lookup(r); ok {
+		return []string{action}
+	}
+	return nil
+}
+
+// ActionsFunc returns an actions function for RequestAuthorizer which reports
+// requests that hit routes absent from the map.
+func (m ActionMap) ActionsFunc(unmapped func(r *http.Request)) func(*http.Request) []string {
+	return func(r *http.Request) []string {
+		action, ok := m.lookup(r)
+		if !ok {
+			if unmapped != nil {
+				unmapped(r)
+			}
+			return nil
+		}
+		return []string{action}
+	}
+}
+
+func (m ActionMap) lookup(r *http.Request) (string, bool) {
+	if pattern := patternPath(r.Pattern); pattern != "" {
+		if action, ok := m[r.Method+" "+pattern]; ok {
+			return action, true
+		}
+	}
+
+	path := r.URL.Path
+	if path == "" {
+		path = "/"
+	}
+	return m.Action(r.Method, path)
+}
+
+// patternPath strips the method and host from a ServeMux pattern.
+func patternPath(pattern string) string {
+	if _, rest, ok := strings.Cut(pattern, " "); ok {
+		pattern = rest
+	}
+	if i := strings.IndexByte(pattern, '/'); i > 0 {
+		pattern = pattern[i:]
+	}
+	return pattern
+}
+
+func isPathParam(segment string) bool {
+	return len(segment) > 2 && segment[0] == '{' && segment[len(segment)-1] == '}'
+}
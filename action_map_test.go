@@ -0,0 +1,115 @@
+package rbac
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionMap_Action(t *testing.T) {
+	m := ActionMap{
+		"GET /users":                 "listUsers",
+		"GET /users/{id}":            "getUser",
+		"PUT /users/{id}/avatar":     "updateAvatar",
+		"GET /files/{path...}":       "getFile",
+		"DELETE /users/{id}/{extra}": "deleteUser",
+	}
+
+	for _, tt := range []struct {
+		method, path, action string
+		ok                   bool
+	}{
+		{"GET", "/users", "listUsers", true},
+		{"GET", "/users/42", "getUser", true},
+		{"PUT", "/users/42/avatar", "updateAvatar", true},
+		{"GET", "/files/a/b/c", "getFile", true},
+		{"DELETE", "/users/42/x", "deleteUser", true},
+		{"GET", "/users/42/avatar", "", false},
+		{"POST", "/users", "", false},
+		{"GET", "/users/", "", false},
+	} {
+		action, ok := m.Action(tt.method, tt.path)
+		assert.Equal(t, tt.ok, ok, tt.method+" "+tt.path)
+		assert.Equal(t, tt.action, action, tt.method+" "+tt.path)
+	}
+
+	route := actionRoute("GET /users/{id}")
+	require.NotNil(t, route)
+	assert.Same(t, route, actionRoute("GET /users/{id}"))
+	assert.Nil(t, actionRoute("GET users"))
+}
+
+func TestActionMap_MostSpecific(t *testing.T) {
+	m := ActionMap{
+		"GET /users/{id}":       "getUser",
+		"GET /users/me":         "getMe",
+		"GET /users/{path...}":  "browseUsers",
+		"GET /users/{id}/posts": "listPosts",
+		"GET /users/{uid}":      "getUserByUID",
+	}
+
+	for range 100 {
+		action, ok := m.Action("GET", "/users/me")
+		assert.True(t, ok)
+		assert.Equal(t, "getMe", action)
+
+		action, _ = m.Action("GET", "/users/42")
+		assert.Equal(t, "getUser", action)
+
+		action, _ = m.Action("GET", "/users/42/posts")
+		assert.Equal(t, "listPosts", action)
+
+		action, _ = m.Action("GET", "/users/42/likes")
+		assert.Equal(t, "browseUsers", action)
+	}
+}
+
+func TestActionMap_RouteMatcher(t *testing.T) {
+	m := ActionMap{
+		"GET /users":            "listUsers",
+		"GET /users/{id}":       "getUser",
+		"GET /users/me":         "getMe",
+		"GET /users/{uid}":      "getUserByUID",
+		"GET /files/{path...}":  "getFile",
+		"GET /files/*/raw":      "getRaw",
+		"POST /users/{id}/ban":  "banUser",
+		"DELETE /users/{id}/**": "purgeUser",
+	}
+	matcher, err := m.RouteMatcher()
+	require.NoError(t, err)
+
+	for _, method := range []string{"GET", "POST", "DELETE"} {
+		for _, path := range []string{
+			"/", "/users", "/users/", "/users/me", "/users/42", "/users/42/ban",
+			"/users/42/a/b", "/files", "/files/", "/files/a", "/files/a/raw", "/files/a/b/c",
+		} {
+			action, ok := m.Action(method, path)
+
+			var actions []string
+			if ok {
+				actions = []string{action}
+			}
+			assert.Equal(t, actions, matcher.Actions(httptest.NewRequest(method, path, nil)), method+" "+path)
+		}
+	}
+}
+
+func TestActionMap_ActionsFunc(t *testing.T) {
+	m := ActionMap{"GET /users/{id}": "getUser"}
+
+	var unmapped []string
+	actions := m.ActionsFunc(func(r *http.Request) {
+		unmapped = append(unmapped, r.Method+" "+r.URL.Path)
+	})
+
+	assert.Equal(t, []string{"getUser"}, actions(httptest.NewRequest(http.MethodGet, "/users/1", nil)))
+	assert.Nil(t, actions(httptest.NewRequest(http.MethodPost, "/users/1", nil)))
+	assert.Equal(t, []string{"POST /users/1"}, unmapped)
+
+	r := httptest.NewRequest(http.MethodGet, "/other", nil)
+	r.Pattern = "GET example.com/users/{id}"
+	assert.Equal(t, []string{"getUser"}, m.Actions(r))
+}
@@ -0,0 +1,67 @@
+package rbac
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ActionTemplates returns an actions function for RequestAuthorizer that
+// expands "{name}" references with the path values of the matched ServeMux
+// pattern, e.g. "read:org:{org_id}". Values are path escaped, ":" included,
+// so they cannot add segments to the action. Templates referencing a missing
+// or empty value are skipped. Use WithActionTemplates to check the actions
+// anchored, otherwise "read:org:42" also grants "read:org:420".
+func ActionTemplates(templates ...string) func(*http.Request) []string {
+	return func(r *http.Request) []string {
+		value := func(name string) string {
+			return escapeActionValue(r.PathValue(name))
+		}
+		actions := make([]string, 0, len(templates))
+		for _, template := range templates {
+			if action, ok := expandActionTemplate(template, value); ok {
+				actions = append(actions, action)
+			}
+		}
+		return actions
+	}
+}
+
+// WithActionTemplates tries the ActionTemplates with Target.Anchored set, so
+// permissions must match whole actions.
+func WithActionTemplates(templates ...string) RequestOption {
+	actions := ActionTemplates(templates...)
+	return func(a *requestAuthorizer) {
+		a.actions = actions
+		a.anchor = func(*http.Request, string) bool { return true }
+	}
+}
+
+func escapeActionValue(value string) string {
+	return strings.ReplaceAll(url.PathEscape(value), ":", "%3A")
+}
+
+func expandActionTemplate(template string, value func(name string) string) (string, bool) {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			b.WriteString(template)
+			return b.String(), true
+		}
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			b.WriteString(template)
+			return b.String(), true
+		}
+		end += start
+
+		v := value(template[start+1 : end])
+		if v == "" {
+			return "", false
+		}
+		b.WriteString(template[:start])
+		b.WriteString(v)
+		template = template[end+1:]
+	}
+}
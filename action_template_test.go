@@ -0,0 +1,101 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionTemplates(t *testing.T) {
+	var actions []string
+	var info RequestInfo
+
+	rbac := New()
+	member := NewRole("member")
+	require.NoError(t, member.AddPermissionsE("read:org:acme"))
+	require.NoError(t, rbac.AddRole(member))
+	authorizer := NewDefaultAuthorizer(rbac)
+	capture := authorizerFunc(func(ctx context.Context, claims *Claims, target *Target) Decision {
+		info = CtxRequestInfo(ctx)
+		return authorizer.Authorize(ctx, claims, target)
+	})
+	authorize := RequestAuthorizer(capture, WithActions(func(r *http.Request) []string {
+		actions = ActionTemplates("read:org:{org_id}", "read:post:{org_id}/{id}", "read:{missing}", "static")(r)
+		return actions
+	}))
+
+	var d Decision
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orgs/{org_id}/posts/{id}/{rest...}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithClaims(r.Context(), &Claims{Subject: NewSubject("u1", "member")})
+		d = authorize(r.WithContext(ctx))
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orgs/acme/posts/42/a/b", nil))
+
+	assert.Equal(t, DecisionAllow, d)
+	assert.Equal(t, []string{"read:org:acme", "read:post:acme/42", "static"}, actions)
+	assert.Equal(t, map[string]string{"org_id": "acme", "id": "42", "rest": "a/b"}, info.PathValues)
+}
+
+func TestWithActionTemplates(t *testing.T) {
+	rbac := New()
+	member := NewRole("member")
+	require.NoError(t, member.AddPermissionsE("read:org:42", "read:team:.*"))
+	require.NoError(t, rbac.AddRole(member))
+	authorize := RequestAuthorizer(NewDefaultAuthorizer(rbac), WithActionTemplates("read:org:{org_id}", "read:team:{org_id}:{team}"))
+
+	decisions := map[string]Decision{}
+	mux := http.NewServeMux()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithClaims(r.Context(), &Claims{Subject: NewSubject("u1", "member")})
+		decisions[r.URL.Path] = authorize(r.WithContext(ctx))
+	}
+	mux.HandleFunc("GET /orgs/{org_id}", handler)
+	mux.HandleFunc("GET /orgs/{org_id}/teams/{team}", handler)
+	for _, path := range []string{"/orgs/42", "/orgs/420", "/orgs/4", "/orgs/42%3Aadmin", "/orgs/7/teams/a"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, map[string]Decision{
+		"/orgs/42":        DecisionAllow,
+		"/orgs/420":       DecisionDeny,
+		"/orgs/4":         DecisionDeny,
+		"/orgs/42:admin":  DecisionDeny,
+		"/orgs/7/teams/a": DecisionAllow,
+	}, decisions)
+}
+
+type authorizerFunc func(ctx context.Context, claims *Claims, target *Target) Decision
+
+func (f authorizerFunc) Authorize(ctx context.Context, claims *Claims, target *Target) Decision {
+	return f(ctx, claims, target)
+}
+
+func TestExpandActionTemplate(t *testing.T) {
+	values := map[string]string{"a": "1"}
+	lookup := func(name string) string { return values[name] }
+
+	assert.Equal(t, "42%3Aadmin%2Fx", escapeActionValue("42:admin/x"))
+
+	for template, expected := range map[string]string{
+		"x:{a}":   "x:1",
+		"{a}{a}":  "11",
+		"x:{a":    "x:{a",
+		"no vars": "no vars",
+	} {
+		action, ok := expandActionTemplate(template, lookup)
+		assert.True(t, ok)
+		assert.Equal(t, expected, action)
+	}
+
+	_, ok := expandActionTemplate("x:{b}", lookup)
+	assert.False(t, ok)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(context.Background())
+	assert.Empty(t, ActionTemplates("x:{a}")(r))
+	assert.Nil(t, CtxRequestInfo(r.Context()).PathValues)
+}
@@ -0,0 +1,116 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+var _ Assertion = (*IPAssertion)(nil)
+
+// IPAssertion passes requests whose client address, taken from
+// CtxRequestInfo, lies in one of the allowed networks.
+type IPAssertion struct {
+	prefixes []netip.Prefix
+	proxies  []netip.Prefix
+}
+
+// NewIPAssertion allows the given networks in CIDR notation; bare addresses
+// allow just themselves.
+func NewIPAssertion(cidrs ...string) (*IPAssertion, error) {
+	prefixes, err := parsePrefixes(cidrs)
+	if err != nil {
+		return nil, err
+	}
+	return &IPAssertion{prefixes: prefixes}, nil
+}
+
+// SetTrustedProxies sets the networks of the proxies in front of the server.
+// Requests from them are attributed to the rightmost X-Forwarded-For entry
+// that is not a trusted proxy, so that entries a client prepends are ignored.
+func (a *IPAssertion) SetTrustedProxies(cidrs ...string) (*IPAssertion, error) {
+	proxies, err := parsePrefixes(cidrs)
+	if err != nil {
+		return a, err
+	}
+	a.proxies = proxies
+	return a, nil
+}
+
+func parsePrefixes(cidrs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(cidr)
+			if addrErr != nil {
+				return nil, fmt.Errorf(`rbac: invalid network "%s": %w`, cidr, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+func (a *IPAssertion) Name() string {
+	return "ip"
+}
+
+func (a *IPAssertion) Assert(ctx context.Context, _ *Role, _ string) bool {
+	addr, ok := a.clientAddr(CtxRequestInfo(ctx))
+	if !ok {
+		return false
+	}
+	for _, prefix := range a.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *IPAssertion) clientAddr(info RequestInfo) (netip.Addr, bool) {
+	addr, ok := parseHostAddr(info.RemoteAddr)
+	if !ok || !a.trusted(addr) {
+		return addr, ok
+	}
+
+	var hops []string
+	for _, value := range info.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parseHostAddr(strings.TrimSpace(hops[i]))
+		if !ok {
+			return netip.Addr{}, false
+		}
+		addr = hop
+		if !a.trusted(addr) {
+			break
+		}
+	}
+	return addr, true
+}
+
+func (a *IPAssertion) trusted(addr netip.Addr) bool {
+	for _, prefix := range a.proxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseHostAddr(remote string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	addr, err := netip.ParseAddr(remote)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
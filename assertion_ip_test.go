@@ -0,0 +1,67 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPAssertion(t *testing.T) {
+	a, err := NewIPAssertion("10.0.0.0/8", "192.168.1.7", "2001:db8::/32")
+	require.NoError(t, err)
+	assert.Equal(t, "ip", AssertionName(a))
+
+	assertAddr := func(remoteAddr string, header http.Header) bool {
+		ctx := WithRequestInfo(context.Background(), RequestInfo{RemoteAddr: remoteAddr, Header: header})
+		return a.Assert(ctx, nil, "admin.view")
+	}
+	assert.True(t, assertAddr("10.1.2.3:5123", nil))
+	assert.True(t, assertAddr("192.168.1.7:80", nil))
+	assert.True(t, assertAddr("[2001:db8::1]:443", nil))
+	assert.True(t, assertAddr("[::ffff:10.0.0.1]:443", nil))
+	assert.True(t, assertAddr("10.0.0.1", nil))
+	assert.False(t, assertAddr("192.168.1.8:80", nil))
+	assert.False(t, assertAddr("not-an-ip", nil))
+	assert.False(t, a.Assert(context.Background(), nil, "admin.view"))
+
+	forwarded := http.Header{"X-Forwarded-For": {"10.9.9.9"}}
+	assert.False(t, assertAddr("203.0.113.1:80", forwarded))
+
+	_, err = a.SetTrustedProxies("203.0.113.0/24", "10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, assertAddr("203.0.113.1:80", forwarded))
+	assert.True(t, assertAddr("203.0.113.1:80", http.Header{"X-Forwarded-For": {"8.8.8.8, 10.9.9.9, 203.0.113.7"}}))
+	// a spoofed leftmost entry does not make an untrusted hop the client
+	assert.False(t, assertAddr("203.0.113.1:80", http.Header{"X-Forwarded-For": {"10.9.9.9, 8.8.8.8"}}))
+	assert.False(t, assertAddr("203.0.113.1:80", http.Header{"X-Forwarded-For": {"10.9.9.9", "8.8.8.8"}}))
+	assert.False(t, assertAddr("203.0.113.1:80", http.Header{"X-Forwarded-For": {"10.9.9.9, garbage"}}))
+	// untrusted peers cannot forward
+	assert.False(t, assertAddr("8.8.8.8:80", forwarded))
+	// chains of trusted proxies fall back to the leftmost hop
+	assert.False(t, assertAddr("10.0.0.1:80", http.Header{"X-Forwarded-For": {"203.0.113.9"}}))
+	assert.True(t, assertAddr("10.0.0.1:80", http.Header{"X-Forwarded-For": {"10.0.0.1, 203.0.113.9"}}))
+	assert.True(t, assertAddr("10.0.0.1:80", nil))
+
+	_, err = a.SetTrustedProxies("bogus")
+	assert.ErrorContains(t, err, `invalid network "bogus"`)
+
+	_, err = NewIPAssertion("10.0.0.0/33")
+	assert.ErrorContains(t, err, `invalid network "10.0.0.0/33"`)
+}
+
+func TestIPAssertion_IsGranted(t *testing.T) {
+	rbac := New()
+	admin := NewRole("admin")
+	require.NoError(t, admin.AddPermissionsE("admin.view"))
+	require.NoError(t, rbac.AddRole(admin))
+
+	a, err := NewIPAssertion("10.0.0.0/8")
+	require.NoError(t, err)
+	ctx := WithRequestInfo(context.Background(), RequestInfo{RemoteAddr: "10.0.0.1:80"})
+	assert.True(t, rbac.IsGranted(ctx, "admin", "admin.view", a))
+	ctx = WithRequestInfo(context.Background(), RequestInfo{RemoteAddr: "8.8.8.8:80"})
+	assert.False(t, rbac.IsGranted(ctx, "admin", "admin.view", a))
+}
@@ -0,0 +1,155 @@
+package rbac
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+type AssertionOutcome int
+
+const (
+	AssertionPassed AssertionOutcome = iota
+	AssertionFailed
+	AssertionWarned
+	AssertionErrored
+	AssertionPanicked
+)
+
+func (o AssertionOutcome) String() string {
+	switch o {
+	case AssertionPassed:
+		return "passed"
+	case AssertionFailed:
+		return "failed"
+	case AssertionWarned:
+		return "warned"
+	case AssertionErrored:
+		return "errored"
+	case AssertionPanicked:
+		return "panicked"
+	default:
+		return "unknown"
+	}
+}
+
+type AssertionEvent struct {
+	Name       string
+	Role       string
+	Permission string
+	Duration   time.Duration
+	Outcome    AssertionOutcome
+	Err        error
+}
+
+// AssertionObserver is invoked after every assertion evaluated by IsGrantedE.
+type AssertionObserver func(ctx context.Context, event AssertionEvent)
+
+// SetAssertionObserver installs observers called in order around every
+// assertion; no observers disables instrumentation.
+func (rbac *RBAC) SetAssertionObserver(observers ...AssertionObserver) *RBAC {
+	switch len(observers) {
+	case 0:
+		rbac.observer = nil
+	case 1:
+		rbac.observer = observers[0]
+	default:
+		rbac.observer = func(ctx context.Context, event AssertionEvent) {
+			for _, observer := range observers {
+				observer(ctx, event)
+			}
+		}
+	}
+	return rbac
+}
+
+// SlowAssertionLogger logs assertions taking at least threshold.
+func SlowAssertionLogger(logger *slog.Logger, threshold time.Duration) AssertionObserver {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(ctx context.Context, event AssertionEvent) {
+		if event.Duration < threshold {
+			return
+		}
+		logger.WarnContext(ctx, "rbac: slow assertion",
+			slog.String("assertion", event.Name),
+			slog.String("role", event.Role),
+			slog.String("permission", event.Permission),
+			slog.Duration("duration", event.Duration),
+			slog.String("outcome", event.Outcome.String()),
+		)
+	}
+}
+
+var DefaultAssertionBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	time.Second,
+}
+
+type AssertionStats struct {
+	Count uint64
+	Sum   time.Duration
+	// Buckets holds cumulative counts per upper bound of the histogram,
+	// followed by the total count as the +Inf bucket.
+	Buckets []uint64
+}
+
+// AssertionHistogram aggregates assertion durations per assertion name. Its
+// Observe method is an AssertionObserver.
+type AssertionHistogram struct {
+	mu      sync.Mutex
+	buckets []time.Duration
+	stats   map[string]*AssertionStats
+}
+
+func NewAssertionHistogram(buckets ...time.Duration) *AssertionHistogram {
+	if len(buckets) == 0 {
+		buckets = DefaultAssertionBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	return &AssertionHistogram{buckets: buckets, stats: map[string]*AssertionStats{}}
+}
+
+func (h *AssertionHistogram) Bounds() []time.Duration {
+	return slices.Clone(h.buckets)
+}
+
+func (h *AssertionHistogram) Observe(_ context.Context, event AssertionEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats, ok := h.stats[event.Name]
+	if !ok {
+		stats = &AssertionStats{Buckets: make([]uint64, len(h.buckets)+1)}
+		h.stats[event.Name] = stats
+	}
+	stats.Count++
+	stats.Sum += event.Duration
+	for i, bound := range h.buckets {
+		if event.Duration <= bound {
+			stats.Buckets[i]++
+		}
+	}
+	stats.Buckets[len(h.buckets)]++
+}
+
+func (h *AssertionHistogram) Snapshot() map[string]AssertionStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	snapshot := make(map[string]AssertionStats, len(h.stats))
+	for name, stats := range h.stats {
+		snapshot[name] = AssertionStats{Count: stats.Count, Sum: stats.Sum, Buckets: slices.Clone(stats.Buckets)}
+	}
+	return snapshot
+}
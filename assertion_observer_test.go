@@ -0,0 +1,127 @@
+package rbac
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sleepAssertion struct {
+	delay time.Duration
+}
+
+func (a sleepAssertion) Assert(context.Context, *Role, string) bool {
+	time.Sleep(a.delay)
+	return true
+}
+
+func (sleepAssertion) Name() string {
+	return "sleep"
+}
+
+type errAssertion struct {
+	err error
+}
+
+func (a errAssertion) Assert(context.Context, *Role, string) bool {
+	return a.err == nil
+}
+
+func (a errAssertion) AssertE(context.Context, *Role, string) error {
+	return a.err
+}
+
+func (errAssertion) Name() string {
+	return "err"
+}
+
+func TestRBAC_SetAssertionObserver(t *testing.T) {
+	rbac := New()
+	role := NewRole("user")
+	role.AddPermissions("read")
+	require.NoError(t, rbac.AddRole(role))
+
+	var events []AssertionEvent
+	rbac.SetAssertionObserver(func(_ context.Context, event AssertionEvent) {
+		events = append(events, event)
+	})
+
+	ok, err := rbac.IsGrantedE(context.Background(), "user", "read",
+		sleepAssertion{delay: time.Millisecond},
+		errAssertion{err: ErrWarn},
+		&testAssertion{shouldPass: false},
+	)
+	assert.False(t, ok)
+	assert.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, "sleep", events[0].Name)
+	assert.Equal(t, "user", events[0].Role)
+	assert.Equal(t, "read", events[0].Permission)
+	assert.Equal(t, AssertionPassed, events[0].Outcome)
+	assert.GreaterOrEqual(t, events[0].Duration, time.Millisecond)
+	assert.Equal(t, AssertionWarned, events[1].Outcome)
+	assert.ErrorIs(t, events[1].Err, ErrWarn)
+	assert.Equal(t, AssertionFailed, events[2].Outcome)
+
+	events = nil
+	boom := errors.New("boom")
+	_, err = rbac.IsGrantedE(context.Background(), "user", "read", errAssertion{err: boom}, sleepAssertion{})
+	assert.ErrorIs(t, err, boom)
+	require.Len(t, events, 1)
+	assert.Equal(t, AssertionErrored, events[0].Outcome)
+
+	events = nil
+	_, err = rbac.IsGrantedE(context.Background(), "user", "read", &panicAssertion{})
+	assert.Error(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, AssertionPanicked, events[0].Outcome)
+
+	events = nil
+	_, _ = rbac.IsGrantedE(context.Background(), "user", "write", sleepAssertion{})
+	assert.Empty(t, events)
+
+	rbac.SetAssertionObserver()
+	_, _ = rbac.IsGrantedE(context.Background(), "user", "read", sleepAssertion{})
+	assert.Empty(t, events)
+}
+
+func TestSlowAssertionLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	observer := SlowAssertionLogger(logger, 50*time.Millisecond)
+
+	observer(context.Background(), AssertionEvent{Name: "fast", Duration: time.Millisecond})
+	assert.Empty(t, buf.String())
+
+	observer(context.Background(), AssertionEvent{Name: "ownership", Role: "user", Permission: "read", Duration: 80 * time.Millisecond})
+	assert.Contains(t, buf.String(), "rbac: slow assertion")
+	assert.Contains(t, buf.String(), "assertion=ownership")
+	assert.Contains(t, buf.String(), "duration=80ms")
+	assert.Contains(t, buf.String(), "outcome=passed")
+}
+
+func TestAssertionHistogram(t *testing.T) {
+	h := NewAssertionHistogram(10*time.Millisecond, time.Millisecond)
+	assert.Equal(t, []time.Duration{time.Millisecond, 10 * time.Millisecond}, h.Bounds())
+
+	rbac := New()
+	role := NewRole("user")
+	role.AddPermissions("read")
+	require.NoError(t, rbac.AddRole(role))
+	rbac.SetAssertionObserver(h.Observe, SlowAssertionLogger(slog.New(slog.DiscardHandler), time.Second))
+
+	assert.True(t, rbac.IsGranted(context.Background(), "user", "read", sleepAssertion{}))
+	h.Observe(context.Background(), AssertionEvent{Name: "sleep", Duration: 5 * time.Millisecond})
+	h.Observe(context.Background(), AssertionEvent{Name: "sleep", Duration: time.Second})
+
+	stats := h.Snapshot()["sleep"]
+	assert.Equal(t, uint64(3), stats.Count)
+	assert.Equal(t, []uint64{1, 2, 3}, stats.Buckets)
+	assert.GreaterOrEqual(t, stats.Sum, time.Second+5*time.Millisecond)
+}
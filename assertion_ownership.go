@@ -0,0 +1,56 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+)
+
+var _ SubjectAssertion = (*OwnershipAssertion)(nil)
+
+// MetadataOwner is the default Target.Metadata key of the resource owner.
+const MetadataOwner = "owner"
+
+// OwnershipAssertion passes when the subject of the claims in ctx owns the
+// target, i.e. its identifier equals the owner found in the target's
+// metadata, covering "users can edit their own resources".
+type OwnershipAssertion struct {
+	key string
+}
+
+// NewOwnershipAssertion reads the owner from the metadata key, MetadataOwner
+// if empty. Owners other than strings, e.g. numeric IDs, are compared in
+// their fmt.Sprint form.
+func NewOwnershipAssertion(key string) *OwnershipAssertion {
+	if key == "" {
+		key = MetadataOwner
+	}
+	return &OwnershipAssertion{key: key}
+}
+
+func (a *OwnershipAssertion) Name() string {
+	return "owner"
+}
+
+func (a *OwnershipAssertion) ComparesSubject() bool {
+	return true
+}
+
+func (a *OwnershipAssertion) Assert(ctx context.Context, _ *Role, _ string) bool {
+	claims, target := CtxClaims(ctx), CtxTarget(ctx)
+	if claims == nil || target == nil {
+		return false
+	}
+	id := SubjectID(claims.Subject)
+	if id == "" {
+		return false
+	}
+
+	switch owner := target.Metadata[a.key].(type) {
+	case nil:
+		return false
+	case string:
+		return owner == id
+	default:
+		return fmt.Sprint(owner) == id
+	}
+}
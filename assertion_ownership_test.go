@@ -0,0 +1,37 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwnershipAssertion(t *testing.T) {
+	rbac := New()
+	user := NewRole("user")
+	require.NoError(t, user.AddPermissionsE("posts.edit"))
+	require.NoError(t, rbac.AddRole(user))
+	authorizer := NewDefaultAuthorizer(rbac)
+
+	owner := NewOwnershipAssertion("")
+	assert.Equal(t, "owner", AssertionName(owner))
+	claims := &Claims{Subject: NewSubject("42", "user")}
+	edit := func(metadata map[string]any, assertion Assertion) Decision {
+		return authorizer.Authorize(context.Background(), claims, &Target{Action: "posts.edit", Metadata: metadata, Assertions: []Assertion{assertion}})
+	}
+
+	assert.Equal(t, DecisionAllow, edit(map[string]any{MetadataOwner: "42"}, owner))
+	assert.Equal(t, DecisionAllow, edit(map[string]any{MetadataOwner: 42}, owner))
+	assert.Equal(t, DecisionDeny, edit(map[string]any{MetadataOwner: "7"}, owner))
+	assert.Equal(t, DecisionDeny, edit(map[string]any{}, owner))
+
+	authorID := NewOwnershipAssertion("author_id")
+	assert.Equal(t, DecisionAllow, edit(map[string]any{"author_id": "42", MetadataOwner: "7"}, authorID))
+
+	claims = &Claims{Subject: NewSubject("", "user")}
+	assert.Equal(t, DecisionDeny, edit(map[string]any{MetadataOwner: ""}, owner))
+
+	assert.False(t, owner.Assert(context.Background(), user, "posts.edit"))
+}
@@ -0,0 +1,216 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var ErrUnknownAssertion = errors.New("unknown assertion")
+
+// AssertionRegistry resolves assertions named in configuration, e.g. the
+// "assertions" of an AccessConfig entry. Parameterized assertions are
+// registered as configured instances, e.g. an IPAssertion of the office
+// networks as "office-network".
+type AssertionRegistry struct {
+	mu         sync.RWMutex
+	assertions map[string]Assertion
+}
+
+// NewAssertionRegistry returns a registry holding the built-in "owner"
+// assertion, an OwnershipAssertion reading MetadataOwner.
+func NewAssertionRegistry() *AssertionRegistry {
+	r := &AssertionRegistry{assertions: map[string]Assertion{}}
+	return r.Register("owner", NewOwnershipAssertion(""))
+}
+
+// Register adds or replaces the assertion under the name, which AssertionName
+// reports for it from then on.
+func (r *AssertionRegistry) Register(name string, assertion Assertion) *AssertionRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if e, ok := assertion.(ErrorAssertion); ok {
+		r.assertions[name] = &registeredErrorAssertion{registeredAssertion{name: name, Assertion: assertion}, e}
+	} else {
+		r.assertions[name] = &registeredAssertion{name: name, Assertion: assertion}
+	}
+	return r
+}
+
+func (r *AssertionRegistry) Assertion(name string) (Assertion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if assertion, ok := r.assertions[name]; ok {
+		return assertion, nil
+	}
+	return nil, fmt.Errorf(`%w: no assertion is registered as "%s"`, ErrUnknownAssertion, name)
+}
+
+// Names returns the registered names sorted.
+func (r *AssertionRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return sortedKeys(r.assertions)
+}
+
+type registeredAssertion struct {
+	name string
+	Assertion
+}
+
+func (a *registeredAssertion) Name() string {
+	return a.name
+}
+
+type registeredErrorAssertion struct {
+	registeredAssertion
+	e ErrorAssertion
+}
+
+func (a *registeredErrorAssertion) AssertE(ctx context.Context, role *Role, permission string) error {
+	return a.e.AssertE(ctx, role, permission)
+}
+
+// SetAssertionRegistry sets the registry resolving assertions named in
+// configuration applied afterwards.
+func (rbac *RBAC) SetAssertionRegistry(registry *AssertionRegistry) *RBAC {
+	rbac.assertions = registry
+	return rbac
+}
+
+func (rbac *RBAC) AssertionRegistry() *AssertionRegistry {
+	return rbac.assertions
+}
+
+func (rbac *RBAC) resolveAssertions(names []string) ([]Assertion, error) {
+	if len(names) > 0 && rbac.assertions == nil {
+		return nil, fmt.Errorf(`%w: "%s", no assertion registry is set`, ErrUnknownAssertion, strings.Join(names, `", "`))
+	}
+	assertions := make([]Assertion, 0, len(names))
+	var errs []error
+	for _, name := range names {
+		assertion, err := rbac.assertions.Assertion(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		assertions = append(assertions, assertion)
+	}
+	return assertions, errors.Join(errs...)
+}
+
+// SetPermissionAssertions makes the permission, as held by the role itself,
+// grant only when all assertions pass. Calling it without assertions makes
+// the permission unconditional again.
+func (r *Role) SetPermissionAssertions(permission string, assertions ...Assertion) *Role {
+	if len(assertions) == 0 {
+		delete(r.conditions, permission)
+		return r
+	}
+	if r.conditions == nil {
+		r.conditions = map[string][]Assertion{}
+	}
+	r.conditions[permission] = slices.Clone(assertions)
+	return r
+}
+
+func (r *Role) PermissionAssertions(permission string) []Assertion {
+	return slices.Clone(r.conditions[permission])
+}
+
+// permissionAssertions returns the assertions the permission is granted
+// under by the role or its descendants, directly or through implications:
+// none if any matching permission is unconditional, otherwise the assertions
+// of one of the matching ones. Patterns match as selected by mode.
+func (r *Role) permissionAssertions(permission string, mode actionMatching) []Assertion {
+	return r.conditionsOf(permission, mode, map[string]struct{}{})
+}
+
+// conditionsOf collects the conditions of the grants of permission, including
+// those of held permissions implying it.
+func (r *Role) conditionsOf(permission string, mode actionMatching, seen map[string]struct{}) []Assertion {
+	seen[permission] = struct{}{}
+	var sets [][]Assertion
+	unconditional := false
+	visited := map[*Role]struct{}{}
+	var walk func(r *Role)
+	walk = func(r *Role) {
+		if _, ok := visited[r]; ok || unconditional {
+			return
+		}
+		visited[r] = struct{}{}
+		for pattern := range r.permissions {
+			if pattern != permission && !mode.match(r.permissions[pattern], permission) {
+				continue
+			}
+			if len(r.conditions[pattern]) == 0 {
+				unconditional = true
+				return
+			}
+			sets = append(sets, r.conditions[pattern])
+		}
+		for child := range r.Children() {
+			walk(child)
+		}
+	}
+	walk(r)
+
+	for _, from := range r.implications.implying(permission, mode == matchEqual) {
+		if unconditional {
+			break
+		}
+		if _, ok := seen[from]; ok || !r.HasPermission(from) {
+			continue
+		}
+		if conditions := r.conditionsOf(from, matchPartial, seen); len(conditions) > 0 {
+			sets = append(sets, conditions)
+		} else {
+			unconditional = true
+		}
+	}
+
+	switch {
+	case unconditional || len(sets) == 0:
+		return nil
+	case len(sets) == 1:
+		return sets[0]
+	default:
+		return []Assertion{anyAssertions(sets)}
+	}
+}
+
+// anyAssertions passes if all assertions of one of the sets pass.
+type anyAssertions [][]Assertion
+
+func (a anyAssertions) Name() string {
+	names := make([]string, len(a))
+	for i, set := range a {
+		setNames := make([]string, len(set))
+		for j, assertion := range set {
+			setNames[j] = AssertionName(assertion)
+		}
+		names[i] = strings.Join(setNames, "+")
+	}
+	return strings.Join(names, "|")
+}
+
+func (a anyAssertions) Assert(ctx context.Context, role *Role, permission string) bool {
+	for _, set := range a {
+		if !slices.ContainsFunc(set, func(assertion Assertion) bool {
+			if e, ok := assertion.(ErrorAssertion); ok {
+				err := e.AssertE(ctx, role, permission)
+				return err != nil && !errors.Is(err, ErrWarn)
+			}
+			return !assertion.Assert(ctx, role, permission)
+		}) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestAssertionRegistry(t *testing.T) {
+	office, err := NewIPAssertion("10.0.0.0/8")
+	require.NoError(t, err)
+	r := NewAssertionRegistry().Register("office", office).Register("warn", Warn(&testAssertion{}, "outside policy"))
+	assert.Equal(t, []string{"office", "owner", "warn"}, r.Names())
+
+	a, err := r.Assertion("office")
+	require.NoError(t, err)
+	assert.Equal(t, "office", AssertionName(a))
+	_, isErrorAssertion := a.(ErrorAssertion)
+	assert.False(t, isErrorAssertion)
+
+	a, err = r.Assertion("warn")
+	require.NoError(t, err)
+	e, ok := a.(ErrorAssertion)
+	require.True(t, ok)
+	assert.ErrorIs(t, e.AssertE(context.Background(), nil, "x"), ErrWarn)
+
+	_, err = r.Assertion("missing")
+	assert.ErrorIs(t, err, ErrUnknownAssertion)
+}
+
+const conditionalConfig = `
+roleHierarchy:
+  - role: admin
+  - role: user
+accessControl:
+  - role: admin
+    permissions: ["settings.edit"]
+    assertions: [office]
+  - role: user
+    permissions: ["posts.edit"]
+    assertions: [owner]
+  - role: user
+    permissions: ["posts.view"]
+`
+
+func TestConfig_Assertions(t *testing.T) {
+	var cfg Config
+	require.NoError(t, yaml.Unmarshal([]byte(conditionalConfig), &cfg))
+
+	office, err := NewIPAssertion("10.0.0.0/8")
+	require.NoError(t, err)
+	rbac := New().SetAssertionRegistry(NewAssertionRegistry().Register("office", office))
+	require.NoError(t, rbac.Apply(cfg))
+	authorizer := NewDefaultAuthorizer(rbac)
+
+	admin := &Claims{Subject: NewSubject("1", "admin")}
+	inside := WithRequestInfo(context.Background(), RequestInfo{RemoteAddr: "10.0.0.1:80"})
+	outside := WithRequestInfo(context.Background(), RequestInfo{RemoteAddr: "8.8.8.8:80"})
+	assert.Equal(t, DecisionAllow, authorizer.Authorize(inside, admin, &Target{Action: "settings.edit"}))
+	d, err := authorizer.AuthorizeE(outside, admin, &Target{Action: "settings.edit"})
+	assert.Equal(t, DecisionDeny, d)
+	assert.Contains(t, Reasons(err), ReasonAssertionFailed{Name: "office"})
+
+	user := &Claims{Subject: NewSubject("42", "user")}
+	assert.Equal(t, DecisionAllow, authorizer.Authorize(outside, user, &Target{Action: "posts.edit", Metadata: map[string]any{MetadataOwner: "42"}}))
+	assert.Equal(t, DecisionDeny, authorizer.Authorize(outside, user, &Target{Action: "posts.edit", Metadata: map[string]any{MetadataOwner: "7"}}))
+	assert.Equal(t, DecisionAllow, authorizer.Authorize(outside, user, &Target{Action: "posts.view"}))
+
+	exported := rbac.Export()
+	assert.Contains(t, exported.AccessControl, AccessConfig{Role: "admin", Permissions: []string{"settings.edit"}, Assertions: []string{"office"}})
+	assert.Contains(t, exported.AccessControl, AccessConfig{Role: "user", Permissions: []string{"posts.view"}})
+
+	err = New().Apply(cfg)
+	assert.ErrorIs(t, err, ErrUnknownAssertion)
+	assert.ErrorContains(t, err, "no assertion registry is set")
+	err = New().SetAssertionRegistry(NewAssertionRegistry()).Apply(cfg)
+	assert.ErrorContains(t, err, `no assertion is registered as "office"`)
+
+	merged, err := cfg.Merge(Config{AccessControl: []AccessConfig{{Role: "user", Permissions: []string{"posts.delete"}, Assertions: []string{"owner"}}}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"posts.edit", "posts.delete"}, merged.AccessControl[1].Permissions)
+	assert.Len(t, merged.AccessControl, 3)
+}
+
+func TestRole_PermissionAssertions(t *testing.T) {
+	deny := AssertionFunc(func(context.Context, *Role, string) bool { return false })
+	allow := AssertionFunc(func(context.Context, *Role, string) bool { return true })
+
+	rbac := New()
+	editor := NewRole("editor")
+	require.NoError(t, editor.AddPermissionsE("posts.edit", "posts\\..*"))
+	editor.SetPermissionAssertions("posts.edit", deny)
+	require.NoError(t, rbac.AddRole(editor))
+	assert.Len(t, editor.PermissionAssertions("posts.edit"), 1)
+
+	// the unconditional pattern grants as well
+	assert.True(t, rbac.IsGranted(context.Background(), "editor", "posts.edit"))
+
+	editor.SetPermissionAssertions("posts\\..*", deny)
+	assert.False(t, rbac.IsGranted(context.Background(), "editor", "posts.edit"))
+	editor.SetPermissionAssertions("posts\\..*", allow)
+	assert.True(t, rbac.IsGranted(context.Background(), "editor", "posts.edit"))
+
+	// conditions of inherited permissions apply to the parent
+	require.NoError(t, rbac.AddRole("lead"))
+	lead, _ := rbac.Role("lead")
+	require.NoError(t, lead.AddChild(editor))
+	editor.SetPermissionAssertions("posts\\..*", deny)
+	assert.False(t, rbac.IsGranted(context.Background(), "lead", "posts.edit"))
+	assert.False(t, rbac.IsGranted(context.Background(), "lead", "posts.edit", allow))
+
+	editor.RemovePermissions("posts\\..*")
+	assert.Empty(t, editor.PermissionAssertions("posts\\..*"))
+	editor.SetPermissionAssertions("posts.edit")
+	assert.True(t, rbac.IsGranted(context.Background(), "lead", "posts.edit"))
+
+	failing := errorAssertionFunc(func(context.Context, *Role, string) error { return errors.New("boom") })
+	editor.SetPermissionAssertions("posts.edit", failing)
+	granted, err := rbac.IsGrantedE(context.Background(), "editor", "posts.edit")
+	assert.False(t, granted)
+	assert.EqualError(t, err, "boom")
+}
+
+type errorAssertionFunc func(ctx context.Context, role *Role, permission string) error
+
+func (f errorAssertionFunc) Assert(ctx context.Context, role *Role, permission string) bool {
+	return f(ctx, role, permission) == nil
+}
+
+func (f errorAssertionFunc) AssertE(ctx context.Context, role *Role, permission string) error {
+	return f(ctx, role, permission)
+}
+
+func TestAnyAssertions(t *testing.T) {
+	pass := AssertionFunc(func(context.Context, *Role, string) bool { return true })
+	fail := AssertionFunc(func(context.Context, *Role, string) bool { return false })
+
+	role := NewRole("r")
+	require.NoError(t, role.AddPermissionsE("a\\..*", "a\\.b.*"))
+	role.SetPermissionAssertions("a\\..*", fail)
+	role.SetPermissionAssertions("a\\.b.*", pass, fail)
+	conditions := role.permissionAssertions("a.b", matchPartial)
+	require.Len(t, conditions, 1)
+	assert.False(t, conditions[0].Assert(context.Background(), role, "a.b"))
+
+	role.SetPermissionAssertions("a\\.b.*", pass)
+	assert.True(t, role.permissionAssertions("a.b", matchPartial)[0].Assert(context.Background(), role, "a.b"))
+	assert.Nil(t, role.permissionAssertions("c", matchPartial))
+}
@@ -0,0 +1,127 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	_ Assertion      = (*StepUpAssertion)(nil)
+	_ ErrorAssertion = (*StepUpAssertion)(nil)
+)
+
+var ErrStepUpRequired = errors.New("step-up authentication required")
+
+const (
+	MetadataAMR = "amr"
+	MetadataACR = "acr"
+	MetadataMFA = "mfa"
+)
+
+type AuthLevel int8
+
+const (
+	AuthLevelNone AuthLevel = iota
+	AuthLevelSingleFactor
+	AuthLevelMultiFactor
+)
+
+func (l AuthLevel) String() string {
+	switch l {
+	case AuthLevelNone:
+		return "none"
+	case AuthLevelSingleFactor:
+		return "single-factor"
+	case AuthLevelMultiFactor:
+		return "multi-factor"
+	default:
+		return "unknown"
+	}
+}
+
+type StepUpError struct {
+	Required AuthLevel
+	Current  AuthLevel
+}
+
+func (e *StepUpError) Error() string {
+	return fmt.Sprintf("%s: authentication level %s is below required %s", ErrStepUpRequired, e.Current, e.Required)
+}
+
+func (e *StepUpError) Reason() Reason {
+	return ReasonStepUpRequired{Required: e.Required}
+}
+
+func (e *StepUpError) Unwrap() error {
+	return ErrStepUpRequired
+}
+
+type StepUpAssertion struct {
+	Required AuthLevel
+	// ACRLevels maps "acr" claim values onto authentication levels.
+	ACRLevels map[string]AuthLevel
+}
+
+func AuthStrengthAssertion(required AuthLevel) *StepUpAssertion {
+	return &StepUpAssertion{Required: required}
+}
+
+func (a *StepUpAssertion) Assert(ctx context.Context, role *Role, permission string) bool {
+	return a.AssertE(ctx, role, permission) == nil
+}
+
+func (a *StepUpAssertion) AssertE(ctx context.Context, _ *Role, _ string) error {
+	if current := a.Level(CtxClaims(ctx)); current < a.Required {
+		return &StepUpError{Required: a.Required, Current: current}
+	}
+	return nil
+}
+
+func (a *StepUpAssertion) Level(claims *Claims) AuthLevel {
+	if claims == nil || claims.Metadata == nil {
+		return AuthLevelNone
+	}
+
+	level := AuthLevelNone
+
+	if mfa, _ := claims.Metadata[MetadataMFA].(bool); mfa {
+		level = AuthLevelMultiFactor
+	}
+
+	if amr := metadataStrings(claims.Metadata[MetadataAMR]); len(amr) > 0 {
+		l := AuthLevelSingleFactor
+		if slices.Contains(amr, "mfa") || len(amr) > 1 {
+			l = AuthLevelMultiFactor
+		}
+		level = max(level, l)
+	}
+
+	if acr, ok := claims.Metadata[MetadataACR].(string); ok {
+		if l, ok := a.ACRLevels[acr]; ok {
+			level = max(level, l)
+		}
+	}
+
+	return level
+}
+
+func metadataStrings(value any) []string {
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []string:
+		return value
+	case []any:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			if v, ok := v.(string); ok {
+				values = append(values, v)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
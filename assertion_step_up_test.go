@@ -0,0 +1,47 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepUpAssertion_Level(t *testing.T) {
+	a := AuthStrengthAssertion(AuthLevelMultiFactor)
+	a.ACRLevels = map[string]AuthLevel{"urn:mace:incommon:iap:silver": AuthLevelMultiFactor}
+
+	assert.Equal(t, AuthLevelNone, a.Level(nil))
+	assert.Equal(t, AuthLevelNone, a.Level(&Claims{}))
+	assert.Equal(t, AuthLevelSingleFactor, a.Level(&Claims{Metadata: map[string]any{MetadataAMR: []string{"pwd"}}}))
+	assert.Equal(t, AuthLevelMultiFactor, a.Level(&Claims{Metadata: map[string]any{MetadataAMR: []any{"pwd", "otp"}}}))
+	assert.Equal(t, AuthLevelMultiFactor, a.Level(&Claims{Metadata: map[string]any{MetadataAMR: "mfa"}}))
+	assert.Equal(t, AuthLevelMultiFactor, a.Level(&Claims{Metadata: map[string]any{MetadataMFA: true}}))
+	assert.Equal(t, AuthLevelMultiFactor, a.Level(&Claims{Metadata: map[string]any{MetadataACR: "urn:mace:incommon:iap:silver"}}))
+	assert.Equal(t, AuthLevelNone, a.Level(&Claims{Metadata: map[string]any{MetadataACR: "unknown"}}))
+}
+
+func TestStepUpAssertion_IsGranted(t *testing.T) {
+	rbac := New()
+	role := NewRole("admin")
+	role.AddPermissions("payments:refund")
+	assert.NoError(t, rbac.AddRole(role))
+
+	authorizer := NewDefaultAuthorizer(rbac)
+	target := &Target{Action: "payments:refund", Assertions: []Assertion{AuthStrengthAssertion(AuthLevelMultiFactor)}}
+
+	claims := &Claims{Subject: &testSubject{roles: []string{"admin"}}, Metadata: map[string]any{MetadataAMR: []string{"pwd"}}}
+	decision, err := authorizer.AuthorizeE(context.Background(), claims, target)
+	assert.Equal(t, DecisionDeny, decision)
+	assert.ErrorIs(t, err, ErrStepUpRequired)
+
+	var stepUp *StepUpError
+	assert.ErrorAs(t, err, &stepUp)
+	assert.Equal(t, AuthLevelMultiFactor, stepUp.Required)
+	assert.Equal(t, AuthLevelSingleFactor, stepUp.Current)
+
+	claims.Metadata[MetadataMFA] = true
+	decision, err = authorizer.AuthorizeE(context.Background(), claims, target)
+	assert.Equal(t, DecisionAllow, decision)
+	assert.NoError(t, err)
+}
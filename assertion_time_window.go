@@ -0,0 +1,65 @@
+package rbac
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+var _ Assertion = (*TimeWindowAssertion)(nil)
+
+// TimeWindowAssertion passes during a daily window, e.g. business hours or
+// a maintenance window.
+type TimeWindowAssertion struct {
+	location   *time.Location
+	days       []time.Weekday
+	start, end time.Duration
+	now        func() time.Time
+}
+
+// NewTimeWindowAssertion passes from start to end, both offsets from
+// midnight in the location, on the given days or every day if none are
+// given. A window ending before it starts crosses midnight and belongs to
+// the day it starts on. A nil location means time.Local.
+func NewTimeWindowAssertion(location *time.Location, days []time.Weekday, start, end time.Duration) *TimeWindowAssertion {
+	if location == nil {
+		location = time.Local
+	}
+	return &TimeWindowAssertion{location: location, days: days, start: start, end: end, now: time.Now}
+}
+
+func (a *TimeWindowAssertion) SetNow(now func() time.Time) *TimeWindowAssertion {
+	a.now = now
+	return a
+}
+
+func (a *TimeWindowAssertion) Name() string {
+	return "time_window"
+}
+
+// Assert checks the time set by WithDecisionTime, if any, or the current
+// one.
+func (a *TimeWindowAssertion) Assert(ctx context.Context, _ *Role, _ string) bool {
+	return a.Contains(decisionTime(ctx, a.now))
+}
+
+// Contains reports whether t falls into the window.
+func (a *TimeWindowAssertion) Contains(t time.Time) bool {
+	t = t.In(a.location)
+	// wall clock offset, unaffected by daylight saving transitions
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+
+	if a.start <= a.end {
+		return a.on(t.Weekday()) && offset >= a.start && offset < a.end
+	}
+	// crossing midnight: the evening of today or the morning after yesterday
+	if offset >= a.start {
+		return a.on(t.Weekday())
+	}
+	return offset < a.end && a.on((t.Weekday()+6)%7)
+}
+
+func (a *TimeWindowAssertion) on(day time.Weekday) bool {
+	return len(a.days) == 0 || slices.Contains(a.days, day)
+}
@@ -0,0 +1,51 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeWindowAssertion(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+	a := NewTimeWindowAssertion(berlin, weekdays, 9*time.Hour, 17*time.Hour)
+	assert.Equal(t, "time_window", AssertionName(a))
+
+	// 2026-10-16 is a Friday
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 10, day, hour, minute, 0, 0, berlin) }
+	assert.True(t, a.Contains(at(16, 9, 0)))
+	assert.True(t, a.Contains(at(16, 16, 59)))
+	assert.False(t, a.Contains(at(16, 17, 0)))
+	assert.False(t, a.Contains(at(16, 8, 59)))
+	assert.False(t, a.Contains(at(17, 12, 0)))
+	assert.True(t, a.Contains(at(16, 12, 0).UTC()))
+
+	now := at(16, 12, 0)
+	a.SetNow(func() time.Time { return now })
+	assert.True(t, a.Assert(context.Background(), nil, "deploy"))
+	now = at(18, 12, 0)
+	assert.False(t, a.Assert(context.Background(), nil, "deploy"))
+	assert.True(t, a.Assert(WithDecisionTime(context.Background(), at(16, 12, 0)), nil, "deploy"))
+
+	// Friday night maintenance crossing midnight
+	m := NewTimeWindowAssertion(berlin, []time.Weekday{time.Friday}, 22*time.Hour, 2*time.Hour)
+	assert.True(t, m.Contains(at(16, 23, 0)))
+	assert.True(t, m.Contains(at(17, 1, 59)))
+	assert.False(t, m.Contains(at(17, 2, 0)))
+	assert.False(t, m.Contains(at(17, 23, 0)))
+	assert.False(t, m.Contains(at(16, 1, 0)))
+
+	// 2026-10-25 03:00 CEST falls back to 02:00 CET
+	sunday := NewTimeWindowAssertion(berlin, []time.Weekday{time.Sunday}, 9*time.Hour, 17*time.Hour)
+	assert.True(t, sunday.Contains(at(25, 9, 0)))
+	assert.False(t, sunday.Contains(at(25, 8, 30)))
+
+	every := NewTimeWindowAssertion(nil, nil, 0, 24*time.Hour)
+	assert.True(t, every.Contains(time.Now()))
+}
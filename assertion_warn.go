@@ -0,0 +1,33 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+)
+
+var (
+	_ Assertion      = (*WarnAssertion)(nil)
+	_ ErrorAssertion = (*WarnAssertion)(nil)
+)
+
+// WarnAssertion turns a failing assertion into a warning: the permission is
+// still granted but the decision becomes DecisionWarn.
+type WarnAssertion struct {
+	assertion Assertion
+	reason    string
+}
+
+func Warn(assertion Assertion, reason string) *WarnAssertion {
+	return &WarnAssertion{assertion: assertion, reason: reason}
+}
+
+func (a *WarnAssertion) Assert(context.Context, *Role, string) bool {
+	return true
+}
+
+func (a *WarnAssertion) AssertE(ctx context.Context, role *Role, permission string) error {
+	if a.assertion.Assert(ctx, role, permission) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrWarn, a.reason)
+}
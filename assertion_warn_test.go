@@ -0,0 +1,46 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarnAssertion(t *testing.T) {
+	rbac := New()
+	user := NewRole("user")
+	user.AddPermissions("posts:delete")
+	assert.NoError(t, rbac.AddRole(user))
+
+	warn := Warn(&testAssertion{shouldPass: false}, "deleting posts will require approval")
+
+	granted, err := rbac.IsGrantedE(context.Background(), "user", "posts:delete", warn)
+	assert.True(t, granted)
+	assert.ErrorIs(t, err, ErrWarn)
+	assert.ErrorContains(t, err, "deleting posts will require approval")
+	assert.True(t, rbac.IsGranted(context.Background(), "user", "posts:delete", warn))
+	assert.False(t, rbac.IsGranted(context.Background(), "user", "posts:delete", warn, &testAssertion{shouldPass: false}))
+
+	authorizer := NewDefaultAuthorizer(rbac)
+	claims := &Claims{Subject: &testSubject{roles: []string{"user"}}}
+
+	d, err := authorizer.AuthorizeE(context.Background(), claims, &Target{Action: "posts:delete", Assertions: []Assertion{warn}})
+	assert.Equal(t, DecisionWarn, d)
+	assert.True(t, d.Allowed())
+	assert.ErrorIs(t, err, ErrWarn)
+
+	d, err = authorizer.AuthorizeE(context.Background(), claims, &Target{
+		Action:     "posts:delete",
+		Assertions: []Assertion{Warn(&testAssertion{shouldPass: true}, "unused")},
+	})
+	assert.Equal(t, DecisionAllow, d)
+	assert.NoError(t, err)
+}
+
+func TestRequestAuthorizer_Warn(t *testing.T) {
+	authorize := RequestAuthorizer(&mockAuthorizer{decision: DecisionWarn})
+	assert.Equal(t, DecisionWarn, authorize(httptest.NewRequest(http.MethodGet, "/", nil)))
+}
@@ -0,0 +1,206 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	_ AssignmentStore = (*MemoryAssignmentStore)(nil)
+
+	ErrInvalidAssignment = errors.New("invalid assignment")
+)
+
+// Assignment grants a role to a subject. A zero ValidFrom or ValidUntil
+// leaves the grant unbounded on that side, ValidUntil is exclusive.
+type Assignment struct {
+	Subject    string    `json:"subject"`
+	Role       string    `json:"role"`
+	ValidFrom  time.Time `json:"valid_from,omitzero"`
+	ValidUntil time.Time `json:"valid_until,omitzero"`
+}
+
+// Active reports whether the grant is in effect at the given time.
+func (a Assignment) Active(at time.Time) bool {
+	return (a.ValidFrom.IsZero() || !at.Before(a.ValidFrom)) && (a.ValidUntil.IsZero() || at.Before(a.ValidUntil))
+}
+
+// Expired reports whether the grant has ended at the given time.
+func (a Assignment) Expired(at time.Time) bool {
+	return !a.ValidUntil.IsZero() && !at.Before(a.ValidUntil)
+}
+
+func (a Assignment) validate() error {
+	if a.Subject == "" || a.Role == "" {
+		return fmt.Errorf("%w: subject and role are required", ErrInvalidAssignment)
+	}
+	if !a.ValidFrom.IsZero() && !a.ValidUntil.IsZero() && !a.ValidFrom.Before(a.ValidUntil) {
+		return fmt.Errorf(`%w: grant of role "%s" to "%s" ends before it starts`, ErrInvalidAssignment, a.Role, a.Subject)
+	}
+	return nil
+}
+
+// AssignmentStore persists which roles are assigned to which subjects.
+type AssignmentStore interface {
+	// AssignRole grants the role to the subject without time bounds.
+	AssignRole(ctx context.Context, subject, role string) error
+	// GrantRole creates or replaces the grant of a role to a subject.
+	GrantRole(ctx context.Context, assignment Assignment) error
+	RevokeRole(ctx context.Context, subject, role string) error
+	// RolesOf returns the roles currently in effect for the subject sorted
+	// by name.
+	RolesOf(ctx context.Context, subject string) ([]string, error)
+	// Assignments returns every grant of the subject, including pending and
+	// expired ones, sorted by role.
+	Assignments(ctx context.Context, subject string) ([]Assignment, error)
+	// Sweep removes grants expired at the given time and returns their number.
+	Sweep(ctx context.Context, now time.Time) (int, error)
+}
+
+// AssignmentResolver resolves subjects to the roles currently assigned in
+// the store. Subjects without assignments resolve without roles.
+func AssignmentResolver(store AssignmentStore) SubjectResolver {
+	return SubjectResolverFunc(func(ctx context.Context, identifier string) (Subject, error) {
+		roles, err := store.RolesOf(ctx, identifier)
+		if err != nil {
+			return nil, err
+		}
+		return NewSubject(identifier, roles...), nil
+	})
+}
+
+// ClaimsOf builds claims for the subject carrying the roles currently
+// assigned in the store. The ends of time-bounded grants are recorded under
+// MetadataRoleExpiry, so DefaultAuthorizer ignores roles whose grant expires
+// while the claims are still in use.
+func ClaimsOf(ctx context.Context, store AssignmentStore, subject string) (*Claims, error) {
+	assignments, err := store.Assignments(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var roles []string
+	expiry := map[string]time.Time{}
+	for _, a := range assignments {
+		if !a.Active(now) {
+			continue
+		}
+		roles = append(roles, a.Role)
+		if !a.ValidUntil.IsZero() {
+			expiry[a.Role] = a.ValidUntil
+		}
+	}
+
+	claims := &Claims{Subject: NewSubject(subject, roles...), Metadata: map[string]any{}}
+	if len(expiry) > 0 {
+		claims.Metadata[MetadataRoleExpiry] = expiry
+	}
+	return claims, nil
+}
+
+// SweepAssignments sweeps expired grants from the store every interval until
+// ctx is done or sweeping fails.
+func SweepAssignments(ctx context.Context, store AssignmentStore, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := store.Sweep(ctx, time.Now()); err != nil {
+			return err
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func activeRoles(assignments []Assignment, at time.Time) []string {
+	var roles []string
+	for _, a := range assignments {
+		if a.Active(at) {
+			roles = append(roles, a.Role)
+		}
+	}
+	return roles
+}
+
+type MemoryAssignmentStore struct {
+	mu          sync.RWMutex
+	assignments map[string]map[string]Assignment
+}
+
+func NewMemoryAssignmentStore() *MemoryAssignmentStore {
+	return &MemoryAssignmentStore{assignments: map[string]map[string]Assignment{}}
+}
+
+func (s *MemoryAssignmentStore) AssignRole(ctx context.Context, subject, role string) error {
+	return s.GrantRole(ctx, Assignment{Subject: subject, Role: role})
+}
+
+func (s *MemoryAssignmentStore) GrantRole(_ context.Context, assignment Assignment) error {
+	if err := assignment.validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.assignments[assignment.Subject] == nil {
+		s.assignments[assignment.Subject] = map[string]Assignment{}
+	}
+	s.assignments[assignment.Subject][assignment.Role] = assignment
+	return nil
+}
+
+func (s *MemoryAssignmentStore) RevokeRole(_ context.Context, subject, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revoke(subject, role)
+	return nil
+}
+
+func (s *MemoryAssignmentStore) RolesOf(ctx context.Context, subject string) ([]string, error) {
+	assignments, err := s.Assignments(ctx, subject)
+	return activeRoles(assignments, time.Now()), err
+}
+
+func (s *MemoryAssignmentStore) Assignments(_ context.Context, subject string) ([]Assignment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var assignments []Assignment
+	for _, role := range sortedKeys(s.assignments[subject]) {
+		assignments = append(assignments, s.assignments[subject][role])
+	}
+	return assignments, nil
+}
+
+func (s *MemoryAssignmentStore) Sweep(_ context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int
+	for subject, roles := range s.assignments {
+		for role, a := range roles {
+			if a.Expired(now) {
+				s.revoke(subject, role)
+				n++
+			}
+		}
+	}
+	return n, nil
+}
+
+func (s *MemoryAssignmentStore) revoke(subject, role string) {
+	delete(s.assignments[subject], role)
+	if len(s.assignments[subject]) == 0 {
+		delete(s.assignments, subject)
+	}
+}
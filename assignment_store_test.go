@@ -0,0 +1,136 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAssignmentStore(t *testing.T, store AssignmentStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	require.NoError(t, store.AssignRole(ctx, "u1", "editor"))
+	require.NoError(t, store.AssignRole(ctx, "u1", "admin"))
+	require.NoError(t, store.AssignRole(ctx, "u1", "admin"))
+	require.NoError(t, store.AssignRole(ctx, "u2", "viewer"))
+
+	roles, err := store.RolesOf(ctx, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin", "editor"}, roles)
+
+	require.NoError(t, store.RevokeRole(ctx, "u1", "admin"))
+	require.NoError(t, store.RevokeRole(ctx, "u1", "missing"))
+	roles, err = store.RolesOf(ctx, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"editor"}, roles)
+
+	roles, err = store.RolesOf(ctx, "unknown")
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+
+	// bounds are kept at second precision
+	now := time.Now().Truncate(time.Second)
+	require.NoError(t, store.GrantRole(ctx, Assignment{Subject: "u3", Role: "oncall", ValidUntil: now.Add(-time.Second)}))
+	require.NoError(t, store.GrantRole(ctx, Assignment{Subject: "u3", Role: "contractor", ValidFrom: now.Add(-time.Hour), ValidUntil: now.Add(time.Hour)}))
+	require.NoError(t, store.GrantRole(ctx, Assignment{Subject: "u3", Role: "pending", ValidFrom: now.Add(time.Hour)}))
+	assert.ErrorIs(t, store.GrantRole(ctx, Assignment{Subject: "u3", Role: "x", ValidFrom: now, ValidUntil: now}), ErrInvalidAssignment)
+
+	roles, err = store.RolesOf(ctx, "u3")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"contractor"}, roles)
+
+	assignments, err := store.Assignments(ctx, "u3")
+	require.NoError(t, err)
+	require.Len(t, assignments, 3)
+	assert.Equal(t, "contractor", assignments[0].Role)
+	assert.True(t, assignments[0].ValidUntil.Equal(now.Add(time.Hour)))
+	assert.True(t, assignments[2].ValidUntil.IsZero())
+
+	n, err := store.Sweep(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assignments, err = store.Assignments(ctx, "u3")
+	require.NoError(t, err)
+	assert.Len(t, assignments, 2)
+
+	n, err = store.Sweep(ctx, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	roles, err = store.RolesOf(ctx, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"editor"}, roles)
+}
+
+func TestMemoryAssignmentStore(t *testing.T) {
+	testAssignmentStore(t, NewMemoryAssignmentStore())
+}
+
+func TestAssignmentResolver(t *testing.T) {
+	rbac := New()
+	editor := NewRole("editor")
+	require.NoError(t, editor.AddPermissionsE("posts.edit"))
+	require.NoError(t, rbac.AddRole(editor))
+
+	store := NewMemoryAssignmentStore()
+	require.NoError(t, store.AssignRole(context.Background(), "u1", "editor"))
+
+	subject, err := AssignmentResolver(store).Resolve(context.Background(), "u1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"editor"}, subject.Roles())
+
+	claims, err := ClaimsOf(context.Background(), store, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, "u1", SubjectID(claims.Subject))
+
+	authorizer := NewDefaultAuthorizer(rbac)
+	assert.Equal(t, DecisionAllow, authorizer.Authorize(context.Background(), claims, &Target{Action: "posts.edit"}))
+
+	claims, err = ClaimsOf(context.Background(), store, "u2")
+	require.NoError(t, err)
+	assert.Equal(t, DecisionDeny, authorizer.Authorize(context.Background(), claims, &Target{Action: "posts.edit"}))
+}
+
+func TestClaimsOf_GrantExpiry(t *testing.T) {
+	rbac := New()
+	editor := NewRole("editor")
+	require.NoError(t, editor.AddPermissionsE("posts.edit"))
+	require.NoError(t, rbac.AddRole(editor))
+	authorizer := NewDefaultAuthorizer(rbac).SetMaxTTL(time.Hour)
+
+	store := NewMemoryAssignmentStore()
+	until := time.Now().Add(time.Minute)
+	require.NoError(t, store.GrantRole(context.Background(), Assignment{Subject: "u1", Role: "editor", ValidUntil: until}))
+
+	claims, err := ClaimsOf(context.Background(), store, "u1")
+	require.NoError(t, err)
+	exp, ok := RoleGrantExpiry(claims, "editor")
+	require.True(t, ok)
+	assert.Equal(t, until, exp)
+	assert.Equal(t, DecisionAllow, authorizer.Authorize(context.Background(), claims, &Target{Action: "posts.edit"}))
+	assert.LessOrEqual(t, authorizer.DecisionTTL(context.Background(), claims, nil), time.Minute)
+
+	// claims outliving the grant
+	claims.Metadata[MetadataRoleExpiry] = map[string]any{"editor": time.Now().Add(-time.Second).Unix()}
+	d, err := authorizer.AuthorizeE(context.Background(), claims, &Target{Action: "posts.edit"})
+	assert.Equal(t, DecisionDeny, d)
+	assert.Equal(t, []Reason{ReasonGrantExpired{Role: "editor"}}, Reasons(err))
+	assert.Zero(t, authorizer.DecisionTTL(context.Background(), claims, nil))
+	assert.Equal(t, "Your access as editor has expired.", DefaultMessageCatalog.Message("en", ReasonGrantExpired{Role: "editor"}))
+}
+
+func TestSweepAssignments(t *testing.T) {
+	store := NewMemoryAssignmentStore()
+	require.NoError(t, store.GrantRole(context.Background(), Assignment{Subject: "u1", Role: "oncall", ValidUntil: time.Now().Add(-time.Second)}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, SweepAssignments(ctx, store, time.Millisecond), context.DeadlineExceeded)
+
+	assignments, err := store.Assignments(context.Background(), "u1")
+	require.NoError(t, err)
+	assert.Empty(t, assignments)
+}
@@ -0,0 +1,394 @@
+package rbac
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var _ Authorizer = (*AuditAuthorizer)(nil)
+
+// AuditEvent describes a single authorization decision for security
+// tooling. Unlike DecisionRecord it is not anonymized.
+type AuditEvent struct {
+	Time     time.Time `json:"time"`
+	Subject  string    `json:"subject,omitempty"`
+	Actor    string    `json:"actor,omitempty"`
+	Roles    []string  `json:"roles,omitempty"`
+	Action   string    `json:"action"`
+	Decision Decision  `json:"decision"`
+	// Role is the subject role that granted the action, if known.
+	Role       string        `json:"role,omitempty"`
+	Latency    time.Duration `json:"latency,omitempty"`
+	Method     string        `json:"method,omitempty"`
+	Path       string        `json:"path,omitempty"`
+	RemoteAddr string        `json:"remoteAddr,omitempty"`
+}
+
+func NewAuditEvent(ctx context.Context, claims *Claims, target *Target, d Decision) AuditEvent {
+	event := AuditEvent{Time: time.Now().UTC(), Decision: d}
+	if target != nil {
+		event.Action = target.Action
+	}
+	if claims != nil {
+		if claims.Subject != nil {
+			event.Subject = SubjectID(claims.Subject)
+			event.Roles = claims.Subject.Roles()
+		}
+		if claims.Actor != nil {
+			event.Actor = SubjectID(claims.Actor)
+		}
+	}
+	info := CtxRequestInfo(ctx)
+	event.Method, event.RemoteAddr = info.Method, info.RemoteAddr
+	if info.URL != nil {
+		event.Path = info.URL.Path
+	}
+	return event
+}
+
+type AuditEncoder interface {
+	Encode(event AuditEvent) ([]byte, error)
+}
+
+type AuditShipper interface {
+	Ship(ctx context.Context, payload []byte) error
+}
+
+// CEFEncoder encodes events in ArcSight Common Event Format.
+type CEFEncoder struct {
+	Vendor  string
+	Product string
+	Version string
+}
+
+var cefHeaderEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`)
+
+var cefValueEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+
+func (e CEFEncoder) Encode(event AuditEvent) ([]byte, error) {
+	severity, name := 1, "Authorization allowed"
+	if !event.Decision.Allowed() {
+		severity, name = 5, "Authorization denied"
+	}
+
+	var b strings.Builder
+	b.WriteString("CEF:0")
+	for _, field := range []string{e.Vendor, e.Product, e.Version, "authz:" + event.Decision.String(), name, strconv.Itoa(severity)} {
+		b.WriteByte('|')
+		b.WriteString(cefHeaderEscaper.Replace(field))
+	}
+	b.WriteByte('|')
+
+	ext := []string{
+		"rt", strconv.FormatInt(event.Time.UnixMilli(), 10),
+		"act", event.Action,
+		"outcome", event.Decision.String(),
+		"suid", event.Subject,
+		"suser", event.Actor,
+		"cs1Label", "roles",
+		"cs1", strings.Join(event.Roles, ","),
+		"requestMethod", event.Method,
+		"request", event.Path,
+		"src", remoteIP(event.RemoteAddr),
+	}
+	first := true
+	for i := 0; i < len(ext); i += 2 {
+		if ext[i+1] == "" {
+			continue
+		}
+		if !first {
+			b.WriteByte(' ')
+		}
+		first = false
+		b.WriteString(ext[i])
+		b.WriteByte('=')
+		b.WriteString(cefValueEscaper.Replace(ext[i+1]))
+	}
+	return []byte(b.String()), nil
+}
+
+// OCSFEncoder encodes events as OCSF Authorize Session (class 3003) JSON.
+type OCSFEncoder struct {
+	Vendor  string
+	Product string
+	Version string
+}
+
+func (e OCSFEncoder) Encode(event AuditEvent) ([]byte, error) {
+	status, statusID, severityID := "Success", 1, 1
+	if !event.Decision.Allowed() {
+		status, statusID, severityID = "Failure", 2, 3
+	}
+
+	groups := make([]map[string]string, 0, len(event.Roles))
+	for _, role := range event.Roles {
+		groups = append(groups, map[string]string{"name": role})
+	}
+
+	record := map[string]any{
+		"class_uid":     3003,
+		"category_uid":  3,
+		"activity_id":   99,
+		"activity_name": "Authorize",
+		"type_uid":      300399,
+		"time":          event.Time.UnixMilli(),
+		"severity_id":   severityID,
+		"status_id":     statusID,
+		"status":        status,
+		"message":       fmt.Sprintf("%s %s", event.Action, event.Decision),
+		"privileges":    []string{event.Action},
+		"user":          map[string]any{"uid": event.Subject, "groups": groups},
+		"metadata": map[string]any{
+			"version": "1.1.0",
+			"product": map[string]string{"name": e.Product, "vendor_name": e.Vendor, "version": e.Version},
+		},
+	}
+	if event.Actor != "" {
+		record["actor"] = map[string]any{"user": map[string]string{"uid": event.Actor}}
+	}
+	if ip := remoteIP(event.RemoteAddr); ip != "" {
+		record["src_endpoint"] = map[string]string{"ip": ip}
+	}
+	if event.Method != "" || event.Path != "" {
+		record["http_request"] = map[string]any{"http_method": event.Method, "url": map[string]string{"path": event.Path}}
+	}
+	return json.Marshal(record)
+}
+
+func remoteIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// SyslogShipper writes RFC 5424 messages, e.g. to a net.Conn from
+// net.Dial("udp", "siem:514"). Messages are newline terminated for TCP.
+type SyslogShipper struct {
+	mu       sync.Mutex
+	w        io.Writer
+	facility int
+	hostname string
+	appName  string
+}
+
+func NewSyslogShipper(w io.Writer, appName string) *SyslogShipper {
+	hostname, _ := os.Hostname()
+	return &SyslogShipper{w: w, facility: 13, hostname: hostname, appName: appName}
+}
+
+// SetFacility sets the syslog facility, 13 (log audit) by default.
+func (s *SyslogShipper) SetFacility(facility int) *SyslogShipper {
+	s.facility = facility
+	return s
+}
+
+func (s *SyslogShipper) Ship(_ context.Context, payload []byte) error {
+	msg := fmt.Sprintf("<%d>1 %s %s %s %d - - %s\n",
+		s.facility*8+6, time.Now().UTC().Format(time.RFC3339Nano), nilValue(s.hostname), nilValue(s.appName), os.Getpid(), payload)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := io.WriteString(s.w, msg)
+	return err
+}
+
+func nilValue(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// HTTPShipper posts every payload to a collector such as a Splunk HEC or
+// Sentinel data collection endpoint.
+type HTTPShipper struct {
+	url         string
+	client      *http.Client
+	timeout     time.Duration
+	header      http.Header
+	contentType string
+}
+
+// NewHTTPShipper bounds every post by a timeout of 5 seconds, so a stalled
+// collector cannot block the shipping queue.
+func NewHTTPShipper(url, contentType string) *HTTPShipper {
+	return &HTTPShipper{url: url, client: http.DefaultClient, timeout: 5 * time.Second, header: http.Header{}, contentType: contentType}
+}
+
+func (s *HTTPShipper) SetClient(client *http.Client) *HTTPShipper {
+	s.client = client
+	return s
+}
+
+// SetTimeout bounds every post, zero disables the timeout.
+func (s *HTTPShipper) SetTimeout(timeout time.Duration) *HTTPShipper {
+	s.timeout = timeout
+	return s
+}
+
+// SetHeader adds a request header, typically Authorization.
+func (s *HTTPShipper) SetHeader(key, value string) *HTTPShipper {
+	s.header.Set(key, value)
+	return s
+}
+
+func (s *HTTPShipper) Ship(ctx context.Context, payload []byte) error {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	for key, values := range s.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", s.contentType)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("audit: collector responded with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// DefaultAuditQueueSize is the number of events AuditAuthorizer buffers for
+// shipping unless set.
+const DefaultAuditQueueSize = 1024
+
+// AuditAuthorizer ships an audit event for every decision of the wrapped
+// authorizer. Events are shipped in the background from a bounded queue;
+// when it is full they are dropped and counted. Shipping errors are
+// reported to the OnError callback and never change the decision.
+type AuditAuthorizer struct {
+	authorizer Authorizer
+	encoder    AuditEncoder
+	shipper    AuditShipper
+	sampler    *DecisionSampler
+	onError    func(err error)
+	queueSize  int
+
+	start   sync.Once
+	mu      sync.RWMutex
+	queue   chan auditPayload
+	closed  bool
+	done    chan struct{}
+	dropped atomic.Int64
+}
+
+type auditPayload struct {
+	ctx     context.Context
+	payload []byte
+}
+
+func NewAuditAuthorizer(authorizer Authorizer, encoder AuditEncoder, shipper AuditShipper) *AuditAuthorizer {
+	return &AuditAuthorizer{authorizer: authorizer, encoder: encoder, shipper: shipper, queueSize: DefaultAuditQueueSize}
+}
+
+// SetSampler limits the shipped events; without a sampler every decision
+// is shipped.
+func (a *AuditAuthorizer) SetSampler(sampler *DecisionSampler) *AuditAuthorizer {
+	a.sampler = sampler
+	return a
+}
+
+func (a *AuditAuthorizer) OnError(fn func(err error)) *AuditAuthorizer {
+	a.onError = fn
+	return a
+}
+
+// SetQueueSize sets how many events wait for shipping before new ones are
+// dropped. It has no effect after the first decision.
+func (a *AuditAuthorizer) SetQueueSize(size int) *AuditAuthorizer {
+	a.queueSize = max(size, 1)
+	return a
+}
+
+// Dropped returns how many events were dropped because the queue was full
+// or the authorizer closed.
+func (a *AuditAuthorizer) Dropped() int64 {
+	return a.dropped.Load()
+}
+
+// Close ships the queued events and stops shipping; later events are
+// dropped.
+func (a *AuditAuthorizer) Close() error {
+	a.start.Do(a.run)
+
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+
+	<-a.done
+	return nil
+}
+
+func (a *AuditAuthorizer) Authorize(ctx context.Context, claims *Claims, target *Target) Decision {
+	d := a.authorizer.Authorize(ctx, claims, target)
+	if !a.sampler.Sample(claims, target, d) {
+		return d
+	}
+
+	payload, err := a.encoder.Encode(NewAuditEvent(ctx, claims, target, d))
+	if err != nil {
+		a.report(err)
+		return d
+	}
+	a.enqueue(auditPayload{ctx: context.WithoutCancel(ctx), payload: payload})
+	return d
+}
+
+func (a *AuditAuthorizer) enqueue(p auditPayload) {
+	a.start.Do(a.run)
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		a.dropped.Add(1)
+		return
+	}
+	select {
+	case a.queue <- p:
+	default:
+		a.dropped.Add(1)
+	}
+}
+
+func (a *AuditAuthorizer) run() {
+	a.queue = make(chan auditPayload, a.queueSize)
+	a.done = make(chan struct{})
+	go func() {
+		defer close(a.done)
+		for p := range a.queue {
+			a.report(a.shipper.Ship(p.ctx, p.payload))
+		}
+	}()
+}
+
+func (a *AuditAuthorizer) report(err error) {
+	if err != nil && a.onError != nil {
+		a.onError(err)
+	}
+}
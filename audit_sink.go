@@ -0,0 +1,168 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+var _ AuditSink = (*JSONLinesAuditSink)(nil)
+
+// AuditSink receives an event for every decision of a DefaultAuthorizer.
+// RequestAuthorizer records one event per request instead of one per tried
+// action, with the final decision and the request method, path and remote
+// address.
+type AuditSink interface {
+	Record(ctx context.Context, event AuditEvent)
+}
+
+type AuditSinkFunc func(ctx context.Context, event AuditEvent)
+
+func (f AuditSinkFunc) Record(ctx context.Context, event AuditEvent) {
+	f(ctx, event)
+}
+
+// SetAuditSink records every decision with the granting role and latency.
+// Memoized decisions are recorded without role.
+func (a *DefaultAuthorizer) SetAuditSink(sink AuditSink) *DefaultAuthorizer {
+	a.sink = sink
+	return a
+}
+
+func (a *DefaultAuthorizer) authorizeAudited(ctx context.Context, claims *Claims, target *Target) (Decision, error) {
+	started := time.Now()
+	e := &explanation{}
+	d, err := a.authorizeMemo(context.WithValue(ctx, explanationKey{}, e), claims, target)
+
+	event := NewAuditEvent(ctx, claims, target, d)
+	event.Latency = time.Since(started)
+	for _, r := range e.roles {
+		if r.Granted {
+			event.Role = r.Role
+			break
+		}
+	}
+	if audit, ok := ctx.Value(requestAuditKey{}).(*requestAudit); ok {
+		audit.add(a.sink, event)
+	} else {
+		a.sink.Record(ctx, event)
+	}
+
+	return d, err
+}
+
+type requestAuditKey struct{}
+
+// requestAudit collects the events of the actions tried for a request so
+// that a single one is recorded with the final decision.
+type requestAudit struct {
+	mu      sync.Mutex
+	started time.Time
+	sink    AuditSink
+	event   AuditEvent
+}
+
+func withRequestAudit(ctx context.Context) (context.Context, *requestAudit) {
+	audit := &requestAudit{started: time.Now()}
+	return context.WithValue(ctx, requestAuditKey{}, audit), audit
+}
+
+// add keeps the first allowed event, the latest one otherwise.
+func (r *requestAudit) add(sink AuditSink, event AuditEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sink != nil && r.event.Decision.Allowed() {
+		return
+	}
+	r.sink, r.event = sink, event
+}
+
+// flush records the request decision if any action was audited.
+func (r *requestAudit) flush(ctx context.Context, action string, d Decision) {
+	r.mu.Lock()
+	sink, event := r.sink, r.event
+	r.mu.Unlock()
+	if sink == nil {
+		return
+	}
+	event.Action, event.Decision = action, d
+	if !d.Allowed() {
+		event.Role = ""
+	}
+	event.Latency = time.Since(r.started)
+	sink.Record(ctx, event)
+}
+
+// SlogAuditSink logs allowed decisions at info and denials at warn level.
+func SlogAuditSink(logger *slog.Logger) AuditSink {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return AuditSinkFunc(func(ctx context.Context, event AuditEvent) {
+		level := slog.LevelInfo
+		if !event.Decision.Allowed() {
+			level = slog.LevelWarn
+		}
+		logger.Log(ctx, level, "rbac: decision",
+			slog.String("subject", event.Subject),
+			slog.String("action", event.Action),
+			slog.String("decision", event.Decision.String()),
+			slog.String("role", event.Role),
+			slog.Duration("latency", event.Latency),
+			slog.String("method", event.Method),
+			slog.String("path", event.Path),
+			slog.String("remote_addr", event.RemoteAddr),
+		)
+	})
+}
+
+// JSONLinesAuditSink writes one JSON object per event.
+type JSONLinesAuditSink struct {
+	mu      sync.Mutex
+	w       io.Writer
+	onError func(err error)
+}
+
+func NewJSONLinesAuditSink(w io.Writer) *JSONLinesAuditSink {
+	return &JSONLinesAuditSink{w: w}
+}
+
+// OpenJSONLinesAuditSink appends events to the file at path.
+func OpenJSONLinesAuditSink(path string) (*JSONLinesAuditSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return NewJSONLinesAuditSink(f), nil
+}
+
+func (s *JSONLinesAuditSink) OnError(fn func(err error)) *JSONLinesAuditSink {
+	s.onError = fn
+	return s
+}
+
+func (s *JSONLinesAuditSink) Record(_ context.Context, event AuditEvent) {
+	line, err := json.Marshal(event)
+	if err == nil {
+		line = append(line, '\n')
+
+		s.mu.Lock()
+		_, err = s.w.Write(line)
+		s.mu.Unlock()
+	}
+	if err != nil && s.onError != nil {
+		s.onError(err)
+	}
+}
+
+// Close closes the underlying writer if it is an io.Closer.
+func (s *JSONLinesAuditSink) Close() error {
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
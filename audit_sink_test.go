@@ -0,0 +1,102 @@
+package rbac
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestDefaultAuthorizer_SetAuditSink(t *testing.T) {
+	rbac := New()
+	role := NewRole("editor")
+	require.NoError(t, role.AddPermissionsE("GET /posts"))
+	require.NoError(t, rbac.AddRole(role))
+
+	var events []AuditEvent
+	a := NewDefaultAuthorizer(rbac).SetAuditSink(AuditSinkFunc(func(_ context.Context, event AuditEvent) {
+		events = append(events, event)
+	}))
+
+	authorize := RequestAuthorizer(a)
+	r := httptest.NewRequest(http.MethodGet, "/posts", nil)
+	r = r.WithContext(WithClaims(r.Context(), &Claims{Subject: NewSubject("u1", "viewer", "editor")}))
+	assert.Equal(t, DecisionAllow, authorize(r))
+
+	require.Len(t, events, 1)
+	last := events[0]
+	assert.Equal(t, "u1", last.Subject)
+	assert.Equal(t, "GET /posts", last.Action)
+	assert.Equal(t, DecisionAllow, last.Decision)
+	assert.Equal(t, "editor", last.Role)
+	assert.Equal(t, http.MethodGet, last.Method)
+	assert.Equal(t, "/posts", last.Path)
+	assert.Positive(t, last.Latency)
+
+	events = nil
+	r = httptest.NewRequest(http.MethodDelete, "/posts", nil)
+	r = r.WithContext(WithClaims(r.Context(), &Claims{Subject: NewSubject("u1", "editor")}))
+	assert.Equal(t, DecisionDeny, authorize(r))
+
+	require.Len(t, events, 1)
+	assert.Equal(t, "DELETE /posts", events[0].Action)
+	assert.Equal(t, DecisionDeny, events[0].Decision)
+	assert.Empty(t, events[0].Role)
+
+	events = nil
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), &Claims{Subject: NewSubject("u1", "editor")}, &Target{Action: "GET /posts"}))
+	assert.Len(t, events, 1)
+}
+
+func TestSlogAuditSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := SlogAuditSink(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	sink.Record(context.Background(), AuditEvent{Subject: "u1", Action: "read", Decision: DecisionDeny})
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "WARN", record["level"])
+	assert.Equal(t, "u1", record["subject"])
+	assert.Equal(t, "deny", record["decision"])
+}
+
+func TestJSONLinesAuditSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := OpenJSONLinesAuditSink(path)
+	require.NoError(t, err)
+
+	sink.Record(context.Background(), AuditEvent{Subject: "u1", Action: "read", Decision: DecisionAllow, Role: "viewer"})
+	sink.Record(context.Background(), AuditEvent{Subject: "u2", Action: "write", Decision: DecisionDeny})
+	require.NoError(t, sink.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var event AuditEvent
+	require.NoError(t, json.Unmarshal(lines[0], &event))
+	assert.Equal(t, "viewer", event.Role)
+	assert.Equal(t, DecisionAllow, event.Decision)
+
+	var failed error
+	NewJSONLinesAuditSink(errWriter{}).OnError(func(err error) { failed = err }).Record(context.Background(), AuditEvent{})
+	assert.EqualError(t, failed, "disk full")
+	assert.NoError(t, NewJSONLinesAuditSink(&bytes.Buffer{}).Close())
+}
@@ -0,0 +1,190 @@
+package rbac
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type auditShipperFunc func(ctx context.Context, payload []byte) error
+
+func (f auditShipperFunc) Ship(ctx context.Context, payload []byte) error {
+	return f(ctx, payload)
+}
+
+func testAuditEvent() AuditEvent {
+	return AuditEvent{
+		Time:       time.UnixMilli(1700000000123).UTC(),
+		Subject:    "u=1",
+		Roles:      []string{"user", "editor"},
+		Action:     "GET /api/posts",
+		Decision:   DecisionDeny,
+		Method:     http.MethodGet,
+		Path:       "/api/posts",
+		RemoteAddr: "10.0.0.1:5555",
+	}
+}
+
+func TestNewAuditEvent(t *testing.T) {
+	ctx := WithRequestInfo(context.Background(), RequestInfo{
+		Method:     http.MethodPost,
+		RemoteAddr: "10.0.0.2:1234",
+		URL:        &url.URL{Path: "/posts"},
+	})
+	event := NewAuditEvent(ctx, &Claims{Subject: NewSubject("u1", "user"), Actor: NewSubject("admin")}, &Target{Action: "write"}, DecisionAllow)
+
+	assert.Equal(t, "u1", event.Subject)
+	assert.Equal(t, "admin", event.Actor)
+	assert.Equal(t, []string{"user"}, event.Roles)
+	assert.Equal(t, "write", event.Action)
+	assert.Equal(t, http.MethodPost, event.Method)
+	assert.Equal(t, "/posts", event.Path)
+	assert.False(t, event.Time.IsZero())
+
+	event = NewAuditEvent(context.Background(), nil, nil, DecisionDeny)
+	assert.Empty(t, event.Subject)
+	assert.Empty(t, event.Action)
+}
+
+func TestCEFEncoder(t *testing.T) {
+	payload, err := CEFEncoder{Vendor: "Acme", Product: "api|gw", Version: "1.0"}.Encode(testAuditEvent())
+	require.NoError(t, err)
+	assert.Equal(t,
+		`CEF:0|Acme|api\|gw|1.0|authz:deny|Authorization denied|5|rt=1700000000123 act=GET /api/posts outcome=deny suid=u\=1 cs1Label=roles cs1=user,editor requestMethod=GET request=/api/posts src=10.0.0.1`,
+		string(payload))
+}
+
+func TestOCSFEncoder(t *testing.T) {
+	payload, err := OCSFEncoder{Vendor: "Acme", Product: "api"}.Encode(testAuditEvent())
+	require.NoError(t, err)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(payload, &record))
+	assert.EqualValues(t, 3003, record["class_uid"])
+	assert.EqualValues(t, 2, record["status_id"])
+	assert.EqualValues(t, 1700000000123, record["time"])
+	assert.Equal(t, map[string]any{"uid": "u=1", "groups": []any{map[string]any{"name": "user"}, map[string]any{"name": "editor"}}}, record["user"])
+	assert.Equal(t, map[string]any{"ip": "10.0.0.1"}, record["src_endpoint"])
+	assert.NotContains(t, record, "actor")
+}
+
+func TestSyslogShipper(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewSyslogShipper(&buf, "api")
+	s.hostname = "host-1"
+	require.NoError(t, s.Ship(context.Background(), []byte("CEF:0|x")))
+
+	assert.Regexp(t, regexp.MustCompile(`^<110>1 \S+ host-1 api \d+ - - CEF:0\|x\n$`), buf.String())
+}
+
+func TestHTTPShipper(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("Authorization") != "Splunk token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, `{"event":1}`, string(body))
+	}))
+	defer srv.Close()
+
+	s := NewHTTPShipper(srv.URL, "application/json")
+	assert.ErrorContains(t, s.Ship(context.Background(), []byte(`{"event":1}`)), "401")
+	assert.NoError(t, s.SetHeader("Authorization", "Splunk token").Ship(context.Background(), []byte(`{"event":1}`)))
+}
+
+func TestHTTPShipper_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	s := NewHTTPShipper(srv.URL, "application/json").SetTimeout(20 * time.Millisecond)
+	assert.ErrorIs(t, s.Ship(context.Background(), []byte(`{"event":1}`)), context.DeadlineExceeded)
+}
+
+func TestAuditAuthorizer(t *testing.T) {
+	var shipped [][]byte
+	var errs []error
+	a := NewAuditAuthorizer(&mockAuthorizer{decision: DecisionAllow}, CEFEncoder{}, auditShipperFunc(func(_ context.Context, payload []byte) error {
+		shipped = append(shipped, payload)
+		if len(shipped) > 1 {
+			return errors.New("unreachable")
+		}
+		return nil
+	})).OnError(func(err error) { errs = append(errs, err) })
+
+	claims := &Claims{Subject: NewSubject("u1", "user"), Actor: NewSubject("admin")}
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), claims, &Target{Action: "read"}))
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), claims, &Target{Action: "read"}))
+	require.NoError(t, a.Close())
+	assert.Len(t, shipped, 2)
+	assert.Contains(t, string(shipped[0]), "act=read outcome=allow suid=u1 suser=admin")
+	assert.Len(t, errs, 1)
+
+	a.Authorize(context.Background(), claims, &Target{Action: "read"})
+	assert.Len(t, shipped, 2)
+	assert.Equal(t, int64(1), a.Dropped())
+}
+
+func TestAuditAuthorizer_Queue(t *testing.T) {
+	release := make(chan struct{})
+	var shipped atomic.Int64
+	a := NewAuditAuthorizer(&mockAuthorizer{decision: DecisionAllow}, CEFEncoder{}, auditShipperFunc(func(ctx context.Context, _ []byte) error {
+		<-release
+		shipped.Add(1)
+		return ctx.Err()
+	})).SetQueueSize(2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 10 {
+			assert.Equal(t, DecisionAllow, a.Authorize(ctx, nil, &Target{Action: "read"}))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Authorize blocked on the shipper")
+	}
+	cancel()
+
+	close(release)
+	require.NoError(t, a.Close())
+	// one event is being shipped, two are queued
+	assert.Equal(t, int64(10), shipped.Load()+a.Dropped())
+	assert.GreaterOrEqual(t, a.Dropped(), int64(7))
+}
+
+func TestAuditAuthorizer_Sampler(t *testing.T) {
+	var shipped int
+	a := NewAuditAuthorizer(&mockAuthorizer{decision: DecisionAllow}, CEFEncoder{}, auditShipperFunc(func(context.Context, []byte) error {
+		shipped++
+		return nil
+	})).SetSampler(NewDecisionSampler(0).AlwaysActions("export"))
+
+	a.Authorize(context.Background(), nil, &Target{Action: "read"})
+	a.Authorize(context.Background(), nil, &Target{Action: "export"})
+	require.NoError(t, a.Close())
+	assert.Equal(t, 1, shipped)
+}
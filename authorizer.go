@@ -2,26 +2,73 @@ package rbac
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"time"
 )
 
 var _ Authorizer = (*DefaultAuthorizer)(nil)
 
-var ErrDeny = errors.New("deny")
+var (
+	ErrDeny                = errors.New("deny")
+	ErrUnauthenticated     = errors.New("unauthenticated")
+	ErrImpersonationDenied = errors.New("impersonation denied")
+	ErrInvalidDecision     = errors.New("invalid decision")
+)
+
+const ActionImpersonate = "impersonate"
 
 type Subject interface {
 	Roles() []string
 }
 
+type Identifier interface {
+	Identifier() string
+}
+
+func SubjectID(subject Subject) string {
+	if id, ok := subject.(Identifier); ok {
+		return id.Identifier()
+	}
+	return ""
+}
+
 type Claims struct {
 	Subject  Subject
+	Actor    Subject
 	Metadata map[string]any
 }
 
+func (c *Claims) IsImpersonated() bool {
+	return c != nil && c.Actor != nil
+}
+
 type Target struct {
 	Action     string
 	Assertions []Assertion
 	Metadata   map[string]any
+	// Literal makes only permissions equal to Action grant it, for actions
+	// carrying their own wildcards such as those of KafkaActions. Patterns
+	// would otherwise match them too broadly, e.g. the regular expression
+	// "kafka:write:topic:orders-*" matches "kafka:write:topic:ordersevil".
+	Literal bool
+	// Anchored makes patterns match the whole Action only, not any part of
+	// it as regular expressions otherwise do, e.g. "GET billing.internal"
+	// does not match "GET billing.internal.evil.com/". Literal takes
+	// precedence.
+	Anchored bool
+}
+
+func (t *Target) matching() actionMatching {
+	switch {
+	case t.Literal:
+		return matchEqual
+	case t.Anchored:
+		return matchAnchored
+	default:
+		return matchPartial
+	}
 }
 
 func (t *Target) reset() {
@@ -32,36 +79,144 @@ func (t *Target) reset() {
 	t.Action = ""
 	t.Assertions = nil
 	t.Metadata = nil
+	t.Literal = false
+	t.Anchored = false
 }
 
+// Decision values are part of wire formats and audit logs and must not change.
 type Decision int8
 
 const (
-	DecisionDeny Decision = iota
-	DecisionAllow
+	DecisionDeny  Decision = 0
+	DecisionAllow Decision = 1
+	// DecisionWarn allows the request but flags it, e.g. for policies that
+	// are being tightened gradually.
+	DecisionWarn Decision = 2
+	// DecisionAbstain means the authorizer has no opinion, e.g. because the
+	// subject holds no role the policy knows about. It is not allowed, but
+	// composite authorizers may consult other authorizers instead.
+	DecisionAbstain Decision = 3
 )
 
+func ParseDecision(s string) (Decision, error) {
+	switch s {
+	case "deny":
+		return DecisionDeny, nil
+	case "allow":
+		return DecisionAllow, nil
+	case "warn":
+		return DecisionWarn, nil
+	case "abstain":
+		return DecisionAbstain, nil
+	default:
+		return DecisionDeny, fmt.Errorf(`%w: "%s"`, ErrInvalidDecision, s)
+	}
+}
+
+func (d Decision) Allowed() bool {
+	return d == DecisionAllow || d == DecisionWarn
+}
+
 func (d Decision) String() string {
 	switch d {
 	case DecisionDeny:
 		return "deny"
 	case DecisionAllow:
 		return "allow"
+	case DecisionWarn:
+		return "warn"
+	case DecisionAbstain:
+		return "abstain"
 	default:
 		return "unknown"
 	}
 }
 
+func (d Decision) MarshalText() ([]byte, error) {
+	if s := d.String(); s != "unknown" {
+		return []byte(s), nil
+	}
+	return nil, fmt.Errorf("%w: %d", ErrInvalidDecision, d)
+}
+
+func (d *Decision) UnmarshalText(text []byte) (err error) {
+	*d, err = ParseDecision(string(text))
+	return
+}
+
+func (d Decision) MarshalJSON() ([]byte, error) {
+	text, err := d.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON accepts the canonical string as well as the numeric value.
+func (d *Decision) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return d.UnmarshalText([]byte(s))
+	}
+
+	var n int8
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidDecision, data)
+	}
+	if Decision(n).String() == "unknown" {
+		return fmt.Errorf("%w: %d", ErrInvalidDecision, n)
+	}
+	*d = Decision(n)
+	return nil
+}
+
 type Authorizer interface {
 	Authorize(ctx context.Context, claims *Claims, target *Target) Decision
 }
 
+// authorizeErr calls AuthorizeE of authorizers implementing it, so wrappers
+// keep the reasons of denials.
+func authorizeErr(ctx context.Context, authorizer Authorizer, claims *Claims, target *Target) (Decision, error) {
+	if e, ok := authorizer.(interface {
+		AuthorizeE(context.Context, *Claims, *Target) (Decision, error)
+	}); ok {
+		return e.AuthorizeE(ctx, claims, target)
+	}
+	return authorizer.Authorize(ctx, claims, target), nil
+}
+
 type DefaultAuthorizer struct {
-	rbac *RBAC
+	holder          *RBACHolder
+	scopes          *ScopeMapping
+	maxTTL          time.Duration
+	abstain         bool
+	defaultDecision Decision
+	actionDefaults  []actionDefault
+	batchWorkers    int
+	sink            AuditSink
+	constraints     *ConstraintRegistry
 }
 
 func NewDefaultAuthorizer(rbac *RBAC) *DefaultAuthorizer {
-	return &DefaultAuthorizer{rbac: rbac}
+	return NewHeldAuthorizer(NewRBACHolder(rbac))
+}
+
+// NewHeldAuthorizer evaluates whatever policy the holder currently holds.
+func NewHeldAuthorizer(holder *RBACHolder) *DefaultAuthorizer {
+	return &DefaultAuthorizer{holder: holder}
+}
+
+// Holder returns the holder of the policy, Swap on it to reload the policy.
+func (a *DefaultAuthorizer) Holder() *RBACHolder {
+	return a.holder
+}
+
+// SetAbstain makes the authorizer return DecisionAbstain instead of
+// DecisionDeny when none of the subject roles is registered or holds the
+// action. Denials by assertions, scopes or impersonation stay DecisionDeny.
+func (a *DefaultAuthorizer) SetAbstain(abstain bool) *DefaultAuthorizer {
+	a.abstain = abstain
+	return a
 }
 
 func (a *DefaultAuthorizer) Authorize(ctx context.Context, claims *Claims, target *Target) Decision {
@@ -69,24 +224,206 @@ func (a *DefaultAuthorizer) Authorize(ctx context.Context, claims *Claims, targe
 	return d
 }
 
-func (a *DefaultAuthorizer) AuthorizeE(ctx context.Context, claims *Claims, target *Target) (d Decision, err error) {
-	d = DecisionDeny
-	err = ErrDeny
+func (a *DefaultAuthorizer) AuthorizeE(ctx context.Context, claims *Claims, target *Target) (Decision, error) {
+	if a.sink != nil {
+		return a.authorizeAudited(ctx, claims, target)
+	}
+	return a.authorizeMemo(ctx, claims, target)
+}
+
+func (a *DefaultAuthorizer) authorizeMemo(ctx context.Context, claims *Claims, target *Target) (Decision, error) {
+	if memo := CtxDecisionMemo(ctx); memo != nil {
+		return memo.do(a, claims, target, func() (Decision, error) {
+			return a.authorizeE(ctx, claims, target)
+		})
+	}
+	return a.authorizeE(ctx, claims, target)
+}
 
+func (a *DefaultAuthorizer) authorizeE(ctx context.Context, claims *Claims, target *Target) (Decision, error) {
 	if target == nil || target.Action == "" {
-		return
+		return DecisionDeny, NewAuthzError(AuthzPolicyError, "", &ReasonError{Reason: ReasonInvalidTarget{}})
 	}
 
 	if claims == nil || claims.Subject == nil {
-		return
+		return DecisionDeny, NewAuthzError(AuthzUnauthenticated, target.Action, &ReasonError{Reason: ReasonUnauthenticated{}})
+	}
+
+	if CtxClaims(ctx) != claims {
+		ctx = WithClaims(ctx, claims)
+	}
+	if CtxTarget(ctx) != target {
+		ctx = WithTarget(ctx, target)
+	}
+
+	// loaded once, so a concurrent swap cannot split a decision across policies
+	rbac := a.holder.Load()
+
+	if claims.IsImpersonated() {
+		if err := a.authorizeImpersonation(ctx, rbac, claims); err != nil {
+			return DecisionDeny, NewAuthzError(AuthzForbidden, target.Action, err)
+		}
+	}
+
+	if err := a.authorizeScopes(claims, target.Action); err != nil {
+		return DecisionDeny, NewAuthzError(AuthzForbidden, target.Action, err)
 	}
 
+	var (
+		errs       []error
+		warn       error
+		warnRole   string
+		applicable bool
+	)
+	explanation := ctxExplanation(ctx)
+	now := decisionTime(ctx, time.Now)
+	tx := CtxRoleTransaction(ctx)
+	if a.constraints == nil {
+		tx = nil
+	}
+	id := SubjectID(claims.Subject)
 	for _, role := range claims.Subject.Roles() {
-		granted, err1 := a.rbac.IsGrantedE(ctx, role, target.Action, target.Assertions...)
-		if granted && err1 == nil {
+		var skip Reason
+		if exp, ok := RoleGrantExpiry(claims, role); ok && !now.Before(exp) {
+			skip = ReasonGrantExpired{Role: role}
+		} else if tx != nil {
+			if exercised := a.constraints.conflict(role, tx.Exercised(id)); exercised != "" {
+				skip = ReasonExclusiveRole{Role: role, Exercised: exercised}
+			}
+		}
+		if skip != nil {
+			if explanation != nil {
+				explanation.role(role, false, skip, nil)
+			}
+			errs = append(errs, &ReasonError{Reason: skip})
+			continue
+		}
+
+		granted, reason, err := rbac.evaluate(ctx, role, target.Action, target.matching(), target.Assertions...)
+		if explanation != nil {
+			explanation.role(role, granted, reason, err)
+		}
+		if granted && err == nil {
+			if skip := a.exercise(tx, rbac, id, role, target); skip != nil {
+				errs = append(errs, &ReasonError{Reason: skip})
+				continue
+			}
 			return DecisionAllow, nil
 		}
-		err = errors.Join(err, err1)
+		if granted && warn == nil {
+			warn, warnRole = err, role
+			continue
+		}
+		switch reason.(type) {
+		case ReasonRoleMissing, ReasonPermissionMissing:
+		default:
+			applicable = true
+		}
+		if reason != nil {
+			err = &ReasonError{Reason: reason, Err: err}
+		}
+		errs = append(errs, err)
 	}
-	return
+	if warn != nil {
+		skip := a.exercise(tx, rbac, id, warnRole, target)
+		if skip == nil {
+			return DecisionWarn, warn
+		}
+		errs = append(errs, &ReasonError{Reason: skip})
+	}
+	if !applicable {
+		switch d := a.ActionDefault(target.Action); {
+		case d.Allowed():
+			// the default replaces roles, not the assertions of the target
+			return assertTarget(ctx, d, target)
+		case d == DecisionAbstain || a.abstain:
+			return DecisionAbstain, NewAuthzError(AuthzForbidden, target.Action, errs...)
+		}
+	}
+	return DecisionDeny, NewAuthzError(AuthzForbidden, target.Action, errs...)
+}
+
+// Cacheable reports whether the decision depends on the claims and the action
+// only: no role transaction or explanation is in ctx and none of the subject
+// roles holds the action under conditions.
+func (a *DefaultAuthorizer) Cacheable(ctx context.Context, claims *Claims, target *Target) bool {
+	if ctxExplanation(ctx) != nil || (a.constraints != nil && CtxRoleTransaction(ctx) != nil) {
+		return false
+	}
+	if claims == nil || claims.Subject == nil || target == nil {
+		return true
+	}
+	rbac := a.holder.Load()
+	for _, name := range claims.Subject.Roles() {
+		if r, ok := rbac.roles[name]; ok && len(r.permissionAssertions(target.Action, target.matching())) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// assertTarget evaluates the target assertions, which cannot be expressed to
+// external policy engines, after such an engine allowed with d. Assertions
+// get a nil role.
+func assertTarget(ctx context.Context, d Decision, target *Target) (_ Decision, err error) {
+	var current Assertion
+	defer func() {
+		if rec := recover(); rec != nil {
+			if err, _ = rec.(error); err == nil {
+				err = fmt.Errorf("%v", rec)
+			}
+			d, err = DecisionDeny, NewAuthzError(AuthzForbidden, target.Action, &ReasonError{Reason: ReasonAssertionFailed{Name: AssertionName(current)}, Err: err})
+		}
+	}()
+
+	var warn error
+	for _, assertion := range target.Assertions {
+		current = assertion
+		if e, ok := assertion.(ErrorAssertion); ok {
+			switch err := e.AssertE(ctx, nil, target.Action); {
+			case errors.Is(err, ErrWarn):
+				warn = errors.Join(warn, err)
+			case err != nil:
+				reason := errorReason(err, ReasonAssertionFailed{Name: AssertionName(assertion)})
+				return DecisionDeny, NewAuthzError(AuthzForbidden, target.Action, &ReasonError{Reason: reason, Err: err})
+			}
+			continue
+		}
+		if !assertion.Assert(ctx, nil, target.Action) {
+			return DecisionDeny, NewAuthzError(AuthzForbidden, target.Action, &ReasonError{Reason: ReasonAssertionFailed{Name: AssertionName(assertion)}})
+		}
+	}
+	if warn != nil {
+		return DecisionWarn, warn
+	}
+	return d, nil
+}
+
+// exercise records the constrained roles through which role granted the
+// target, inherited ones included, as exercised by the subject unless a role
+// exclusive with one of them was exercised, which it returns the reason for.
+func (a *DefaultAuthorizer) exercise(tx *RoleTransaction, rbac *RBAC, subject, role string, target *Target) Reason {
+	if tx == nil {
+		return nil
+	}
+	if role, exercised := tx.exercise(a.constraints, subject, a.grantingRoles(rbac, role, target)); exercised != "" {
+		return ReasonExclusiveRole{Role: role, Exercised: exercised}
+	}
+	return nil
+}
+
+func (a *DefaultAuthorizer) authorizeImpersonation(ctx context.Context, rbac *RBAC, claims *Claims) error {
+	var errs []error
+	for _, role := range claims.Actor.Roles() {
+		granted, err := rbac.IsGrantedE(ctx, role, ActionImpersonate)
+		if granted && err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	actor, subject := SubjectID(claims.Actor), SubjectID(claims.Subject)
+	return errors.Join(append([]error{&ReasonError{
+		Reason: ReasonImpersonationDenied{Actor: actor, Subject: subject},
+		Err:    fmt.Errorf(`%w: actor "%s" cannot act as subject "%s"`, ErrImpersonationDenied, actor, subject),
+	}}, errs...)...)
 }
@@ -1,9 +1,14 @@
 package rbac
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"net"
 	"net/http"
 	"net/url"
+	"slices"
+	"strings"
 	"sync"
 )
 
@@ -16,60 +21,323 @@ type RequestInfo struct {
 	Header     http.Header
 	URL        *url.URL
 	IsTLS      bool
+	// PathValues holds the wildcards of the matched ServeMux pattern.
+	PathValues map[string]string
 }
 
-func RequestAuthorizer(authorizer Authorizer, actions func(*http.Request) []string) func(*http.Request) Decision {
-	if actions == nil {
-		actions = defaultActions
+// RequestOption configures RequestAuthorizer and RequestAuthorizerE.
+type RequestOption func(*requestAuthorizer)
+
+// WithActions sets the actions tried in order until one is allowed, the
+// default ones if nil.
+func WithActions(actions func(*http.Request) []string) RequestOption {
+	return func(a *requestAuthorizer) {
+		if actions != nil {
+			a.actions = actions
+		}
 	}
+}
 
-	pool := &sync.Pool{New: func() any {
-		return new(Target)
-	}}
+// WithSkipper allows requests the skipper reports without authorizing them,
+// e.g. PublicRoutes. Requests reported by any of several skippers are
+// allowed.
+func WithSkipper(skipper func(*http.Request) bool) RequestOption {
+	return func(a *requestAuthorizer) {
+		if skipper != nil {
+			a.skippers = append(a.skippers, skipper)
+		}
+	}
+}
 
-	return func(r *http.Request) Decision {
-		ctx := r.Context()
-		claims := CtxClaims(ctx)
-		assertions := CtxAssertions(ctx)
-
-		target := pool.Get().(*Target)
-		defer func() {
-			target.reset()
-			pool.Put(target)
-		}()
-
-		ctx = WithRequestInfo(ctx, RequestInfo{
-			Method:     r.Method,
-			Host:       r.Host,
-			RequestURI: r.RequestURI,
-			Pattern:    r.Pattern,
-			RemoteAddr: r.RemoteAddr,
-			Header:     r.Header,
-			URL:        r.URL,
+// PublicRoutes reports requests matching any of the routes, each a path
+// optionally preceded by a method, e.g. "GET /healthz", "/metrics" or
+// "POST /auth/*". Paths are globs: "*" stops at "/", "**" does not.
+func PublicRoutes(routes ...string) func(*http.Request) bool {
+	type route struct{ method, path string }
+	parsed := make([]route, 0, len(routes))
+	for _, r := range routes {
+		method, path, ok := strings.Cut(r, " ")
+		if !ok {
+			method, path = "", r
+		}
+		parsed = append(parsed, route{method, strings.TrimSpace(path)})
+	}
+	return func(r *http.Request) bool {
+		path := r.URL.Path
+		if path == "" {
+			path = "/"
+		}
+		return slices.ContainsFunc(parsed, func(route route) bool {
+			return (route.method == "" || route.method == r.Method) && globMatch(route.path, path)
 		})
+	}
+}
+
+// WithOnDeny replaces the error RequestAuthorizerE returns for denied
+// requests, err being the *AuthzError it would return otherwise.
+func WithOnDeny(onDeny func(r *http.Request, err error) error) RequestOption {
+	return func(a *requestAuthorizer) {
+		a.onDeny = onDeny
+	}
+}
+
+// WithClaimsLoader loads the claims of requests whose context carries none.
+// Requests are denied as unauthenticated if it fails.
+func WithClaimsLoader(loader func(*http.Request) (*Claims, error)) RequestOption {
+	return func(a *requestAuthorizer) {
+		a.claimsLoader = loader
+	}
+}
+
+// WithTargetBuilder fills the target, e.g. its Metadata, before the actions
+// are authorized. The target is reused, the builder must not retain it.
+func WithTargetBuilder(builder func(r *http.Request, target *Target)) RequestOption {
+	return func(a *requestAuthorizer) {
+		a.targetBuilder = builder
+	}
+}
 
-		target.Assertions = assertions
-		for _, action := range actions(r) {
-			target.Action = action
+type requestAuthorizer struct {
+	authorizer    Authorizer
+	actions       func(*http.Request) []string
+	skippers      []func(*http.Request) bool
+	onDeny        func(*http.Request, error) error
+	claimsLoader  func(*http.Request) (*Claims, error)
+	targetBuilder func(*http.Request, *Target)
+	anchor        func(r *http.Request, action string) bool
+	pool          sync.Pool
+}
 
-			if authorizer.Authorize(ctx, claims, target) == DecisionAllow {
-				return DecisionAllow
-			}
+func newRequestAuthorizer(authorizer Authorizer, opts []RequestOption) *requestAuthorizer {
+	a := &requestAuthorizer{
+		authorizer: authorizer,
+		actions:    defaultActions,
+		pool: sync.Pool{New: func() any {
+			return new(Target)
+		}},
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(a)
 		}
+	}
+	return a
+}
 
-		return DecisionDeny
+// RequestAuthorizer authorizes requests with the claims and assertions of
+// their context, allowing them if any of their actions is allowed. The
+// wildcards of the matched ServeMux pattern are added to Target.Metadata,
+// e.g. "id" for "/posts/{id}", unless the target builder set them.
+func RequestAuthorizer(authorizer Authorizer, opts ...RequestOption) func(*http.Request) Decision {
+	a := newRequestAuthorizer(authorizer, opts)
+	return func(r *http.Request) Decision {
+		d, _ := a.authorize(r)
+		return d
 	}
 }
 
+// RequestAuthorizerE is RequestAuthorizer returning nil for allowed requests
+// and an *AuthzError, or the error of WithOnDeny, for denied ones. Requests
+// without claims fail with ErrUnauthenticated, others with ErrDeny only, so
+// adapters can answer 401 and 403 respectively.
+func RequestAuthorizerE(authorizer Authorizer, opts ...RequestOption) func(*http.Request) error {
+	a := newRequestAuthorizer(authorizer, opts)
+	return func(r *http.Request) error {
+		d, err := a.authorize(r)
+		if d.Allowed() {
+			return nil
+		}
+		if a.onDeny != nil {
+			return a.onDeny(r, err)
+		}
+		return err
+	}
+}
+
+func (a *requestAuthorizer) authorize(r *http.Request) (Decision, error) {
+	for _, skip := range a.skippers {
+		if skip(r) {
+			return DecisionAllow, nil
+		}
+	}
+
+	ctx := r.Context()
+	claims := CtxClaims(ctx)
+	if claims == nil && a.claimsLoader != nil {
+		loaded, err := a.claimsLoader(r)
+		if err != nil {
+			return DecisionDeny, NewAuthzError(AuthzUnauthenticated, "", err)
+		}
+		if loaded != nil {
+			claims = loaded
+			ctx = WithClaims(ctx, claims)
+		}
+	}
+
+	target := a.pool.Get().(*Target)
+	defer func() {
+		target.reset()
+		a.pool.Put(target)
+	}()
+
+	params := pathValues(r)
+	ctx = WithRequestInfo(ctx, RequestInfo{
+		Method:     r.Method,
+		Host:       r.Host,
+		RequestURI: r.RequestURI,
+		Pattern:    r.Pattern,
+		RemoteAddr: r.RemoteAddr,
+		Header:     r.Header,
+		URL:        r.URL,
+		PathValues: params,
+	})
+	ctx, audit := withRequestAudit(ctx)
+
+	current := a.authorizer
+	if authorizer := CtxAuthorizer(ctx); authorizer != nil {
+		current = authorizer
+	}
+
+	if a.targetBuilder != nil {
+		a.targetBuilder(r, target)
+	}
+	anchored := target.Anchored
+	target.addParams(params)
+	target.Assertions = append(slices.Clip(target.Assertions), CtxAssertions(ctx)...)
+
+	var (
+		action string
+		err    error
+	)
+	for _, action = range a.actions(r) {
+		target.Action = action
+		target.Anchored = anchored || a.anchor != nil && a.anchor(r, action)
+
+		var d Decision
+		d, err = authorizeErr(ctx, current, claims, target)
+		if d.Allowed() {
+			audit.flush(ctx, action, d)
+			return d, nil
+		}
+	}
+	audit.flush(ctx, action, DecisionDeny)
+
+	var authzErr *AuthzError
+	if errors.As(err, &authzErr) {
+		return DecisionDeny, authzErr
+	}
+	kind := AuthzForbidden
+	if claims == nil {
+		kind = AuthzUnauthenticated
+	}
+	return DecisionDeny, NewAuthzError(kind, action, err)
+}
+
+// addParams adds path parameters to a copy of the metadata without
+// overriding it, as target builders may share one map between requests.
+func (t *Target) addParams(params map[string]string) {
+	if len(params) == 0 {
+		return
+	}
+	if t.Metadata == nil {
+		t.Metadata = make(map[string]any, len(params))
+	} else {
+		t.Metadata = maps.Clone(t.Metadata)
+	}
+	for name, value := range params {
+		if _, ok := t.Metadata[name]; !ok {
+			t.Metadata[name] = value
+		}
+	}
+}
+
+func pathValues(r *http.Request) map[string]string {
+	var values map[string]string
+	for segment := range strings.SplitSeq(patternPath(r.Pattern), "/") {
+		if !isPathParam(segment) {
+			continue
+		}
+		name := strings.TrimSuffix(segment[1:len(segment)-1], "...")
+		if name == "$" {
+			continue
+		}
+		if values == nil {
+			values = map[string]string{}
+		}
+		values[name] = r.PathValue(name)
+	}
+	return values
+}
+
+// HostActions extends the default actions with host-qualified ones, e.g.
+// "admin.example.com", "admin.example.com /users" and
+// "GET admin.example.com /users". The port is not part of the host, which is
+// taken from the Host header and thus chosen by the client. The path-only
+// default actions still grant access on every host, see WithHostActions.
+func HostActions(r *http.Request) []string {
+	return append(defaultActions(r), hostActions(r)...)
+}
+
+// WithHostActions tries the host-qualified actions of HostActions, checking
+// them anchored so "api.example.com" does not grant "api.example.com.evil".
+// Unless pathActions is set, the default actions are left out, so that
+// host-qualified permissions restrict access to their host.
+func WithHostActions(pathActions bool) RequestOption {
+	return func(a *requestAuthorizer) {
+		a.actions = HostActions
+		if !pathActions {
+			a.actions = hostActions
+		}
+		a.anchor = isHostAction
+	}
+}
+
+func hostActions(r *http.Request) []string {
+	host := requestHost(r)
+	if host == "" {
+		return nil
+	}
+	path := requestPath(r)
+	return []string{host, fmt.Sprintf("%s %s", host, path), fmt.Sprintf("%s %s %s", r.Method, host, path)}
+}
+
+func isHostAction(r *http.Request, action string) bool {
+	host := requestHost(r)
+	return host != "" && (action == host || strings.HasPrefix(action, host+" ") || strings.HasPrefix(action, r.Method+" "+host+" "))
+}
+
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if host == "" && r.URL != nil {
+		host = r.URL.Host
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// defaultActions yields "*", the method, the route pattern of ServeMux with
+// and without the method, e.g. "GET /users/{id}", and the same for the path.
+// Routes come first so they are the actions reported when both are allowed.
 func defaultActions(r *http.Request) []string {
-	method, path := r.Method, r.URL.Path
-	if path == "" {
-		path = "/"
+	method, path := r.Method, requestPath(r)
+	actions := []string{"*", method}
+	if route := requestRoute(r); route != "" && route != path {
+		actions = append(actions, route, fmt.Sprintf("%s %s", method, route))
 	}
-	return []string{
-		"*",
-		method,
-		path,
-		fmt.Sprintf("%s %s", method, path),
+	return append(actions, path, fmt.Sprintf("%s %s", method, path))
+}
+
+func requestPath(r *http.Request) string {
+	if r.URL == nil || r.URL.Path == "" {
+		return "/"
 	}
+	return r.URL.Path
+}
+
+// requestRoute returns the path of the ServeMux pattern matching r, "{$}"
+// dropped.
+func requestRoute(r *http.Request) string {
+	return strings.TrimSuffix(normalizeRoutePattern(patternPath(r.Pattern)), "{$}")
 }
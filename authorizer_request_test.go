@@ -2,6 +2,9 @@ package rbac
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"maps"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -75,7 +78,7 @@ func (s *authorizerRequestSuit) TestRequestInfo_Fields() {
 
 func (s *authorizerRequestSuit) TestRequestAuthorizer_NilActions() {
 	// When actions is nil, should use defaultActions
-	authorizerFunc := RequestAuthorizer(s.authorizer, nil)
+	authorizerFunc := RequestAuthorizer(s.authorizer)
 
 	// Setup context with claims and subject
 	subject := &testRequestSubject{roles: []string{"user"}}
@@ -100,7 +103,7 @@ func (s *authorizerRequestSuit) TestRequestAuthorizer_CustomActions() {
 	// Setup authorizer to allow custom action
 	s.authorizer.decision = DecisionAllow
 
-	authorizerFunc := RequestAuthorizer(s.authorizer, customActions)
+	authorizerFunc := RequestAuthorizer(s.authorizer, WithActions(customActions))
 
 	// Setup context with claims and subject
 	subject := &testRequestSubject{roles: []string{"user"}}
@@ -120,7 +123,7 @@ func (s *authorizerRequestSuit) TestRequestAuthorizer_WithClaimsInContext() {
 	// Setup authorizer to allow
 	s.authorizer.decision = DecisionAllow
 
-	authorizerFunc := RequestAuthorizer(s.authorizer, nil)
+	authorizerFunc := RequestAuthorizer(s.authorizer)
 
 	// Setup context with claims and subject
 	subject := &testRequestSubject{roles: []string{"admin"}}
@@ -140,7 +143,7 @@ func (s *authorizerRequestSuit) TestRequestAuthorizer_WithAssertions() {
 	// Setup authorizer to allow
 	s.authorizer.decision = DecisionAllow
 
-	authorizerFunc := RequestAuthorizer(s.authorizer, nil)
+	authorizerFunc := RequestAuthorizer(s.authorizer)
 
 	// Setup context with claims and assertions
 	subject := &testRequestSubject{roles: []string{"user"}}
@@ -160,7 +163,7 @@ func (s *authorizerRequestSuit) TestRequestAuthorizer_WithAssertions() {
 }
 
 func (s *authorizerRequestSuit) TestRequestAuthorizer_NoClaimsInContext() {
-	authorizerFunc := RequestAuthorizer(s.authorizer, nil)
+	authorizerFunc := RequestAuthorizer(s.authorizer)
 
 	// Request without claims in context
 	req := httptest.NewRequest("GET", "/api/users", nil)
@@ -170,7 +173,7 @@ func (s *authorizerRequestSuit) TestRequestAuthorizer_NoClaimsInContext() {
 }
 
 func (s *authorizerRequestSuit) TestRequestAuthorizer_ClaimsWithNilSubject() {
-	authorizerFunc := RequestAuthorizer(s.authorizer, nil)
+	authorizerFunc := RequestAuthorizer(s.authorizer)
 
 	// Claims with nil subject
 	claims := &Claims{
@@ -194,7 +197,7 @@ func (s *authorizerRequestSuit) TestRequestAuthorizer_MultipleActions_FirstSucce
 	// Setup authorizer to allow first action
 	s.authorizer.decision = DecisionAllow
 
-	authorizerFunc := RequestAuthorizer(s.authorizer, customActions)
+	authorizerFunc := RequestAuthorizer(s.authorizer, WithActions(customActions))
 
 	// Setup context with claims
 	subject := &testRequestSubject{roles: []string{"user"}}
@@ -220,7 +223,7 @@ func (s *authorizerRequestSuit) TestRequestAuthorizer_MultipleActions_SecondSucc
 		decision: DecisionAllow,
 	}
 
-	authorizerFunc := RequestAuthorizer(s.authorizer, customActions)
+	authorizerFunc := RequestAuthorizer(s.authorizer, WithActions(customActions))
 
 	// Setup context with claims
 	subject := &testRequestSubject{roles: []string{"user"}}
@@ -240,7 +243,7 @@ func (s *authorizerRequestSuit) TestRequestAuthorizer_RequestInfoInContext() {
 	// Setup authorizer to allow
 	s.authorizer.decision = DecisionAllow
 
-	authorizerFunc := RequestAuthorizer(s.authorizer, nil)
+	authorizerFunc := RequestAuthorizer(s.authorizer)
 
 	// Setup context with claims
 	subject := &testRequestSubject{roles: []string{"user"}}
@@ -266,7 +269,7 @@ func (s *authorizerRequestSuit) TestRequestAuthorizer_TLSRequest() {
 	// Setup authorizer to allow
 	s.authorizer.decision = DecisionAllow
 
-	authorizerFunc := RequestAuthorizer(s.authorizer, nil)
+	authorizerFunc := RequestAuthorizer(s.authorizer)
 
 	// Setup context with claims
 	subject := &testRequestSubject{roles: []string{"user"}}
@@ -292,7 +295,7 @@ func (s *authorizerRequestSuit) TestRequestAuthorizer_ObjectPoolUsage() {
 	// This test verifies that the object pool is working correctly
 	// by making multiple requests and checking that the pool is being used
 
-	authorizerFunc := RequestAuthorizer(s.authorizer, nil)
+	authorizerFunc := RequestAuthorizer(s.authorizer)
 
 	// Setup context with claims
 	subject := &testRequestSubject{roles: []string{"user"}}
@@ -402,7 +405,7 @@ func (s *authorizerRequestSuit) TestRequestAuthorizer_ComplexURL() {
 	// Setup authorizer to allow
 	s.authorizer.decision = DecisionAllow
 
-	authorizerFunc := RequestAuthorizer(s.authorizer, nil)
+	authorizerFunc := RequestAuthorizer(s.authorizer)
 
 	// Setup context with claims
 	subject := &testRequestSubject{roles: []string{"user"}}
@@ -423,3 +426,211 @@ func (s *authorizerRequestSuit) TestRequestAuthorizer_ComplexURL() {
 	info := CtxRequestInfo(req.Context())
 	s.NotNil(info)
 }
+
+func (s *authorizerRequestSuit) TestRequestAuthorizer_ContextAuthorizer() {
+	authorizerFunc := RequestAuthorizer(&mockAuthorizer{decision: DecisionDeny})
+
+	req := httptest.NewRequest("GET", "/api/users", nil)
+	s.Equal(DecisionDeny, authorizerFunc(req))
+
+	ctx := WithAuthorizer(req.Context(), &mockAuthorizer{decision: DecisionAllow})
+	s.NotNil(CtxAuthorizer(ctx))
+	s.Nil(CtxAuthorizer(req.Context()))
+	s.Equal(DecisionAllow, authorizerFunc(req.WithContext(ctx)))
+}
+
+func (s *authorizerRequestSuit) TestHostActions() {
+	req := httptest.NewRequest("GET", "http://Admin.Example.com:8443/users", nil)
+
+	s.Equal([]string{
+		"*",
+		"GET",
+		"/users",
+		"GET /users",
+		"admin.example.com",
+		"admin.example.com /users",
+		"GET admin.example.com /users",
+	}, HostActions(req))
+
+	req.Host = ""
+	req.URL.Host = ""
+	s.Equal(defaultActions(req), HostActions(req))
+}
+
+func (s *authorizerRequestSuit) TestHostActionsPolicy() {
+	rbac := New()
+	role := NewRole("admin")
+	role.AddPermissions("admin.example.com")
+	_ = rbac.AddRole(role)
+
+	authorize := RequestAuthorizer(NewDefaultAuthorizer(rbac), WithActions(HostActions))
+	claims := &Claims{Subject: NewSubject("1", "admin")}
+
+	req := httptest.NewRequest("GET", "http://admin.example.com/users", nil)
+	s.Equal(DecisionAllow, authorize(req.WithContext(WithClaims(req.Context(), claims))))
+
+	req = httptest.NewRequest("GET", "http://www.example.com/users", nil)
+	s.Equal(DecisionDeny, authorize(req.WithContext(WithClaims(req.Context(), claims))))
+
+	role.AddPermissions("/reports")
+	for _, tt := range []struct {
+		opt    RequestOption
+		url    string
+		result Decision
+	}{
+		{WithHostActions(false), "http://admin.example.com/users", DecisionAllow},
+		{WithHostActions(false), "http://admin.example.com.evil/users", DecisionDeny},
+		{WithHostActions(false), "http://www.example.com/reports", DecisionDeny},
+		{WithHostActions(true), "http://www.example.com/reports", DecisionAllow},
+		{WithHostActions(true), "http://admin.example.com.evil/users", DecisionDeny},
+	} {
+		authorize = RequestAuthorizer(NewDefaultAuthorizer(rbac), tt.opt)
+		req = httptest.NewRequest("GET", tt.url, nil)
+		s.Equal(tt.result, authorize(req.WithContext(WithClaims(req.Context(), claims))), tt.url)
+	}
+}
+
+func (s *authorizerRequestSuit) TestRequestAuthorizer_Options() {
+	rbac := New()
+	role := NewRole("editor")
+	role.AddPermissions("GET /posts/7")
+	_ = rbac.AddRole(role)
+	authorizer := NewDefaultAuthorizer(rbac)
+
+	var metadata map[string]any
+	capture := authorizerFunc(func(ctx context.Context, claims *Claims, target *Target) Decision {
+		metadata = target.Metadata
+		return authorizer.Authorize(ctx, claims, target)
+	})
+	authorize := RequestAuthorizerE(capture,
+		WithSkipper(func(r *http.Request) bool { return r.URL.Path == "/healthz" }),
+		WithClaimsLoader(func(r *http.Request) (*Claims, error) {
+			if r.Header.Get("X-User") == "" {
+				return nil, nil
+			}
+			return &Claims{Subject: NewSubject(r.Header.Get("X-User"), "editor")}, nil
+		}),
+		WithTargetBuilder(func(r *http.Request, target *Target) {
+			target.Metadata = map[string]any{"path": r.URL.Path}
+		}),
+	)
+
+	s.NoError(authorize(httptest.NewRequest("GET", "/healthz", nil)))
+
+	req := httptest.NewRequest("GET", "/posts/7", nil)
+	err := authorize(req)
+	s.ErrorIs(err, ErrDeny)
+	s.ErrorIs(err, ErrUnauthenticated)
+	var authzErr *AuthzError
+	s.ErrorAs(err, &authzErr)
+	s.Equal(AuthzUnauthenticated, authzErr.Kind)
+	s.Equal(http.StatusUnauthorized, authzErr.HTTPStatus())
+	s.Equal("GET /posts/7", authzErr.Action)
+
+	req.Header.Set("X-User", "1")
+	s.NoError(authorize(req))
+	s.Equal(map[string]any{"path": "/posts/7"}, metadata)
+
+	req = httptest.NewRequest("GET", "/posts/8", nil)
+	req.Header.Set("X-User", "1")
+	err = authorize(req)
+	s.ErrorIs(err, ErrDeny)
+	s.NotErrorIs(err, ErrUnauthenticated)
+	s.ErrorAs(err, &authzErr)
+	s.Equal(http.StatusForbidden, authzErr.HTTPStatus())
+
+	custom := errors.New("custom")
+	authorize = RequestAuthorizerE(authorizer,
+		WithActions(func(*http.Request) []string { return []string{"GET /posts/8"} }),
+		WithClaimsLoader(func(*http.Request) (*Claims, error) { return nil, custom }),
+		WithOnDeny(func(_ *http.Request, err error) error { return fmt.Errorf("wrapped: %w", err) }),
+	)
+	err = authorize(httptest.NewRequest("GET", "/posts/7", nil))
+	s.ErrorIs(err, custom)
+	s.ErrorIs(err, ErrUnauthenticated)
+	s.ErrorContains(err, "wrapped")
+}
+
+func (s *authorizerRequestSuit) TestPublicRoutes() {
+	public := PublicRoutes("GET /healthz", "/metrics", "POST /auth/*", "/static/**")
+
+	s.True(public(httptest.NewRequest("GET", "/healthz", nil)))
+	s.False(public(httptest.NewRequest("POST", "/healthz", nil)))
+	s.True(public(httptest.NewRequest("DELETE", "/metrics", nil)))
+	s.True(public(httptest.NewRequest("POST", "/auth/login", nil)))
+	s.False(public(httptest.NewRequest("POST", "/auth/login/extra", nil)))
+	s.True(public(httptest.NewRequest("GET", "/static/css/site.css", nil)))
+	s.False(public(httptest.NewRequest("GET", "/api/users", nil)))
+
+	authorize := RequestAuthorizer(&mockAuthorizer{decision: DecisionDeny},
+		WithSkipper(public),
+		WithSkipper(func(r *http.Request) bool { return r.Header.Get("X-Internal") != "" }),
+	)
+	s.Equal(DecisionAllow, authorize(httptest.NewRequest("GET", "/healthz", nil)))
+	s.Equal(DecisionDeny, authorize(httptest.NewRequest("GET", "/api/users", nil)))
+
+	req := httptest.NewRequest("GET", "/api/users", nil)
+	req.Header.Set("X-Internal", "1")
+	s.Equal(DecisionAllow, authorize(req))
+}
+
+func (s *authorizerRequestSuit) TestDefaultActions_Pattern() {
+	var actions []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) { actions = defaultActions(r) })
+	mux.HandleFunc("GET example.com/{$}", func(w http.ResponseWriter, r *http.Request) { actions = defaultActions(r) })
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+	s.Equal([]string{"*", "GET", "/users/{id}", "GET /users/{id}", "/users/42", "GET /users/42"}, actions)
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://example.com/", nil))
+	s.Equal([]string{"*", "GET", "/", "GET /"}, actions)
+
+	rbac := New()
+	role := NewRole("user")
+	_ = role.AddLiteralPermissions("GET /users/{id}")
+	_ = rbac.AddRole(role)
+	authorize := RequestAuthorizer(NewDefaultAuthorizer(rbac))
+
+	var d Decision
+	mux = http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		d = authorize(r.WithContext(WithClaims(r.Context(), &Claims{Subject: NewSubject("1", "user")})))
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+	s.Equal(DecisionAllow, d)
+}
+
+func (s *authorizerRequestSuit) TestRequestAuthorizer_PathParams() {
+	rbac := New()
+	role := NewRole("user")
+	_ = role.AddLiteralPermissions("GET /users/{user_id}/{tab}")
+	_ = rbac.AddRole(role)
+	authorizer := NewDefaultAuthorizer(rbac)
+
+	var metadata map[string]any
+	capture := authorizerFunc(func(ctx context.Context, claims *Claims, target *Target) Decision {
+		metadata = maps.Clone(target.Metadata)
+		return authorizer.Authorize(ctx, claims, target)
+	})
+	shared := map[string]any{"tab": "builder"}
+	authorize := RequestAuthorizer(capture, WithTargetBuilder(func(r *http.Request, target *Target) {
+		target.Metadata = shared
+	}))
+
+	var d Decision
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{user_id}/{tab}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithClaims(r.Context(), &Claims{Subject: NewSubject("42", "user")})
+		ctx = WithAssertions(ctx, NewOwnershipAssertion("user_id"))
+		d = authorize(r.WithContext(ctx))
+	})
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42/profile", nil))
+	s.Equal(DecisionAllow, d)
+	s.Equal(map[string]any{"user_id": "42", "tab": "builder"}, metadata)
+	s.Equal(map[string]any{"tab": "builder"}, shared)
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/7/profile", nil))
+	s.Equal(DecisionDeny, d)
+}
@@ -2,6 +2,7 @@ package rbac
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"testing"
 
@@ -53,7 +54,7 @@ func (s *authorizerSuit) TestNewDefaultAuthorizer() {
 	authorizer := NewDefaultAuthorizer(rbac)
 
 	s.NotNil(authorizer)
-	s.Equal(rbac, authorizer.rbac)
+	s.Equal(rbac, authorizer.Holder().Load())
 }
 
 func (s *authorizerSuit) TestAuthorize_ValidRequestWithPermission() {
@@ -400,7 +401,10 @@ func (s *authorizerSuit) TestDecisionString() {
 	allow := DecisionAllow
 	s.Equal("deny", deny.String())
 	s.Equal("allow", allow.String())
-	s.Equal("unknown", Decision(2).String()) // Invalid decision
+	s.Equal("warn", DecisionWarn.String())
+	s.Equal("abstain", DecisionAbstain.String())
+	s.False(DecisionAbstain.Allowed())
+	s.Equal("unknown", Decision(99).String()) // Invalid decision
 }
 
 func (s *authorizerSuit) TestTargetReset() {
@@ -446,3 +450,131 @@ func (s *authorizerSuit) TestAuthorize_WithContext() {
 	s.Equal(DecisionAllow, decision)
 	s.NoError(err)
 }
+
+type testIdentifiedSubject struct {
+	id    string
+	roles []string
+}
+
+func (s *testIdentifiedSubject) Identifier() string {
+	return s.id
+}
+
+func (s *testIdentifiedSubject) Roles() []string {
+	return s.roles
+}
+
+func (s *authorizerSuit) TestAuthorize_Impersonation() {
+	support := NewRole("support")
+	support.AddPermissions(ActionImpersonate)
+	user := NewRole("user")
+	user.AddPermissions("read:posts")
+
+	s.Nil(s.rbac.AddRole(support))
+	s.Nil(s.rbac.AddRole(user))
+
+	actor := &testIdentifiedSubject{id: "agent-1", roles: []string{"support"}}
+	subject := &testIdentifiedSubject{id: "user-42", roles: []string{"user"}}
+
+	ctx := WithImpersonation(WithClaims(context.Background(), &Claims{Metadata: map[string]any{"key": "value"}}), actor, subject)
+	claims := CtxClaims(ctx)
+	s.True(claims.IsImpersonated())
+	s.Equal("value", claims.Metadata["key"])
+
+	decision, err := s.authorizer.AuthorizeE(ctx, claims, &Target{Action: "read:posts"})
+	s.Equal(DecisionAllow, decision)
+	s.NoError(err)
+
+	decision, err = s.authorizer.AuthorizeE(ctx, claims, &Target{Action: "write:posts"})
+	s.Equal(DecisionDeny, decision)
+	s.ErrorIs(err, ErrDeny)
+	s.NotErrorIs(err, ErrImpersonationDenied)
+}
+
+func (s *authorizerSuit) TestAuthorize_ImpersonationDenied() {
+	user := NewRole("user")
+	user.AddPermissions("read:posts")
+	s.Nil(s.rbac.AddRole(user))
+
+	actor := &testIdentifiedSubject{id: "user-1", roles: []string{"user"}}
+	subject := &testIdentifiedSubject{id: "user-42", roles: []string{"user"}}
+
+	claims := CtxClaims(WithImpersonation(context.Background(), actor, subject))
+
+	decision, err := s.authorizer.AuthorizeE(context.Background(), claims, &Target{Action: "read:posts"})
+	s.Equal(DecisionDeny, decision)
+	s.ErrorIs(err, ErrDeny)
+	s.ErrorIs(err, ErrImpersonationDenied)
+	s.ErrorContains(err, `actor "user-1" cannot act as subject "user-42"`)
+}
+
+func (s *authorizerSuit) TestSubjectID() {
+	s.Equal("", SubjectID(&testSubject{}))
+	s.Equal("id", SubjectID(&testIdentifiedSubject{id: "id"}))
+}
+
+func (s *authorizerSuit) TestDecisionValues() {
+	s.Equal(Decision(0), DecisionDeny)
+	s.Equal(Decision(1), DecisionAllow)
+	s.Equal(Decision(2), DecisionWarn)
+	s.Equal(Decision(3), DecisionAbstain)
+}
+
+func (s *authorizerSuit) TestSetAbstain() {
+	rbac := New()
+	role := NewRole("editor")
+	role.AddPermissions("post.edit")
+	_ = rbac.AddRole(role)
+
+	authorizer := NewDefaultAuthorizer(rbac).SetAbstain(true)
+	claims := &Claims{Subject: NewSubject("1", "editor", "ghost")}
+
+	s.Equal(DecisionAllow, authorizer.Authorize(context.Background(), claims, &Target{Action: "post.edit"}))
+
+	d, err := authorizer.AuthorizeE(context.Background(), claims, &Target{Action: "billing.read"})
+	s.Equal(DecisionAbstain, d)
+	s.Error(err)
+
+	d = authorizer.Authorize(context.Background(), claims, &Target{
+		Action:     "post.edit",
+		Assertions: []Assertion{&testAssertion{shouldPass: false}},
+	})
+	s.Equal(DecisionDeny, d)
+
+	s.Equal(DecisionDeny, authorizer.SetAbstain(false).Authorize(context.Background(), claims, &Target{Action: "billing.read"}))
+}
+
+func (s *authorizerSuit) TestParseDecision() {
+	for _, d := range []Decision{DecisionDeny, DecisionAllow, DecisionWarn, DecisionAbstain} {
+		parsed, err := ParseDecision(d.String())
+		s.NoError(err)
+		s.Equal(d, parsed)
+	}
+
+	_, err := ParseDecision("maybe")
+	s.ErrorIs(err, ErrInvalidDecision)
+}
+
+func (s *authorizerSuit) TestDecisionJSON() {
+	data, err := json.Marshal(map[string]Decision{"d": DecisionWarn})
+	s.NoError(err)
+	s.JSONEq(`{"d":"warn"}`, string(data))
+
+	_, err = json.Marshal(Decision(99))
+	s.ErrorIs(err, ErrInvalidDecision)
+
+	var d Decision
+	s.NoError(json.Unmarshal([]byte(`"allow"`), &d))
+	s.Equal(DecisionAllow, d)
+	s.NoError(json.Unmarshal([]byte(`2`), &d))
+	s.Equal(DecisionWarn, d)
+	s.ErrorIs(json.Unmarshal([]byte(`"maybe"`), &d), ErrInvalidDecision)
+	s.ErrorIs(json.Unmarshal([]byte(`99`), &d), ErrInvalidDecision)
+	s.ErrorIs(json.Unmarshal([]byte(`{}`), &d), ErrInvalidDecision)
+
+	text, err := DecisionDeny.MarshalText()
+	s.NoError(err)
+	s.Equal("deny", string(text))
+	s.NoError(d.UnmarshalText([]byte("deny")))
+	s.Equal(DecisionDeny, d)
+}
@@ -0,0 +1,88 @@
+package rbac
+
+import (
+	"errors"
+	"net/http"
+)
+
+type AuthzKind int8
+
+const (
+	AuthzForbidden AuthzKind = iota
+	AuthzUnauthenticated
+	AuthzPolicyError
+)
+
+func (k AuthzKind) String() string {
+	switch k {
+	case AuthzForbidden:
+		return "forbidden"
+	case AuthzUnauthenticated:
+		return "unauthenticated"
+	case AuthzPolicyError:
+		return "policy-error"
+	default:
+		return "unknown"
+	}
+}
+
+// AuthzError describes a denial in transport independent terms. It matches
+// ErrDeny, AuthzUnauthenticated ones ErrUnauthenticated as well, and unwraps
+// to the individual causes.
+type AuthzError struct {
+	Kind   AuthzKind
+	Code   string
+	Action string
+	Err    error
+}
+
+func NewAuthzError(kind AuthzKind, action string, causes ...error) *AuthzError {
+	e := &AuthzError{Kind: kind, Action: action, Err: errors.Join(causes...)}
+	if reasons := Reasons(e.Err); len(reasons) > 0 {
+		e.Code = reasons[0].Code()
+	}
+	return e
+}
+
+func (e *AuthzError) Error() string {
+	msg := ErrDeny.Error() + ": " + e.Kind.String()
+	if e.Action != "" {
+		msg += ` "` + e.Action + `"`
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *AuthzError) Is(target error) bool {
+	return target == ErrDeny || target == ErrUnauthenticated && e.Kind == AuthzUnauthenticated
+}
+
+func (e *AuthzError) Unwrap() error {
+	return e.Err
+}
+
+func (e *AuthzError) HTTPStatus() int {
+	switch e.Kind {
+	case AuthzUnauthenticated:
+		return http.StatusUnauthorized
+	case AuthzPolicyError:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusForbidden
+	}
+}
+
+// GRPCCode returns the numeric google.golang.org/grpc/codes value,
+// convert it with codes.Code(err.GRPCCode()).
+func (e *AuthzError) GRPCCode() uint32 {
+	switch e.Kind {
+	case AuthzUnauthenticated:
+		return 16 // Unauthenticated
+	case AuthzPolicyError:
+		return 13 // Internal
+	default:
+		return 7 // PermissionDenied
+	}
+}
@@ -0,0 +1,54 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthzError(t *testing.T) {
+	rbac := New()
+	user := NewRole("user")
+	user.AddPermissions("posts:read")
+	assert.NoError(t, rbac.AddRole(user))
+	authorizer := NewDefaultAuthorizer(rbac)
+
+	var authzErr *AuthzError
+
+	_, err := authorizer.AuthorizeE(context.Background(), &Claims{Subject: &testSubject{roles: []string{"user"}}}, &Target{Action: "posts:write"})
+	assert.ErrorIs(t, err, ErrDeny)
+	assert.ErrorAs(t, err, &authzErr)
+	assert.Equal(t, AuthzForbidden, authzErr.Kind)
+	assert.Equal(t, ReasonCodePermissionMissing, authzErr.Code)
+	assert.Equal(t, "posts:write", authzErr.Action)
+	assert.Equal(t, http.StatusForbidden, authzErr.HTTPStatus())
+	assert.Equal(t, uint32(7), authzErr.GRPCCode())
+	assert.Equal(t, `deny: forbidden "posts:write": permission_missing`, err.Error())
+
+	_, err = authorizer.AuthorizeE(context.Background(), nil, &Target{Action: "posts:write"})
+	assert.ErrorAs(t, err, &authzErr)
+	assert.Equal(t, AuthzUnauthenticated, authzErr.Kind)
+	assert.Equal(t, ReasonCodeUnauthenticated, authzErr.Code)
+	assert.Equal(t, http.StatusUnauthorized, authzErr.HTTPStatus())
+	assert.Equal(t, uint32(16), authzErr.GRPCCode())
+
+	_, err = authorizer.AuthorizeE(context.Background(), nil, nil)
+	assert.ErrorAs(t, err, &authzErr)
+	assert.Equal(t, AuthzPolicyError, authzErr.Kind)
+	assert.Equal(t, http.StatusInternalServerError, authzErr.HTTPStatus())
+	assert.Equal(t, uint32(13), authzErr.GRPCCode())
+	assert.Equal(t, "policy-error", authzErr.Kind.String())
+
+	plain := NewAuthzError(AuthzForbidden, "", errors.New("boom"))
+	assert.Empty(t, plain.Code)
+	assert.Equal(t, "deny: forbidden: boom", plain.Error())
+}
+
+func TestAuthzError_Unauthenticated(t *testing.T) {
+	assert.ErrorIs(t, NewAuthzError(AuthzUnauthenticated, "posts:write"), ErrUnauthenticated)
+	assert.ErrorIs(t, NewAuthzError(AuthzUnauthenticated, "posts:write"), ErrDeny)
+	assert.NotErrorIs(t, NewAuthzError(AuthzForbidden, "posts:write"), ErrUnauthenticated)
+}
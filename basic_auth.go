@@ -0,0 +1,94 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+var (
+	_ ClaimsExtractor = (*BasicClaimsExtractor)(nil)
+	_ BasicVerifier   = (*PasswordMap)(nil)
+)
+
+// BasicVerifier checks HTTP Basic credentials and returns the user's roles,
+// e.g. through an LDAP bind or a password file.
+type BasicVerifier interface {
+	VerifyBasic(ctx context.Context, username, password string) ([]string, error)
+}
+
+type BasicVerifierFunc func(ctx context.Context, username, password string) ([]string, error)
+
+func (f BasicVerifierFunc) VerifyBasic(ctx context.Context, username, password string) ([]string, error) {
+	return f(ctx, username, password)
+}
+
+// PasswordMap verifies credentials against stored password hashes. Compare
+// has the signature of bcrypt.CompareHashAndPassword. Unknown users are
+// compared against DummyHash, or any stored hash if unset, so that response
+// times do not reveal which users exist.
+type PasswordMap struct {
+	Hashes    map[string]string
+	Roles     map[string][]string
+	Compare   func(hashedPassword, password []byte) error
+	DummyHash string
+}
+
+func (m *PasswordMap) VerifyBasic(_ context.Context, username, password string) ([]string, error) {
+	if m.Compare == nil {
+		return nil, ErrInvalidCredentials
+	}
+	hash, ok := m.Hashes[username]
+	if !ok {
+		_ = m.Compare([]byte(m.dummyHash()), []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err := m.Compare([]byte(hash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return m.Roles[username], nil
+}
+
+func (m *PasswordMap) dummyHash() string {
+	if m.DummyHash != "" {
+		return m.DummyHash
+	}
+	for _, hash := range m.Hashes {
+		return hash
+	}
+	return ""
+}
+
+// BasicClaimsExtractor builds claims from HTTP Basic credentials. The
+// username becomes the subject identifier; Roles are added to the roles
+// returned by the verifier.
+type BasicClaimsExtractor struct {
+	Verifier BasicVerifier
+	Roles    []string
+}
+
+func (e *BasicClaimsExtractor) ExtractClaims(r *http.Request) (*Claims, error) {
+	authorization := r.Header.Get("Authorization")
+	if authorization == "" {
+		return nil, nil
+	}
+	if scheme, _, _ := strings.Cut(authorization, " "); !strings.EqualFold(scheme, "basic") {
+		return nil, nil
+	}
+
+	username, password, ok := r.BasicAuth()
+	if !ok || username == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	roles, err := e.Verifier.VerifyBasic(r.Context(), username, password)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+
+	roles = append(slices.Clone(e.Roles), roles...)
+
+	return &Claims{Subject: NewSubject(username, roles...), Metadata: map[string]any{}}, nil
+}
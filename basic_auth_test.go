@@ -0,0 +1,97 @@
+package rbac
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func plainCompare(hashed, password []byte) error {
+	if !bytes.Equal(hashed, password) {
+		return errors.New("mismatch")
+	}
+	return nil
+}
+
+func TestPasswordMap_VerifyBasic(t *testing.T) {
+	m := &PasswordMap{
+		Hashes:  map[string]string{"alice": "secret"},
+		Roles:   map[string][]string{"alice": {"editor"}},
+		Compare: plainCompare,
+	}
+
+	roles, err := m.VerifyBasic(context.Background(), "alice", "secret")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"editor"}, roles)
+
+	_, err = m.VerifyBasic(context.Background(), "alice", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = m.VerifyBasic(context.Background(), "bob", "secret")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = (&PasswordMap{Hashes: m.Hashes}).VerifyBasic(context.Background(), "alice", "secret")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestPasswordMap_VerifyBasic_UnknownUserCompares(t *testing.T) {
+	var compared []string
+	m := &PasswordMap{
+		Hashes: map[string]string{"alice": "secret"},
+		Compare: func(hashedPassword, password []byte) error {
+			compared = append(compared, string(hashedPassword))
+			return plainCompare(hashedPassword, password)
+		},
+	}
+
+	_, err := m.VerifyBasic(context.Background(), "bob", "secret")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	assert.Equal(t, []string{"secret"}, compared)
+
+	m.DummyHash = "dummy"
+	_, err = m.VerifyBasic(context.Background(), "bob", "dummy")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	assert.Equal(t, []string{"secret", "dummy"}, compared)
+}
+
+func TestBasicClaimsExtractor_ExtractClaims(t *testing.T) {
+	e := &BasicClaimsExtractor{
+		Verifier: BasicVerifierFunc(func(_ context.Context, username, password string) ([]string, error) {
+			if username != "alice" || password != "secret" {
+				return nil, errors.New("bind failed")
+			}
+			return []string{"editor"}, nil
+		}),
+		Roles: []string{"user"},
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	claims, err := e.ExtractClaims(r)
+	assert.NoError(t, err)
+	assert.Nil(t, claims)
+
+	r.Header.Set("Authorization", "Bearer token")
+	claims, err = e.ExtractClaims(r)
+	assert.NoError(t, err)
+	assert.Nil(t, claims)
+
+	r.SetBasicAuth("alice", "secret")
+	claims, err = e.ExtractClaims(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", SubjectID(claims.Subject))
+	assert.Equal(t, []string{"user", "editor"}, claims.Subject.Roles())
+	assert.Equal(t, []string{"user"}, e.Roles)
+
+	r.SetBasicAuth("alice", "wrong")
+	_, err = e.ExtractClaims(r)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	r.Header.Set("Authorization", "Basic !!!")
+	_, err = e.ExtractClaims(r)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
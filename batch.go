@@ -0,0 +1,72 @@
+package rbac
+
+import (
+	"context"
+	"sync"
+)
+
+// SetBatchWorkers makes AuthorizeBatch evaluate targets on up to n
+// goroutines, one at a time for n <= 1.
+func (a *DefaultAuthorizer) SetBatchWorkers(n int) *DefaultAuthorizer {
+	a.batchWorkers = n
+	return a
+}
+
+func (a *DefaultAuthorizer) BatchWorkers() int {
+	return a.batchWorkers
+}
+
+// AuthorizeBatch decides the targets with BatchWorkers goroutines, see
+// AuthorizeBatch.
+func (a *DefaultAuthorizer) AuthorizeBatch(ctx context.Context, claims *Claims, targets []*Target) ([]Decision, error) {
+	return AuthorizeBatch(ctx, a, claims, targets, a.batchWorkers)
+}
+
+// AuthorizeBatch decides the targets with any authorizer, e.g. the visibility
+// of every button of a page, on up to workers goroutines, see
+// WithDecisionMemo to evaluate repeated actions once. Targets are decided one
+// at a time, in order, within a RoleTransaction, whose outcome depends on
+// the order. Decisions are in the order of the targets; targets left when
+// ctx is done are denied and ctx.Err() is returned.
+func AuthorizeBatch(ctx context.Context, authorizer Authorizer, claims *Claims, targets []*Target, workers int) ([]Decision, error) {
+	decisions := make([]Decision, len(targets))
+	if len(targets) == 0 {
+		return decisions, nil
+	}
+	ctx = withClaimsOnce(ctx, claims)
+
+	workers = min(max(workers, 1), len(targets))
+	if workers == 1 || CtxRoleTransaction(ctx) != nil {
+		for i, target := range targets {
+			if ctx.Err() != nil {
+				break
+			}
+			decisions[i] = authorizer.Authorize(ctx, claims, target)
+		}
+		return decisions, ctx.Err()
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for i := range indexes {
+				decisions[i] = authorizer.Authorize(ctx, claims, targets[i])
+			}
+		})
+	}
+feed:
+	for i := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	return decisions, ctx.Err()
+}
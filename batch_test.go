@@ -0,0 +1,107 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizeBatch(t *testing.T) {
+	rbac := New()
+	user := NewRole("user")
+	require.NoError(t, user.AddPermissionsE("posts:read", "posts:update"))
+	require.NoError(t, rbac.AddRole(user))
+
+	claims := &Claims{Subject: NewSubject("1", "user")}
+	var targets []*Target
+	want := make([]Decision, 0, 60)
+	for i := range 20 {
+		targets = append(targets,
+			&Target{Action: "posts:read"},
+			&Target{Action: "posts:update", Metadata: map[string]any{"id": i}},
+			&Target{Action: "posts:delete"},
+		)
+		want = append(want, DecisionAllow, DecisionAllow, DecisionDeny)
+	}
+
+	for _, workers := range []int{0, 1, 4, 100} {
+		t.Run(fmt.Sprint(workers), func(t *testing.T) {
+			a := NewDefaultAuthorizer(rbac).SetBatchWorkers(workers)
+			assert.Equal(t, workers, a.BatchWorkers())
+
+			decisions, err := a.AuthorizeBatch(context.Background(), claims, targets)
+			require.NoError(t, err)
+			assert.Equal(t, want, decisions)
+		})
+	}
+
+	decisions, err := AuthorizeBatch(context.Background(), NewDefaultAuthorizer(rbac), claims, nil, 4)
+	assert.NoError(t, err)
+	assert.Empty(t, decisions)
+}
+
+func TestAuthorizeBatch_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	targets := []*Target{{Action: "a"}, {Action: "b"}}
+	for _, workers := range []int{1, 2} {
+		decisions, err := AuthorizeBatch(ctx, &mockAuthorizer{decision: DecisionAllow}, &Claims{}, targets, workers)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, []Decision{DecisionDeny, DecisionDeny}, decisions)
+	}
+}
+
+func TestAuthorizeBatch_RoleTransaction(t *testing.T) {
+	rbac := New()
+	clerk := NewRole("clerk")
+	require.NoError(t, clerk.AddPermissionsE("payment:create"))
+	auditor := NewRole("auditor")
+	require.NoError(t, auditor.AddPermissionsE("payment:approve"))
+	require.NoError(t, rbac.AddRole(clerk))
+	require.NoError(t, rbac.AddRole(auditor))
+
+	a := NewDefaultAuthorizer(rbac).
+		SetConstraints(NewConstraintRegistry().Add("payments", "clerk", "auditor")).
+		SetBatchWorkers(8)
+	claims := &Claims{Subject: NewSubject("1", "clerk", "auditor")}
+
+	targets := []*Target{{Action: "payment:create"}}
+	want := []Decision{DecisionAllow}
+	for range 50 {
+		targets = append(targets, &Target{Action: "payment:approve"}, &Target{Action: "payment:create"})
+		want = append(want, DecisionDeny, DecisionAllow)
+	}
+
+	for range 10 {
+		decisions, err := a.AuthorizeBatch(WithRoleTransaction(context.Background()), claims, targets)
+		require.NoError(t, err)
+		assert.Equal(t, want, decisions)
+	}
+}
+
+func TestAuthorizeBatch_Memo(t *testing.T) {
+	rbac := New()
+	role := NewRole("user")
+	require.NoError(t, role.AddPermissionsE("a"))
+	require.NoError(t, rbac.AddRole(role))
+	claims := &Claims{Subject: NewSubject("1", "user")}
+	targets := []*Target{{Action: "a"}, {Action: "a"}, {Action: "a"}}
+
+	var memos []*DecisionMemo
+	a := NewDefaultAuthorizer(rbac).SetAuditSink(AuditSinkFunc(func(ctx context.Context, _ AuditEvent) {
+		memos = append(memos, CtxDecisionMemo(ctx))
+	}))
+
+	_, err := AuthorizeBatch(context.Background(), a, claims, targets, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []*DecisionMemo{nil, nil, nil}, memos)
+
+	ctx := WithDecisionMemo(context.Background())
+	_, err = AuthorizeBatch(ctx, a, claims, targets, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), CtxDecisionMemo(ctx).Hits())
+}
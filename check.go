@@ -0,0 +1,87 @@
+package rbac
+
+import (
+	"context"
+	"slices"
+)
+
+// Input carries everything needed for an authorization decision explicitly,
+// for callers without an HTTP request such as jobs, CLIs or gRPC services.
+type Input struct {
+	Claims     *Claims
+	Action     string
+	Metadata   map[string]any
+	Assertions []Assertion
+}
+
+func (in Input) Target() *Target {
+	return &Target{Action: in.Action, Metadata: in.Metadata, Assertions: in.Assertions}
+}
+
+func (a *DefaultAuthorizer) Check(ctx context.Context, in Input) (Decision, error) {
+	return a.AuthorizeE(ctx, in.Claims, in.Target())
+}
+
+// Authorize evaluates target with additional assertions using any
+// authorizer, without relying on claims or assertions stored in ctx. The
+// target is not modified.
+func Authorize(ctx context.Context, authorizer Authorizer, claims *Claims, target *Target, assertions ...Assertion) Decision {
+	if target != nil && len(assertions) > 0 {
+		t := *target
+		t.Assertions = append(slices.Clip(target.Assertions), assertions...)
+		target = &t
+	}
+	return authorizer.Authorize(ctx, claims, target)
+}
+
+// AuthorizeAny returns the first allowing decision for the targets, e.g. to
+// show an edit button when the subject may update or delete. It abstains if
+// every target abstains and denies without targets.
+func AuthorizeAny(ctx context.Context, authorizer Authorizer, claims *Claims, targets ...*Target) Decision {
+	if len(targets) == 0 {
+		return DecisionDeny
+	}
+	ctx = withClaimsOnce(ctx, claims)
+	abstained := 0
+	for _, target := range targets {
+		switch d := authorizer.Authorize(ctx, claims, target); {
+		case d.Allowed():
+			return d
+		case d == DecisionAbstain:
+			abstained++
+		}
+	}
+	if abstained == len(targets) {
+		return DecisionAbstain
+	}
+	return DecisionDeny
+}
+
+// AuthorizeAll returns the first decision not allowing one of the targets,
+// DecisionWarn if one of them warns and DecisionAllow otherwise. It denies
+// without targets.
+func AuthorizeAll(ctx context.Context, authorizer Authorizer, claims *Claims, targets ...*Target) Decision {
+	if len(targets) == 0 {
+		return DecisionDeny
+	}
+	ctx = withClaimsOnce(ctx, claims)
+	decision := DecisionAllow
+	for _, target := range targets {
+		switch d := authorizer.Authorize(ctx, claims, target); {
+		case !d.Allowed():
+			return d
+		case d == DecisionWarn:
+			decision = d
+		}
+	}
+	return decision
+}
+
+// withClaimsOnce stores the claims in ctx so authorizers evaluating several
+// targets do not each add them.
+func withClaimsOnce(ctx context.Context, claims *Claims) context.Context {
+	if claims == nil || CtxClaims(ctx) == claims {
+		return ctx
+	}
+	return WithClaims(ctx, claims)
+}
@@ -0,0 +1,73 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultAuthorizer_Check(t *testing.T) {
+	rbac := New()
+	user := NewRole("user")
+	user.AddPermissions("reports:generate")
+	assert.NoError(t, rbac.AddRole(user))
+	authorizer := NewDefaultAuthorizer(rbac)
+
+	claims := &Claims{Subject: &testSubject{roles: []string{"user"}}}
+
+	d, err := authorizer.Check(context.Background(), Input{Claims: claims, Action: "reports:generate"})
+	assert.Equal(t, DecisionAllow, d)
+	assert.NoError(t, err)
+
+	d, err = authorizer.Check(context.Background(), Input{
+		Claims:     claims,
+		Action:     "reports:generate",
+		Metadata:   map[string]any{"job": "nightly"},
+		Assertions: []Assertion{&testAssertion{shouldPass: false}},
+	})
+	assert.Equal(t, DecisionDeny, d)
+	assert.ErrorIs(t, err, ErrDeny)
+}
+
+func TestAuthorize(t *testing.T) {
+	rbac := New()
+	user := NewRole("user")
+	user.AddPermissions("reports:generate")
+	assert.NoError(t, rbac.AddRole(user))
+	authorizer := NewDefaultAuthorizer(rbac)
+
+	claims := &Claims{Subject: &testSubject{roles: []string{"user"}}}
+	target := &Target{Action: "reports:generate", Assertions: []Assertion{&testAssertion{shouldPass: true}}}
+
+	assert.Equal(t, DecisionAllow, Authorize(context.Background(), authorizer, claims, target))
+	assert.Equal(t, DecisionDeny, Authorize(context.Background(), authorizer, claims, target, &testAssertion{shouldPass: false}))
+	assert.Len(t, target.Assertions, 1)
+	assert.Equal(t, DecisionDeny, Authorize(context.Background(), authorizer, claims, nil, &testAssertion{shouldPass: true}))
+}
+
+func TestAuthorizeAnyAll(t *testing.T) {
+	rbac := New()
+	user := NewRole("user")
+	assert.NoError(t, user.AddPermissionsE("posts:update", "posts:read"))
+	assert.NoError(t, rbac.AddRole(user))
+	authorizer := NewDefaultAuthorizer(rbac)
+
+	ctx := context.Background()
+	claims := &Claims{Subject: &testSubject{roles: []string{"user"}}}
+	read, update, remove := &Target{Action: "posts:read"}, &Target{Action: "posts:update"}, &Target{Action: "posts:delete"}
+
+	assert.Equal(t, DecisionAllow, AuthorizeAny(ctx, authorizer, claims, remove, update))
+	assert.Equal(t, DecisionDeny, AuthorizeAny(ctx, authorizer, claims, remove))
+	assert.Equal(t, DecisionDeny, AuthorizeAny(ctx, authorizer, claims))
+	assert.Equal(t, DecisionAllow, AuthorizeAll(ctx, authorizer, claims, read, update))
+	assert.Equal(t, DecisionDeny, AuthorizeAll(ctx, authorizer, claims, read, remove))
+	assert.Equal(t, DecisionDeny, AuthorizeAll(ctx, authorizer, claims))
+
+	authorizer.SetAbstain(true)
+	assert.Equal(t, DecisionAbstain, AuthorizeAny(ctx, authorizer, claims, remove, remove))
+
+	warn := &mockAuthorizer{decision: DecisionWarn}
+	assert.Equal(t, DecisionWarn, AuthorizeAll(ctx, warn, claims, read, update))
+	assert.Equal(t, DecisionWarn, AuthorizeAny(ctx, warn, claims, read))
+}
@@ -0,0 +1,90 @@
+package rbac
+
+import (
+	"context"
+	"maps"
+)
+
+// ClaimsBuilder assembles Claims without mutating the claims it starts from.
+type ClaimsBuilder struct {
+	claims Claims
+}
+
+// NewClaimsBuilder starts from a copy of base, which may be nil.
+func NewClaimsBuilder(base *Claims) *ClaimsBuilder {
+	b := &ClaimsBuilder{}
+	if base != nil {
+		b.claims = Claims{Subject: base.Subject, Actor: base.Actor, Metadata: maps.Clone(base.Metadata)}
+	}
+	return b
+}
+
+func (b *ClaimsBuilder) WithSubject(subject Subject) *ClaimsBuilder {
+	b.claims.Subject = subject
+	return b
+}
+
+func (b *ClaimsBuilder) WithActor(actor Subject) *ClaimsBuilder {
+	b.claims.Actor = actor
+	return b
+}
+
+// WithRole appends roles to the current subject, keeping its identifier.
+func (b *ClaimsBuilder) WithRole(roles ...string) *ClaimsBuilder {
+	if b.claims.Subject == nil {
+		b.claims.Subject = NewSubject("", roles...)
+		return b
+	}
+	current := b.claims.Subject.Roles()
+	merged := make([]string, 0, len(current)+len(roles))
+	b.claims.Subject = NewSubject(SubjectID(b.claims.Subject), append(append(merged, current...), roles...)...)
+	return b
+}
+
+// WithMeta sets a metadata key, overwriting any existing value.
+func (b *ClaimsBuilder) WithMeta(key string, value any) *ClaimsBuilder {
+	if b.claims.Metadata == nil {
+		b.claims.Metadata = make(map[string]any)
+	}
+	b.claims.Metadata[key] = value
+	return b
+}
+
+// MergeMeta adds metadata keys that are not already set; existing values win.
+func (b *ClaimsBuilder) MergeMeta(metadata map[string]any) *ClaimsBuilder {
+	for key, value := range metadata {
+		if _, ok := b.claims.Metadata[key]; ok {
+			continue
+		}
+		b.WithMeta(key, value)
+	}
+	return b
+}
+
+func (b *ClaimsBuilder) Build() *Claims {
+	claims := b.claims
+	claims.Metadata = maps.Clone(b.claims.Metadata)
+	return &claims
+}
+
+// MergeClaims installs claims combining the ones already in ctx with extra.
+// Upstream values take precedence: extra only fills a missing subject, actor
+// or metadata key. Neither the upstream claims nor extra are modified.
+func MergeClaims(ctx context.Context, extra *Claims) context.Context {
+	if extra == nil {
+		return ctx
+	}
+	current := CtxClaims(ctx)
+	if current == nil {
+		return WithClaims(ctx, NewClaimsBuilder(extra).Build())
+	}
+
+	b := NewClaimsBuilder(current).MergeMeta(extra.Metadata)
+	if current.Subject == nil {
+		b.WithSubject(extra.Subject)
+	}
+	if current.Actor == nil {
+		b.WithActor(extra.Actor)
+	}
+	return WithClaims(ctx, b.Build())
+}
@@ -0,0 +1,52 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaimsBuilder(t *testing.T) {
+	base := &Claims{Subject: NewSubject("u1", "user"), Metadata: map[string]any{"tenant": "acme"}}
+
+	claims := NewClaimsBuilder(base).
+		WithRole("editor").
+		WithMeta("tenant", "globex").
+		MergeMeta(map[string]any{"tenant": "ignored", "region": "eu"}).
+		Build()
+
+	assert.Equal(t, "u1", SubjectID(claims.Subject))
+	assert.Equal(t, []string{"user", "editor"}, claims.Subject.Roles())
+	assert.Equal(t, map[string]any{"tenant": "globex", "region": "eu"}, claims.Metadata)
+
+	assert.Equal(t, []string{"user"}, base.Subject.Roles())
+	assert.Equal(t, map[string]any{"tenant": "acme"}, base.Metadata)
+
+	empty := NewClaimsBuilder(nil).WithRole("guest").Build()
+	assert.Equal(t, []string{"guest"}, empty.Subject.Roles())
+	assert.Nil(t, empty.Metadata)
+}
+
+func TestMergeClaims(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, ctx, MergeClaims(ctx, nil))
+
+	extra := &Claims{Subject: NewSubject("u2"), Metadata: map[string]any{"tenant": "globex", "ip": "10.0.0.1"}}
+	claims := CtxClaims(MergeClaims(ctx, extra))
+	assert.Equal(t, extra.Metadata, claims.Metadata)
+	assert.NotSame(t, extra, claims)
+
+	upstream := &Claims{Subject: NewSubject("u1", "user"), Metadata: map[string]any{"tenant": "acme"}}
+	ctx = WithClaims(ctx, upstream)
+	claims = CtxClaims(MergeClaims(ctx, extra))
+	assert.Equal(t, "u1", SubjectID(claims.Subject))
+	assert.Nil(t, claims.Actor)
+	assert.Equal(t, map[string]any{"tenant": "acme", "ip": "10.0.0.1"}, claims.Metadata)
+	assert.Equal(t, map[string]any{"tenant": "acme"}, upstream.Metadata)
+
+	actor := NewSubject("admin", "admin")
+	claims = CtxClaims(MergeClaims(WithClaims(context.Background(), &Claims{}), &Claims{Subject: extra.Subject, Actor: actor}))
+	assert.Same(t, extra.Subject, claims.Subject)
+	assert.Same(t, actor, claims.Actor)
+}
@@ -0,0 +1,105 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+type ClaimsExtractor interface {
+	ExtractClaims(r *http.Request) (*Claims, error)
+}
+
+type ClaimsExtractorFunc func(r *http.Request) (*Claims, error)
+
+func (f ClaimsExtractorFunc) ExtractClaims(r *http.Request) (*Claims, error) {
+	return f(r)
+}
+
+// ClaimsMiddleware installs the extracted claims into the request context.
+// Requests without credentials pass through without claims, invalid
+// credentials are rejected with 401.
+func ClaimsMiddleware(extractor ClaimsExtractor) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, err := extractor.ExtractClaims(r)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			if claims != nil {
+				r = r.WithContext(WithClaims(r.Context(), claims))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type HeaderClaimsExtractor struct {
+	// VerifyToken turns a bearer token into claims.
+	VerifyToken func(ctx context.Context, token string) (*Claims, error)
+	// SubjectHeader and RolesHeader are only honored behind a trusted gateway.
+	SubjectHeader string
+	RolesHeader   string
+}
+
+func (e *HeaderClaimsExtractor) ExtractClaims(r *http.Request) (*Claims, error) {
+	return e.extract(r.Context(), r.Header.Values)
+}
+
+func (e *HeaderClaimsExtractor) extract(ctx context.Context, values func(string) []string) (*Claims, error) {
+	if authorization := firstValue(values("authorization")); authorization != "" && e.VerifyToken != nil {
+		scheme, token, ok := strings.Cut(authorization, " ")
+		if !ok || !strings.EqualFold(scheme, "bearer") || token == "" {
+			return nil, ErrInvalidCredentials
+		}
+		return e.VerifyToken(ctx, strings.TrimSpace(token))
+	}
+
+	if e.RolesHeader == "" {
+		return nil, nil
+	}
+
+	var roles []string
+	for _, value := range values(e.RolesHeader) {
+		for role := range strings.SplitSeq(value, ",") {
+			if role = strings.TrimSpace(role); role != "" {
+				roles = append(roles, role)
+			}
+		}
+	}
+	if len(roles) == 0 {
+		return nil, nil
+	}
+
+	var id string
+	if e.SubjectHeader != "" {
+		id = firstValue(values(e.SubjectHeader))
+	}
+
+	return &Claims{Subject: NewSubject(id, roles...), Metadata: map[string]any{}}, nil
+}
+
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// claimPath resolves a dot separated path such as "realm_access.roles" in
+// decoded token claims.
+func claimPath(claims map[string]any, path string) any {
+	var value any = claims
+	for key := range strings.SplitSeq(path, ".") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
@@ -0,0 +1,84 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testVerifyToken(_ context.Context, token string) (*Claims, error) {
+	if token != "good" {
+		return nil, errors.New("bad token")
+	}
+	return &Claims{Subject: NewSubject("user-1", "user")}, nil
+}
+
+func TestHeaderClaimsExtractor_ExtractClaims(t *testing.T) {
+	e := &HeaderClaimsExtractor{VerifyToken: testVerifyToken, SubjectHeader: "X-Subject", RolesHeader: "X-Roles"}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	claims, err := e.ExtractClaims(r)
+	assert.NoError(t, err)
+	assert.Nil(t, claims)
+
+	r.Header.Set("Authorization", "Bearer good")
+	claims, err = e.ExtractClaims(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", SubjectID(claims.Subject))
+
+	r.Header.Set("Authorization", "Basic good")
+	_, err = e.ExtractClaims(r)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	r.Header.Del("Authorization")
+	r.Header.Set("X-Subject", "svc")
+	r.Header.Add("X-Roles", "admin, user")
+	r.Header.Add("X-Roles", "ops")
+	claims, err = e.ExtractClaims(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "svc", SubjectID(claims.Subject))
+	assert.Equal(t, []string{"admin", "user", "ops"}, claims.Subject.Roles())
+}
+
+func TestClaimsMiddleware(t *testing.T) {
+	e := &HeaderClaimsExtractor{VerifyToken: testVerifyToken}
+
+	var claims *Claims
+	handler := ClaimsMiddleware(e)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		claims = CtxClaims(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer good")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "user-1", SubjectID(claims.Subject))
+
+	claims = nil
+	r.Header.Set("Authorization", "Bearer bad")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Nil(t, claims)
+}
+
+func TestHeaderClaimsExtractor_InstallMetadataClaims(t *testing.T) {
+	e := &HeaderClaimsExtractor{VerifyToken: testVerifyToken, SubjectHeader: "x-subject", RolesHeader: "X-Roles"}
+
+	ctx, err := e.InstallMetadataClaims(context.Background(), map[string][]string{"authorization": {"Bearer good"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", SubjectID(CtxClaims(ctx).Subject))
+
+	ctx, err = e.InstallMetadataClaims(context.Background(), map[string][]string{"x-subject": {"gw"}, "x-roles": {"admin"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"admin"}, CtxClaims(ctx).Subject.Roles())
+
+	ctx, err = e.InstallMetadataClaims(context.Background(), map[string][]string{"authorization": {"Bearer bad"}})
+	assert.Error(t, err)
+	assert.Nil(t, CtxClaims(ctx))
+}
@@ -0,0 +1,28 @@
+package rbac
+
+import (
+	"context"
+	"strings"
+)
+
+// ExtractMetadataClaims builds claims from incoming gRPC metadata. The md
+// argument has the shape of metadata.MD, so the result of
+// metadata.FromIncomingContext can be passed as is.
+func (e *HeaderClaimsExtractor) ExtractMetadataClaims(ctx context.Context, md map[string][]string) (*Claims, error) {
+	return e.extract(ctx, func(key string) []string {
+		return md[strings.ToLower(key)]
+	})
+}
+
+// InstallMetadataClaims is meant to be called from unary and stream server
+// interceptors before the request is authorized.
+func (e *HeaderClaimsExtractor) InstallMetadataClaims(ctx context.Context, md map[string][]string) (context.Context, error) {
+	claims, err := e.ExtractMetadataClaims(ctx, md)
+	if err != nil {
+		return ctx, err
+	}
+	if claims != nil {
+		ctx = WithClaims(ctx, claims)
+	}
+	return ctx, nil
+}
@@ -0,0 +1,148 @@
+package rbac
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const ClientPolicyVersion = 1
+
+// ClientPolicy is the rule set exported for frontends. The JSON schema of
+// version 1 is:
+//
+//	{
+//	  "version": 1,
+//	  "subject": "user-1",
+//	  "roles": ["editor"],
+//	  "rules": [
+//	    {"action": "read", "subject": "posts"},
+//	    {"action": "posts:\\d+", "pattern": true}
+//	  ]
+//	}
+//
+// Rules are a CASL-like allow list. When pattern is set the action is a
+// regular expression matched against the whole action. Clients must ignore
+// unknown fields and reject unknown versions.
+type ClientPolicy struct {
+	Version int          `json:"version"`
+	Subject string       `json:"subject,omitempty"`
+	Roles   []string     `json:"roles,omitempty"`
+	Rules   []ClientRule `json:"rules"`
+}
+
+type ClientRule struct {
+	Action  string `json:"action"`
+	Subject string `json:"subject,omitempty"`
+	Pattern bool   `json:"pattern,omitempty"`
+}
+
+// ClientPolicyExporter limits the exported rules to the effective
+// permissions of a subject, including implied ones, and exports superusers
+// as allowed every action. Assertions are evaluated server side only, so the
+// result is meant for optimistic UI gating.
+type ClientPolicyExporter struct {
+	rbac  *RBAC
+	split func(permission string) (action, subject string)
+}
+
+func NewClientPolicyExporter(rbac *RBAC) *ClientPolicyExporter {
+	return &ClientPolicyExporter{rbac: rbac}
+}
+
+// SetSplit maps literal permissions onto a CASL action and subject, see
+// SplitPermission. Without it the whole permission is the action.
+func (e *ClientPolicyExporter) SetSplit(split func(permission string) (action, subject string)) *ClientPolicyExporter {
+	e.split = split
+	return e
+}
+
+func (e *ClientPolicyExporter) Export(subject Subject) ClientPolicy {
+	policy := ClientPolicy{Version: ClientPolicyVersion, Rules: []ClientRule{}}
+	if subject == nil {
+		return policy
+	}
+	policy.Subject = SubjectID(subject)
+	policy.Roles = subject.Roles()
+
+	if slices.ContainsFunc(policy.Roles, e.rbac.IsSuperuser) {
+		policy.Rules = append(policy.Rules, ClientRule{Action: ".*", Pattern: true})
+		return policy
+	}
+
+	for _, permission := range e.rbac.EffectivePermissions(policy.Roles...) {
+		rule := ClientRule{Action: permission}
+		if pattern, ok := e.pattern(policy.Roles, permission); ok {
+			rule.Action, rule.Pattern = pattern, true
+		} else if e.split != nil {
+			rule.Action, rule.Subject = e.split(permission)
+		}
+		policy.Rules = append(policy.Rules, rule)
+	}
+	return policy
+}
+
+// pattern returns the regular expression of a pattern permission: a regex, or
+// a glob granted by a glob matching role or an implication.
+func (e *ClientPolicyExporter) pattern(roles []string, permission string) (string, bool) {
+	if isPattern(permission) {
+		return permission, true
+	}
+	if !strings.ContainsAny(permission, "*?") {
+		return "", false
+	}
+	glob := true
+	for _, name := range roles {
+		if r, ok := e.rbac.roles[name]; ok {
+			walkRoles(r, map[*Role]struct{}{}, func(r *Role) bool {
+				if matcher, held := r.permissions[permission]; held {
+					_, glob = matcher.(globMatcher)
+					return false
+				}
+				return true
+			})
+		}
+	}
+	if !glob {
+		return "", false
+	}
+	return globRegexp(permission), true
+}
+
+// ServeHTTP writes the policy of the subject in the request claims.
+func (e *ClientPolicyExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var subject Subject
+	if claims := CtxClaims(r.Context()); claims != nil {
+		subject = claims.Subject
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "private, no-store")
+	_ = json.NewEncoder(w).Encode(e.Export(subject))
+}
+
+// SplitPermission splits "subject<sep>action" permissions, e.g. "posts:read"
+// with ":" becomes action "read" on subject "posts".
+func SplitPermission(sep string) func(permission string) (action, subject string) {
+	return func(permission string) (string, string) {
+		if i := strings.LastIndex(permission, sep); i >= 0 {
+			return permission[i+len(sep):], permission[:i]
+		}
+		return permission, ""
+	}
+}
+
+func isPattern(permission string) bool {
+	value, ok := perms.Load(permission)
+	if !ok {
+		return false
+	}
+	re, _ := value.(*regexp.Regexp)
+	if re == nil {
+		return false
+	}
+	_, complete := re.LiteralPrefix()
+	return !complete
+}
@@ -0,0 +1,92 @@
+package rbac
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newClientPolicyRBAC(t *testing.T) *RBAC {
+	rbac := New()
+	admin := NewRole("admin")
+	require.NoError(t, admin.AddPermissionsE("users:delete", `posts:\d+`))
+	editor := NewRole("editor")
+	require.NoError(t, editor.AddPermissionsE("posts:read", "posts:write", "dashboard"))
+	require.NoError(t, rbac.AddRole(admin))
+	require.NoError(t, rbac.AddRole(editor, "admin"))
+	return rbac
+}
+
+func TestClientPolicyExporter_Export(t *testing.T) {
+	e := NewClientPolicyExporter(newClientPolicyRBAC(t))
+
+	policy := e.Export(NewSubject("u1", "editor", "missing"))
+	assert.Equal(t, ClientPolicy{
+		Version: ClientPolicyVersion,
+		Subject: "u1",
+		Roles:   []string{"editor", "missing"},
+		Rules: []ClientRule{
+			{Action: "dashboard"},
+			{Action: "posts:read"},
+			{Action: "posts:write"},
+		},
+	}, policy)
+
+	policy = e.SetSplit(SplitPermission(":")).Export(NewSubject("u2", "admin"))
+	assert.Equal(t, []ClientRule{
+		{Action: "dashboard"},
+		{Action: `posts:\d+`, Pattern: true},
+		{Action: "read", Subject: "posts"},
+		{Action: "write", Subject: "posts"},
+		{Action: "delete", Subject: "users"},
+	}, policy.Rules)
+
+	assert.Equal(t, ClientPolicy{Version: ClientPolicyVersion, Rules: []ClientRule{}}, e.Export(nil))
+}
+
+func TestClientPolicyExporter_ImpliedAndSuperuser(t *testing.T) {
+	rbac := newClientPolicyRBAC(t).AddImplication("posts:write", "comments:*", "drafts:**")
+	require.NoError(t, rbac.AddRole("root"))
+	rbac.SetSuperuserRoles("root")
+	glob := NewRole("glob").SetPermissionMatching(MatchGlob)
+	require.NoError(t, glob.AddPermissionsE("files:*.txt"))
+	literal := NewRole("literal")
+	require.NoError(t, literal.AddLiteralPermissions("queues:exports-*"))
+	require.NoError(t, rbac.AddRole(glob))
+	require.NoError(t, rbac.AddRole(literal))
+
+	e := NewClientPolicyExporter(rbac)
+	assert.Equal(t, []ClientRule{
+		{Action: `comments:[^/]*`, Pattern: true},
+		{Action: "dashboard"},
+		{Action: `drafts:.*`, Pattern: true},
+		{Action: `files:[^/]*\.txt`, Pattern: true},
+		{Action: "posts:read"},
+		{Action: "posts:write"},
+		{Action: "queues:exports-*"},
+	}, e.Export(NewSubject("u1", "editor", "glob", "literal")).Rules)
+
+	assert.Equal(t, []ClientRule{{Action: ".*", Pattern: true}}, e.Export(NewSubject("u2", "editor", "root")).Rules)
+}
+
+func TestClientPolicyExporter_ServeHTTP(t *testing.T) {
+	e := NewClientPolicyExporter(newClientPolicyRBAC(t))
+
+	r := httptest.NewRequest(http.MethodGet, "/policy", nil)
+	r = r.WithContext(WithClaims(r.Context(), &Claims{Subject: NewSubject("u1", "editor")}))
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"version":1,"subject":"u1","roles":["editor"],"rules":[{"action":"dashboard"},{"action":"posts:read"},{"action":"posts:write"}]}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/policy", nil))
+	var policy ClientPolicy
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &policy))
+	assert.Empty(t, policy.Rules)
+}
@@ -0,0 +1,41 @@
+// Command rbac-constants generates typed role and permission constants from a
+// JSON or YAML policy file:
+//
+//	//go:generate go run github.com/gowool/rbac/cmd/rbac-constants -config rbac.yaml -pkg authz -out constants_gen.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gowool/rbac"
+)
+
+func main() {
+	config := flag.String("config", "rbac.yaml", "path to the policy file")
+	pkg := flag.String("pkg", "main", "package name of the generated file")
+	out := flag.String("out", "constants_gen.go", "output file")
+	flag.Parse()
+
+	data, err := os.ReadFile(*config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var cfg rbac.Config
+	if err = yaml.Unmarshal(data, &cfg); err != nil {
+		log.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err = rbac.GeneratePolicyConstants(&buf, *pkg, cfg); err != nil {
+		log.Fatal(err)
+	}
+	if err = os.WriteFile(*out, buf.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
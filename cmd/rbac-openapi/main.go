@@ -0,0 +1,40 @@
+// Command rbac-openapi generates action constants and an rbac.ActionMap from
+// an OpenAPI document:
+//
+//	//go:generate go run github.com/gowool/rbac/cmd/rbac-openapi -spec openapi.yaml -pkg api -out actions_gen.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/gowool/rbac"
+)
+
+func main() {
+	spec := flag.String("spec", "openapi.yaml", "path to the OpenAPI document")
+	pkg := flag.String("pkg", "main", "package name of the generated file")
+	out := flag.String("out", "actions_gen.go", "output file")
+	flag.Parse()
+
+	f, err := os.Open(*spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	m, err := rbac.ParseOpenAPI(f)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err = rbac.GenerateActions(&buf, *pkg, m); err != nil {
+		log.Fatal(err)
+	}
+	if err = os.WriteFile(*out, buf.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,188 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	_ Authorizer = (*ComparisonAuthorizer)(nil)
+
+	ErrShadowFailed = errors.New("shadow evaluation failed")
+)
+
+type Divergence struct {
+	Subject   string
+	Roles     []string
+	Action    string
+	Current   Decision
+	Candidate Decision
+	// Err reports a shadow evaluation that panicked or timed out, of the
+	// candidate or, for RolloutAuthorizer, of the policy not served. Its
+	// decision is then DecisionDeny.
+	Err error
+}
+
+// ChangesAccess reports whether the candidate allows what the current policy
+// denies or the other way round.
+func (d Divergence) ChangesAccess() bool {
+	return d.Current.Allowed() != d.Candidate.Allowed()
+}
+
+// Direction reports "loosened" when the candidate allows what the current
+// policy denies, and "tightened" the other way round. Divergences that do not
+// change access are "failed" when Err is set, "flagged" when one side warns,
+// e.g. allow and warn, and "abstained" when one side abstains, e.g. deny and
+// abstain.
+func (d Divergence) Direction() string {
+	switch {
+	case d.ChangesAccess() && d.Candidate.Allowed():
+		return "loosened"
+	case d.ChangesAccess():
+		return "tightened"
+	case d.Err != nil:
+		return "failed"
+	case d.Current == DecisionWarn || d.Candidate == DecisionWarn:
+		return "flagged"
+	default:
+		return "abstained"
+	}
+}
+
+// ComparisonAuthorizer serves decisions of the current authorizer while
+// evaluating the candidate on the same input and recording divergences. The
+// candidate runs without the per-request state of ctx, e.g. the role
+// transaction, decision memo and audit, so it cannot change what the current
+// authorizer serves or records.
+type ComparisonAuthorizer struct {
+	current     Authorizer
+	candidate   Authorizer
+	timeout     time.Duration
+	onDivergent func(ctx context.Context, d Divergence)
+	evaluations atomic.Int64
+	divergences atomic.Int64
+}
+
+func CompareAuthorizer(current, candidate Authorizer) *ComparisonAuthorizer {
+	return &ComparisonAuthorizer{current: current, candidate: candidate}
+}
+
+// SetTimeout bounds the candidate, which then runs in its own goroutine;
+// zero waits for it. A candidate timing out diverges with Divergence.Err set.
+func (a *ComparisonAuthorizer) SetTimeout(timeout time.Duration) *ComparisonAuthorizer {
+	a.timeout = timeout
+	return a
+}
+
+// OnDivergence calls fn whenever the decisions differ or the candidate
+// fails, including warn and abstain changes that do not change access.
+func (a *ComparisonAuthorizer) OnDivergence(fn func(ctx context.Context, d Divergence)) *ComparisonAuthorizer {
+	a.onDivergent = fn
+	return a
+}
+
+func (a *ComparisonAuthorizer) Evaluations() int64 {
+	return a.evaluations.Load()
+}
+
+// Divergences counts decisions where the candidate changes access, see
+// Divergence.ChangesAccess.
+func (a *ComparisonAuthorizer) Divergences() int64 {
+	return a.divergences.Load()
+}
+
+func (a *ComparisonAuthorizer) Authorize(ctx context.Context, claims *Claims, target *Target) Decision {
+	current := a.current.Authorize(ctx, claims, target)
+	candidate, err := shadowAuthorize(ctx, a.candidate, a.timeout, claims, target)
+
+	a.evaluations.Add(1)
+	if current == candidate && err == nil {
+		return current
+	}
+
+	d := newDivergence(claims, target, current, candidate, err)
+	if d.ChangesAccess() {
+		a.divergences.Add(1)
+	}
+	if a.onDivergent != nil {
+		a.onDivergent(ctx, d)
+	}
+
+	return current
+}
+
+func newDivergence(claims *Claims, target *Target, current, candidate Decision, err error) Divergence {
+	d := Divergence{Current: current, Candidate: candidate, Err: err}
+	if target != nil {
+		d.Action = target.Action
+	}
+	if claims != nil && claims.Subject != nil {
+		d.Subject = SubjectID(claims.Subject)
+		d.Roles = claims.Subject.Roles()
+	}
+	return d
+}
+
+// shadowContext hides the per-request state updated by the served
+// authorizer from a shadow evaluation.
+type shadowContext struct {
+	context.Context
+}
+
+func (c shadowContext) Value(key any) any {
+	switch key.(type) {
+	case roleTransactionKey, decisionMemoKey, requestAuditKey, explanationKey, spanKey:
+		return nil
+	}
+	return c.Context.Value(key)
+}
+
+// shadowAuthorize evaluates the authorizer detached from the cancellation and
+// per-request state of ctx. Panics and timeouts deny with an error.
+func shadowAuthorize(ctx context.Context, authorizer Authorizer, timeout time.Duration, claims *Claims, target *Target) (Decision, error) {
+	ctx = shadowContext{context.WithoutCancel(ctx)}
+	if timeout <= 0 {
+		return recoverAuthorize(ctx, authorizer, claims, target)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// the evaluation may outlive the call, so it gets its own copy of a
+	// possibly pooled target
+	if target != nil {
+		t := *target
+		target = &t
+	}
+
+	type result struct {
+		decision Decision
+		err      error
+	}
+	results := make(chan result, 1)
+	go func() {
+		d, err := recoverAuthorize(ctx, authorizer, claims, target)
+		results <- result{d, err}
+	}()
+
+	select {
+	case r := <-results:
+		if ctx.Err() == nil {
+			return r.decision, r.err
+		}
+	case <-ctx.Done():
+	}
+	return DecisionDeny, fmt.Errorf("%w: %w", ErrShadowFailed, ctx.Err())
+}
+
+func recoverAuthorize(ctx context.Context, authorizer Authorizer, claims *Claims, target *Target) (d Decision, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			d, err = DecisionDeny, fmt.Errorf("%w: panic: %v", ErrShadowFailed, rec)
+		}
+	}()
+	return authorizer.Authorize(ctx, claims, target), nil
+}
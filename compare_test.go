@@ -0,0 +1,109 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareAuthorizer(t *testing.T) {
+	current := &mockAuthorizer{decision: DecisionDeny}
+	candidate := &mockAuthorizer{decision: DecisionAllow}
+
+	var divergences []Divergence
+	a := CompareAuthorizer(current, candidate).OnDivergence(func(_ context.Context, d Divergence) {
+		divergences = append(divergences, d)
+	})
+
+	claims := &Claims{Subject: &testIdentifiedSubject{id: "u1", roles: []string{"user"}}}
+
+	assert.Equal(t, DecisionDeny, a.Authorize(context.Background(), claims, &Target{Action: "posts:write"}))
+	assert.Equal(t, []Divergence{{
+		Subject:   "u1",
+		Roles:     []string{"user"},
+		Action:    "posts:write",
+		Current:   DecisionDeny,
+		Candidate: DecisionAllow,
+	}}, divergences)
+	assert.Equal(t, "loosened", divergences[0].Direction())
+
+	current.decision = DecisionAllow
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), claims, &Target{Action: "posts:read"}))
+	assert.Len(t, divergences, 1)
+
+	candidate.decision = DecisionDeny
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), nil, nil))
+	assert.Equal(t, "tightened", divergences[1].Direction())
+
+	assert.Equal(t, int64(3), a.Evaluations())
+	assert.Equal(t, int64(2), a.Divergences())
+
+	candidate.decision = DecisionWarn
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), claims, &Target{Action: "posts:read"}))
+	assert.Equal(t, "flagged", divergences[2].Direction())
+
+	current.decision, candidate.decision = DecisionDeny, DecisionAbstain
+	assert.Equal(t, DecisionDeny, a.Authorize(context.Background(), claims, &Target{Action: "posts:read"}))
+	assert.Equal(t, "abstained", divergences[3].Direction())
+
+	current.decision = DecisionWarn
+	assert.Equal(t, DecisionWarn, a.Authorize(context.Background(), claims, &Target{Action: "posts:read"}))
+	assert.Equal(t, "tightened", divergences[4].Direction())
+
+	assert.Equal(t, int64(6), a.Evaluations())
+	assert.Equal(t, int64(3), a.Divergences())
+}
+
+type ctxAuthorizer struct {
+	ctx context.Context
+}
+
+func (a *ctxAuthorizer) Authorize(ctx context.Context, _ *Claims, _ *Target) Decision {
+	a.ctx = ctx
+	return DecisionAllow
+}
+
+func TestCompareAuthorizer_Shadow(t *testing.T) {
+	var divergences []Divergence
+	onDivergence := func(_ context.Context, d Divergence) {
+		divergences = append(divergences, d)
+	}
+
+	ctx, cancel := context.WithCancel(WithDecisionMemo(WithRoleTransaction(context.Background())))
+	ctx = WithRequestInfo(ctx, RequestInfo{Method: "GET"})
+	cancel()
+
+	candidate := &ctxAuthorizer{}
+	a := CompareAuthorizer(&mockAuthorizer{decision: DecisionAllow}, candidate).OnDivergence(onDivergence)
+	assert.Equal(t, DecisionAllow, a.Authorize(ctx, nil, &Target{Action: "read"}))
+	assert.Empty(t, divergences)
+	assert.NoError(t, candidate.ctx.Err())
+	assert.Nil(t, CtxRoleTransaction(candidate.ctx))
+	assert.Nil(t, CtxDecisionMemo(candidate.ctx))
+	assert.Equal(t, "GET", CtxRequestInfo(candidate.ctx).Method)
+
+	a = CompareAuthorizer(&mockAuthorizer{decision: DecisionDeny}, panicAuthorizer{}).OnDivergence(onDivergence)
+	assert.Equal(t, DecisionDeny, a.Authorize(context.Background(), nil, &Target{Action: "read"}))
+	require.Len(t, divergences, 1)
+	assert.ErrorIs(t, divergences[0].Err, ErrShadowFailed)
+	assert.Equal(t, DecisionDeny, divergences[0].Candidate)
+	assert.Equal(t, "failed", divergences[0].Direction())
+	assert.Equal(t, int64(0), a.Divergences())
+
+	slow := slowAuthorizer{delay: time.Second, decision: DecisionAllow}
+	a = CompareAuthorizer(&mockAuthorizer{decision: DecisionAllow}, slow).SetTimeout(10 * time.Millisecond).OnDivergence(onDivergence)
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), nil, &Target{Action: "read"}))
+	require.Len(t, divergences, 2)
+	assert.ErrorIs(t, divergences[1].Err, context.DeadlineExceeded)
+	assert.Equal(t, "tightened", divergences[1].Direction())
+	assert.Equal(t, int64(1), a.Divergences())
+
+	a.SetTimeout(time.Second)
+	a.candidate = panicAuthorizer{}
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), nil, &Target{Action: "read"}))
+	require.Len(t, divergences, 3)
+	assert.ErrorIs(t, divergences[2].Err, ErrShadowFailed)
+}
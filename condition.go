@@ -0,0 +1,289 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	_ Assertion = (*ConditionAssertion)(nil)
+
+	ErrInvalidCondition = errors.New("invalid condition")
+)
+
+// ConditionConfig is a declarative attribute condition. Attribute is one of
+// "claims.<key>" and "target.<key>", dot separated paths into the metadata
+// of the claims and the target, "subject.id", "subject.roles" or
+// "request.<field>", a field of CtxRequestInfo: method, host, path, pattern,
+// remoteAddr, header.<name> or pathValue.<name>. Exactly one operator must
+// be set; it passes if any value of the attribute satisfies it. Time needs no
+// attribute.
+type ConditionConfig struct {
+	Attribute string   `env:"ATTRIBUTE" json:"attribute,omitempty" yaml:"attribute,omitempty"`
+	Equals    string   `env:"EQUALS" json:"equals,omitempty" yaml:"equals,omitempty"`
+	In        []string `env:"IN" json:"in,omitempty" yaml:"in,omitempty"`
+	// Regex must match the whole value.
+	Regex string `env:"REGEX" json:"regex,omitempty" yaml:"regex,omitempty"`
+	// CIDR lists networks, the attribute being an address such as
+	// request.remoteAddr.
+	CIDR []string       `env:"CIDR" json:"cidr,omitempty" yaml:"cidr,omitempty"`
+	Time *TimeCondition `envPrefix:"TIME_" json:"time,omitempty" yaml:"time,omitempty"`
+}
+
+// TimeCondition is a daily window as accepted by NewTimeWindowAssertion.
+type TimeCondition struct {
+	// Location is an IANA time zone, the local one if empty.
+	Location string `env:"LOCATION" json:"location,omitempty" yaml:"location,omitempty"`
+	// Days are weekday names such as "monday" or "mon", every day if empty.
+	Days  []string `env:"DAYS" json:"days,omitempty" yaml:"days,omitempty"`
+	Start string   `env:"START" json:"start,omitempty" yaml:"start,omitempty"`
+	End   string   `env:"END" json:"end,omitempty" yaml:"end,omitempty"`
+}
+
+// ConditionAssertion is a compiled ConditionConfig.
+type ConditionAssertion struct {
+	cfg   ConditionConfig
+	match func(value string) bool
+	time  *TimeWindowAssertion
+}
+
+func NewConditionAssertion(cfg ConditionConfig) (*ConditionAssertion, error) {
+	a := &ConditionAssertion{cfg: cfg}
+	invalid := func(format string, args ...any) (*ConditionAssertion, error) {
+		return nil, fmt.Errorf("%w: "+format, append([]any{ErrInvalidCondition}, args...)...)
+	}
+
+	operators := 0
+	for _, set := range []bool{cfg.Equals != "", cfg.In != nil, cfg.Regex != "", cfg.CIDR != nil, cfg.Time != nil} {
+		if set {
+			operators++
+		}
+	}
+	if operators != 1 {
+		return invalid(`condition on "%s" needs exactly one of equals, in, regex, cidr and time`, cfg.Attribute)
+	}
+	if cfg.Time == nil && !validAttribute(cfg.Attribute) {
+		return invalid(`unknown attribute "%s"`, cfg.Attribute)
+	}
+
+	switch {
+	case cfg.Equals != "":
+		a.match = func(value string) bool { return value == cfg.Equals }
+	case cfg.In != nil:
+		a.match = func(value string) bool { return slices.Contains(cfg.In, value) }
+	case cfg.Regex != "":
+		re, err := regexp.Compile(`^(?:` + cfg.Regex + `)$`)
+		if err != nil {
+			return invalid(`regex "%s" of "%s": %w`, cfg.Regex, cfg.Attribute, err)
+		}
+		a.match = re.MatchString
+	case cfg.CIDR != nil:
+		ip, err := NewIPAssertion(cfg.CIDR...)
+		if err != nil {
+			return invalid(`cidr of "%s": %w`, cfg.Attribute, err)
+		}
+		a.match = func(value string) bool {
+			addr, ok := ip.clientAddr(RequestInfo{RemoteAddr: value})
+			return ok && slices.ContainsFunc(ip.prefixes, func(p netip.Prefix) bool { return p.Contains(addr) })
+		}
+	default:
+		window, err := cfg.Time.window()
+		if err != nil {
+			return invalid("%w", err)
+		}
+		a.time = window
+	}
+	return a, nil
+}
+
+// Config returns the condition the assertion was compiled from.
+func (a *ConditionAssertion) Config() ConditionConfig {
+	return a.cfg
+}
+
+func (a *ConditionAssertion) Name() string {
+	if a.time != nil {
+		return "condition(time)"
+	}
+	return "condition(" + a.cfg.Attribute + ")"
+}
+
+// ComparesSubject reports whether the condition checks "subject.id".
+func (a *ConditionAssertion) ComparesSubject() bool {
+	return a.time == nil && a.cfg.Attribute == "subject.id"
+}
+
+func (a *ConditionAssertion) Assert(ctx context.Context, role *Role, permission string) bool {
+	if a.time != nil {
+		return a.time.Assert(ctx, role, permission)
+	}
+	return slices.ContainsFunc(conditionValues(ctx, a.cfg.Attribute), a.match)
+}
+
+// conditionsKey identifies a list of conditions, e.g. to group entries.
+func conditionsKey(conditions []ConditionConfig) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	key, _ := json.Marshal(conditions)
+	return string(key)
+}
+
+func compileConditions(configs []ConditionConfig) ([]Assertion, error) {
+	var (
+		assertions []Assertion
+		errs       []error
+	)
+	for _, cfg := range configs {
+		assertion, err := NewConditionAssertion(cfg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		assertions = append(assertions, assertion)
+	}
+	return assertions, errors.Join(errs...)
+}
+
+func validAttribute(attribute string) bool {
+	source, key, _ := strings.Cut(attribute, ".")
+	switch source {
+	case "claims", "target":
+		return key != ""
+	case "subject":
+		return key == "id" || key == "roles"
+	case "request":
+		field, name, _ := strings.Cut(key, ".")
+		switch field {
+		case "method", "host", "path", "pattern", "remoteAddr":
+			return name == ""
+		case "header", "pathValue":
+			return name != ""
+		}
+	}
+	return false
+}
+
+// conditionValues resolves the attribute in ctx to its values.
+func conditionValues(ctx context.Context, attribute string) []string {
+	source, key, _ := strings.Cut(attribute, ".")
+	switch source {
+	case "claims":
+		if claims := CtxClaims(ctx); claims != nil {
+			return stringValues(claimPath(claims.Metadata, key))
+		}
+	case "target":
+		if target := CtxTarget(ctx); target != nil {
+			return stringValues(claimPath(target.Metadata, key))
+		}
+	case "subject":
+		claims := CtxClaims(ctx)
+		if claims == nil || claims.Subject == nil {
+			return nil
+		}
+		if key == "roles" {
+			return claims.Subject.Roles()
+		}
+		if id := SubjectID(claims.Subject); id != "" {
+			return []string{id}
+		}
+	case "request":
+		info := CtxRequestInfo(ctx)
+		field, name, _ := strings.Cut(key, ".")
+		switch field {
+		case "method":
+			return stringValues(info.Method)
+		case "host":
+			return stringValues(info.Host)
+		case "path":
+			if info.URL != nil {
+				return stringValues(info.URL.Path)
+			}
+		case "pattern":
+			return stringValues(info.Pattern)
+		case "remoteAddr":
+			return stringValues(info.RemoteAddr)
+		case "header":
+			return info.Header.Values(name)
+		case "pathValue":
+			if value, ok := info.PathValues[name]; ok {
+				return []string{value}
+			}
+		}
+	}
+	return nil
+}
+
+func stringValues(value any) []string {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case string:
+		if value == "" {
+			return nil
+		}
+		return []string{value}
+	case []string:
+		return value
+	case []any:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			values = append(values, fmt.Sprint(v))
+		}
+		return values
+	default:
+		return []string{fmt.Sprint(value)}
+	}
+}
+
+func (c *TimeCondition) window() (*TimeWindowAssertion, error) {
+	location := time.Local
+	if c.Location != "" {
+		var err error
+		if location, err = time.LoadLocation(c.Location); err != nil {
+			return nil, err
+		}
+	}
+
+	var days []time.Weekday
+	for _, name := range c.Days {
+		day := slices.IndexFunc(weekdays, func(d string) bool {
+			return strings.EqualFold(name, d) || strings.EqualFold(name, d[:3])
+		})
+		if day < 0 {
+			return nil, fmt.Errorf(`unknown day "%s"`, name)
+		}
+		days = append(days, time.Weekday(day))
+	}
+
+	start, err := clockOffset(c.Start)
+	if err != nil {
+		return nil, err
+	}
+	end, err := clockOffset(c.End)
+	if err != nil {
+		return nil, err
+	}
+	return NewTimeWindowAssertion(location, days, start, end), nil
+}
+
+var weekdays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// clockOffset parses "15:04" into the offset from midnight, "24:00" included.
+func clockOffset(clock string) (time.Duration, error) {
+	if clock == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf(`invalid time of day "%s", want "15:04"`, clock)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
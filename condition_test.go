@@ -0,0 +1,154 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConditionAssertion(t *testing.T) {
+	claims := NewClaimsBuilder(nil).
+		WithSubject(NewSubject("42", "user", "auditor")).
+		WithMeta("tenant", map[string]any{"id": "acme", "tier": 2}).
+		WithMeta("groups", []any{"eng", "ops"}).
+		Build()
+	ctx := WithClaims(context.Background(), claims)
+	ctx = WithTarget(ctx, &Target{Action: "invoices.view", Metadata: map[string]any{"region": "eu-west"}})
+	ctx = WithRequestInfo(ctx, RequestInfo{
+		Method:     http.MethodGet,
+		Pattern:    "GET /invoices/{id}",
+		RemoteAddr: "10.1.2.3:4000",
+		Header:     http.Header{"X-Env": {"prod"}},
+		URL:        &url.URL{Path: "/invoices/7"},
+		PathValues: map[string]string{"id": "7"},
+	})
+
+	for _, tt := range []struct {
+		cfg  ConditionConfig
+		want bool
+	}{
+		{ConditionConfig{Attribute: "claims.tenant.id", Equals: "acme"}, true},
+		{ConditionConfig{Attribute: "claims.tenant.id", Equals: "globex"}, false},
+		{ConditionConfig{Attribute: "claims.tenant.tier", In: []string{"2", "3"}}, true},
+		{ConditionConfig{Attribute: "claims.groups", Equals: "ops"}, true},
+		{ConditionConfig{Attribute: "claims.missing", Equals: "x"}, false},
+		{ConditionConfig{Attribute: "target.region", Regex: "eu-.*"}, true},
+		{ConditionConfig{Attribute: "target.region", Regex: "eu"}, false},
+		{ConditionConfig{Attribute: "subject.id", Equals: "42"}, true},
+		{ConditionConfig{Attribute: "subject.roles", In: []string{"auditor"}}, true},
+		{ConditionConfig{Attribute: "request.method", In: []string{"GET", "HEAD"}}, true},
+		{ConditionConfig{Attribute: "request.path", Regex: "/invoices/\\d+"}, true},
+		{ConditionConfig{Attribute: "request.pattern", Equals: "GET /invoices/{id}"}, true},
+		{ConditionConfig{Attribute: "request.header.X-Env", Equals: "prod"}, true},
+		{ConditionConfig{Attribute: "request.pathValue.id", Equals: "8"}, false},
+		{ConditionConfig{Attribute: "request.remoteAddr", CIDR: []string{"10.0.0.0/8"}}, true},
+		{ConditionConfig{Attribute: "request.remoteAddr", CIDR: []string{"192.168.0.0/16"}}, false},
+	} {
+		a, err := NewConditionAssertion(tt.cfg)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, a.Assert(ctx, nil, "invoices.view"), tt.cfg)
+		assert.False(t, a.Assert(context.Background(), nil, "invoices.view"), tt.cfg)
+	}
+
+	a, err := NewConditionAssertion(ConditionConfig{Attribute: "target.region", Equals: "eu-west"})
+	require.NoError(t, err)
+	assert.Equal(t, "condition(target.region)", AssertionName(a))
+	assert.Equal(t, ConditionConfig{Attribute: "target.region", Equals: "eu-west"}, a.Config())
+}
+
+func TestConditionAssertion_Time(t *testing.T) {
+	a, err := NewConditionAssertion(ConditionConfig{Time: &TimeCondition{
+		Location: "Europe/Berlin",
+		Days:     []string{"mon", "Tuesday", "wed", "thu", "fri"},
+		Start:    "09:00",
+		End:      "17:00",
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "condition(time)", AssertionName(a))
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	// 2026-10-16 is a Friday
+	assert.True(t, a.time.Contains(time.Date(2026, 10, 16, 12, 0, 0, 0, berlin)))
+	assert.False(t, a.time.Contains(time.Date(2026, 10, 16, 18, 0, 0, 0, berlin)))
+	assert.False(t, a.time.Contains(time.Date(2026, 10, 17, 12, 0, 0, 0, berlin)))
+
+	always, err := NewConditionAssertion(ConditionConfig{Time: &TimeCondition{Start: "00:00", End: "24:00"}})
+	require.NoError(t, err)
+	assert.True(t, always.Assert(context.Background(), nil, "deploy"))
+}
+
+func TestConditionAssertion_Invalid(t *testing.T) {
+	for _, cfg := range []ConditionConfig{
+		{Attribute: "claims.tenant"},
+		{Attribute: "claims.tenant", Equals: "acme", In: []string{"acme"}},
+		{Attribute: "claims", Equals: "acme"},
+		{Attribute: "subject.name", Equals: "acme"},
+		{Attribute: "request.body", Equals: "acme"},
+		{Attribute: "request.header", Equals: "acme"},
+		{Attribute: "target.id", Regex: "("},
+		{Attribute: "request.remoteAddr", CIDR: []string{"10.0.0.0/33"}},
+		{Time: &TimeCondition{Start: "9am", End: "17:00"}},
+		{Time: &TimeCondition{Days: []string{"someday"}, Start: "09:00", End: "17:00"}},
+		{Time: &TimeCondition{Location: "Mars/Olympus", Start: "09:00", End: "17:00"}},
+	} {
+		_, err := NewConditionAssertion(cfg)
+		assert.ErrorIs(t, err, ErrInvalidCondition, cfg)
+	}
+}
+
+const conditionConfig = `
+accessControl:
+  - role: support
+    permissions: [tickets.view]
+    conditions:
+      - attribute: claims.tenant
+        equals: acme
+      - attribute: request.remoteAddr
+        cidr: [10.0.0.0/8]
+  - role: support
+    permissions: [tickets.list]
+`
+
+func TestConfig_Conditions(t *testing.T) {
+	var cfg Config
+	require.NoError(t, yaml.Unmarshal([]byte(conditionConfig), &cfg))
+	cfg.CreateMissingRoles = true
+	require.NoError(t, cfg.Validate())
+
+	rbac, err := NewWithConfig(cfg)
+	require.NoError(t, err)
+	authorizer := NewDefaultAuthorizer(rbac)
+
+	acme := NewClaimsBuilder(nil).WithSubject(NewSubject("1", "support")).WithMeta("tenant", "acme").Build()
+	globex := NewClaimsBuilder(nil).WithSubject(NewSubject("2", "support")).WithMeta("tenant", "globex").Build()
+	office := WithRequestInfo(context.Background(), RequestInfo{RemoteAddr: "10.0.0.1:80"})
+	home := WithRequestInfo(context.Background(), RequestInfo{RemoteAddr: "8.8.8.8:80"})
+	assert.Equal(t, DecisionAllow, authorizer.Authorize(office, acme, &Target{Action: "tickets.view"}))
+	assert.Equal(t, DecisionDeny, authorizer.Authorize(home, acme, &Target{Action: "tickets.view"}))
+	assert.Equal(t, DecisionDeny, authorizer.Authorize(office, globex, &Target{Action: "tickets.view"}))
+	assert.Equal(t, DecisionAllow, authorizer.Authorize(home, globex, &Target{Action: "tickets.list"}))
+
+	exported := rbac.Export()
+	assert.Contains(t, exported.AccessControl, cfg.AccessControl[0])
+	assert.Contains(t, exported.AccessControl, cfg.AccessControl[1])
+
+	merged, err := cfg.Merge(Config{AccessControl: []AccessConfig{
+		{Role: "support", Permissions: []string{"tickets.edit"}, Conditions: cfg.AccessControl[0].Conditions},
+		{Role: "support", Permissions: []string{"tickets.close"}, Conditions: []ConditionConfig{{Attribute: "claims.tenant", Equals: "globex"}}},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tickets.view", "tickets.edit"}, merged.AccessControl[0].Permissions)
+	assert.Len(t, merged.AccessControl, 3)
+
+	cfg.AccessControl[0].Conditions[0].Attribute = "claims"
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidCondition)
+	err = New().Apply(cfg)
+	assert.ErrorIs(t, err, ErrInvalidCondition)
+}
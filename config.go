@@ -1,20 +1,60 @@
 package rbac
 
+import (
+	"errors"
+	"fmt"
+)
+
 type RoleConfig struct {
 	Role     string   `env:"ROLE" json:"role,omitempty" yaml:"role,omitempty"`
 	Parents  []string `env:"PARENTS" json:"parents,omitempty" yaml:"parents,omitempty"`
 	Children []string `env:"CHILDREN" json:"children,omitempty" yaml:"children,omitempty"`
+	Tags     []string `env:"TAGS" json:"tags,omitempty" yaml:"tags,omitempty"`
+	// PermissionMatching overrides Config.PermissionMatching for the role.
+	PermissionMatching PermissionMatching `env:"PERMISSION_MATCHING" json:"permissionMatching,omitempty" yaml:"permissionMatching,omitempty"`
 }
 
 type AccessConfig struct {
 	Role        string   `env:"ROLE" json:"role,omitempty" yaml:"role,omitempty"`
 	Permissions []string `env:"PERMISSIONS" json:"permissions,omitempty" yaml:"permissions,omitempty"`
+	// Matching adds the permissions with the given matching instead of the
+	// one of the role.
+	Matching PermissionMatching `env:"MATCHING" json:"matching,omitempty" yaml:"matching,omitempty"`
+	// Assertions names assertions of the RBAC's AssertionRegistry that must
+	// all pass for the permissions to grant.
+	Assertions []string `env:"ASSERTIONS" json:"assertions,omitempty" yaml:"assertions,omitempty"`
+	// Conditions are attribute conditions that must all pass as well.
+	Conditions []ConditionConfig `envPrefix:"CONDITIONS_" json:"conditions,omitempty" yaml:"conditions,omitempty"`
+	// PermissionSets names sets of Config.PermissionSets whose permissions
+	// are granted like Permissions.
+	PermissionSets []string `env:"PERMISSION_SETS" json:"permissionSets,omitempty" yaml:"permissionSets,omitempty"`
+}
+
+// ExclusiveRolesConfig declares roles no subject may hold together.
+type ExclusiveRolesConfig struct {
+	Name  string   `env:"NAME" json:"name,omitempty" yaml:"name,omitempty"`
+	Roles []string `env:"ROLES" json:"roles,omitempty" yaml:"roles,omitempty"`
 }
 
 type Config struct {
-	CreateMissingRoles bool           `env:"CREATE_MISSING_ROLES" json:"createMissingRoles,omitempty" yaml:"createMissingRoles,omitempty"`
-	RoleHierarchy      []RoleConfig   `envPrefix:"ROLE_CONFIG_" json:"roleHierarchy,omitempty" yaml:"roleHierarchy,omitempty"`
-	AccessControl      []AccessConfig `envPrefix:"ACCESS_CONFIG_" json:"accessControl,omitempty" yaml:"accessControl,omitempty"`
+	CreateMissingRoles bool             `env:"CREATE_MISSING_ROLES" json:"createMissingRoles,omitempty" yaml:"createMissingRoles,omitempty"`
+	RoleHierarchy      []RoleConfig     `envPrefix:"ROLE_CONFIG_" json:"roleHierarchy,omitempty" yaml:"roleHierarchy,omitempty"`
+	AccessControl      []AccessConfig   `envPrefix:"ACCESS_CONFIG_" json:"accessControl,omitempty" yaml:"accessControl,omitempty"`
+	PermissionLimits   PermissionLimits `envPrefix:"PERMISSION_LIMITS_" json:"permissionLimits,omitzero" yaml:"permissionLimits,omitempty"`
+	Permissions        []string         `env:"PERMISSIONS" json:"permissions,omitempty" yaml:"permissions,omitempty"`
+	// PermissionMatching is "regex" (default), "literal" or "glob".
+	PermissionMatching PermissionMatching `env:"PERMISSION_MATCHING" json:"permissionMatching,omitempty" yaml:"permissionMatching,omitempty"`
+	// ExclusiveRoles are static separation of duty constraints.
+	ExclusiveRoles []ExclusiveRolesConfig `envPrefix:"EXCLUSIVE_ROLES_" json:"exclusiveRoles,omitempty" yaml:"exclusiveRoles,omitempty"`
+	// Implications are permissions granting weaker ones.
+	Implications []ImplicationConfig `envPrefix:"IMPLICATIONS_" json:"implications,omitempty" yaml:"implications,omitempty"`
+	// PermissionSets are named bundles of permissions.
+	PermissionSets []PermissionSetConfig `envPrefix:"PERMISSION_SETS_" json:"permissionSets,omitempty" yaml:"permissionSets,omitempty"`
+	// SuperuserRoles are granted every action, see RBAC.SetSuperuserRoles.
+	// When empty, the superusers set in code are kept along with their
+	// SuperuserAssertions, which the configuration can only turn on.
+	SuperuserRoles      []string `env:"SUPERUSER_ROLES" json:"superuserRoles,omitempty" yaml:"superuserRoles,omitempty"`
+	SuperuserAssertions bool     `env:"SUPERUSER_ASSERTIONS" json:"superuserAssertions,omitempty" yaml:"superuserAssertions,omitempty"`
 }
 
 func NewWithConfig(cfg Config) (*RBAC, error) {
@@ -23,48 +63,213 @@ func NewWithConfig(cfg Config) (*RBAC, error) {
 	return rbac, err
 }
 
+// Apply builds the new policy on a copy of the current state and takes it
+// over only if every step succeeds, leaving rbac untouched otherwise. All
+// problems are reported together, and change events are emitted once the
+// new state is in place. Roles obtained from rbac before Apply are replaced
+// by their copies. Use RBACHolder.Apply while rbac serves requests.
+// Permission limits and superuser roles set in code are kept when cfg leaves
+// them empty, like the permission matching.
 func (rbac *RBAC) Apply(cfg Config) error {
+	staged := rbac.clone()
+	var (
+		events     []PolicyEvent
+		undeclared []string
+	)
+	if rbac.notify != nil {
+		staged.OnChange(func(event PolicyEvent) {
+			events = append(events, event)
+		})
+	}
+	onUndeclared := rbac.registry.onUndeclared
+	if onUndeclared != nil {
+		staged.registry.onUndeclared = func(permission string) {
+			undeclared = append(undeclared, permission)
+		}
+	}
+	if err := staged.apply(cfg); err != nil {
+		return err
+	}
+
+	staged.registry.onUndeclared = onUndeclared
+	rbac.adopt(staged)
+	for _, event := range events {
+		rbac.emit(event)
+	}
+	for _, permission := range undeclared {
+		onUndeclared(permission)
+	}
+	return nil
+}
+
+// adopt replaces the policy of rbac with the one of staged, a clone of
+// rbac, keeping the callbacks and usage of rbac.
+func (rbac *RBAC) adopt(staged *RBAC) {
+	for _, role := range rbac.roles {
+		role.notify = nil
+	}
+	rbac.roles = staged.roles
+	rbac.createMissingRoles = staged.createMissingRoles
+	rbac.limits = staged.limits
+	rbac.registry = staged.registry
+	rbac.matching = staged.matching
+	rbac.exclusive = staged.exclusive
+	rbac.implications = staged.implications
+	rbac.permissionSets = staged.permissionSets
+	rbac.superusers, rbac.superuserAssertions = staged.superusers, staged.superuserAssertions
+	rbac.rewire()
+}
+
+func (rbac *RBAC) apply(cfg Config) error {
 	rbac.SetCreateMissingRoles(cfg.CreateMissingRoles)
+	if !cfg.PermissionLimits.isZero() {
+		rbac.SetPermissionLimits(cfg.PermissionLimits)
+	}
+	rbac.DeclarePermissions(cfg.Permissions...)
+	if len(cfg.SuperuserRoles) > 0 {
+		rbac.SetSuperuserRoles(cfg.SuperuserRoles...)
+		rbac.SetSuperuserAssertions(cfg.SuperuserAssertions)
+	} else if cfg.SuperuserAssertions {
+		rbac.SetSuperuserAssertions(true)
+	}
+
+	var errs []error
+
+	for _, implication := range cfg.Implications {
+		if implication.Permission == "" {
+			errs = append(errs, fmt.Errorf("%w: implication without permission", ErrInvalidPermission))
+			continue
+		}
+		rbac.AddImplication(implication.Permission, implication.Implies...)
+	}
+
+	for _, set := range cfg.PermissionSets {
+		rbac.SetPermissionSet(set.Name, set.Permissions...)
+	}
+
+	if err := rbac.applyPermissionMatching(cfg.PermissionMatching); err != nil {
+		errs = append(errs, err)
+	}
 
 	for _, role := range cfg.RoleHierarchy {
 		if err := rbac.AddRole(role.Role); err != nil {
-			return err
+			errs = append(errs, err)
 		}
 	}
 
 	for _, role := range cfg.RoleHierarchy {
 		r, err := rbac.Role(role.Role)
 		if err != nil {
-			return err
+			errs = append(errs, err)
+			continue
+		}
+
+		r.AddTags(role.Tags...)
+
+		switch {
+		case role.PermissionMatching == "":
+		case role.PermissionMatching.valid():
+			r.SetPermissionMatching(role.PermissionMatching)
+		default:
+			errs = append(errs, fmt.Errorf(`%w: unknown permission matching "%s"`, ErrInvalidPermission, role.PermissionMatching))
 		}
 
 		for _, parent := range role.Parents {
-			p, err := rbac.Role(parent)
-			if err != nil {
-				return err
+			p, err := rbac.configRole(parent)
+			if err == nil {
+				err = r.AddParent(p)
 			}
-			if err = r.AddParent(p); err != nil {
-				return err
+			if err != nil {
+				errs = append(errs, err)
 			}
 		}
 
 		for _, child := range role.Children {
-			c, err := rbac.Role(child)
-			if err != nil {
-				return err
+			c, err := rbac.configRole(child)
+			if err == nil {
+				err = r.AddChild(c)
 			}
-			if err = r.AddChild(c); err != nil {
-				return err
+			if err != nil {
+				errs = append(errs, err)
 			}
 		}
 	}
 
 	for _, access := range cfg.AccessControl {
-		r, err := rbac.Role(access.Role)
+		r, err := rbac.configRole(access.Role)
+		if err == nil {
+			err = addConfigPermissions(r, access)
+		}
+		if err == nil && len(access.PermissionSets) > 0 {
+			err = rbac.grantPermissionSets(r, access.PermissionSets, func(permissions ...string) error {
+				return addConfigPermissions(r, AccessConfig{Permissions: permissions, Matching: access.Matching})
+			})
+			for _, name := range access.PermissionSets {
+				access.Permissions = unite(access.Permissions, rbac.permissionSets[name])
+			}
+		}
 		if err != nil {
-			return err
+			errs = append(errs, err)
+			continue
+		}
+		if len(access.Assertions) == 0 && len(access.Conditions) == 0 {
+			continue
+		}
+
+		assertions, err := rbac.resolveAssertions(access.Assertions)
+		if err == nil {
+			var conditions []Assertion
+			conditions, err = compileConditions(access.Conditions)
+			assertions = append(assertions, conditions...)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, permission := range access.Permissions {
+			r.SetPermissionAssertions(permission, assertions...)
 		}
-		r.AddPermissions(access.Permissions...)
 	}
-	return nil
+
+	for _, exclusive := range cfg.ExclusiveRoles {
+		if err := rbac.AddExclusiveRoles(exclusive.Name, exclusive.Roles...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, name := range cfg.SuperuserRoles {
+		if _, err := rbac.configRole(name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// configRole returns a role referenced by the configuration, creating it if
+// CreateMissingRoles is set.
+func (rbac *RBAC) configRole(name string) (*Role, error) {
+	if _, ok := rbac.roles[name]; !ok && rbac.createMissingRoles {
+		if err := rbac.AddRole(name); err != nil {
+			return nil, err
+		}
+	}
+	return rbac.Role(name)
+}
+
+func addConfigPermissions(r *Role, access AccessConfig) error {
+	switch access.Matching {
+	case "":
+		return r.AddPermissionsE(access.Permissions...)
+	case MatchRegex:
+		return r.AddRegexPermissions(access.Permissions...)
+	case MatchLiteral:
+		return r.AddLiteralPermissions(access.Permissions...)
+	case MatchGlob:
+		return r.AddGlobPermissions(access.Permissions...)
+	case MatchPath:
+		return r.AddPathPermissions(access.Permissions...)
+	default:
+		return fmt.Errorf(`%w: unknown permission matching "%s"`, ErrInvalidPermission, access.Matching)
+	}
 }
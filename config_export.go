@@ -0,0 +1,128 @@
+package rbac
+
+import (
+	"cmp"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// PermissionMatchingOf reports how a permission held by the role itself
+// matches actions. Permissions that only match themselves are MatchLiteral.
+func (r *Role) PermissionMatchingOf(permission string) (PermissionMatching, bool) {
+	m, ok := r.permissions[permission]
+	if !ok {
+		return "", false
+	}
+	switch m.(type) {
+	case *regexp.Regexp:
+		return MatchRegex, true
+	case globMatcher:
+		return MatchGlob, true
+	case pathPermission:
+		return MatchPath, true
+	default:
+		return MatchLiteral, true
+	}
+}
+
+// Export describes the current policy as a Config. Applying it to a new RBAC
+// yields the same roles, hierarchy, tags, permissions and matchings.
+// Permissions whose matching differs from the one of their role are
+// exported as separate AccessControl entries, granted permission sets as an
+// entry of their own next to the permissions they expanded to.
+func (rbac *RBAC) Export() Config {
+	cfg := Config{
+		CreateMissingRoles:  rbac.createMissingRoles,
+		PermissionLimits:    rbac.limits,
+		Permissions:         rbac.DeclaredPermissions(),
+		PermissionMatching:  rbac.matching,
+		ExclusiveRoles:      rbac.ExclusiveRoles(),
+		SuperuserRoles:      rbac.SuperuserRoles(),
+		SuperuserAssertions: rbac.superuserAssertions,
+	}
+
+	for _, name := range rbac.PermissionSets() {
+		cfg.PermissionSets = append(cfg.PermissionSets, PermissionSetConfig{Name: name, Permissions: rbac.permissionSets[name]})
+	}
+
+	implications := rbac.Implications()
+	for _, permission := range sortedKeys(implications) {
+		cfg.Implications = append(cfg.Implications, ImplicationConfig{Permission: permission, Implies: implications[permission]})
+	}
+
+	for _, name := range sortedKeys(rbac.roles) {
+		r := rbac.roles[name]
+		cfg.RoleHierarchy = append(cfg.RoleHierarchy, RoleConfig{
+			Role:               name,
+			Children:           sortedKeys(r.children),
+			Tags:               sortedKeys(r.tags),
+			PermissionMatching: r.matching,
+		})
+
+		// entries by matching, then by the permission assertions and conditions
+		type group struct {
+			matching   PermissionMatching
+			assertions string
+			conditions string
+		}
+		groups := map[group]*AccessConfig{}
+		for _, permission := range sortedKeys(r.permissions) {
+			matching, _ := r.PermissionMatchingOf(permission)
+			if r.addsAs(permission, matching) {
+				matching = ""
+			}
+			var (
+				assertions []string
+				conditions []ConditionConfig
+			)
+			for _, assertion := range r.conditions[permission] {
+				if condition, ok := assertion.(*ConditionAssertion); ok {
+					conditions = append(conditions, condition.Config())
+					continue
+				}
+				assertions = append(assertions, AssertionName(assertion))
+			}
+			key := group{matching, strings.Join(assertions, "\x00"), conditionsKey(conditions)}
+			if groups[key] == nil {
+				groups[key] = &AccessConfig{Role: name, Matching: matching, Assertions: assertions, Conditions: conditions}
+			}
+			groups[key].Permissions = append(groups[key].Permissions, permission)
+		}
+		keys := slices.SortedFunc(maps.Keys(groups), func(a, b group) int {
+			return cmp.Or(cmp.Compare(matchingOrder(a.matching), matchingOrder(b.matching)), cmp.Compare(a.assertions, b.assertions), cmp.Compare(a.conditions, b.conditions))
+		})
+		for _, key := range keys {
+			cfg.AccessControl = append(cfg.AccessControl, *groups[key])
+		}
+		if sets := r.PermissionSets(); len(sets) > 0 {
+			cfg.AccessControl = append(cfg.AccessControl, AccessConfig{Role: name, PermissionSets: sets})
+		}
+	}
+
+	return cfg
+}
+
+func matchingOrder(matching PermissionMatching) int {
+	return slices.Index([]PermissionMatching{"", MatchRegex, MatchLiteral, MatchGlob, MatchPath}, matching)
+}
+
+// addsAs reports whether AddPermissions would store the permission with the
+// given matching.
+func (r *Role) addsAs(permission string, matching PermissionMatching) bool {
+	switch r.PermissionMatching() {
+	case MatchLiteral, MatchPath:
+		return matching == r.PermissionMatching()
+	case MatchGlob:
+		if compileGlob(permission) != nil {
+			return matching == MatchGlob
+		}
+		return matching == MatchLiteral
+	default:
+		if re, _ := compilePermission(permission); re != nil {
+			return matching == MatchRegex
+		}
+		return matching == MatchLiteral
+	}
+}
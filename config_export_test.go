@@ -0,0 +1,84 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRole_PermissionMatchingOf(t *testing.T) {
+	r := NewRole("user")
+	require.NoError(t, r.AddRegexPermissions("posts.*"))
+	require.NoError(t, r.AddLiteralPermissions("a.b"))
+	require.NoError(t, r.AddGlobPermissions("files/**"))
+
+	for permission, want := range map[string]PermissionMatching{"posts.*": MatchRegex, "a.b": MatchLiteral, "files/**": MatchGlob} {
+		got, ok := r.PermissionMatchingOf(permission)
+		assert.True(t, ok)
+		assert.Equal(t, want, got, permission)
+	}
+	_, ok := r.PermissionMatchingOf("missing")
+	assert.False(t, ok)
+}
+
+func TestRBAC_Export(t *testing.T) {
+	rbac := New().SetCreateMissingRoles(true).SetPermissionLimits(PermissionLimits{MaxLength: 64})
+	rbac.DeclarePermissions("posts.read", "users.delete")
+
+	admin, editor, viewer := NewRole("admin"), NewRole("editor"), NewRole("viewer")
+	require.NoError(t, rbac.AddRole(viewer))
+	require.NoError(t, rbac.AddRole(editor))
+	require.NoError(t, rbac.AddRole(admin))
+	require.NoError(t, admin.AddChild(editor))
+	require.NoError(t, editor.AddChild(viewer))
+	admin.AddTags("staff")
+
+	require.NoError(t, admin.AddPermissionsE("users\\..*"))
+	require.NoError(t, admin.AddLiteralPermissions("a.b"))
+	require.NoError(t, editor.SetPermissionMatching(MatchGlob).AddPermissionsE("posts/*", "exact"))
+	require.NoError(t, viewer.AddPermissionsE("posts.read", "[invalid"))
+
+	cfg := rbac.Export()
+	assert.Equal(t, []RoleConfig{
+		{Role: "admin", Children: []string{"editor"}, Tags: []string{"staff"}},
+		{Role: "editor", Children: []string{"viewer"}, PermissionMatching: MatchGlob},
+		{Role: "viewer"},
+	}, cfg.RoleHierarchy)
+	assert.Equal(t, []AccessConfig{
+		{Role: "admin", Permissions: []string{"users\\..*"}},
+		{Role: "admin", Permissions: []string{"a.b"}, Matching: MatchLiteral},
+		{Role: "editor", Permissions: []string{"exact", "posts/*"}},
+		{Role: "viewer", Permissions: []string{"[invalid", "posts.read"}},
+	}, cfg.AccessControl)
+
+	data, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	var decoded Config
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	restored, err := NewWithConfig(decoded)
+	require.NoError(t, err)
+	assert.Equal(t, cfg, restored.Export())
+
+	ctx := context.Background()
+	assert.True(t, restored.IsGranted(ctx, "admin", "users.delete"))
+	assert.True(t, restored.IsGranted(ctx, "admin", "a.b"))
+	assert.False(t, restored.IsGranted(ctx, "admin", "aXb"))
+	assert.True(t, restored.IsGranted(ctx, "admin", "posts/new"))
+	assert.False(t, restored.IsGranted(ctx, "editor", "posts/a/b"))
+	assert.True(t, restored.IsGranted(ctx, "viewer", "[invalid"))
+}
+
+func TestConfig_InvalidMatching(t *testing.T) {
+	_, err := NewWithConfig(Config{RoleHierarchy: []RoleConfig{{Role: "a", PermissionMatching: "fuzzy"}}})
+	assert.ErrorIs(t, err, ErrInvalidPermission)
+
+	_, err = NewWithConfig(Config{
+		RoleHierarchy: []RoleConfig{{Role: "a"}},
+		AccessControl: []AccessConfig{{Role: "a", Permissions: []string{"x"}, Matching: "fuzzy"}},
+	})
+	assert.ErrorIs(t, err, ErrInvalidPermission)
+}
@@ -0,0 +1,183 @@
+package rbac
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrConfigFormat = errors.New("unsupported config format")
+
+// LoadConfig reads a Config from a .yaml, .yml or .json file. Unknown fields
+// are rejected.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	return parseConfig(path, data)
+}
+
+func parseConfig(path string, data []byte) (cfg Config, err error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err = dec.Decode(&cfg); errors.Is(err, io.EOF) {
+			err = nil
+		}
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&cfg)
+	default:
+		return cfg, fmt.Errorf(`%w: "%s"`, ErrConfigFormat, ext)
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ConfigOption configures how policies are built from a Config.
+type ConfigOption func(*configOptions)
+
+type configOptions struct {
+	base *RBAC
+}
+
+// WithBaseRBAC applies the config to a copy of base instead of a new RBAC,
+// keeping what is set in code, e.g. the assertion registry, permission
+// limits, superusers, implications and the permission registry. base is
+// copied right away, later changes to it are not picked up.
+func WithBaseRBAC(base *RBAC) ConfigOption {
+	return func(o *configOptions) {
+		if base != nil {
+			o.base = base.clone()
+		}
+	}
+}
+
+func newConfigOptions(opts []ConfigOption) configOptions {
+	var o configOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
+// build returns a policy from cfg, leaving the base untouched.
+func (o configOptions) build(cfg Config) (*RBAC, error) {
+	if o.base == nil {
+		return NewWithConfig(cfg)
+	}
+	rbac := o.base.clone()
+	err := rbac.Apply(cfg)
+	return rbac, err
+}
+
+// ConfigWatcher serves an RBAC built from a config file and rebuilds it when
+// the file changes. A rebuilt RBAC replaces the current one atomically, a
+// file that fails to load or apply keeps the current one in place.
+type ConfigWatcher struct {
+	path     string
+	poll     time.Duration
+	holder   *RBACHolder
+	options  configOptions
+	data     []byte
+	onReload func(*RBAC)
+	onError  func(error)
+}
+
+// NewConfigWatcher loads the file and fails if it is invalid. Without
+// WithBaseRBAC every load starts from a new RBAC, so configs naming
+// assertions fail to load.
+func NewConfigWatcher(path string, opts ...ConfigOption) (*ConfigWatcher, error) {
+	w := &ConfigWatcher{path: path, poll: time.Second, holder: NewRBACHolder(nil), options: newConfigOptions(opts)}
+	if err := w.reload(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *ConfigWatcher) SetPollInterval(poll time.Duration) *ConfigWatcher {
+	w.poll = poll
+	return w
+}
+
+// OnReload registers a function called with every rebuilt RBAC.
+func (w *ConfigWatcher) OnReload(fn func(*RBAC)) *ConfigWatcher {
+	w.onReload = fn
+	return w
+}
+
+// OnError registers a function called when a changed file fails to reload.
+func (w *ConfigWatcher) OnError(fn func(error)) *ConfigWatcher {
+	w.onError = fn
+	return w
+}
+
+// RBAC returns the current policy. It must be treated as read-only.
+func (w *ConfigWatcher) RBAC() *RBAC {
+	return w.holder.Load()
+}
+
+// Holder returns the holder of the current policy, e.g. for NewHeldAuthorizer.
+func (w *ConfigWatcher) Holder() *RBACHolder {
+	return w.holder
+}
+
+// Run polls the file for changes until ctx is done.
+func (w *ConfigWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.poll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.reload(); err != nil && w.onError != nil {
+				w.onError(err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *ConfigWatcher) reload() error {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return err
+	}
+	if w.data != nil && bytes.Equal(data, w.data) {
+		return nil
+	}
+	// remembered before applying, so a broken file is reported once
+	w.data = data
+
+	cfg, err := parseConfig(w.path, data)
+	if err != nil {
+		return err
+	}
+	rbac, err := w.options.build(cfg)
+	if err != nil {
+		return fmt.Errorf("%s: %w", w.path, err)
+	}
+
+	w.holder.Swap(rbac)
+	if w.onReload != nil {
+		w.onReload(rbac)
+	}
+	return nil
+}
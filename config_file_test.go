@@ -0,0 +1,125 @@
+package rbac
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, "rbac.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(`
+roleHierarchy:
+  - role: admin
+    children: [user]
+  - role: user
+accessControl:
+  - role: user
+    permissions: [posts.read]
+`), 0o600))
+	cfg, err := LoadConfig(yamlPath)
+	require.NoError(t, err)
+	assert.Len(t, cfg.RoleHierarchy, 2)
+	assert.Equal(t, []string{"posts.read"}, cfg.AccessControl[0].Permissions)
+
+	jsonPath := filepath.Join(dir, "rbac.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"roleHierarchy":[{"role":"admin"}]}`), 0o600))
+	cfg, err = LoadConfig(jsonPath)
+	require.NoError(t, err)
+	assert.Equal(t, "admin", cfg.RoleHierarchy[0].Role)
+
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"roles":[]}`), 0o600))
+	_, err = LoadConfig(jsonPath)
+	assert.Error(t, err)
+
+	emptyPath := filepath.Join(dir, "empty.yml")
+	require.NoError(t, os.WriteFile(emptyPath, nil, 0o600))
+	_, err = LoadConfig(emptyPath)
+	assert.NoError(t, err)
+
+	_, err = LoadConfig(filepath.Join(dir, "rbac.toml"))
+	assert.Error(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "rbac.toml"), nil, 0o600))
+	_, err = LoadConfig(filepath.Join(dir, "rbac.toml"))
+	assert.ErrorIs(t, err, ErrConfigFormat)
+}
+
+func TestConfigWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rbac.json")
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	write(`{"accessControl":[{"role":"user","permissions":["posts.read"]}],"createMissingRoles":true,"roleHierarchy":[{"role":"user"}]}`)
+
+	w, err := NewConfigWatcher(path)
+	require.NoError(t, err)
+	first := w.RBAC()
+	assert.True(t, first.IsGranted(context.Background(), "user", "posts.read"))
+
+	reloaded := make(chan *RBAC, 1)
+	errs := make(chan error, 1)
+	w.SetPollInterval(time.Millisecond).OnReload(func(r *RBAC) { reloaded <- r }).OnError(func(err error) { errs <- err })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	write(`{"roleHierarchy":[{"role":"user"}],"accessControl":[{"role":"user","permissions":["posts.write"]}]}`)
+	select {
+	case r := <-reloaded:
+		assert.Same(t, r, w.RBAC())
+		assert.True(t, r.IsGranted(context.Background(), "user", "posts.write"))
+		assert.True(t, first.IsGranted(context.Background(), "user", "posts.read"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("config not reloaded")
+	}
+
+	current := w.RBAC()
+	write(`{"accessControl":[{"role":"missing","permissions":["x"]}]}`)
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, ErrRoleNotFound)
+		assert.Same(t, current, w.RBAC())
+	case <-time.After(5 * time.Second):
+		t.Fatal("reload error not reported")
+	}
+
+	_, err = NewConfigWatcher(path)
+	assert.ErrorIs(t, err, ErrRoleNotFound)
+}
+
+func TestConfigWatcher_Base(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rbac.json")
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	write(`{"roleHierarchy":[{"role":"user"}],"accessControl":[{"role":"user","permissions":["posts.edit"],"assertions":["owner"]}]}`)
+
+	_, err := NewConfigWatcher(path)
+	require.Error(t, err)
+
+	base := New().SetAssertionRegistry(NewAssertionRegistry()).SetPermissionLimits(PermissionLimits{MaxLength: 32})
+	require.NoError(t, base.AddRole("root"))
+	base.SetSuperuserRoles("root")
+	w, err := NewConfigWatcher(path, WithBaseRBAC(base))
+	require.NoError(t, err)
+
+	write(`{"roleHierarchy":[{"role":"user"}],"accessControl":[{"role":"user","permissions":["posts.delete"],"assertions":["owner"]}]}`)
+	require.NoError(t, w.reload())
+	rbac := w.RBAC()
+	ctx := WithTarget(WithClaims(context.Background(), &Claims{Subject: NewSubject("u1", "user")}), &Target{Metadata: map[string]any{MetadataOwner: "u1"}})
+	assert.True(t, rbac.IsGranted(ctx, "user", "posts.delete"))
+	assert.False(t, rbac.IsGranted(ctx, "user", "posts.edit"))
+	assert.True(t, rbac.IsSuperuser("root"))
+	assert.Equal(t, PermissionLimits{MaxLength: 32}, rbac.PermissionLimits())
+	ok, err := base.HasRole("user")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
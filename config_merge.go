@@ -0,0 +1,180 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var ErrConfigConflict = errors.New("config conflict")
+
+// Merge layers other on top of cfg. Roles and permission sets are merged by
+// name, access entries by role, matching, assertions and conditions and
+// implications by permission, their lists are united in order of first
+// appearance. Exclusive
+// roles are concatenated dropping duplicates. Scalars set in both
+// configurations with different values are conflicts: other's value wins and
+// the conflict is reported, wrapping ErrConfigConflict, alongside the merged
+// configuration.
+func (cfg Config) Merge(other Config) (Config, error) {
+	var errs []error
+	conflict := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]any{ErrConfigConflict}, args...)...))
+	}
+
+	merged := Config{
+		CreateMissingRoles:  cfg.CreateMissingRoles || other.CreateMissingRoles,
+		SuperuserRoles:      unite(cfg.SuperuserRoles, other.SuperuserRoles),
+		SuperuserAssertions: cfg.SuperuserAssertions || other.SuperuserAssertions,
+		PermissionLimits:    cfg.PermissionLimits,
+		Permissions:         unite(cfg.Permissions, other.Permissions),
+		PermissionMatching:  cfg.PermissionMatching,
+	}
+
+	if other.PermissionMatching != "" {
+		if merged.PermissionMatching != "" && merged.PermissionMatching != other.PermissionMatching {
+			conflict(`permissionMatching "%s" overridden by "%s"`, merged.PermissionMatching, other.PermissionMatching)
+		}
+		merged.PermissionMatching = other.PermissionMatching
+	}
+
+	limits := &merged.PermissionLimits
+	for _, limit := range []struct {
+		name        string
+		base, layer int
+		target      *int
+	}{
+		{"maxLength", cfg.PermissionLimits.MaxLength, other.PermissionLimits.MaxLength, &limits.MaxLength},
+		{"maxRepeat", cfg.PermissionLimits.MaxRepeat, other.PermissionLimits.MaxRepeat, &limits.MaxRepeat},
+		{"maxPerRole", cfg.PermissionLimits.MaxPerRole, other.PermissionLimits.MaxPerRole, &limits.MaxPerRole},
+	} {
+		if limit.layer == 0 {
+			continue
+		}
+		if limit.base != 0 && limit.base != limit.layer {
+			conflict("permissionLimits.%s %d overridden by %d", limit.name, limit.base, limit.layer)
+		}
+		*limit.target = limit.layer
+	}
+	limits.BannedConstructs = unite(cfg.PermissionLimits.BannedConstructs, other.PermissionLimits.BannedConstructs)
+
+	roles := map[string]int{}
+	for _, role := range slices.Concat(cfg.RoleHierarchy, other.RoleHierarchy) {
+		i, ok := roles[role.Role]
+		if !ok {
+			roles[role.Role] = len(merged.RoleHierarchy)
+			role.Parents, role.Children, role.Tags = unite(role.Parents), unite(role.Children), unite(role.Tags)
+			merged.RoleHierarchy = append(merged.RoleHierarchy, role)
+			continue
+		}
+		r := &merged.RoleHierarchy[i]
+		r.Parents = unite(r.Parents, role.Parents)
+		r.Children = unite(r.Children, role.Children)
+		r.Tags = unite(r.Tags, role.Tags)
+		if role.PermissionMatching != "" {
+			if r.PermissionMatching != "" && r.PermissionMatching != role.PermissionMatching {
+				conflict(`permissionMatching "%s" of role "%s" overridden by "%s"`, r.PermissionMatching, role.Role, role.PermissionMatching)
+			}
+			r.PermissionMatching = role.PermissionMatching
+		}
+	}
+
+	type accessKey struct {
+		role       string
+		matching   PermissionMatching
+		assertions string
+		conditions string
+	}
+	access := map[accessKey]int{}
+	for _, entry := range slices.Concat(cfg.AccessControl, other.AccessControl) {
+		key := accessKey{entry.Role, entry.Matching, strings.Join(entry.Assertions, "\x00"), conditionsKey(entry.Conditions)}
+		if i, ok := access[key]; ok {
+			merged.AccessControl[i].Permissions = unite(merged.AccessControl[i].Permissions, entry.Permissions)
+			merged.AccessControl[i].PermissionSets = unite(merged.AccessControl[i].PermissionSets, entry.PermissionSets)
+			continue
+		}
+		access[key] = len(merged.AccessControl)
+		entry.Permissions, entry.PermissionSets = unite(entry.Permissions), unite(entry.PermissionSets)
+		merged.AccessControl = append(merged.AccessControl, entry)
+	}
+
+	for _, exclusive := range slices.Concat(cfg.ExclusiveRoles, other.ExclusiveRoles) {
+		if !slices.ContainsFunc(merged.ExclusiveRoles, exclusive.equal) {
+			merged.ExclusiveRoles = append(merged.ExclusiveRoles, exclusive)
+		}
+	}
+
+	implications := map[string]int{}
+	for _, implication := range slices.Concat(cfg.Implications, other.Implications) {
+		if i, ok := implications[implication.Permission]; ok {
+			merged.Implications[i].Implies = unite(merged.Implications[i].Implies, implication.Implies)
+			continue
+		}
+		implications[implication.Permission] = len(merged.Implications)
+		implication.Implies = unite(implication.Implies)
+		merged.Implications = append(merged.Implications, implication)
+	}
+
+	sets := map[string]int{}
+	for _, set := range slices.Concat(cfg.PermissionSets, other.PermissionSets) {
+		if i, ok := sets[set.Name]; ok {
+			merged.PermissionSets[i].Permissions = unite(merged.PermissionSets[i].Permissions, set.Permissions)
+			continue
+		}
+		sets[set.Name] = len(merged.PermissionSets)
+		set.Permissions = unite(set.Permissions)
+		merged.PermissionSets = append(merged.PermissionSets, set)
+	}
+
+	return merged, errors.Join(errs...)
+}
+
+// LoadConfigs loads and merges files in order, e.g. a base configuration
+// followed by per-environment overrides. Patterns are expanded with
+// filepath.Glob in lexical order; a pattern without wildcards must name an
+// existing file, one with wildcards may match nothing. Conflicts are
+// reported like by Merge, prefixed with the file introducing them.
+func LoadConfigs(patterns ...string) (Config, error) {
+	var (
+		merged Config
+		errs   []error
+	)
+	for _, pattern := range patterns {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %w", pattern, err)
+		}
+		if !strings.ContainsAny(pattern, "*?[") {
+			paths = []string{pattern}
+		}
+
+		for _, path := range paths {
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				return Config{}, err
+			}
+			if merged, err = merged.Merge(cfg); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			}
+		}
+	}
+	return merged, errors.Join(errs...)
+}
+
+// unite concatenates the lists dropping duplicates, keeping the first
+// occurrence.
+func unite(lists ...[]string) []string {
+	var united []string
+	seen := map[string]struct{}{}
+	for _, list := range lists {
+		for _, value := range list {
+			if _, ok := seen[value]; !ok {
+				seen[value] = struct{}{}
+				united = append(united, value)
+			}
+		}
+	}
+	return united
+}
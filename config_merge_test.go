@@ -0,0 +1,99 @@
+package rbac
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Merge(t *testing.T) {
+	base := Config{
+		PermissionLimits: PermissionLimits{MaxLength: 64, BannedConstructs: []string{"(?i)"}},
+		Permissions:      []string{"posts.read"},
+		RoleHierarchy: []RoleConfig{
+			{Role: "admin", Children: []string{"user"}},
+			{Role: "user", Tags: []string{"default"}},
+		},
+		AccessControl: []AccessConfig{
+			{Role: "user", Permissions: []string{"posts.read"}},
+		},
+	}
+	override := Config{
+		CreateMissingRoles: true,
+		PermissionLimits:   PermissionLimits{MaxPerRole: 10},
+		Permissions:        []string{"posts.read", "posts.write"},
+		RoleHierarchy: []RoleConfig{
+			{Role: "user", Tags: []string{"default", "staging"}, PermissionMatching: MatchGlob},
+			{Role: "tester", Parents: []string{"admin"}},
+		},
+		AccessControl: []AccessConfig{
+			{Role: "user", Permissions: []string{"posts.read", "posts.write"}},
+			{Role: "user", Permissions: []string{"a.b"}, Matching: MatchLiteral},
+		},
+	}
+
+	merged, err := base.Merge(override)
+	require.NoError(t, err)
+	assert.Equal(t, Config{
+		CreateMissingRoles: true,
+		PermissionLimits:   PermissionLimits{MaxLength: 64, MaxPerRole: 10, BannedConstructs: []string{"(?i)"}},
+		Permissions:        []string{"posts.read", "posts.write"},
+		RoleHierarchy: []RoleConfig{
+			{Role: "admin", Children: []string{"user"}},
+			{Role: "user", Tags: []string{"default", "staging"}, PermissionMatching: MatchGlob},
+			{Role: "tester", Parents: []string{"admin"}},
+		},
+		AccessControl: []AccessConfig{
+			{Role: "user", Permissions: []string{"posts.read", "posts.write"}},
+			{Role: "user", Permissions: []string{"a.b"}, Matching: MatchLiteral},
+		},
+	}, merged)
+
+	// merging does not alias the inputs
+	merged.AccessControl[0].Permissions[0] = "changed"
+	assert.Equal(t, "posts.read", base.AccessControl[0].Permissions[0])
+}
+
+func TestConfig_Merge_Conflicts(t *testing.T) {
+	merged, err := Config{
+		PermissionMatching: MatchRegex,
+		PermissionLimits:   PermissionLimits{MaxLength: 64},
+		RoleHierarchy:      []RoleConfig{{Role: "user", PermissionMatching: MatchLiteral}},
+	}.Merge(Config{
+		PermissionMatching: MatchGlob,
+		PermissionLimits:   PermissionLimits{MaxLength: 128},
+		RoleHierarchy:      []RoleConfig{{Role: "user", PermissionMatching: MatchGlob}},
+	})
+	assert.ErrorIs(t, err, ErrConfigConflict)
+	assert.ErrorContains(t, err, `permissionMatching "regex" overridden by "glob"`)
+	assert.ErrorContains(t, err, "permissionLimits.maxLength 64 overridden by 128")
+	assert.ErrorContains(t, err, `permissionMatching "literal" of role "user" overridden by "glob"`)
+	assert.Equal(t, MatchGlob, merged.PermissionMatching)
+	assert.Equal(t, 128, merged.PermissionLimits.MaxLength)
+	assert.Equal(t, MatchGlob, merged.RoleHierarchy[0].PermissionMatching)
+}
+
+func TestLoadConfigs(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+	base := write("base.yaml", "roleHierarchy:\n  - role: user\naccessControl:\n  - role: user\n    permissions: [posts.read]\n")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "env"), 0o700))
+	write("env/10-staging.json", `{"accessControl":[{"role":"user","permissions":["posts.write"]}],"permissionMatching":"glob"}`)
+	write("env/20-local.json", `{"permissionMatching":"literal"}`)
+
+	cfg, err := LoadConfigs(base, filepath.Join(dir, "env", "*.json"), filepath.Join(dir, "none", "*.json"))
+	assert.ErrorIs(t, err, ErrConfigConflict)
+	assert.ErrorContains(t, err, "20-local.json")
+	assert.Equal(t, MatchLiteral, cfg.PermissionMatching)
+	assert.Equal(t, []AccessConfig{{Role: "user", Permissions: []string{"posts.read", "posts.write"}}}, cfg.AccessControl)
+
+	_, err = LoadConfigs(filepath.Join(dir, "missing.yaml"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
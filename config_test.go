@@ -1,6 +1,7 @@
 package rbac
 
 import (
+	"context"
 	"slices"
 	"testing"
 
@@ -446,3 +447,117 @@ func (s *configSuit) TestApplyMultipleTimes() {
 	s.NoError(err)
 	s.True(role2.HasPermission("permission2"))
 }
+
+func (s *configSuit) TestApplyWithTags() {
+	cfg := Config{
+		RoleHierarchy: []RoleConfig{
+			{Role: "acme:admin", Tags: []string{"tenant:acme"}},
+			{Role: "global"},
+		},
+	}
+
+	s.NoError(s.rbac.Apply(cfg))
+
+	roles := slices.Collect(s.rbac.RolesByTag("tenant:acme"))
+	s.Len(roles, 1)
+	s.Equal("acme:admin", roles[0].Name())
+}
+
+func (s *configSuit) TestApplyKeepsProgrammaticSettings() {
+	limits := PermissionLimits{MaxLength: 64}
+	s.rbac.SetPermissionLimits(limits).SetSuperuserRoles("root").SetSuperuserAssertions(true)
+
+	s.NoError(s.rbac.Apply(Config{CreateMissingRoles: true, RoleHierarchy: []RoleConfig{{Role: "user"}}}))
+	s.Equal(limits, s.rbac.PermissionLimits())
+	s.Equal([]string{"root"}, s.rbac.SuperuserRoles())
+	s.True(s.rbac.SuperuserAssertions())
+
+	s.NoError(s.rbac.Apply(Config{
+		CreateMissingRoles: true,
+		RoleHierarchy:      []RoleConfig{{Role: "ops"}},
+		PermissionLimits:   PermissionLimits{MaxRepeat: 2},
+		SuperuserRoles:     []string{"ops"},
+	}))
+	s.Equal(PermissionLimits{MaxRepeat: 2}, s.rbac.PermissionLimits())
+	s.Equal([]string{"ops"}, s.rbac.SuperuserRoles())
+	s.False(s.rbac.SuperuserAssertions())
+}
+
+func (s *configSuit) TestApplyAtomic() {
+	s.NoError(s.rbac.Apply(Config{
+		RoleHierarchy: []RoleConfig{{Role: "admin"}},
+		AccessControl: []AccessConfig{{Role: "admin", Permissions: []string{"read"}}},
+	}))
+	admin, err := s.rbac.Role("admin")
+	s.NoError(err)
+
+	err = s.rbac.Apply(Config{
+		RoleHierarchy: []RoleConfig{
+			{Role: "editor", Parents: []string{"missing-parent"}},
+			{Role: "admin", Children: []string{"editor"}},
+		},
+		AccessControl: []AccessConfig{
+			{Role: "editor", Permissions: []string{"write"}},
+			{Role: "missing-role", Permissions: []string{"delete"}},
+		},
+	})
+	s.ErrorIs(err, ErrRoleNotFound)
+	s.ErrorContains(err, "missing-parent")
+	s.ErrorContains(err, "missing-role")
+
+	ok, err := s.rbac.HasRole("editor")
+	s.NoError(err)
+	s.False(ok)
+	s.Empty(slices.Collect(admin.Children()))
+	s.False(admin.HasPermission("write"))
+
+	current, err := s.rbac.Role("admin")
+	s.NoError(err)
+	s.Same(admin, current)
+}
+
+func (s *configSuit) TestApplyAtomicEvents() {
+	var events []PolicyEvent
+	var granted []bool
+	s.rbac.OnChange(func(event PolicyEvent) {
+		events = append(events, event)
+		granted = append(granted, s.rbac.IsGranted(context.Background(), "admin", "read"))
+	})
+
+	err := s.rbac.Apply(Config{
+		RoleHierarchy: []RoleConfig{{Role: "admin"}},
+		AccessControl: []AccessConfig{
+			{Role: "admin", Permissions: []string{"read"}},
+			{Role: "missing-role", Permissions: []string{"delete"}},
+		},
+	})
+	s.ErrorIs(err, ErrRoleNotFound)
+	s.Empty(events)
+
+	s.NoError(s.rbac.Apply(Config{
+		RoleHierarchy: []RoleConfig{{Role: "admin"}},
+		AccessControl: []AccessConfig{{Role: "admin", Permissions: []string{"read"}}},
+	}))
+	s.NotEmpty(events)
+	// events are emitted once the whole policy is in place
+	for _, ok := range granted {
+		s.True(ok)
+	}
+
+	admin, err := s.rbac.Role("admin")
+	s.NoError(err)
+	events = nil
+	s.NoError(admin.AddPermissionsE("write"))
+	s.Len(events, 1)
+}
+
+func (s *configSuit) TestApplyAtomicCircularReference() {
+	s.NoError(s.rbac.Apply(Config{RoleHierarchy: []RoleConfig{{Role: "a", Children: []string{"b"}}, {Role: "b"}}}))
+
+	err := s.rbac.Apply(Config{RoleHierarchy: []RoleConfig{{Role: "b", Children: []string{"a"}}, {Role: "c"}}})
+	s.ErrorIs(err, ErrCircularRef)
+
+	ok, err := s.rbac.HasRole("c")
+	s.NoError(err)
+	s.False(ok)
+}
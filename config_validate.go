@@ -0,0 +1,178 @@
+package rbac
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var ErrEmptyRoleName = errors.New("empty role name")
+
+// Validate checks the configuration without touching any RBAC. It reports
+// empty and duplicate role names, references to undefined roles unless
+// CreateMissingRoles is set, cycles in the declared hierarchy, unknown
+// matchings, roles inheriting exclusive roles, invalid attribute conditions,
+// implications without permission, unnamed and undefined permission sets and
+// permissions violating PermissionLimits. Permissions matched as regular expressions that do not
+// compile are reported too, as they would silently only match themselves.
+func (cfg Config) Validate() error {
+	var errs []error
+
+	if !cfg.PermissionMatching.valid() {
+		errs = append(errs, fmt.Errorf(`%w: unknown permission matching "%s"`, ErrInvalidPermission, cfg.PermissionMatching))
+	}
+
+	defined := map[string]RoleConfig{}
+	for i, role := range cfg.RoleHierarchy {
+		switch _, ok := defined[role.Role]; {
+		case role.Role == "":
+			errs = append(errs, fmt.Errorf("%w: roleHierarchy[%d]", ErrEmptyRoleName, i))
+		case ok:
+			errs = append(errs, fmt.Errorf(`%w: role "%s" is declared more than once`, ErrRoleExists, role.Role))
+		default:
+			defined[role.Role] = role
+		}
+		if !role.PermissionMatching.valid() {
+			errs = append(errs, fmt.Errorf(`%w: unknown permission matching "%s" of role "%s"`, ErrInvalidPermission, role.PermissionMatching, role.Role))
+		}
+	}
+
+	reference := func(name, where string) {
+		if name == "" {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrEmptyRoleName, where))
+			return
+		}
+		if _, ok := defined[name]; !ok && !cfg.CreateMissingRoles {
+			errs = append(errs, fmt.Errorf(`%w: role "%s" referenced by %s is not declared`, ErrRoleNotFound, name, where))
+		}
+	}
+
+	// edges from a role to the children it inherits from
+	edges := map[string][]string{}
+	for _, role := range cfg.RoleHierarchy {
+		for _, parent := range role.Parents {
+			reference(parent, fmt.Sprintf(`the parents of "%s"`, role.Role))
+			edges[parent] = append(edges[parent], role.Role)
+		}
+		for _, child := range role.Children {
+			reference(child, fmt.Sprintf(`the children of "%s"`, role.Role))
+			edges[role.Role] = append(edges[role.Role], child)
+		}
+	}
+	errs = append(errs, configCycles(edges)...)
+
+	for i, role := range cfg.SuperuserRoles {
+		reference(role, fmt.Sprintf("superuserRoles[%d]", i))
+	}
+
+	for i, exclusive := range cfg.ExclusiveRoles {
+		for _, role := range exclusive.Roles {
+			reference(role, fmt.Sprintf("exclusiveRoles[%d]", i))
+		}
+	}
+	if len(cfg.ExclusiveRoles) > 0 {
+		for _, name := range unite(sortedKeys(defined), sortedKeys(edges)) {
+			held := map[string]struct{}{}
+			var walk func(name string)
+			walk = func(name string) {
+				if _, ok := held[name]; ok {
+					return
+				}
+				held[name] = struct{}{}
+				for _, child := range edges[name] {
+					walk(child)
+				}
+			}
+			walk(name)
+			errs = append(errs, exclusiveViolations(cfg.ExclusiveRoles, held, fmt.Sprintf(`role "%s"`, name))...)
+		}
+	}
+
+	sets := map[string]struct{}{}
+	for i, set := range cfg.PermissionSets {
+		if set.Name == "" {
+			errs = append(errs, fmt.Errorf("%w: permissionSets[%d] has no name", ErrInvalidPermission, i))
+		}
+		sets[set.Name] = struct{}{}
+		for _, permission := range set.Permissions {
+			if err := cfg.PermissionLimits.Validate(permission); err != nil {
+				errs = append(errs, fmt.Errorf(`permission set "%s": %w`, set.Name, err))
+			}
+		}
+	}
+
+	for i, access := range cfg.AccessControl {
+		reference(access.Role, fmt.Sprintf("accessControl[%d]", i))
+		for _, set := range access.PermissionSets {
+			if _, ok := sets[set]; !ok {
+				errs = append(errs, fmt.Errorf(`%w: unknown permission set "%s" in accessControl[%d]`, ErrInvalidPermission, set, i))
+			}
+		}
+		if _, err := compileConditions(access.Conditions); err != nil {
+			errs = append(errs, fmt.Errorf("accessControl[%d]: %w", i, err))
+		}
+
+		matching := cmp.Or(access.Matching, defined[access.Role].PermissionMatching, cfg.PermissionMatching, MatchRegex)
+		if !matching.valid() {
+			errs = append(errs, fmt.Errorf(`%w: unknown permission matching "%s" in accessControl[%d]`, ErrInvalidPermission, access.Matching, i))
+			continue
+		}
+		for _, permission := range access.Permissions {
+			if err := cfg.PermissionLimits.Validate(permission); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if matching == MatchRegex {
+				if _, err := regexp.Compile(permission); err != nil {
+					errs = append(errs, fmt.Errorf(`%w: "%s" of role "%s" is not a regular expression: %w`, ErrInvalidPermission, permission, access.Role, err))
+				}
+			}
+		}
+	}
+
+	for i, implication := range cfg.Implications {
+		if implication.Permission == "" {
+			errs = append(errs, fmt.Errorf("%w: implications[%d] has no permission", ErrInvalidPermission, i))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// configCycles reports every cycle of the hierarchy once.
+func configCycles(edges map[string][]string) []error {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	var (
+		errs  []error
+		state = map[string]int{}
+		path  []string
+		visit func(name string)
+	)
+	visit = func(name string) {
+		state[name] = visiting
+		path = append(path, name)
+		for _, child := range edges[name] {
+			switch state[child] {
+			case visiting:
+				cycle := append(slices.Clone(path[slices.Index(path, child):]), child)
+				errs = append(errs, fmt.Errorf("%w: %s", ErrCircularRef, strings.Join(cycle, " -> ")))
+			case 0:
+				visit(child)
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+	}
+	for _, name := range sortedKeys(edges) {
+		if state[name] == 0 {
+			visit(name)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,79 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	valid := Config{
+		RoleHierarchy: []RoleConfig{
+			{Role: "admin", Children: []string{"user"}},
+			{Role: "user", Parents: []string{"admin"}, PermissionMatching: MatchGlob},
+		},
+		AccessControl: []AccessConfig{
+			{Role: "admin", Permissions: []string{"users.*"}},
+			{Role: "user", Permissions: []string{"posts/[*"}},
+		},
+	}
+	require.NoError(t, valid.Validate())
+	_, err := NewWithConfig(valid)
+	require.NoError(t, err)
+
+	err = Config{
+		PermissionMatching: "fuzzy",
+		RoleHierarchy: []RoleConfig{
+			{Role: "a", Children: []string{"b"}},
+			{Role: "b", Children: []string{"c"}},
+			{Role: "c", Children: []string{"a"}, Parents: []string{"ghost"}},
+			{Role: "a"},
+			{Role: ""},
+			{Role: "d", PermissionMatching: "fuzzy"},
+		},
+		AccessControl: []AccessConfig{
+			{Role: "nobody", Permissions: []string{"x"}},
+			{Role: "a", Permissions: []string{"[invalid", ""}, Matching: MatchRegex},
+		},
+	}.Validate()
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrEmptyRoleName)
+	assert.ErrorIs(t, err, ErrRoleExists)
+	assert.ErrorIs(t, err, ErrRoleNotFound)
+	assert.ErrorIs(t, err, ErrCircularRef)
+	assert.ErrorIs(t, err, ErrInvalidPermission)
+	assert.ErrorContains(t, err, "a -> b -> c -> a")
+	assert.ErrorContains(t, err, `role "ghost" referenced by the parents of "c"`)
+	assert.ErrorContains(t, err, `role "nobody" referenced by accessControl[0]`)
+	assert.ErrorContains(t, err, `"[invalid" of role "a" is not a regular expression`)
+	assert.NotContains(t, err.Error(), "accessControl[1]:")
+}
+
+func TestConfig_Validate_CreateMissingRoles(t *testing.T) {
+	cfg := Config{
+		CreateMissingRoles: true,
+		RoleHierarchy:      []RoleConfig{{Role: "admin", Children: []string{"user"}}},
+		AccessControl:      []AccessConfig{{Role: "guest", Permissions: []string{"read"}}},
+	}
+	require.NoError(t, cfg.Validate())
+
+	rbac, err := NewWithConfig(cfg)
+	require.NoError(t, err)
+	for _, name := range []string{"user", "guest"} {
+		ok, err := rbac.HasRole(name)
+		require.NoError(t, err)
+		assert.True(t, ok, name)
+	}
+
+	cfg.CreateMissingRoles = false
+	assert.ErrorIs(t, cfg.Validate(), ErrRoleNotFound)
+	_, err = NewWithConfig(cfg)
+	assert.ErrorIs(t, err, ErrRoleNotFound)
+}
+
+func TestConfig_Validate_SelfCycle(t *testing.T) {
+	err := Config{RoleHierarchy: []RoleConfig{{Role: "a", Children: []string{"a"}}}}.Validate()
+	assert.ErrorIs(t, err, ErrCircularRef)
+	assert.ErrorContains(t, err, "a -> a")
+}
@@ -0,0 +1,77 @@
+package rbac
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"io"
+	"maps"
+	"slices"
+)
+
+var ErrConstantCollision = errors.New("constant name collision")
+
+// GeneratePolicyConstants writes a Go source file declaring a typed constant
+// for every role name and permission in cfg. Names without letters or digits,
+// such as "*", are skipped.
+func GeneratePolicyConstants(w io.Writer, pkg string, cfg Config) error {
+	roles := map[string]struct{}{}
+	permissions := map[string]struct{}{}
+
+	for _, role := range cfg.RoleHierarchy {
+		roles[role.Role] = struct{}{}
+		for _, name := range append(slices.Clone(role.Parents), role.Children...) {
+			roles[name] = struct{}{}
+		}
+	}
+	for _, access := range cfg.AccessControl {
+		roles[access.Role] = struct{}{}
+		for _, permission := range access.Permissions {
+			permissions[permission] = struct{}{}
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by rbac-constants. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	buf.WriteString("type (\nRoleName string\nPermission string\n)\n\n")
+
+	if err := writeConstants(&buf, "Role", "RoleName", roles); err != nil {
+		return err
+	}
+	if err := writeConstants(&buf, "Perm", "Permission", permissions); err != nil {
+		return err
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+func writeConstants(buf *bytes.Buffer, prefix, typ string, values map[string]struct{}) error {
+	names := map[string]string{}
+	for _, value := range slices.Sorted(maps.Keys(values)) {
+		ident := exportedIdent(value)
+		if ident == "" {
+			continue
+		}
+		if other, ok := names[prefix+ident]; ok {
+			return fmt.Errorf(`%w: "%s" and "%s" both map to %s%s`, ErrConstantCollision, other, value, prefix, ident)
+		}
+		names[prefix+ident] = value
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	buf.WriteString("const (\n")
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		fmt.Fprintf(buf, "%s %s = %q\n", name, typ, names[name])
+	}
+	buf.WriteString(")\n\n")
+	return nil
+}
@@ -0,0 +1,47 @@
+package rbac
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratePolicyConstants(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, GeneratePolicyConstants(&buf, "authz", Config{
+		RoleHierarchy: []RoleConfig{{Role: "super_admin", Children: []string{"admin"}}},
+		AccessControl: []AccessConfig{
+			{Role: "admin", Permissions: []string{"post:edit", "post:read", ".*"}},
+		},
+	}))
+
+	assert.Equal(t, `// Code generated by rbac-constants. DO NOT EDIT.
+
+package authz
+
+type (
+	RoleName   string
+	Permission string
+)
+
+const (
+	RoleAdmin      RoleName = "admin"
+	RoleSuperAdmin RoleName = "super_admin"
+)
+
+const (
+	PermPostEdit Permission = "post:edit"
+	PermPostRead Permission = "post:read"
+)
+`, buf.String())
+}
+
+func TestGeneratePolicyConstants_Collision(t *testing.T) {
+	var buf bytes.Buffer
+	err := GeneratePolicyConstants(&buf, "authz", Config{
+		AccessControl: []AccessConfig{{Role: "admin", Permissions: []string{"post:edit", "post.edit"}}},
+	})
+	assert.ErrorIs(t, err, ErrConstantCollision)
+}
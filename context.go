@@ -1,11 +1,18 @@
 package rbac
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type (
-	claimsKey      struct{}
-	assertionsKey  struct{}
-	requestInfoKey struct{}
+	claimsKey       struct{}
+	assertionsKey   struct{}
+	requestInfoKey  struct{}
+	authorizerKey   struct{}
+	roleSessionKey  struct{}
+	targetKey       struct{}
+	decisionTimeKey struct{}
 )
 
 func WithClaims(ctx context.Context, claims *Claims) context.Context {
@@ -17,6 +24,46 @@ func CtxClaims(ctx context.Context) *Claims {
 	return claims
 }
 
+// WithTarget installs the target being authorized, so assertions can read
+// its metadata. DefaultAuthorizer installs it for every decision.
+func WithTarget(ctx context.Context, target *Target) context.Context {
+	return context.WithValue(ctx, targetKey{}, target)
+}
+
+func CtxTarget(ctx context.Context) *Target {
+	target, _ := ctx.Value(targetKey{}).(*Target)
+	return target
+}
+
+// WithDecisionTime sets the time decisions are made at instead of the
+// current one, e.g. to replay recorded decisions. It applies to grant expiry
+// and time windows.
+func WithDecisionTime(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, decisionTimeKey{}, t)
+}
+
+// CtxDecisionTime returns the time set by WithDecisionTime, the zero time if
+// none is.
+func CtxDecisionTime(ctx context.Context) time.Time {
+	t, _ := ctx.Value(decisionTimeKey{}).(time.Time)
+	return t
+}
+
+func decisionTime(ctx context.Context, now func() time.Time) time.Time {
+	if t := CtxDecisionTime(ctx); !t.IsZero() {
+		return t
+	}
+	return now()
+}
+
+func WithImpersonation(ctx context.Context, actor, subject Subject) context.Context {
+	claims := &Claims{Subject: subject, Actor: actor}
+	if current := CtxClaims(ctx); current != nil {
+		claims.Metadata = current.Metadata
+	}
+	return WithClaims(ctx, claims)
+}
+
 func WithAssertions(ctx context.Context, assertions ...Assertion) context.Context {
 	return context.WithValue(ctx, assertionsKey{}, assertions)
 }
@@ -34,3 +81,29 @@ func CtxRequestInfo(ctx context.Context) RequestInfo {
 	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
 	return info
 }
+
+// WithAuthorizer selects the authorizer RequestAuthorizer uses for the
+// request instead of the one it was created with.
+func WithAuthorizer(ctx context.Context, authorizer Authorizer) context.Context {
+	return context.WithValue(ctx, authorizerKey{}, authorizer)
+}
+
+func CtxAuthorizer(ctx context.Context) Authorizer {
+	authorizer, _ := ctx.Value(authorizerKey{}).(Authorizer)
+	return authorizer
+}
+
+// WithRoleSession installs the session and claims with the session as
+// subject, keeping the actor and metadata of the current claims.
+func WithRoleSession(ctx context.Context, session *RoleSession) context.Context {
+	claims := &Claims{Subject: session}
+	if current := CtxClaims(ctx); current != nil {
+		claims.Actor, claims.Metadata = current.Actor, current.Metadata
+	}
+	return WithClaims(context.WithValue(ctx, roleSessionKey{}, session), claims)
+}
+
+func CtxRoleSession(ctx context.Context) *RoleSession {
+	session, _ := ctx.Value(roleSessionKey{}).(*RoleSession)
+	return session
+}
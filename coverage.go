@@ -0,0 +1,76 @@
+package rbac
+
+import (
+	"cmp"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+type RouteCoverage struct {
+	// Reachable maps routes onto the roles that can reach them.
+	Reachable map[string][]string
+	// Unreachable lists routes no role can reach.
+	Unreachable []string
+	// DeadGrants maps roles onto their own permissions that match no route.
+	DeadGrants map[string][]string
+}
+
+// CheckRouteCoverage evaluates every route ("GET /users/{id}", a ServeMux
+// pattern, or a path for GET) through actions, defaultActions when nil, and
+// reports which roles can reach it and which grants are never exercised.
+func CheckRouteCoverage(rbac *RBAC, routes []string, actions func(*http.Request) []string) RouteCoverage {
+	if actions == nil {
+		actions = defaultActions
+	}
+
+	coverage := RouteCoverage{
+		Reachable:  map[string][]string{},
+		DeadGrants: map[string][]string{},
+	}
+
+	roles := slices.SortedFunc(rbac.Roles(), func(a, b *Role) int {
+		return cmp.Compare(a.Name(), b.Name())
+	})
+
+	var all []string
+	for _, route := range routes {
+		routeActions := actions(routeRequest(route))
+		all = append(all, routeActions...)
+
+		for _, role := range roles {
+			if slices.ContainsFunc(routeActions, role.HasPermission) {
+				coverage.Reachable[route] = append(coverage.Reachable[route], role.Name())
+			}
+		}
+		if _, ok := coverage.Reachable[route]; !ok {
+			coverage.Unreachable = append(coverage.Unreachable, route)
+		}
+	}
+
+	for _, role := range roles {
+		for _, permission := range slices.Sorted(role.Permissions(false)) {
+			if !slices.ContainsFunc(all, func(action string) bool { return role.grants(permission, action) }) {
+				coverage.DeadGrants[role.Name()] = append(coverage.DeadGrants[role.Name()], permission)
+			}
+		}
+	}
+
+	return coverage
+}
+
+func routeRequest(route string) *http.Request {
+	method, path := http.MethodGet, route
+	if m, rest, ok := strings.Cut(route, " "); ok {
+		method, path = m, rest
+	}
+	path = patternPath(path)
+
+	return &http.Request{
+		Method:  method,
+		URL:     &url.URL{Path: path},
+		Pattern: route,
+		Header:  http.Header{},
+	}
+}
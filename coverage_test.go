@@ -0,0 +1,46 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckRouteCoverage(t *testing.T) {
+	rbac := New()
+	admin := NewRole("admin")
+	admin.AddPermissions("DELETE /users/{id}", "GET /reports")
+	user := NewRole("user")
+	user.AddPermissions("GET /users/{id}")
+	assert.NoError(t, rbac.AddRole(admin))
+	assert.NoError(t, rbac.AddRole(user))
+	assert.NoError(t, admin.AddChild(user))
+
+	coverage := CheckRouteCoverage(rbac, []string{
+		"GET /users/{id}",
+		"DELETE /users/{id}",
+		"POST /users",
+		"/health",
+	}, nil)
+
+	assert.Equal(t, map[string][]string{
+		"GET /users/{id}":    {"admin", "user"},
+		"DELETE /users/{id}": {"admin"},
+	}, coverage.Reachable)
+	assert.Equal(t, []string{"POST /users", "/health"}, coverage.Unreachable)
+	assert.Equal(t, map[string][]string{"admin": {"GET /reports"}}, coverage.DeadGrants)
+}
+
+func TestCheckRouteCoverage_ActionMap(t *testing.T) {
+	rbac := New()
+	user := NewRole("user")
+	user.AddPermissions("getUser")
+	assert.NoError(t, rbac.AddRole(user))
+
+	m := ActionMap{"GET /users/{id}": "getUser", "POST /users": "createUser"}
+	coverage := CheckRouteCoverage(rbac, []string{"GET /users/{id}", "POST /users"}, m.Actions)
+
+	assert.Equal(t, map[string][]string{"GET /users/{id}": {"user"}}, coverage.Reachable)
+	assert.Equal(t, []string{"POST /users"}, coverage.Unreachable)
+	assert.Empty(t, coverage.DeadGrants)
+}
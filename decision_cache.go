@@ -0,0 +1,335 @@
+package rbac
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	_ Authorizer     = (*CachingAuthorizer)(nil)
+	_ DecisionStore  = (*MemoryDecisionStore)(nil)
+	_ DecisionPurger = (*MemoryDecisionStore)(nil)
+)
+
+// DecisionStore is a shared TTL'd key/value store for decisions. A Redis
+// backed implementation maps Get to GET and Set to SET ... EX.
+type DecisionStore interface {
+	Get(ctx context.Context, key string) (Decision, bool, error)
+	Set(ctx context.Context, key string, d Decision, ttl time.Duration) error
+}
+
+// DecisionPurger is implemented by stores able to drop entries by key prefix,
+// which CachingAuthorizer does when they can no longer be hit instead of
+// leaving them to expire.
+type DecisionPurger interface {
+	Purge(ctx context.Context, prefix string) error
+}
+
+// CacheableAuthorizer is implemented by authorizers able to tell whether a
+// decision depends on more than the claims and the action, e.g. on the
+// request through conditions.
+type CacheableAuthorizer interface {
+	Authorizer
+	Cacheable(ctx context.Context, claims *Claims, target *Target) bool
+}
+
+var _ CacheableAuthorizer = (*DefaultAuthorizer)(nil)
+
+// CachingAuthorizer caches the decisions of a slow authorizer in a
+// DecisionStore shared between instances. Keys include the policy version,
+// so a new version invalidates all previous entries and instances serving
+// the same version share them. Requests carrying assertions or target
+// metadata, evaluated within a RoleTransaction or found not Cacheable by the
+// authorizer are never cached, nor are decisions failing with a policy
+// error.
+type CachingAuthorizer struct {
+	authorizer Authorizer
+	store      DecisionStore
+	ttl        time.Duration
+	prefix     string
+	version    atomic.Pointer[string]
+}
+
+func NewCachingAuthorizer(authorizer Authorizer, store DecisionStore, ttl time.Duration) *CachingAuthorizer {
+	a := &CachingAuthorizer{authorizer: authorizer, store: store, ttl: ttl, prefix: "rbac:decision:"}
+	a.SetVersion("")
+	return a
+}
+
+func (a *CachingAuthorizer) SetPrefix(prefix string) *CachingAuthorizer {
+	a.prefix = prefix
+	return a
+}
+
+// SetVersion switches to a new policy version, typically PolicyHash called
+// from RBAC.OnChange. Entries of the previous version are purged.
+func (a *CachingAuthorizer) SetVersion(version string) *CachingAuthorizer {
+	if previous := a.version.Swap(&version); previous != nil && *previous != version {
+		a.purge(a.prefix + *previous + ":")
+	}
+	return a
+}
+
+func (a *CachingAuthorizer) Version() string {
+	return *a.version.Load()
+}
+
+// Invalidate drops the cached decisions of the current version from stores
+// implementing DecisionPurger, for all instances sharing the store.
+func (a *CachingAuthorizer) Invalidate() {
+	a.purge(a.prefix + a.Version() + ":")
+}
+
+// Watch sets the version to the PolicyHash of rbac and updates it whenever
+// roles or permissions change, so instances watching the same policy share
+// entries. It replaces versions set with SetVersion.
+func (a *CachingAuthorizer) Watch(rbac *RBAC) *CachingAuthorizer {
+	a.SetVersion(PolicyHash(rbac))
+	rbac.Watch(func(PolicyEvent) { a.SetVersion(PolicyHash(rbac)) })
+	return a
+}
+
+func (a *CachingAuthorizer) purge(prefix string) {
+	if purger, ok := a.store.(DecisionPurger); ok {
+		_ = purger.Purge(context.Background(), prefix)
+	}
+}
+
+func (a *CachingAuthorizer) Authorize(ctx context.Context, claims *Claims, target *Target) Decision {
+	d, _ := a.AuthorizeE(ctx, claims, target)
+	return d
+}
+
+// AuthorizeE returns the error of the wrapped authorizer, if it implements
+// AuthorizeE, for decisions not served from the cache. Cached denials fail
+// with an AuthzForbidden error without reasons.
+func (a *CachingAuthorizer) AuthorizeE(ctx context.Context, claims *Claims, target *Target) (Decision, error) {
+	key, ok := a.key(ctx, claims, target)
+	if !ok {
+		return authorizeErr(ctx, a.authorizer, claims, target)
+	}
+
+	if d, ok, err := a.store.Get(ctx, key); err == nil && ok {
+		if d.Allowed() {
+			return d, nil
+		}
+		return d, NewAuthzError(AuthzForbidden, target.Action)
+	}
+
+	d, err := authorizeErr(ctx, a.authorizer, claims, target)
+	var authzErr *AuthzError
+	if errors.As(err, &authzErr) && authzErr.Kind == AuthzPolicyError {
+		return d, err
+	}
+
+	ttl := a.ttl
+	if ttlAuthorizer, ok := a.authorizer.(TTLAuthorizer); ok {
+		if hint := ttlAuthorizer.DecisionTTL(ctx, claims, target); hint > 0 && hint < ttl {
+			ttl = hint
+		}
+	}
+	if ttl > 0 {
+		_ = a.store.Set(ctx, key, d, ttl)
+	}
+	return d, err
+}
+
+func (a *CachingAuthorizer) key(ctx context.Context, claims *Claims, target *Target) (string, bool) {
+	if claims == nil || claims.Subject == nil || target == nil || len(target.Assertions) > 0 || len(target.Metadata) > 0 {
+		return "", false
+	}
+	if CtxRoleTransaction(ctx) != nil {
+		return "", false
+	}
+	if c, ok := a.authorizer.(CacheableAuthorizer); ok && !c.Cacheable(ctx, claims, target) {
+		return "", false
+	}
+
+	roles := slices.Sorted(slices.Values(claims.Subject.Roles()))
+	scopes := slices.Sorted(slices.Values(ClaimsScopes(claims)))
+	var actor string
+	if claims.Actor != nil {
+		actor = SubjectID(claims.Actor)
+	}
+	grants := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if exp, ok := RoleGrantExpiry(claims, role); ok {
+			grants = append(grants, role+"@"+strconv.FormatInt(exp.UnixNano(), 10))
+		}
+	}
+
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%q\x00%q\x00%q\x00%q\x00%q\x00%q\x00%d",
+		SubjectID(claims.Subject), actor, strings.Join(roles, ","), strings.Join(scopes, " "), strings.Join(grants, ","), target.Action, target.matching())
+	return a.prefix + a.Version() + ":" + hex.EncodeToString(h.Sum(nil)), true
+}
+
+// PolicyHash is a stable digest of everything the decisions of the policy
+// depend on: roles, permissions and how they match, conditions, hierarchy,
+// implications, permission sets, superusers, exclusive roles and declared
+// permissions.
+func PolicyHash(rbac *RBAC) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "matching %q\n", rbac.matching)
+	for _, name := range sortedKeys(rbac.roles) {
+		role := rbac.roles[name]
+		_, _ = fmt.Fprintf(h, "role %q %q %q\n", name, role.matching, role.inherited)
+		for _, permission := range sortedKeys(role.permissions) {
+			_, _ = fmt.Fprintf(h, "perm %q %T\n", permission, role.permissions[permission])
+			for _, assertion := range role.conditions[permission] {
+				_, _ = fmt.Fprintf(h, "cond %q\n", assertionDigest(assertion))
+			}
+		}
+		for _, child := range sortedKeys(role.children) {
+			_, _ = fmt.Fprintf(h, "child %q\n", child)
+		}
+	}
+	for _, permission := range rbac.implications.keys {
+		_, _ = fmt.Fprintf(h, "implies %q %q\n", permission, rbac.implications.rules[permission])
+	}
+	for _, name := range sortedKeys(rbac.permissionSets) {
+		_, _ = fmt.Fprintf(h, "set %q %q\n", name, rbac.permissionSets[name])
+	}
+	_, _ = fmt.Fprintf(h, "superusers %q %t\n", slices.Sorted(slices.Values(rbac.superusers)), rbac.superuserAssertions)
+	for _, c := range rbac.exclusive {
+		_, _ = fmt.Fprintf(h, "exclusive %q %q\n", c.Name, slices.Sorted(slices.Values(c.Roles)))
+	}
+	_, _ = fmt.Fprintf(h, "declared %q %q\n", rbac.PermissionMode(), rbac.DeclaredPermissions())
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// assertionDigest identifies an assertion including its parameters where
+// they are known, so reconfigured conditions change the PolicyHash.
+func assertionDigest(assertion Assertion) string {
+	if c, ok := assertion.(*ConditionAssertion); ok {
+		return conditionsKey([]ConditionConfig{c.Config()})
+	}
+	return AssertionName(assertion)
+}
+
+// MemoryDecisionStore is a process-local DecisionStore. With a maximum size
+// the least recently used entries are evicted first. Expired entries are
+// swept every sweepInterval writes.
+type MemoryDecisionStore struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	maxSize int
+	writes  int
+	now     func() time.Time
+}
+
+const sweepInterval = 1024
+
+type memoryDecision struct {
+	key      string
+	decision Decision
+	expires  time.Time
+}
+
+func NewMemoryDecisionStore() *MemoryDecisionStore {
+	return &MemoryDecisionStore{entries: map[string]*list.Element{}, order: list.New(), now: time.Now}
+}
+
+// SetMaxSize bounds the number of entries, zero means unbounded.
+func (s *MemoryDecisionStore) SetMaxSize(size int) *MemoryDecisionStore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxSize = size
+	s.evict()
+	return s
+}
+
+func (s *MemoryDecisionStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+func (s *MemoryDecisionStore) Get(_ context.Context, key string) (Decision, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return DecisionDeny, false, nil
+	}
+	entry := e.Value.(memoryDecision)
+	if !s.now().Before(entry.expires) {
+		s.remove(e)
+		return DecisionDeny, false, nil
+	}
+	s.order.MoveToFront(e)
+	return entry.decision, true, nil
+}
+
+func (s *MemoryDecisionStore) Set(_ context.Context, key string, d Decision, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := memoryDecision{key: key, decision: d, expires: s.now().Add(ttl)}
+	if e, ok := s.entries[key]; ok {
+		e.Value = entry
+		s.order.MoveToFront(e)
+		return nil
+	}
+	s.entries[key] = s.order.PushFront(entry)
+	if s.writes++; s.writes%sweepInterval == 0 {
+		s.sweep()
+	}
+	s.evict()
+	return nil
+}
+
+// Purge removes the entries whose key starts with prefix.
+func (s *MemoryDecisionStore) Purge(_ context.Context, prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, e := range s.entries {
+		if strings.HasPrefix(key, prefix) {
+			s.remove(e)
+		}
+	}
+	return nil
+}
+
+// Sweep removes the expired entries and returns how many there were.
+func (s *MemoryDecisionStore) Sweep() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sweep()
+}
+
+func (s *MemoryDecisionStore) sweep() int {
+	now, n := s.now(), 0
+	for _, e := range s.entries {
+		if !now.Before(e.Value.(memoryDecision).expires) {
+			s.remove(e)
+			n++
+		}
+	}
+	return n
+}
+
+func (s *MemoryDecisionStore) evict() {
+	for s.maxSize > 0 && s.order.Len() > s.maxSize {
+		s.remove(s.order.Back())
+	}
+}
+
+func (s *MemoryDecisionStore) remove(e *list.Element) {
+	s.order.Remove(e)
+	delete(s.entries, e.Value.(memoryDecision).key)
+}
@@ -0,0 +1,321 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingAuthorizer struct {
+	decision Decision
+	calls    int
+}
+
+func (a *countingAuthorizer) Authorize(context.Context, *Claims, *Target) Decision {
+	a.calls++
+	return a.decision
+}
+
+type failingDecisionStore struct{}
+
+func (failingDecisionStore) Get(context.Context, string) (Decision, bool, error) {
+	return DecisionAllow, true, errors.New("unavailable")
+}
+
+func (failingDecisionStore) Set(context.Context, string, Decision, time.Duration) error {
+	return errors.New("unavailable")
+}
+
+func TestCachingAuthorizer(t *testing.T) {
+	ctx := context.Background()
+	upstream := &countingAuthorizer{decision: DecisionAllow}
+	store := NewMemoryDecisionStore()
+	a := NewCachingAuthorizer(upstream, store, time.Minute).SetVersion("v1")
+
+	claims := &Claims{Subject: NewSubject("u1", "user")}
+	target := &Target{Action: "read"}
+
+	assert.Equal(t, DecisionAllow, a.Authorize(ctx, claims, target))
+	assert.Equal(t, DecisionAllow, a.Authorize(ctx, claims, target))
+	assert.Equal(t, 1, upstream.calls)
+
+	other := NewCachingAuthorizer(upstream, store, time.Minute).SetVersion("v1")
+	assert.Equal(t, DecisionAllow, other.Authorize(ctx, &Claims{Subject: NewSubject("u1", "user")}, &Target{Action: "read"}))
+	assert.Equal(t, 1, upstream.calls)
+
+	assert.Equal(t, DecisionAllow, a.Authorize(ctx, &Claims{Subject: NewSubject("u1", "admin")}, target))
+	assert.Equal(t, 2, upstream.calls)
+
+	a.SetVersion("v2")
+	assert.Equal(t, "v2", a.Version())
+	assert.Equal(t, DecisionAllow, a.Authorize(ctx, claims, target))
+	assert.Equal(t, 3, upstream.calls)
+
+	a.Authorize(ctx, claims, &Target{Action: "read", Assertions: []Assertion{&testAssertion{shouldPass: true}}})
+	a.Authorize(ctx, claims, &Target{Action: "read", Assertions: []Assertion{&testAssertion{shouldPass: true}}})
+	a.Authorize(ctx, nil, target)
+	assert.Equal(t, 6, upstream.calls)
+
+	store.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	a.Authorize(ctx, claims, target)
+	assert.Equal(t, 7, upstream.calls)
+}
+
+func TestCachingAuthorizer_TTLHint(t *testing.T) {
+	rbac := New()
+	require.NoError(t, rbac.AddRole("user"))
+	authorizer := NewDefaultAuthorizer(rbac).SetMaxTTL(time.Second)
+
+	store := NewMemoryDecisionStore()
+	a := NewCachingAuthorizer(authorizer, store, time.Minute)
+	a.Authorize(context.Background(), &Claims{Subject: NewSubject("u1", "user")}, &Target{Action: "read"})
+
+	require.Len(t, store.entries, 1)
+	for key := range store.entries {
+		store.now = func() time.Time { return time.Now().Add(2 * time.Second) }
+		_, ok, err := store.Get(context.Background(), key)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	}
+}
+
+func TestCachingAuthorizer_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	rbac := New()
+	require.NoError(t, rbac.AddRole("user"))
+
+	var events []PolicyEvent
+	rbac.OnChange(func(event PolicyEvent) { events = append(events, event) })
+	upstream := &countingAuthorizer{decision: DecisionAllow}
+	store := NewMemoryDecisionStore()
+	a := NewCachingAuthorizer(upstream, store, time.Minute).Watch(rbac)
+
+	claims := &Claims{Subject: NewSubject("u1", "user")}
+	a.Authorize(ctx, claims, &Target{Action: "read"})
+	a.Authorize(ctx, claims, &Target{Action: "read"})
+	assert.Equal(t, 1, upstream.calls)
+
+	role, err := rbac.Role("user")
+	require.NoError(t, err)
+	require.NoError(t, role.AddPermissionsE("read"))
+
+	a.Authorize(ctx, claims, &Target{Action: "read"})
+	assert.Equal(t, 2, upstream.calls)
+	assert.Len(t, events, 1)
+
+	for range 100 {
+		a.Authorize(ctx, claims, &Target{Action: "read"})
+		a.Invalidate()
+	}
+	assert.Zero(t, store.Len())
+
+	a.Authorize(ctx, claims, &Target{Action: "read"})
+	a.SetVersion("v2")
+	assert.Zero(t, store.Len())
+}
+
+func TestCachingAuthorizer_SharedWatch(t *testing.T) {
+	ctx := context.Background()
+	newRBAC := func() *RBAC {
+		rbac := New()
+		require.NoError(t, rbac.AddRole("user"))
+		return rbac
+	}
+	first, second := newRBAC(), newRBAC()
+	upstream := &countingAuthorizer{decision: DecisionAllow}
+	store := NewMemoryDecisionStore()
+	a := NewCachingAuthorizer(upstream, store, time.Minute).Watch(first)
+	b := NewCachingAuthorizer(upstream, store, time.Minute).Watch(second)
+
+	claims := &Claims{Subject: NewSubject("u1", "user")}
+	a.Authorize(ctx, claims, &Target{Action: "read"})
+	b.Authorize(ctx, claims, &Target{Action: "read"})
+	assert.Equal(t, 1, upstream.calls)
+
+	// both instances apply the same change, a different number of times
+	for _, rbac := range []*RBAC{first, second, second} {
+		role, err := rbac.Role("user")
+		require.NoError(t, err)
+		require.NoError(t, role.AddPermissionsE("read"))
+	}
+	assert.Equal(t, a.Version(), b.Version())
+	a.Authorize(ctx, claims, &Target{Action: "read"})
+	b.Authorize(ctx, claims, &Target{Action: "read"})
+	assert.Equal(t, 2, upstream.calls)
+}
+
+func TestCachingAuthorizer_AuthorizeE(t *testing.T) {
+	ctx := context.Background()
+	rbac := New()
+	require.NoError(t, rbac.AddRole("user"))
+	a := NewCachingAuthorizer(NewDefaultAuthorizer(rbac), NewMemoryDecisionStore(), time.Minute)
+
+	claims := &Claims{Subject: NewSubject("u1", "user")}
+	d, err := a.AuthorizeE(ctx, claims, &Target{Action: "read"})
+	assert.Equal(t, DecisionDeny, d)
+	assert.Contains(t, Reasons(err), Reason(ReasonPermissionMissing{Role: "user", Action: "read"}))
+
+	d, err = a.AuthorizeE(ctx, claims, &Target{Action: "read"})
+	assert.Equal(t, DecisionDeny, d)
+	assert.ErrorIs(t, err, ErrDeny)
+
+	store := NewMemoryDecisionStore()
+	unavailable := errors.New("unavailable")
+	remote := NewRemoteAuthorizerFunc(func(context.Context, PDPRequest) (PDPResponse, error) {
+		return PDPResponse{}, unavailable
+	})
+	a = NewCachingAuthorizer(remote, store, time.Minute)
+	_, err = a.AuthorizeE(ctx, claims, &Target{Action: "read"})
+	assert.ErrorIs(t, err, unavailable)
+	assert.Zero(t, store.Len())
+}
+
+func TestMemoryDecisionStore_Sweep(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryDecisionStore()
+	require.NoError(t, store.Set(ctx, "a", DecisionAllow, time.Second))
+	require.NoError(t, store.Set(ctx, "b", DecisionAllow, time.Hour))
+	require.NoError(t, store.Set(ctx, "other:c", DecisionAllow, time.Hour))
+
+	store.now = func() time.Time { return time.Now().Add(time.Minute) }
+	assert.Equal(t, 1, store.Sweep())
+	assert.Equal(t, 2, store.Len())
+
+	require.NoError(t, store.Purge(ctx, "other:"))
+	assert.Equal(t, 1, store.Len())
+
+	store = NewMemoryDecisionStore()
+	for i := range sweepInterval - 1 {
+		require.NoError(t, store.Set(ctx, strconv.Itoa(i), DecisionAllow, time.Second))
+	}
+	store.now = func() time.Time { return time.Now().Add(time.Minute) }
+	require.NoError(t, store.Set(ctx, "last", DecisionAllow, time.Hour))
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestMemoryDecisionStore_MaxSize(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryDecisionStore().SetMaxSize(2)
+
+	require.NoError(t, store.Set(ctx, "a", DecisionAllow, time.Minute))
+	require.NoError(t, store.Set(ctx, "b", DecisionAllow, time.Minute))
+	_, ok, _ := store.Get(ctx, "a")
+	assert.True(t, ok)
+
+	require.NoError(t, store.Set(ctx, "c", DecisionDeny, time.Minute))
+	assert.Equal(t, 2, store.Len())
+	_, ok, _ = store.Get(ctx, "b")
+	assert.False(t, ok)
+
+	require.NoError(t, store.Set(ctx, "a", DecisionDeny, time.Minute))
+	d, ok, _ := store.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, DecisionDeny, d)
+
+	store.SetMaxSize(1)
+	assert.Equal(t, 1, store.Len())
+	_, ok, _ = store.Get(ctx, "a")
+	assert.True(t, ok)
+
+	store.now = func() time.Time { return time.Now().Add(time.Hour) }
+	_, ok, _ = store.Get(ctx, "a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, store.Len())
+}
+
+func TestCachingAuthorizer_StoreFailure(t *testing.T) {
+	upstream := &countingAuthorizer{decision: DecisionDeny}
+	a := NewCachingAuthorizer(upstream, failingDecisionStore{}, time.Minute)
+	assert.Equal(t, DecisionDeny, a.Authorize(context.Background(), &Claims{Subject: NewSubject("u1")}, &Target{Action: "read"}))
+	assert.Equal(t, 1, upstream.calls)
+}
+
+func TestPolicyHash(t *testing.T) {
+	build := func(permissions ...string) *RBAC {
+		rbac := New()
+		require.NoError(t, rbac.AddRole("admin"))
+		require.NoError(t, rbac.AddRole("user", "admin"))
+		user, _ := rbac.Role("user")
+		require.NoError(t, user.AddPermissionsE(permissions...))
+		return rbac
+	}
+
+	assert.Equal(t, PolicyHash(build("read", "write")), PolicyHash(build("write", "read")))
+	assert.NotEqual(t, PolicyHash(build("read")), PolicyHash(build("read", "write")))
+	assert.Len(t, PolicyHash(New()), 16)
+
+	base := PolicyHash(build("read"))
+	changes := map[string]func(rbac *RBAC){
+		"matching":    func(rbac *RBAC) { rbac.SetPermissionMatching(MatchLiteral) },
+		"superuser":   func(rbac *RBAC) { rbac.SetSuperuserRoles("admin") },
+		"implication": func(rbac *RBAC) { rbac.AddImplication("read", "list") },
+		"set":         func(rbac *RBAC) { rbac.SetPermissionSet("viewer", "read") },
+		"exclusive": func(rbac *RBAC) {
+			require.NoError(t, rbac.AddExclusiveRoles("sod", "user", "auditor"))
+		},
+		"condition": func(rbac *RBAC) {
+			condition, err := NewConditionAssertion(ConditionConfig{Attribute: "request.remoteAddr", CIDR: []string{"10.0.0.0/8"}})
+			require.NoError(t, err)
+			mustRole(t, rbac, "user").SetPermissionAssertions("read", condition)
+		},
+	}
+	for name, change := range changes {
+		rbac := build("read")
+		change(rbac)
+		assert.NotEqual(t, base, PolicyHash(rbac), name)
+	}
+
+	cidr := func(cidr string) string {
+		rbac := build("read")
+		condition, err := NewConditionAssertion(ConditionConfig{Attribute: "request.remoteAddr", CIDR: []string{cidr}})
+		require.NoError(t, err)
+		mustRole(t, rbac, "user").SetPermissionAssertions("read", condition)
+		return PolicyHash(rbac)
+	}
+	assert.NotEqual(t, cidr("10.0.0.0/8"), cidr("192.168.0.0/16"))
+}
+
+func TestCachingAuthorizer_Conditions(t *testing.T) {
+	rbac := New()
+	require.NoError(t, rbac.AddRole("user"))
+	user := mustRole(t, rbac, "user")
+	require.NoError(t, user.AddPermissionsE("read", "list"))
+	condition, err := NewConditionAssertion(ConditionConfig{Attribute: "request.remoteAddr", CIDR: []string{"10.0.0.0/8"}})
+	require.NoError(t, err)
+	user.SetPermissionAssertions("read", condition)
+
+	store := NewMemoryDecisionStore()
+	a := NewCachingAuthorizer(NewDefaultAuthorizer(rbac), store, time.Minute)
+	claims := &Claims{Subject: NewSubject("u1", "user")}
+	internal := WithRequestInfo(context.Background(), RequestInfo{RemoteAddr: "10.1.1.1:1234"})
+	external := WithRequestInfo(context.Background(), RequestInfo{RemoteAddr: "8.8.8.8:1234"})
+
+	assert.Equal(t, DecisionAllow, a.Authorize(internal, claims, &Target{Action: "read"}))
+	assert.Equal(t, DecisionDeny, a.Authorize(external, claims, &Target{Action: "read"}))
+	assert.Zero(t, store.Len())
+
+	assert.Equal(t, DecisionAllow, a.Authorize(external, claims, &Target{Action: "list"}))
+	assert.Equal(t, 1, store.Len())
+
+	assert.Equal(t, DecisionAllow, a.Authorize(WithRoleTransaction(external), claims, &Target{Action: "list"}))
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestCachingAuthorizer_GrantExpiry(t *testing.T) {
+	rbac := New()
+	require.NoError(t, rbac.AddRole("contractor"))
+	require.NoError(t, mustRole(t, rbac, "contractor").AddPermissionsE("read"))
+	a := NewCachingAuthorizer(NewDefaultAuthorizer(rbac), NewMemoryDecisionStore(), time.Minute)
+
+	grant := func(exp time.Time) *Claims {
+		return &Claims{Subject: NewSubject("c1", "contractor"), Metadata: map[string]any{MetadataRoleExpiry: map[string]any{"contractor": exp}}}
+	}
+	ctx := context.Background()
+	assert.Equal(t, DecisionAllow, a.Authorize(ctx, grant(time.Now().Add(time.Hour)), &Target{Action: "read"}))
+	assert.Equal(t, DecisionDeny, a.Authorize(ctx, grant(time.Now().Add(-time.Hour)), &Target{Action: "read"}))
+}
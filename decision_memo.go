@@ -0,0 +1,82 @@
+package rbac
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+type decisionMemoKey struct{}
+
+// DecisionMemo remembers the decisions of a single request, so the same
+// action checked by middleware, handler and templates is evaluated once.
+// Checks with assertions or target metadata are not memoized.
+type DecisionMemo struct {
+	mu      sync.Mutex
+	entries map[memoKey]memoEntry
+	hits    atomic.Int64
+}
+
+type memoKey struct {
+	authorizer Authorizer
+	claims     *Claims
+	action     string
+	matching   actionMatching
+}
+
+type memoEntry struct {
+	decision Decision
+	err      error
+}
+
+// WithDecisionMemo opts the request into memoization by DefaultAuthorizer.
+// A memo already present in ctx is kept.
+func WithDecisionMemo(ctx context.Context) context.Context {
+	if CtxDecisionMemo(ctx) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, decisionMemoKey{}, &DecisionMemo{entries: map[memoKey]memoEntry{}})
+}
+
+func CtxDecisionMemo(ctx context.Context) *DecisionMemo {
+	memo, _ := ctx.Value(decisionMemoKey{}).(*DecisionMemo)
+	return memo
+}
+
+// Hits returns how many decisions were served from the memo.
+func (m *DecisionMemo) Hits() int64 {
+	return m.hits.Load()
+}
+
+// Reset forgets all decisions, e.g. after the request changed its claims'
+// roles in place.
+func (m *DecisionMemo) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	clear(m.entries)
+}
+
+func (m *DecisionMemo) do(authorizer Authorizer, claims *Claims, target *Target, fn func() (Decision, error)) (Decision, error) {
+	if claims == nil || target == nil || len(target.Assertions) > 0 || len(target.Metadata) > 0 {
+		return fn()
+	}
+	key := memoKey{authorizer: authorizer, claims: claims, action: target.Action, matching: target.matching()}
+
+	m.mu.Lock()
+	entry, ok := m.entries[key]
+	m.mu.Unlock()
+	if ok {
+		m.hits.Add(1)
+		return entry.decision, entry.err
+	}
+
+	// evaluated without the lock, assertions may authorize recursively
+	d, err := fn()
+
+	m.mu.Lock()
+	m.entries[key] = memoEntry{decision: d, err: err}
+	m.mu.Unlock()
+
+	return d, err
+}
@@ -0,0 +1,60 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countAssertion struct {
+	calls int
+}
+
+func (a *countAssertion) Assert(context.Context, *Role, string) bool {
+	a.calls++
+	return true
+}
+
+func TestWithDecisionMemo(t *testing.T) {
+	rbac := New()
+	role := NewRole("editor")
+	role.AddPermissions("post.edit")
+	_ = rbac.AddRole(role)
+
+	a := NewDefaultAuthorizer(rbac)
+	claims := &Claims{Subject: NewSubject("1", "editor")}
+
+	ctx := context.Background()
+	assert.Nil(t, CtxDecisionMemo(ctx))
+
+	ctx = WithDecisionMemo(ctx)
+	memo := CtxDecisionMemo(ctx)
+	assert.Same(t, memo, CtxDecisionMemo(WithDecisionMemo(ctx)))
+
+	assert.Equal(t, DecisionAllow, a.Authorize(ctx, claims, &Target{Action: "post.edit"}))
+	assert.Equal(t, DecisionAllow, a.Authorize(ctx, claims, &Target{Action: "post.edit"}))
+	assert.Equal(t, int64(1), memo.Hits())
+
+	_, err := a.AuthorizeE(ctx, claims, &Target{Action: "post.delete"})
+	assert.Error(t, err)
+	_, err = a.AuthorizeE(ctx, claims, &Target{Action: "post.delete"})
+	assert.Error(t, err)
+	assert.Equal(t, int64(2), memo.Hits())
+
+	other := &Claims{Subject: NewSubject("2", "viewer")}
+	assert.Equal(t, DecisionDeny, a.Authorize(ctx, other, &Target{Action: "post.edit"}))
+	assert.Equal(t, int64(2), memo.Hits())
+
+	counter := &countAssertion{}
+	a.Authorize(ctx, claims, &Target{Action: "post.edit", Assertions: []Assertion{counter}})
+	a.Authorize(ctx, claims, &Target{Action: "post.edit", Assertions: []Assertion{counter}})
+	assert.Equal(t, 2, counter.calls)
+	assert.Equal(t, int64(2), memo.Hits())
+
+	memo.Reset()
+	a.Authorize(ctx, claims, &Target{Action: "post.edit"})
+	assert.Equal(t, int64(2), memo.Hits())
+
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), claims, &Target{Action: "post.edit"}))
+}
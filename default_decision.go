@@ -0,0 +1,40 @@
+package rbac
+
+// actionDefault overrides the default decision for actions matching pattern.
+type actionDefault struct {
+	pattern  string
+	decision Decision
+}
+
+// SetDefaultDecision sets the decision returned when none of the subject roles
+// is registered or holds the action, DecisionDeny unless set. Allowing by
+// default turns the policy into a list of explicit denies: roles holding the
+// action are still denied by failing assertions, scopes or impersonation.
+func (a *DefaultAuthorizer) SetDefaultDecision(d Decision) *DefaultAuthorizer {
+	a.defaultDecision = d
+	return a
+}
+
+func (a *DefaultAuthorizer) DefaultDecision() Decision {
+	return a.defaultDecision
+}
+
+// SetActionDefault overrides the default decision for actions matching the
+// globs, e.g. denying "admin.**" while allowing by default. Later overrides
+// take precedence over earlier ones.
+func (a *DefaultAuthorizer) SetActionDefault(d Decision, actions ...string) *DefaultAuthorizer {
+	for _, action := range actions {
+		a.actionDefaults = append(a.actionDefaults, actionDefault{pattern: action, decision: d})
+	}
+	return a
+}
+
+// ActionDefault returns the default decision for the action.
+func (a *DefaultAuthorizer) ActionDefault(action string) Decision {
+	for i := len(a.actionDefaults) - 1; i >= 0; i-- {
+		if o := a.actionDefaults[i]; o.pattern == action || globMatch(o.pattern, action) {
+			return o.decision
+		}
+	}
+	return a.defaultDecision
+}
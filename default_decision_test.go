@@ -0,0 +1,82 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultAuthorizer_DefaultDecision(t *testing.T) {
+	rbac := New()
+	editor := NewRole("editor")
+	require.NoError(t, editor.AddPermissionsE("post.edit"))
+	require.NoError(t, rbac.AddRole(editor))
+
+	a := NewDefaultAuthorizer(rbac)
+	ctx := context.Background()
+	claims := &Claims{Subject: NewSubject("1", "editor", "ghost")}
+	assert.Equal(t, DecisionDeny, a.DefaultDecision())
+
+	a.SetDefaultDecision(DecisionAllow)
+	assert.Equal(t, DecisionAllow, a.DefaultDecision())
+
+	d, err := a.AuthorizeE(ctx, claims, &Target{Action: "billing.read"})
+	assert.Equal(t, DecisionAllow, d)
+	assert.NoError(t, err)
+	assert.Equal(t, DecisionAllow, a.Authorize(ctx, &Claims{Subject: NewSubject("2")}, &Target{Action: "billing.read"}))
+
+	// explicit denies still apply
+	d, err = a.AuthorizeE(ctx, claims, &Target{
+		Action:     "post.edit",
+		Assertions: []Assertion{&testAssertion{shouldPass: false}},
+	})
+	assert.Equal(t, DecisionDeny, d)
+	assert.ErrorIs(t, err, ErrDeny)
+
+	// so do the target assertions when the default allows
+	d, err = a.AuthorizeE(ctx, claims, &Target{
+		Action:     "billing.read",
+		Assertions: []Assertion{&testAssertion{shouldPass: false}},
+	})
+	assert.Equal(t, DecisionDeny, d)
+	assert.ErrorIs(t, err, ErrDeny)
+	assert.Equal(t, DecisionAllow, a.Authorize(ctx, claims, &Target{
+		Action:     "billing.read",
+		Assertions: []Assertion{&testAssertion{shouldPass: true}},
+	}))
+
+	d, err = a.AuthorizeE(ctx, nil, &Target{Action: "billing.read"})
+	assert.Equal(t, DecisionDeny, d)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	a.SetDefaultDecision(DecisionWarn)
+	assert.Equal(t, DecisionWarn, a.Authorize(ctx, claims, &Target{Action: "billing.read"}))
+
+	a.SetDefaultDecision(DecisionAbstain)
+	d, err = a.AuthorizeE(ctx, claims, &Target{Action: "billing.read"})
+	assert.Equal(t, DecisionAbstain, d)
+	assert.ErrorIs(t, err, ErrDeny)
+}
+
+func TestDefaultAuthorizer_ActionDefault(t *testing.T) {
+	a := NewDefaultAuthorizer(New()).
+		SetDefaultDecision(DecisionAllow).
+		SetActionDefault(DecisionDeny, "admin.**", "billing.delete").
+		SetActionDefault(DecisionAllow, "admin.health")
+
+	assert.Equal(t, DecisionAllow, a.ActionDefault("post.read"))
+	assert.Equal(t, DecisionDeny, a.ActionDefault("admin.users.delete"))
+	assert.Equal(t, DecisionDeny, a.ActionDefault("billing.delete"))
+	assert.Equal(t, DecisionAllow, a.ActionDefault("admin.health"))
+
+	ctx := context.Background()
+	claims := &Claims{Subject: NewSubject("1", "ghost")}
+	assert.Equal(t, DecisionAllow, a.Authorize(ctx, claims, &Target{Action: "post.read"}))
+	assert.Equal(t, DecisionDeny, a.Authorize(ctx, claims, &Target{Action: "admin.users.delete"}))
+
+	a.SetAbstain(true)
+	assert.Equal(t, DecisionAbstain, a.Authorize(ctx, claims, &Target{Action: "admin.users.delete"}))
+	assert.Equal(t, DecisionAllow, a.Authorize(ctx, claims, &Target{Action: "post.read"}))
+}
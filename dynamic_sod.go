@@ -0,0 +1,187 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+)
+
+type roleTransactionKey struct{}
+
+// ConstraintRegistry holds dynamic separation of duty constraints: roles a
+// subject may hold together but not exercise in the same session or
+// transaction.
+type ConstraintRegistry struct {
+	mu          sync.RWMutex
+	constraints []ExclusiveRolesConfig
+}
+
+func NewConstraintRegistry() *ConstraintRegistry {
+	return &ConstraintRegistry{}
+}
+
+// Add declares roles of which only one may be active at a time.
+func (r *ConstraintRegistry) Add(name string, roles ...string) *ConstraintRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	constraint := ExclusiveRolesConfig{Name: name, Roles: unite(roles)}
+	if !slices.ContainsFunc(r.constraints, constraint.equal) {
+		r.constraints = append(r.constraints, constraint)
+	}
+	return r
+}
+
+func (r *ConstraintRegistry) Constraints() []ExclusiveRolesConfig {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	constraints := make([]ExclusiveRolesConfig, len(r.constraints))
+	for i, c := range r.constraints {
+		constraints[i] = ExclusiveRolesConfig{Name: c.Name, Roles: slices.Clone(c.Roles)}
+	}
+	return constraints
+}
+
+// Check reports the constraints violated by the roles being active together.
+func (r *ConstraintRegistry) Check(roles ...string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	active := map[string]struct{}{}
+	for _, role := range roles {
+		active[role] = struct{}{}
+	}
+	return errors.Join(exclusiveViolations(r.constraints, active, "")...)
+}
+
+// conflict returns a role of exercised that is exclusive with role.
+func (r *ConstraintRegistry) conflict(role string, exercised []string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, c := range r.constraints {
+		if !slices.Contains(c.Roles, role) {
+			continue
+		}
+		for _, other := range exercised {
+			if other != role && slices.Contains(c.Roles, other) {
+				return other
+			}
+		}
+	}
+	return ""
+}
+
+func (r *ConstraintRegistry) constrained(role string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.ContainsFunc(r.constraints, func(c ExclusiveRolesConfig) bool {
+		return slices.Contains(c.Roles, role)
+	})
+}
+
+// RoleTransaction records the constrained roles each subject exercised
+// during a transaction, e.g. a request.
+type RoleTransaction struct {
+	mu        sync.Mutex
+	exercised map[string][]string
+}
+
+// WithRoleTransaction opts the request into dynamic separation of duty:
+// once a DefaultAuthorizer with constraints grants an action through a
+// constrained role, roles exclusive with it no longer grant anything to the
+// subject for the rest of the transaction. A transaction already present in
+// ctx is kept.
+func WithRoleTransaction(ctx context.Context) context.Context {
+	if CtxRoleTransaction(ctx) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, roleTransactionKey{}, &RoleTransaction{exercised: map[string][]string{}})
+}
+
+func CtxRoleTransaction(ctx context.Context) *RoleTransaction {
+	tx, _ := ctx.Value(roleTransactionKey{}).(*RoleTransaction)
+	return tx
+}
+
+// Exercised returns the constrained roles the subject exercised so far.
+func (t *RoleTransaction) Exercised(subject string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return slices.Clone(t.exercised[subject])
+}
+
+// exercise records constrained roles unless one of them conflicts with an
+// exercised one, which it returns along with the conflicting role, checking
+// and recording atomically.
+func (t *RoleTransaction) exercise(constraints *ConstraintRegistry, subject string, roles []string) (string, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, role := range roles {
+		if exercised := constraints.conflict(role, t.exercised[subject]); exercised != "" {
+			return role, exercised
+		}
+	}
+	for _, role := range roles {
+		if constraints.constrained(role) && !slices.Contains(t.exercised[subject], role) {
+			t.exercised[subject] = append(t.exercised[subject], role)
+		}
+	}
+	return "", ""
+}
+
+// grantingRoles returns the constrained roles through which the role grants
+// the target: the role itself and the inherited roles holding the permission.
+func (a *DefaultAuthorizer) grantingRoles(rbac *RBAC, role string, target *Target) []string {
+	var granting []string
+	if a.constraints.constrained(role) {
+		granting = append(granting, role)
+	}
+	r, ok := rbac.roles[role]
+	if !ok {
+		return granting
+	}
+
+	visited := map[string]struct{}{role: {}}
+	var walk func(r *Role)
+	walk = func(r *Role) {
+		for child := range r.Children() {
+			if _, ok := visited[child.Name()]; ok {
+				continue
+			}
+			visited[child.Name()] = struct{}{}
+			if a.constraints.constrained(child.Name()) && child.hasPermission(target.Action, target.matching()) {
+				granting = append(granting, child.Name())
+			}
+			walk(child)
+		}
+	}
+	walk(r)
+	slices.Sort(granting)
+	return granting
+}
+
+// SetConstraints makes the authorizer enforce dynamic separation of duty
+// within transactions started with WithRoleTransaction.
+func (a *DefaultAuthorizer) SetConstraints(constraints *ConstraintRegistry) *DefaultAuthorizer {
+	a.constraints = constraints
+	return a
+}
+
+// SetConstraints makes Activate reject roles exclusive with an active role.
+func (s *RoleSession) SetConstraints(constraints *ConstraintRegistry) *RoleSession {
+	s.constraints = constraints
+	return s
+}
+
+// SetHierarchy makes Activate take the roles inherited by the activated ones
+// from rbac into account.
+func (s *RoleSession) SetHierarchy(rbac *RBAC) *RoleSession {
+	s.hierarchy = rbac
+	return s
+}
@@ -0,0 +1,131 @@
+package rbac
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPaymentsRBAC(t *testing.T) *RBAC {
+	rbac := New()
+	for name, permissions := range map[string][]string{
+		"creator":  {"payments.create", "payments.view"},
+		"approver": {"payments.approve", "payments.view"},
+		"viewer":   {"reports.view"},
+	} {
+		role := NewRole(name)
+		require.NoError(t, role.AddPermissionsE(permissions...))
+		require.NoError(t, rbac.AddRole(role))
+	}
+	return rbac
+}
+
+func TestConstraintRegistry(t *testing.T) {
+	c := NewConstraintRegistry().Add("payments", "creator", "approver").Add("payments", "approver", "creator")
+	assert.Equal(t, []ExclusiveRolesConfig{{Name: "payments", Roles: []string{"creator", "approver"}}}, c.Constraints())
+
+	assert.NoError(t, c.Check("creator", "viewer"))
+	assert.ErrorIs(t, c.Check("creator", "approver"), ErrSoDViolation)
+	assert.Equal(t, "creator", c.conflict("approver", []string{"viewer", "creator"}))
+	assert.Empty(t, c.conflict("viewer", []string{"creator"}))
+}
+
+func TestDefaultAuthorizer_Constraints(t *testing.T) {
+	authorizer := NewDefaultAuthorizer(newPaymentsRBAC(t)).
+		SetConstraints(NewConstraintRegistry().Add("payments", "creator", "approver"))
+	claims := &Claims{Subject: NewSubject("u1", "creator", "approver", "viewer")}
+	create, approve := &Target{Action: "payments.create"}, &Target{Action: "payments.approve"}
+
+	// without a transaction both roles may be exercised
+	assert.Equal(t, DecisionAllow, authorizer.Authorize(context.Background(), claims, create))
+	assert.Equal(t, DecisionAllow, authorizer.Authorize(context.Background(), claims, approve))
+
+	ctx := WithRoleTransaction(context.Background())
+	assert.Same(t, CtxRoleTransaction(ctx), CtxRoleTransaction(WithRoleTransaction(ctx)))
+	assert.Equal(t, DecisionAllow, authorizer.Authorize(ctx, claims, create))
+	assert.Equal(t, DecisionAllow, authorizer.Authorize(ctx, claims, &Target{Action: "reports.view"}))
+	assert.Equal(t, []string{"creator"}, CtxRoleTransaction(ctx).Exercised("u1"))
+
+	d, err := authorizer.AuthorizeE(ctx, claims, approve)
+	assert.Equal(t, DecisionDeny, d)
+	assert.Contains(t, Reasons(err), ReasonExclusiveRole{Role: "approver", Exercised: "creator"})
+	assert.Equal(t, DecisionAllow, authorizer.Authorize(ctx, claims, &Target{Action: "payments.view"}))
+
+	// other subjects and transactions are unaffected
+	assert.Equal(t, DecisionAllow, authorizer.Authorize(ctx, &Claims{Subject: NewSubject("u2", "creator", "approver")}, approve))
+	assert.Equal(t, DecisionAllow, authorizer.Authorize(WithRoleTransaction(context.Background()), claims, approve))
+
+	assert.Equal(t, "You cannot act as approver after acting as creator.",
+		DefaultMessageCatalog.Message("en", ReasonExclusiveRole{Role: "approver", Exercised: "creator"}))
+}
+
+func TestDefaultAuthorizer_InheritedConstraints(t *testing.T) {
+	rbac := newPaymentsRBAC(t)
+	manager := NewRole("manager")
+	require.NoError(t, manager.AddPermissionsE("reports.export"))
+	require.NoError(t, rbac.AddRole(manager))
+	approver, err := rbac.Role("approver")
+	require.NoError(t, err)
+	require.NoError(t, manager.AddChild(approver))
+
+	authorizer := NewDefaultAuthorizer(rbac).SetConstraints(NewConstraintRegistry().Add("payments", "creator", "approver"))
+	claims := &Claims{Subject: NewSubject("u1", "manager", "creator")}
+
+	ctx := WithRoleTransaction(context.Background())
+	assert.Equal(t, DecisionAllow, authorizer.Authorize(ctx, claims, &Target{Action: "payments.create"}))
+	assert.Equal(t, DecisionAllow, authorizer.Authorize(ctx, claims, &Target{Action: "reports.export"}))
+	assert.Equal(t, []string{"creator"}, CtxRoleTransaction(ctx).Exercised("u1"))
+
+	d, err := authorizer.AuthorizeE(ctx, claims, &Target{Action: "payments.approve"})
+	assert.Equal(t, DecisionDeny, d)
+	assert.Contains(t, Reasons(err), ReasonExclusiveRole{Role: "approver", Exercised: "creator"})
+
+	ctx = WithRoleTransaction(context.Background())
+	assert.Equal(t, DecisionAllow, authorizer.Authorize(ctx, claims, &Target{Action: "payments.approve"}))
+	assert.Equal(t, []string{"approver"}, CtxRoleTransaction(ctx).Exercised("u1"))
+	assert.Equal(t, DecisionDeny, authorizer.Authorize(ctx, claims, &Target{Action: "payments.create"}))
+}
+
+func TestRoleSession_Constraints(t *testing.T) {
+	s := NewRoleSession(NewSubject("u1", "creator", "approver", "viewer")).
+		SetConstraints(NewConstraintRegistry().Add("payments", "creator", "approver"))
+
+	require.NoError(t, s.Activate("creator", "viewer"))
+	assert.ErrorIs(t, s.Activate("approver"), ErrSoDViolation)
+	assert.ErrorIs(t, NewRoleSession(NewSubject("u1", "creator", "approver")).
+		SetConstraints(NewConstraintRegistry().Add("", "creator", "approver")).
+		Activate("creator", "approver"), ErrSoDViolation)
+
+	s.Deactivate("creator")
+	require.NoError(t, s.Activate("approver"))
+	assert.Equal(t, []string{"approver", "viewer"}, s.Roles())
+
+	rbac := newPaymentsRBAC(t)
+	manager := NewRole("manager")
+	require.NoError(t, rbac.AddRole(manager))
+	approver, err := rbac.Role("approver")
+	require.NoError(t, err)
+	require.NoError(t, manager.AddChild(approver))
+	s = NewRoleSession(NewSubject("u1", "creator", "manager")).
+		SetConstraints(NewConstraintRegistry().Add("payments", "creator", "approver")).
+		SetHierarchy(rbac)
+	require.NoError(t, s.Activate("creator"))
+	assert.ErrorIs(t, s.Activate("manager"), ErrSoDViolation)
+}
+
+func TestRoleSession_ConcurrentConstraints(t *testing.T) {
+	for range 100 {
+		s := NewRoleSession(NewSubject("u1", "creator", "approver")).
+			SetConstraints(NewConstraintRegistry().Add("payments", "creator", "approver"))
+
+		var wg sync.WaitGroup
+		for _, role := range []string{"creator", "approver"} {
+			wg.Go(func() { _ = s.Activate(role) })
+		}
+		wg.Wait()
+		assert.Len(t, s.Roles(), 1)
+	}
+}
@@ -0,0 +1,73 @@
+package rbac
+
+import (
+	"errors"
+	"net/http"
+)
+
+var _ http.RoundTripper = (*EgressGuard)(nil)
+
+// EgressGuard authorizes outgoing requests with the claims of their context
+// before they are sent. Denied requests fail with an *AuthzError. Actions are
+// checked with Target.Anchored, so a policy of "GET billing.internal" does
+// not also allow "GET billing.internal.evil.com/".
+type EgressGuard struct {
+	next       http.RoundTripper
+	authorizer Authorizer
+	authorize  func(*http.Request) error
+}
+
+func NewEgressGuard(next http.RoundTripper, authorizer Authorizer) *EgressGuard {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &EgressGuard{next: next, authorizer: authorizer, authorize: egressAuthorizer(authorizer, EgressActions)}
+}
+
+func (g *EgressGuard) SetActions(actions func(*http.Request) []string) *EgressGuard {
+	g.authorize = egressAuthorizer(g.authorizer, actions)
+	return g
+}
+
+func egressAuthorizer(authorizer Authorizer, actions func(*http.Request) []string) func(*http.Request) error {
+	return RequestAuthorizerE(authorizer, WithActions(actions), WithTargetBuilder(func(_ *http.Request, target *Target) {
+		target.Anchored = true
+	}))
+}
+
+func (g *EgressGuard) RoundTrip(r *http.Request) (*http.Response, error) {
+	if err := g.authorize(r); err != nil {
+		if r.Body != nil {
+			_ = r.Body.Close()
+		}
+		kind := AuthzForbidden
+		if CtxClaims(r.Context()) == nil {
+			kind = AuthzUnauthenticated
+		}
+		var authzErr *AuthzError
+		if errors.As(err, &authzErr) {
+			err = authzErr.Err
+		}
+		return nil, NewAuthzError(kind, EgressAction(r), err)
+	}
+	return g.next.RoundTrip(r)
+}
+
+// EgressAction is "METHOD host/path", e.g. "GET billing.internal/invoices".
+func EgressAction(r *http.Request) string {
+	path := r.URL.Path
+	if path == "" {
+		path = "/"
+	}
+	return r.Method + " " + r.URL.Host + path
+}
+
+// EgressActions yields "*", "METHOD host" and EgressAction, so policies can
+// allow a whole host or single endpoints.
+func EgressActions(r *http.Request) []string {
+	return []string{
+		"*",
+		r.Method + " " + r.URL.Host,
+		EgressAction(r),
+	}
+}
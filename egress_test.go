@@ -0,0 +1,85 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEgressGuard(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	rbac := New()
+	reporting := NewRole("reporting")
+	require.NoError(t, reporting.AddPermissionsE(`GET `+host+`/billing/.*`))
+	require.NoError(t, rbac.AddRole(reporting))
+
+	client := &http.Client{Transport: NewEgressGuard(nil, NewDefaultAuthorizer(rbac))}
+	ctx := WithClaims(context.Background(), &Claims{Subject: NewSubject("reporting-svc", "reporting")})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/billing/invoices", nil)
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	req, _ = http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+"/billing/invoices", strings.NewReader("{}"))
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, ErrDeny)
+	var authzErr *AuthzError
+	require.ErrorAs(t, err, &authzErr)
+	assert.Equal(t, AuthzForbidden, authzErr.Kind)
+	assert.Equal(t, "POST "+host+"/billing/invoices", authzErr.Action)
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL+"/billing/invoices", nil)
+	_, err = client.Do(req)
+	require.ErrorAs(t, err, &authzErr)
+	assert.Equal(t, AuthzUnauthenticated, authzErr.Kind)
+}
+
+type noContentTransport struct{}
+
+func (noContentTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody}, nil
+}
+
+func TestEgressGuard_HostSuffix(t *testing.T) {
+	rbac := New()
+	reporting := NewRole("reporting")
+	require.NoError(t, reporting.AddPermissionsE("GET billing.internal"))
+	require.NoError(t, rbac.AddRole(reporting))
+
+	g := NewEgressGuard(noContentTransport{}, NewDefaultAuthorizer(rbac))
+	ctx := WithClaims(context.Background(), &Claims{Subject: NewSubject("reporting-svc", "reporting")})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://billing.internal/invoices", nil)
+	res, err := g.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	for _, host := range []string{"billing.internal.evil.com", "billing.internalx", "evil.billing.internal"} {
+		req, _ = http.NewRequestWithContext(ctx, http.MethodGet, "http://"+host+"/invoices", nil)
+		_, err = g.RoundTrip(req)
+		assert.ErrorIs(t, err, ErrDeny, host)
+		assert.Contains(t, Reasons(err), Reason(ReasonPermissionMissing{Role: "reporting", Action: "GET " + host + "/invoices"}), host)
+	}
+}
+
+func TestEgressActions(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "http://billing.internal", nil)
+	assert.Equal(t, []string{"*", "GET billing.internal", "GET billing.internal/"}, EgressActions(r))
+
+	g := NewEgressGuard(http.DefaultTransport, &mockAuthorizer{decision: DecisionDeny}).
+		SetActions(func(*http.Request) []string { return nil })
+	_, err := g.RoundTrip(r)
+	assert.ErrorIs(t, err, ErrDeny)
+}
@@ -0,0 +1,220 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownEvent = errors.New("unknown policy event")
+
+	_ EventStore = (*MemoryEventStore)(nil)
+)
+
+// EventStore is an append-only log of policy events. Append assigns
+// consecutive sequence numbers starting at 1.
+type EventStore interface {
+	Append(ctx context.Context, events ...PolicyEvent) error
+	// Load returns the events with a sequence number greater than after.
+	Load(ctx context.Context, after uint64) ([]PolicyEvent, error)
+	// Wait blocks until events after the given sequence number exist.
+	Wait(ctx context.Context, after uint64) error
+}
+
+type MemoryEventStore struct {
+	mu      sync.RWMutex
+	events  []PolicyEvent
+	changed chan struct{}
+}
+
+func NewMemoryEventStore() *MemoryEventStore {
+	return &MemoryEventStore{changed: make(chan struct{})}
+}
+
+func (s *MemoryEventStore) Append(_ context.Context, events ...PolicyEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, event := range events {
+		event.Seq = uint64(len(s.events)) + 1
+		s.events = append(s.events, event)
+	}
+	if len(events) > 0 {
+		close(s.changed)
+		s.changed = make(chan struct{})
+	}
+	return nil
+}
+
+func (s *MemoryEventStore) Load(_ context.Context, after uint64) ([]PolicyEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if after >= uint64(len(s.events)) {
+		return nil, nil
+	}
+	return append([]PolicyEvent(nil), s.events[after:]...), nil
+}
+
+func (s *MemoryEventStore) Wait(ctx context.Context, after uint64) error {
+	s.mu.RLock()
+	changed := s.changed
+	ready := after < uint64(len(s.events))
+	s.mu.RUnlock()
+
+	if ready {
+		return nil
+	}
+	select {
+	case <-changed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RecordEvents returns an RBAC.OnChange callback appending every event to
+// the store.
+func RecordEvents(store EventStore, onError func(event PolicyEvent, err error)) func(PolicyEvent) {
+	return func(event PolicyEvent) {
+		if err := store.Append(context.Background(), event); err != nil && onError != nil {
+			onError(event, err)
+		}
+	}
+}
+
+// Project applies events to rbac in order. Missing roles referenced by
+// events are created.
+func Project(rbac *RBAC, events ...PolicyEvent) error {
+	for _, event := range events {
+		if err := project(rbac, event); err != nil {
+			return fmt.Errorf("event %d (%s): %w", event.Seq, event.Type, err)
+		}
+	}
+	return nil
+}
+
+func project(rbac *RBAC, event PolicyEvent) error {
+	switch event.Type {
+	case PolicyRoleAdded:
+		if r, ok := rbac.roles[event.Role]; ok {
+			r.reset()
+			return r.AddPermissionsE(event.Permissions...)
+		}
+		r, err := projectRole(rbac, event.Role)
+		if err != nil {
+			return err
+		}
+		return r.AddPermissionsE(event.Permissions...)
+	case PolicyPermissionsAdded:
+		r, err := projectRole(rbac, event.Role)
+		if err != nil {
+			return err
+		}
+		return r.AddPermissionsE(event.Permissions...)
+	case PolicyPermissionsRemoved:
+		if r, ok := rbac.roles[event.Role]; ok {
+			r.RemovePermissions(event.Permissions...)
+		}
+		return nil
+	case PolicyRoleRemoved:
+		rbac.removeRole(event.Role)
+		return nil
+	case PolicyRoleRenamed:
+		return rbac.RenameRole(event.Previous, event.Role)
+	case PolicyChildAdded:
+		parent, err := projectRole(rbac, event.Role)
+		if err != nil {
+			return err
+		}
+		child, err := projectRole(rbac, event.Child)
+		if err != nil {
+			return err
+		}
+		return parent.AddChild(child)
+	case PolicyChildRemoved:
+		if parent, ok := rbac.roles[event.Role]; ok {
+			if child, ok := parent.children[event.Child]; ok {
+				parent.RemoveChild(child)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownEvent, event.Type)
+	}
+}
+
+// reset turns a role re-added by RBAC.AddRole, which replaces the registered
+// one, into an empty role. Edges to parents are kept since AddRole records
+// them before the role itself.
+func (r *Role) reset() {
+	for _, child := range r.children {
+		delete(child.parents, r.Name())
+	}
+	clear(r.children)
+	r.indexPaths(nil, sortedKeys(r.permissions))
+	clear(r.permissions)
+	clear(r.tags)
+	r.conditions, r.permissionSets, r.matching = nil, nil, ""
+}
+
+func projectRole(rbac *RBAC, name string) (*Role, error) {
+	if r, ok := rbac.roles[name]; ok {
+		return r, nil
+	}
+	if err := rbac.AddRole(name); err != nil {
+		return nil, err
+	}
+	return rbac.roles[name], nil
+}
+
+// Replay rebuilds the policy from the store as it was at the given time; a
+// zero time replays every event.
+func Replay(ctx context.Context, store EventStore, at time.Time) (*RBAC, error) {
+	events, err := store.Load(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	if !at.IsZero() {
+		for i, event := range events {
+			if event.Time.After(at) {
+				events = events[:i]
+				break
+			}
+		}
+	}
+
+	rbac := New()
+	if err = Project(rbac, events...); err != nil {
+		return nil, err
+	}
+	return rbac, nil
+}
+
+// Follow keeps rbac in sync with the store, starting after the given
+// sequence number, until ctx is done. RBAC is not safe for concurrent use,
+// so readers have to be synchronized with fn, which is called after every
+// batch with the last applied sequence number.
+func Follow(ctx context.Context, store EventStore, rbac *RBAC, after uint64, fn func(seq uint64)) error {
+	for {
+		events, err := store.Load(ctx, after)
+		if err != nil {
+			return err
+		}
+		if len(events) > 0 {
+			if err = Project(rbac, events...); err != nil {
+				return err
+			}
+			after = events[len(events)-1].Seq
+			if fn != nil {
+				fn(after)
+			}
+		}
+		if err = store.Wait(ctx, after); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package rbac
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventStore_Replay(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryEventStore()
+	source := New().OnChange(RecordEvents(store, nil))
+
+	viewer := NewRole("viewer")
+	require.NoError(t, viewer.AddPermissionsE("posts:read"))
+	editor := NewRole("editor")
+	require.NoError(t, editor.AddChild(viewer))
+
+	require.NoError(t, source.AddRole("admin"))
+	require.NoError(t, source.AddRole(editor, "admin"))
+	require.NoError(t, source.AddRole(viewer))
+	require.NoError(t, editor.AddPermissionsE("posts:write"))
+
+	events, err := store.Load(ctx, 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+	assert.Equal(t, uint64(1), events[0].Seq)
+	checkpoint := events[len(events)-1].Time
+
+	time.Sleep(time.Millisecond)
+	temp := NewRole("temp")
+	temp.AddTags("temp")
+	require.NoError(t, source.AddRole(temp, "admin"))
+	require.NoError(t, source.RenameRole("viewer", "reader"))
+	source.RemoveByTag("temp")
+
+	replica, err := Replay(ctx, store, time.Time{})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, roleNames(source), roleNames(replica))
+	assert.True(t, replica.IsGranted(ctx, "admin", "posts:read"))
+	assert.True(t, replica.IsGranted(ctx, "admin", "posts:write"))
+	assert.True(t, replica.IsGranted(ctx, "reader", "posts:read"))
+
+	past, err := Replay(ctx, store, checkpoint)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"admin", "editor", "viewer"}, roleNames(past))
+	assert.True(t, past.IsGranted(ctx, "editor", "posts:read"))
+}
+
+func TestEventStore_ReplayReplacedRole(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryEventStore()
+	live := New().OnChange(RecordEvents(store, nil))
+
+	viewer := NewRole("viewer")
+	require.NoError(t, viewer.AddPermissionsE("posts:read", "posts:list"))
+	editor := NewRole("editor")
+	require.NoError(t, editor.AddPermissionsE("posts:write"))
+	require.NoError(t, editor.AddChild(viewer))
+	require.NoError(t, live.AddRole(viewer))
+	require.NoError(t, live.AddRole(editor))
+	require.NoError(t, live.AddRole("admin", "editor"))
+
+	// AddRole replaces the registered roles
+	viewer = NewRole("viewer")
+	require.NoError(t, viewer.AddPermissionsE("posts:list"))
+	require.NoError(t, live.AddRole(viewer))
+	editor = NewRole("editor")
+	require.NoError(t, editor.AddPermissionsE("posts:publish"))
+	require.NoError(t, live.AddRole(editor))
+
+	replica, err := Replay(ctx, store, time.Time{})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, roleNames(live), roleNames(replica))
+	for _, name := range roleNames(live) {
+		assert.ElementsMatch(t,
+			slices.Collect(mustRole(t, live, name).Permissions(true)),
+			slices.Collect(mustRole(t, replica, name).Permissions(true)), name)
+		for _, permission := range []string{"posts:read", "posts:list", "posts:write", "posts:publish"} {
+			assert.Equal(t, live.IsGranted(ctx, name, permission), replica.IsGranted(ctx, name, permission), name+" "+permission)
+		}
+	}
+	assert.False(t, replica.IsGranted(ctx, "viewer", "posts:read"))
+	assert.False(t, replica.IsGranted(ctx, "editor", "posts:write"))
+}
+
+func TestEventStore_Project(t *testing.T) {
+	rbac := New()
+	require.NoError(t, Project(rbac,
+		PolicyEvent{Seq: 1, Type: PolicyChildAdded, Role: "admin", Child: "user"},
+		PolicyEvent{Seq: 2, Type: PolicyPermissionsAdded, Role: "user", Permissions: []string{"read"}},
+	))
+	assert.True(t, rbac.IsGranted(context.Background(), "admin", "read"))
+
+	require.NoError(t, Project(rbac, PolicyEvent{Seq: 3, Type: PolicyChildRemoved, Role: "admin", Child: "user"}))
+	assert.False(t, rbac.IsGranted(context.Background(), "admin", "read"))
+
+	require.NoError(t, Project(rbac, PolicyEvent{Seq: 4, Type: PolicyPermissionsRemoved, Role: "user", Permissions: []string{"read"}}))
+	assert.False(t, rbac.IsGranted(context.Background(), "user", "read"))
+
+	err := Project(rbac, PolicyEvent{Seq: 5, Type: "role.exploded"})
+	assert.ErrorIs(t, err, ErrUnknownEvent)
+	assert.ErrorContains(t, err, "event 5")
+}
+
+func TestEventStore_Follow(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	store := NewMemoryEventStore()
+	leader := New().OnChange(RecordEvents(store, nil))
+	require.NoError(t, leader.AddRole("admin"))
+
+	follower := New()
+	applied := make(chan uint64, 8)
+	done := make(chan error, 1)
+	go func() {
+		done <- Follow(ctx, store, follower, 0, func(seq uint64) { applied <- seq })
+	}()
+	assert.Equal(t, uint64(1), <-applied)
+
+	require.NoError(t, store.Append(ctx, PolicyEvent{Type: PolicyPermissionsAdded, Role: "admin", Permissions: []string{"*"}}))
+	assert.Equal(t, uint64(2), <-applied)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.True(t, follower.IsGranted(context.Background(), "admin", "*"))
+}
+
+func roleNames(rbac *RBAC) []string {
+	var names []string
+	for role := range rbac.Roles() {
+		names = append(names, role.Name())
+	}
+	slices.Sort(names)
+	return names
+}
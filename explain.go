@@ -0,0 +1,110 @@
+package rbac
+
+import (
+	"context"
+	"time"
+)
+
+type explanationKey struct{}
+
+// ExplainAuthorizer is implemented by authorizers able to tell why they
+// reached a decision.
+type ExplainAuthorizer interface {
+	Authorizer
+	AuthorizeExplain(ctx context.Context, claims *Claims, target *Target) DecisionResult
+}
+
+var _ ExplainAuthorizer = (*DefaultAuthorizer)(nil)
+
+// DecisionResult is a decision with the evaluation that led to it.
+type DecisionResult struct {
+	Decision Decision
+	Err      error
+	// Role is the subject role that granted the action.
+	Role string
+	// GrantedBy is the role holding the matched permission, Role itself or
+	// one of its descendants.
+	GrantedBy string
+	// Permission is the literal or pattern permission that matched.
+	Permission string
+	Assertions []AssertionEvent
+	Roles      []RoleResult
+	TTL        time.Duration
+}
+
+// RoleResult is the evaluation of a single subject role.
+type RoleResult struct {
+	Role    string
+	Granted bool
+	Reason  Reason
+	Err     error
+}
+
+type explanation struct {
+	roles      []RoleResult
+	assertions []AssertionEvent
+}
+
+func ctxExplanation(ctx context.Context) *explanation {
+	e, _ := ctx.Value(explanationKey{}).(*explanation)
+	return e
+}
+
+func (e *explanation) role(role string, granted bool, reason Reason, err error) {
+	e.roles = append(e.roles, RoleResult{Role: role, Granted: granted, Reason: reason, Err: err})
+}
+
+// AuthorizeExplain is AuthorizeE returning the evaluation of every subject
+// role and assertion. It bypasses the decision memo.
+func (a *DefaultAuthorizer) AuthorizeExplain(ctx context.Context, claims *Claims, target *Target) DecisionResult {
+	e := &explanation{}
+	d, err := a.authorizeE(context.WithValue(ctx, explanationKey{}, e), claims, target)
+
+	result := DecisionResult{Decision: d, Err: err, Assertions: e.assertions, Roles: e.roles}
+	if !d.Allowed() {
+		return result
+	}
+
+	for _, r := range e.roles {
+		if !r.Granted {
+			continue
+		}
+		result.Role = r.Role
+		if role, ok := a.holder.Load().roles[r.Role]; ok {
+			if holder, permission, ok := role.matchPermission(target.Action, target.matching()); ok {
+				result.GrantedBy, result.Permission = holder.Name(), permission
+			}
+		}
+		break
+	}
+	result.TTL = a.DecisionTTL(ctx, claims, target)
+	return result
+}
+
+// Explain explains the decision of authorizer, falling back to a bare
+// decision for authorizers not implementing ExplainAuthorizer.
+func Explain(ctx context.Context, authorizer Authorizer, claims *Claims, target *Target) DecisionResult {
+	if explainer, ok := authorizer.(ExplainAuthorizer); ok {
+		return explainer.AuthorizeExplain(ctx, claims, target)
+	}
+	return DecisionResult{Decision: authorizer.Authorize(ctx, claims, target)}
+}
+
+// matchPermission finds the role and the permission granting permission,
+// searching like HasPermission with patterns matching as selected by mode.
+func (r *Role) matchPermission(permission string, mode actionMatching) (*Role, string, bool) {
+	if _, ok := r.permissions[permission]; ok {
+		return r, permission, true
+	}
+	for pattern, m := range r.permissions {
+		if mode.match(m, permission) {
+			return r, pattern, true
+		}
+	}
+	for child := range r.Children() {
+		if holder, pattern, ok := child.matchPermission(permission, mode); ok {
+			return holder, pattern, true
+		}
+	}
+	return nil, "", false
+}
@@ -0,0 +1,61 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultAuthorizer_AuthorizeExplain(t *testing.T) {
+	rbac := New()
+	viewer := NewRole("viewer")
+	require.NoError(t, viewer.AddPermissionsE(`post:\d+:read`))
+	editor := NewRole("editor")
+	require.NoError(t, rbac.AddRole(editor))
+	require.NoError(t, rbac.AddRole(viewer, "editor"))
+
+	a := NewDefaultAuthorizer(rbac).SetMaxTTL(time.Minute)
+	claims := &Claims{Subject: NewSubject("1", "ghost", "editor")}
+
+	result := a.AuthorizeExplain(context.Background(), claims, &Target{
+		Action:     "post:1:read",
+		Assertions: []Assertion{&testAssertion{shouldPass: true}},
+	})
+	assert.Equal(t, DecisionAllow, result.Decision)
+	assert.NoError(t, result.Err)
+	assert.Equal(t, "editor", result.Role)
+	assert.Equal(t, "viewer", result.GrantedBy)
+	assert.Equal(t, `post:\d+:read`, result.Permission)
+	assert.Equal(t, time.Minute, result.TTL)
+	require.Len(t, result.Roles, 2)
+	assert.Equal(t, RoleResult{Role: "ghost", Reason: ReasonRoleMissing{Role: "ghost"}, Err: result.Roles[0].Err}, result.Roles[0])
+	assert.ErrorIs(t, result.Roles[0].Err, ErrRoleNotFound)
+	assert.Equal(t, RoleResult{Role: "editor", Granted: true}, result.Roles[1])
+	require.Len(t, result.Assertions, 1)
+	assert.Equal(t, AssertionPassed, result.Assertions[0].Outcome)
+
+	result = a.AuthorizeExplain(context.Background(), claims, &Target{
+		Action:     "post:1:read",
+		Assertions: []Assertion{&testAssertion{shouldPass: false}},
+	})
+	assert.Equal(t, DecisionDeny, result.Decision)
+	assert.Error(t, result.Err)
+	assert.Empty(t, result.Role)
+	assert.Zero(t, result.TTL)
+	require.Len(t, result.Roles, 2)
+	assert.IsType(t, ReasonAssertionFailed{}, result.Roles[1].Reason)
+	require.Len(t, result.Assertions, 1)
+	assert.Equal(t, AssertionFailed, result.Assertions[0].Outcome)
+}
+
+func TestExplain(t *testing.T) {
+	result := Explain(context.Background(), &mockAuthorizer{decision: DecisionAllow}, nil, &Target{Action: "read"})
+	assert.Equal(t, DecisionResult{Decision: DecisionAllow}, result)
+
+	result = Explain(context.Background(), NewDefaultAuthorizer(New()), nil, &Target{Action: "read"})
+	assert.Equal(t, DecisionDeny, result.Decision)
+	assert.Equal(t, []Reason{ReasonUnauthenticated{}}, Reasons(result.Err))
+}
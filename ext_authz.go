@@ -0,0 +1,168 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+const (
+	HeaderAuthzSubject = "X-Authz-Subject"
+	// ExtAuthzJWTNamespace is the filter metadata namespace of Envoy's
+	// jwt_authn filter.
+	ExtAuthzJWTNamespace = "envoy.filters.http.jwt_authn"
+)
+
+var (
+	_ http.Handler    = (*ExtAuthzServer)(nil)
+	_ ClaimsExtractor = (*ExtAuthzJWTClaimsExtractor)(nil)
+)
+
+type extAuthzMetadataKey struct{}
+
+// ExtAuthzRequest carries the attributes of an Envoy ext_authz v3
+// CheckRequest (attributes.request.http and attributes.source) that are
+// relevant for authorization. Headers are keyed by lowercase names as sent by
+// Envoy.
+type ExtAuthzRequest struct {
+	Method  string
+	Scheme  string
+	Host    string
+	Path    string
+	Headers map[string]string
+	Source  string
+	// Metadata is metadata_context.filter_metadata keyed by filter namespace,
+	// e.g. the verified JWT payload stored by jwt_authn.
+	Metadata map[string]any
+}
+
+// ExtAuthzResponse maps onto a CheckResponse: Code is the google.rpc.Status
+// code, HTTPStatus and Headers fill the OkHttpResponse or DeniedHttpResponse.
+type ExtAuthzResponse struct {
+	Code       uint32
+	HTTPStatus int
+	Headers    http.Header
+}
+
+func (r ExtAuthzResponse) Allowed() bool {
+	return r.Code == 0
+}
+
+// ExtAuthzServer runs an Authorizer as an Envoy external authorization
+// service. Check backs a gRPC Authorization server registered by the caller,
+// ServeHTTP serves Envoy's HTTP ext_authz mode.
+type ExtAuthzServer struct {
+	authorizer Authorizer
+	extractor  ClaimsExtractor
+	authorize  func(*http.Request) Decision
+}
+
+func NewExtAuthzServer(authorizer Authorizer, extractor ClaimsExtractor) *ExtAuthzServer {
+	return &ExtAuthzServer{
+		authorizer: authorizer,
+		extractor:  extractor,
+		authorize:  RequestAuthorizer(authorizer),
+	}
+}
+
+func (s *ExtAuthzServer) SetActions(actions func(*http.Request) []string) *ExtAuthzServer {
+	s.authorize = RequestAuthorizer(s.authorizer, WithActions(actions))
+	return s
+}
+
+func (s *ExtAuthzServer) Check(ctx context.Context, req ExtAuthzRequest) ExtAuthzResponse {
+	u, err := url.ParseRequestURI(req.Path)
+	if err != nil {
+		return extAuthzDenied(AuthzPolicyError)
+	}
+	u.Scheme, u.Host = req.Scheme, req.Host
+
+	r := (&http.Request{
+		Method:     req.Method,
+		URL:        u,
+		Host:       req.Host,
+		RequestURI: req.Path,
+		RemoteAddr: req.Source,
+		Header:     make(http.Header, len(req.Headers)),
+	}).WithContext(context.WithValue(ctx, extAuthzMetadataKey{}, req.Metadata))
+	for key, value := range req.Headers {
+		r.Header.Set(key, value)
+	}
+	return s.check(r)
+}
+
+func (s *ExtAuthzServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	res := s.check(r)
+	for key, values := range res.Headers {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(res.HTTPStatus)
+}
+
+func (s *ExtAuthzServer) check(r *http.Request) ExtAuthzResponse {
+	claims, err := s.extractor.ExtractClaims(r)
+	if err != nil {
+		return extAuthzDenied(AuthzUnauthenticated)
+	}
+	if claims != nil {
+		r = r.WithContext(WithClaims(r.Context(), claims))
+	}
+
+	if d := s.authorize(r); !d.Allowed() {
+		if claims == nil {
+			return extAuthzDenied(AuthzUnauthenticated)
+		}
+		return extAuthzDenied(AuthzForbidden)
+	}
+
+	res := ExtAuthzResponse{HTTPStatus: http.StatusOK, Headers: http.Header{}}
+	if claims == nil {
+		return res
+	}
+	if id := SubjectID(claims.Subject); id != "" {
+		res.Headers.Set(HeaderAuthzSubject, id)
+	}
+	if a, ok := s.authorizer.(TTLAuthorizer); ok {
+		WriteDecisionTTL(res.Headers, a.DecisionTTL(r.Context(), claims, nil))
+	}
+	return res
+}
+
+// CtxExtAuthzMetadata returns the filter metadata of the CheckRequest being
+// served by ExtAuthzServer.Check.
+func CtxExtAuthzMetadata(ctx context.Context) map[string]any {
+	metadata, _ := ctx.Value(extAuthzMetadataKey{}).(map[string]any)
+	return metadata
+}
+
+// ExtAuthzJWTClaimsExtractor builds claims from the JWT payload Envoy's
+// jwt_authn filter already verified and stored with payload_in_metadata. The
+// subject is read from "sub", roles from RolesClaim, a dot separated path
+// such as "realm_access.roles".
+type ExtAuthzJWTClaimsExtractor struct {
+	Namespace  string
+	PayloadKey string
+	RolesClaim string
+}
+
+func NewExtAuthzJWTClaimsExtractor(payloadKey, rolesClaim string) *ExtAuthzJWTClaimsExtractor {
+	return &ExtAuthzJWTClaimsExtractor{Namespace: ExtAuthzJWTNamespace, PayloadKey: payloadKey, RolesClaim: rolesClaim}
+}
+
+func (e *ExtAuthzJWTClaimsExtractor) ExtractClaims(r *http.Request) (*Claims, error) {
+	namespace, _ := CtxExtAuthzMetadata(r.Context())[e.Namespace].(map[string]any)
+	payload, ok := namespace[e.PayloadKey].(map[string]any)
+	if !ok {
+		return nil, nil
+	}
+
+	id, _ := payload["sub"].(string)
+	roles := metadataStrings(claimPath(payload, e.RolesClaim))
+
+	return &Claims{Subject: NewSubject(id, roles...), Metadata: payload}, nil
+}
+
+func extAuthzDenied(kind AuthzKind) ExtAuthzResponse {
+	err := &AuthzError{Kind: kind}
+	return ExtAuthzResponse{Code: err.GRPCCode(), HTTPStatus: err.HTTPStatus(), Headers: http.Header{}}
+}
@@ -0,0 +1,120 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newExtAuthzServer(t *testing.T) *ExtAuthzServer {
+	rbac := New()
+	user := NewRole("user")
+	user.AddPermissions("GET /api/posts")
+	require.NoError(t, rbac.AddRole(user))
+
+	authorizer := NewDefaultAuthorizer(rbac).SetMaxTTL(time.Minute)
+	return NewExtAuthzServer(authorizer, &HeaderClaimsExtractor{SubjectHeader: "X-User", RolesHeader: "X-Roles"})
+}
+
+func TestExtAuthzServer_Check(t *testing.T) {
+	s := newExtAuthzServer(t)
+
+	res := s.Check(context.Background(), ExtAuthzRequest{
+		Method:  http.MethodGet,
+		Host:    "example.com",
+		Path:    "/api/posts?page=2",
+		Headers: map[string]string{"x-user": "u1", "x-roles": "user"},
+	})
+	assert.True(t, res.Allowed())
+	assert.Equal(t, http.StatusOK, res.HTTPStatus)
+	assert.Equal(t, "u1", res.Headers.Get(HeaderAuthzSubject))
+	assert.Equal(t, "60", res.Headers.Get(HeaderDecisionTTL))
+
+	res = s.Check(context.Background(), ExtAuthzRequest{
+		Method:  http.MethodPost,
+		Path:    "/api/posts",
+		Headers: map[string]string{"x-user": "u1", "x-roles": "user"},
+	})
+	assert.False(t, res.Allowed())
+	assert.Equal(t, uint32(7), res.Code)
+	assert.Equal(t, http.StatusForbidden, res.HTTPStatus)
+
+	res = s.Check(context.Background(), ExtAuthzRequest{Method: http.MethodGet, Path: "/api/posts"})
+	assert.Equal(t, uint32(16), res.Code)
+	assert.Equal(t, http.StatusUnauthorized, res.HTTPStatus)
+
+	res = s.Check(context.Background(), ExtAuthzRequest{Method: http.MethodGet, Path: "api"})
+	assert.Equal(t, uint32(13), res.Code)
+
+	s.SetActions(func(r *http.Request) []string { return []string{"GET /api/posts"} })
+	res = s.Check(context.Background(), ExtAuthzRequest{
+		Method:  http.MethodDelete,
+		Path:    "/anything",
+		Headers: map[string]string{"x-roles": "user"},
+	})
+	assert.True(t, res.Allowed())
+	assert.Empty(t, res.Headers.Get(HeaderAuthzSubject))
+}
+
+func TestExtAuthzServer_ServeHTTP(t *testing.T) {
+	s := newExtAuthzServer(t)
+
+	r := httptest.NewRequest(http.MethodGet, "/api/posts", nil)
+	r.Header.Set("X-User", "u1")
+	r.Header.Set("X-Roles", "user")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "u1", w.Header().Get(HeaderAuthzSubject))
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/posts", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	s = NewExtAuthzServer(&mockAuthorizer{decision: DecisionAllow}, ClaimsExtractorFunc(func(*http.Request) (*Claims, error) {
+		return nil, ErrInvalidCredentials
+	}))
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/posts", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestExtAuthzJWTClaimsExtractor(t *testing.T) {
+	rbac := New()
+	admin := NewRole("admin")
+	require.NoError(t, admin.AddPermissionsE("*"))
+	require.NoError(t, rbac.AddRole(admin))
+
+	s := NewExtAuthzServer(NewDefaultAuthorizer(rbac), NewExtAuthzJWTClaimsExtractor("jwt_payload", "realm_access.roles"))
+
+	res := s.Check(context.Background(), ExtAuthzRequest{
+		Method: http.MethodGet,
+		Path:   "/api/posts",
+		Metadata: map[string]any{
+			ExtAuthzJWTNamespace: map[string]any{
+				"jwt_payload": map[string]any{
+					"sub":          "u1",
+					"realm_access": map[string]any{"roles": []any{"admin"}},
+				},
+			},
+		},
+	})
+	assert.True(t, res.Allowed())
+	assert.Equal(t, "u1", res.Headers.Get(HeaderAuthzSubject))
+
+	res = s.Check(context.Background(), ExtAuthzRequest{Method: http.MethodGet, Path: "/api/posts"})
+	assert.Equal(t, http.StatusUnauthorized, res.HTTPStatus)
+}
+
+func TestClaimPath(t *testing.T) {
+	claims := map[string]any{"roles": []any{"a"}, "realm_access": map[string]any{"roles": []any{"b"}}}
+	assert.Equal(t, []any{"a"}, claimPath(claims, "roles"))
+	assert.Equal(t, []any{"b"}, claimPath(claims, "realm_access.roles"))
+	assert.Nil(t, claimPath(claims, "roles.nested"))
+	assert.Nil(t, claimPath(claims, "missing"))
+}
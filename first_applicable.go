@@ -0,0 +1,24 @@
+package rbac
+
+import "context"
+
+var _ Authorizer = (*FirstApplicableAuthorizer)(nil)
+
+// FirstApplicableAuthorizer consults the wrapped authorizers in order and
+// returns the first decision that is not DecisionAbstain.
+type FirstApplicableAuthorizer struct {
+	authorizers []Authorizer
+}
+
+func NewFirstApplicableAuthorizer(authorizers ...Authorizer) *FirstApplicableAuthorizer {
+	return &FirstApplicableAuthorizer{authorizers: authorizers}
+}
+
+func (a *FirstApplicableAuthorizer) Authorize(ctx context.Context, claims *Claims, target *Target) Decision {
+	for _, authorizer := range a.authorizers {
+		if d := authorizer.Authorize(ctx, claims, target); d != DecisionAbstain {
+			return d
+		}
+	}
+	return DecisionAbstain
+}
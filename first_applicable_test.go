@@ -0,0 +1,29 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFirstApplicableAuthorizer(t *testing.T) {
+	abstain := &mockAuthorizer{decision: DecisionAbstain}
+	deny := &mockAuthorizer{decision: DecisionDeny}
+	allow := &mockAuthorizer{decision: DecisionAllow}
+	claims := &Claims{Subject: NewSubject("u1", "user")}
+	target := &Target{Action: "read"}
+
+	assert.Equal(t, DecisionAllow, NewFirstApplicableAuthorizer(abstain, allow, deny).Authorize(context.Background(), claims, target))
+	assert.Equal(t, DecisionDeny, NewFirstApplicableAuthorizer(deny, allow).Authorize(context.Background(), claims, target))
+	assert.Equal(t, DecisionAbstain, NewFirstApplicableAuthorizer(abstain).Authorize(context.Background(), claims, target))
+	assert.Equal(t, DecisionAbstain, NewFirstApplicableAuthorizer().Authorize(context.Background(), claims, target))
+
+	rbac := New()
+	role := NewRole("user")
+	role.AddPermissions("read")
+	_ = rbac.AddRole(role)
+	tenant := NewDefaultAuthorizer(New()).SetAbstain(true)
+	global := NewDefaultAuthorizer(rbac)
+	assert.Equal(t, DecisionAllow, NewFirstApplicableAuthorizer(tenant, global).Authorize(context.Background(), claims, target))
+}
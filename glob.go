@@ -0,0 +1,102 @@
+package rbac
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// globMatcher matches actions against a glob permission: "*" matches any run
+// of characters except "/", "**" also matches "/" and "?" matches a single
+// character except "/".
+type globMatcher string
+
+// compileGlob returns nil for permissions without wildcards, which match
+// literally.
+func compileGlob(permission string) permissionMatcher {
+	if !strings.ContainsAny(permission, "*?") {
+		return nil
+	}
+	return globMatcher(permission)
+}
+
+func (g globMatcher) MatchString(s string) bool {
+	return globMatch(string(g), s)
+}
+
+// globRegexp translates a glob into an equivalent regular expression.
+func globRegexp(pattern string) string {
+	var b strings.Builder
+	for pattern != "" {
+		switch {
+		case strings.HasPrefix(pattern, "**"):
+			pattern = strings.TrimLeft(pattern, "*")
+			b.WriteString(".*")
+		case pattern[0] == '*':
+			pattern = pattern[1:]
+			b.WriteString("[^/]*")
+		case pattern[0] == '?':
+			pattern = pattern[1:]
+			b.WriteString("[^/]")
+		default:
+			n := strings.IndexAny(pattern, "*?")
+			if n < 0 {
+				n = len(pattern)
+			}
+			b.WriteString(regexp.QuoteMeta(pattern[:n]))
+			pattern = pattern[n:]
+		}
+	}
+	return b.String()
+}
+
+// globMatch tracks the positions of s reachable after each pattern token, so
+// matching takes O(len(pattern)*len(s)) whatever the wildcards.
+func globMatch(pattern, s string) bool {
+	cur, next := make([]bool, len(s)+1), make([]bool, len(s)+1)
+	cur[0] = true
+	for pattern != "" {
+		clear(next)
+		reachable := false
+		switch {
+		case strings.HasPrefix(pattern, "**"):
+			pattern = strings.TrimLeft(pattern, "*")
+			for i := range next {
+				reachable = reachable || cur[i]
+				next[i] = reachable
+			}
+		case pattern[0] == '*':
+			pattern = pattern[1:]
+			run := false
+			for i := range next {
+				run = run || cur[i]
+				next[i] = run
+				reachable = reachable || run
+				if i < len(s) && s[i] == '/' {
+					run = false
+				}
+			}
+		case pattern[0] == '?':
+			pattern = pattern[1:]
+			for i := range len(s) {
+				if cur[i] && s[i] != '/' {
+					_, size := utf8.DecodeRuneInString(s[i:])
+					next[i+size], reachable = true, true
+				}
+			}
+		default:
+			c := pattern[0]
+			pattern = pattern[1:]
+			for i := range len(s) {
+				if cur[i] && s[i] == c {
+					next[i+1], reachable = true, true
+				}
+			}
+		}
+		if !reachable {
+			return false
+		}
+		cur, next = next, cur
+	}
+	return cur[len(s)]
+}
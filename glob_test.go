@@ -0,0 +1,86 @@
+package rbac
+
+import (
+	"math/rand/v2"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobMatcher(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		action  string
+		match   bool
+	}{
+		{"posts:*", "posts:read", true},
+		{"posts:*", "posts:", true},
+		{"posts:*", "comments:read", false},
+		{"GET /api/users/*", "GET /api/users/1", true},
+		{"GET /api/users/*", "GET /api/users/1/posts", false},
+		{"GET /api/users/**", "GET /api/users/1/posts", true},
+		{"GET /api/users/**", "GET /api/users", false},
+		{"* /api/users", "DELETE /api/users", true},
+		{"/api/**/edit", "/api/posts/1/edit", true},
+		{"/api/**/edit", "/api/posts/1/view", false},
+		{"post:?", "post:ü", true},
+		{"post:?", "post:12", false},
+		{"a?c", "a/c", false},
+		{"**", "anything/at/all", true},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxb/yyc", false},
+		{"a**b*c", "ax/xbyyc", true},
+		{"*/*", "a/b", true},
+		{"*/*", "a/b/c", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pattern+" "+tc.action, func(t *testing.T) {
+			assert.Equal(t, tc.match, compileGlob(tc.pattern).MatchString(tc.action))
+		})
+	}
+
+	assert.Nil(t, compileGlob("posts:read"))
+}
+
+func TestGlobMatch_Pathological(t *testing.T) {
+	s := "GET " + strings.Repeat("a", 200)
+	started := time.Now()
+	assert.False(t, globMatch("GET **a**a**a**a**b", s))
+	assert.False(t, globMatch("GET *a*a*a*a*a*a*b", s))
+	assert.Less(t, time.Since(started), time.Second)
+}
+
+func TestGlobMatch_Regexp(t *testing.T) {
+	toRegexp := func(pattern string) *regexp.Regexp {
+		return regexp.MustCompile("^(?s:" + globRegexp(pattern) + ")$")
+	}
+	random := func(r *rand.Rand, alphabet string, n int) string {
+		b := make([]byte, r.IntN(n))
+		for i := range b {
+			b[i] = alphabet[r.IntN(len(alphabet))]
+		}
+		return string(b)
+	}
+
+	r := rand.New(rand.NewPCG(1, 2))
+	for range 5000 {
+		pattern, s := random(r, "ab/*?", 8), random(r, "ab/", 8)
+		assert.Equal(t, toRegexp(pattern).MatchString(s), globMatch(pattern, s), "%q %q", pattern, s)
+	}
+}
+
+func TestRole_AddGlobPermissions(t *testing.T) {
+	role := NewRole("foo")
+	assert.NoError(t, role.AddGlobPermissions("posts:*", "a.b"))
+	assert.True(t, role.HasPermission("posts:read"))
+	assert.True(t, role.HasPermission("a.b"))
+	assert.False(t, role.HasPermission("aXb"))
+
+	role.SetPermissionMatching(MatchGlob)
+	assert.NoError(t, role.AddPermissionsE("GET /api/**"))
+	assert.True(t, role.HasPermission("GET /api/users/1"))
+}
@@ -0,0 +1,60 @@
+package rbac
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteDOT renders the role hierarchy as a Graphviz digraph. Roles are nodes
+// labeled with their own permissions, edges point from a role to the
+// children whose permissions it inherits.
+func (rbac *RBAC) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("digraph rbac {\n\tnode [shape=box];\n")
+	for _, name := range sortedKeys(rbac.roles) {
+		label := strings.Join(append([]string{name}, sortedKeys(rbac.roles[name].permissions)...), "\n")
+		fmt.Fprintf(bw, "\t%s [label=%s];\n", strconv.Quote(name), strconv.Quote(label))
+	}
+	for _, name := range sortedKeys(rbac.roles) {
+		for _, child := range sortedKeys(rbac.roles[name].children) {
+			fmt.Fprintf(bw, "\t%s -> %s;\n", strconv.Quote(name), strconv.Quote(child))
+		}
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// WriteMermaid renders the role hierarchy as a Mermaid flowchart, see
+// WriteDOT.
+func (rbac *RBAC) WriteMermaid(w io.Writer) error {
+	names := sortedKeys(rbac.roles)
+	ids := make(map[string]string, len(names))
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("flowchart TD\n")
+	for i, name := range names {
+		ids[name] = "r" + strconv.Itoa(i)
+		lines := append([]string{name}, sortedKeys(rbac.roles[name].permissions)...)
+		for j, line := range lines {
+			lines[j] = mermaidEscape(line)
+		}
+		fmt.Fprintf(bw, "\t%s[\"%s\"]\n", ids[name], strings.Join(lines, "<br/>"))
+	}
+	for _, name := range names {
+		for _, child := range sortedKeys(rbac.roles[name].children) {
+			if id, ok := ids[child]; ok {
+				fmt.Fprintf(bw, "\t%s --> %s\n", ids[name], id)
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+var mermaidReplacer = strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;")
+
+func mermaidEscape(s string) string {
+	return mermaidReplacer.Replace(s)
+}
@@ -0,0 +1,48 @@
+package rbac
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGraphRBAC(t *testing.T) *RBAC {
+	t.Helper()
+
+	rbac := New()
+	admin, user := NewRole("admin"), NewRole(`user "basic"`)
+	require.NoError(t, admin.AddPermissionsE("users.*"))
+	require.NoError(t, user.AddPermissionsE("posts.read", "<html>"))
+	require.NoError(t, rbac.AddRole(user))
+	require.NoError(t, rbac.AddRole(admin))
+	require.NoError(t, admin.AddChild(user))
+	return rbac
+}
+
+func TestRBAC_WriteDOT(t *testing.T) {
+	var b strings.Builder
+	require.NoError(t, newGraphRBAC(t).WriteDOT(&b))
+	assert.Equal(t, `digraph rbac {
+	node [shape=box];
+	"admin" [label="admin\nusers.*"];
+	"user \"basic\"" [label="user \"basic\"\n<html>\nposts.read"];
+	"admin" -> "user \"basic\"";
+}
+`, b.String())
+}
+
+func TestRBAC_WriteMermaid(t *testing.T) {
+	var b strings.Builder
+	require.NoError(t, newGraphRBAC(t).WriteMermaid(&b))
+	assert.Equal(t, `flowchart TD
+	r0["admin<br/>users.*"]
+	r1["user #quot;basic#quot;<br/>#lt;html#gt;<br/>posts.read"]
+	r0 --> r1
+`, b.String())
+}
+
+func TestRBAC_WriteDOT_Error(t *testing.T) {
+	assert.Error(t, newGraphRBAC(t).WriteDOT(errWriter{}))
+}
@@ -0,0 +1,106 @@
+package rbac
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ImplicationConfig declares that holding Permission also grants the
+// permissions matching Implies, globs as with MatchGlob, e.g. "posts:write"
+// implying "posts:read" or "admin:*" implying "admin:**".
+type ImplicationConfig struct {
+	Permission string   `env:"PERMISSION" json:"permission,omitempty" yaml:"permission,omitempty"`
+	Implies    []string `env:"IMPLIES" json:"implies,omitempty" yaml:"implies,omitempty"`
+}
+
+// implications maps permissions onto the patterns they imply. It is shared
+// by the RBAC and its roles.
+type implications struct {
+	rules map[string][]string
+	// keys are the permissions of rules sorted, kept up to date by
+	// AddImplication rather than sorted on every check.
+	keys []string
+}
+
+// AddImplication makes roles holding permission, directly, through a pattern
+// or a child, also hold the permissions matching implied. Implications are
+// transitive.
+func (rbac *RBAC) AddImplication(permission string, implied ...string) *RBAC {
+	i := rbac.implications
+	if i.rules == nil {
+		i.rules = map[string][]string{}
+	}
+	if n, found := slices.BinarySearch(i.keys, permission); !found {
+		i.keys = slices.Insert(i.keys, n, permission)
+	}
+	i.rules[permission] = unite(i.rules[permission], implied)
+	return rbac
+}
+
+// Implications returns the implied patterns by permission.
+func (rbac *RBAC) Implications() map[string][]string {
+	implications := make(map[string][]string, len(rbac.implications.rules))
+	for permission, implied := range rbac.implications.rules {
+		implications[permission] = slices.Clone(implied)
+	}
+	return implications
+}
+
+// ImplyingPermissions returns the permissions directly implying permission.
+func (rbac *RBAC) ImplyingPermissions(permission string) []string {
+	return rbac.implications.implying(permission, false)
+}
+
+// implying returns the permissions implying permission, only those implying
+// it literally if literal is set.
+func (i *implications) implying(permission string, literal bool) []string {
+	if i == nil {
+		return nil
+	}
+	var implying []string
+	for _, from := range i.keys {
+		if from != permission && slices.ContainsFunc(i.rules[from], func(pattern string) bool {
+			return pattern == permission || !literal && impliedMatch(pattern, permission)
+		}) {
+			implying = append(implying, from)
+		}
+	}
+	return implying
+}
+
+func (i *implications) clone() *implications {
+	c := &implications{keys: slices.Clone(i.keys)}
+	if i.rules != nil {
+		c.rules = maps.Clone(i.rules)
+	}
+	return c
+}
+
+func impliedMatch(pattern, permission string) bool {
+	if pattern == permission {
+		return true
+	}
+	return strings.ContainsAny(pattern, "*?") && globMatch(pattern, permission)
+}
+
+// impliedPermission reports whether a permission implying permission is held.
+func (r *Role) impliedPermission(permission string, literal bool, seen map[string]struct{}) bool {
+	implying := r.implications.implying(permission, literal)
+	if len(implying) == 0 {
+		return false
+	}
+	if seen == nil {
+		seen = map[string]struct{}{}
+	}
+	seen[permission] = struct{}{}
+	for _, from := range implying {
+		if _, ok := seen[from]; ok {
+			continue
+		}
+		if r.holds(from, matchPartial) || r.impliedPermission(from, false, seen) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRBAC_AddImplication(t *testing.T) {
+	rbac := New().
+		AddImplication("posts:write", "posts:read").
+		AddImplication("posts:admin", "posts:write", "posts:delete").
+		AddImplication("admin:*", "admin:**").
+		AddImplication("a", "b").
+		AddImplication("b", "a")
+
+	writer, admin, owner := NewRole("writer"), NewRole("admin"), NewRole("owner")
+	require.NoError(t, rbac.AddRole(writer))
+	require.NoError(t, rbac.AddRole(owner))
+	require.NoError(t, rbac.AddRole(admin))
+	require.NoError(t, writer.AddPermissionsE("posts:write"))
+	require.NoError(t, admin.AddLiteralPermissions("admin:*"))
+	require.NoError(t, owner.AddChild(writer))
+
+	ctx := context.Background()
+	assert.True(t, rbac.IsGranted(ctx, "writer", "posts:read"))
+	assert.False(t, rbac.IsGranted(ctx, "writer", "posts:delete"))
+	assert.True(t, rbac.IsGranted(ctx, "owner", "posts:read"))
+	assert.True(t, rbac.IsGranted(ctx, "admin", "admin:users:delete"))
+	assert.False(t, rbac.IsGranted(ctx, "admin", "posts:read"))
+
+	require.NoError(t, admin.AddPermissionsE("posts:admin"))
+	assert.True(t, rbac.IsGranted(ctx, "admin", "posts:read"))
+
+	assert.False(t, rbac.IsGranted(ctx, "admin", "a"))
+
+	assert.Equal(t, []string{"posts:write"}, rbac.ImplyingPermissions("posts:read"))
+	assert.Equal(t, []string{"admin:*"}, rbac.ImplyingPermissions("admin:users"))
+	assert.Equal(t, []string{"posts:write", "posts:delete"}, rbac.Implications()["posts:admin"])
+
+	clone := rbac.clone()
+	rbac.AddImplication("posts:write", "comments:write")
+	assert.True(t, rbac.IsGranted(ctx, "writer", "comments:write"))
+	assert.False(t, clone.IsGranted(ctx, "writer", "comments:write"))
+
+	rbac.AddImplication("comments:admin", "posts:read")
+	assert.Equal(t, []string{"comments:admin", "posts:write"}, rbac.ImplyingPermissions("posts:read"))
+	assert.Equal(t, []string{"posts:write"}, clone.ImplyingPermissions("posts:read"))
+}
+
+const implicationConfig = `
+createMissingRoles: true
+implications:
+  - permission: posts:write
+    implies: [posts:read]
+accessControl:
+  - role: writer
+    permissions: [posts:write]
+`
+
+func TestConfig_Implications(t *testing.T) {
+	var cfg Config
+	require.NoError(t, yaml.Unmarshal([]byte(implicationConfig), &cfg))
+	require.NoError(t, cfg.Validate())
+
+	rbac, err := NewWithConfig(cfg)
+	require.NoError(t, err)
+	assert.True(t, rbac.IsGranted(context.Background(), "writer", "posts:read"))
+	assert.Equal(t, cfg.Implications, rbac.Export().Implications)
+
+	merged, err := cfg.Merge(Config{Implications: []ImplicationConfig{
+		{Permission: "posts:write", Implies: []string{"posts:read", "comments:read"}},
+		{Permission: "admin", Implies: []string{"**"}},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []ImplicationConfig{
+		{Permission: "posts:write", Implies: []string{"posts:read", "comments:read"}},
+		{Permission: "admin", Implies: []string{"**"}},
+	}, merged.Implications)
+
+	cfg.Implications = append(cfg.Implications, ImplicationConfig{Implies: []string{"x"}})
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidPermission)
+	assert.ErrorIs(t, New().Apply(cfg), ErrInvalidPermission)
+}
+
+func TestRBAC_AddImplication_Conditions(t *testing.T) {
+	rbac := New().AddImplication("posts:write", "posts:read")
+	editor, viewer := NewRole("editor"), NewRole("viewer")
+	require.NoError(t, rbac.AddRole(editor))
+	require.NoError(t, rbac.AddRole(viewer))
+	require.NoError(t, editor.AddPermissionsE("posts:write"))
+	editor.SetPermissionAssertions("posts:write", NewOwnershipAssertion(MetadataOwner))
+
+	claims := &Claims{Subject: NewSubject("42", "editor")}
+	ctx := WithClaims(context.Background(), claims)
+	own := WithTarget(ctx, &Target{Action: "posts:read", Metadata: map[string]any{MetadataOwner: "42"}})
+	other := WithTarget(ctx, &Target{Action: "posts:read", Metadata: map[string]any{MetadataOwner: "7"}})
+	assert.True(t, rbac.IsGranted(own, "editor", "posts:read"))
+	assert.False(t, rbac.IsGranted(other, "editor", "posts:read"))
+
+	require.NoError(t, editor.AddChild(viewer))
+	require.NoError(t, viewer.AddPermissionsE("posts:read"))
+	assert.True(t, rbac.IsGranted(other, "editor", "posts:read"))
+}
@@ -0,0 +1,248 @@
+package rbac
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrTokenExpired = errors.New("token expired")
+
+// JWTHeader is the decoded JOSE header of a token.
+type JWTHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// JWTOption configures the validation of ClaimsFromJWT.
+type JWTOption func(*jwtOptions)
+
+type jwtOptions struct {
+	algorithms []string
+	issuer     string
+	audience   []string
+}
+
+// WithJWTAlgorithms rejects tokens signed with other algorithms, e.g.
+// "RS256", any supported one if not set.
+func WithJWTAlgorithms(algorithms ...string) JWTOption {
+	return func(o *jwtOptions) {
+		o.algorithms = append(o.algorithms, algorithms...)
+	}
+}
+
+// WithJWTIssuer rejects tokens whose "iss" is not issuer.
+func WithJWTIssuer(issuer string) JWTOption {
+	return func(o *jwtOptions) {
+		o.issuer = issuer
+	}
+}
+
+// WithJWTAudience rejects tokens whose "aud" contains none of audience.
+func WithJWTAudience(audience ...string) JWTOption {
+	return func(o *jwtOptions) {
+		o.audience = append(o.audience, audience...)
+	}
+}
+
+// JWTKeyfunc returns the key verifying a token: []byte for HS*, an
+// *rsa.PublicKey for RS* and PS*, an *ecdsa.PublicKey for ES* and an
+// ed25519.PublicKey for EdDSA. Returning an error rejects the token.
+type JWTKeyfunc func(ctx context.Context, header JWTHeader) (any, error)
+
+// ClaimsFromJWT verifies a compact JWS token and builds claims with the
+// identifier from "sub" and roles from rolesClaim, a dot separated path such
+// as "realm_access.roles". The payload becomes the claims metadata. Tokens
+// past "exp" or before "nbf", or with non-numeric ones, are rejected.
+func ClaimsFromJWT(ctx context.Context, token string, keyfunc JWTKeyfunc, rolesClaim string, opts ...JWTOption) (*Claims, error) {
+	var o jwtOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+
+	var header JWTHeader
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidCredentials)
+	}
+
+	if len(o.algorithms) > 0 && !slices.Contains(o.algorithms, header.Alg) {
+		return nil, fmt.Errorf(`%w: algorithm "%s" not allowed`, ErrInvalidCredentials, header.Alg)
+	}
+
+	key, err := keyfunc(ctx, header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+	if err = verifyJWT(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var payload map[string]any
+	if err = decodeJWTSegment(parts[1], &payload); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	exp, err := numericDate(payload, "exp")
+	if err != nil {
+		return nil, err
+	}
+	if !exp.IsZero() && !now.Before(exp) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredentials, ErrTokenExpired)
+	}
+	nbf, err := numericDate(payload, "nbf")
+	if err != nil {
+		return nil, err
+	}
+	if now.Before(nbf) {
+		return nil, fmt.Errorf("%w: token not valid yet", ErrInvalidCredentials)
+	}
+
+	if iss, _ := payload["iss"].(string); o.issuer != "" && iss != o.issuer {
+		return nil, fmt.Errorf(`%w: unexpected issuer "%s"`, ErrInvalidCredentials, iss)
+	}
+	if len(o.audience) > 0 && !slices.ContainsFunc(metadataStrings(payload["aud"]), func(aud string) bool {
+		return slices.Contains(o.audience, aud)
+	}) {
+		return nil, fmt.Errorf("%w: token not issued for this audience", ErrInvalidCredentials)
+	}
+
+	id, _ := payload["sub"].(string)
+	roles := metadataStrings(claimPath(payload, rolesClaim))
+
+	return &Claims{Subject: NewSubject(id, roles...), Metadata: payload}, nil
+}
+
+// JWTClaimsExtractor reads bearer tokens with ClaimsFromJWT.
+func JWTClaimsExtractor(keyfunc JWTKeyfunc, rolesClaim string, opts ...JWTOption) *HeaderClaimsExtractor {
+	return &HeaderClaimsExtractor{VerifyToken: func(ctx context.Context, token string) (*Claims, error) {
+		return ClaimsFromJWT(ctx, token, keyfunc, rolesClaim, opts...)
+	}}
+}
+
+// JWTMiddleware installs the claims of a verified bearer token into the
+// request context, see ClaimsMiddleware.
+func JWTMiddleware(keyfunc JWTKeyfunc, rolesClaim string, opts ...JWTOption) func(http.Handler) http.Handler {
+	return ClaimsMiddleware(JWTClaimsExtractor(keyfunc, rolesClaim, opts...))
+}
+
+// numericDate returns the time of a NumericDate claim, zero if absent.
+func numericDate(payload map[string]any, name string) (time.Time, error) {
+	value, ok := payload[name]
+	if !ok || value == nil {
+		return time.Time{}, nil
+	}
+	seconds, ok := value.(float64)
+	if !ok {
+		return time.Time{}, fmt.Errorf(`%w: "%s" is not a number`, ErrInvalidCredentials, name)
+	}
+	return time.Unix(int64(seconds), 0), nil
+}
+
+func decodeJWTSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: malformed token: %w", ErrInvalidCredentials, err)
+	}
+	return nil
+}
+
+func verifyJWT(alg string, key any, signed string, signature []byte) error {
+	var hash crypto.Hash
+	switch alg[min(2, len(alg)):] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	}
+
+	var ok bool
+	switch {
+	case alg == "EdDSA":
+		if key, isKey := key.(ed25519.PublicKey); isKey {
+			ok = ed25519.Verify(key, []byte(signed), signature)
+		}
+	case hash == 0:
+	case strings.HasPrefix(alg, "HS"):
+		if key, isKey := key.([]byte); isKey {
+			mac := hmac.New(hash.New, key)
+			mac.Write([]byte(signed))
+			ok = hmac.Equal(mac.Sum(nil), signature)
+		}
+	case strings.HasPrefix(alg, "RS"):
+		if key, isKey := key.(*rsa.PublicKey); isKey {
+			ok = rsa.VerifyPKCS1v15(key, hash, digest(hash, signed), signature) == nil
+		}
+	case strings.HasPrefix(alg, "PS"):
+		if key, isKey := key.(*rsa.PublicKey); isKey {
+			ok = rsa.VerifyPSS(key, hash, digest(hash, signed), signature, nil) == nil
+		}
+	case strings.HasPrefix(alg, "ES"):
+		if key, isKey := key.(*ecdsa.PublicKey); isKey && ecdsaAlg(key) == alg {
+			half := (key.Curve.Params().BitSize + 7) / 8
+			if len(signature) != 2*half {
+				break
+			}
+			r, s := new(big.Int).SetBytes(signature[:half]), new(big.Int).SetBytes(signature[half:])
+			ok = ecdsa.Verify(key, digest(hash, signed), r, s)
+		}
+	}
+
+	if !ok {
+		return fmt.Errorf(`%w: invalid "%s" signature`, ErrInvalidCredentials, alg)
+	}
+	return nil
+}
+
+// ecdsaAlg returns the ES algorithm of the key's curve, see RFC 7518 3.4.
+func ecdsaAlg(key *ecdsa.PublicKey) string {
+	if key.Curve == nil {
+		return ""
+	}
+	switch key.Curve.Params().BitSize {
+	case 256:
+		return "ES256"
+	case 384:
+		return "ES384"
+	case 521:
+		return "ES512"
+	}
+	return ""
+}
+
+func digest(hash crypto.Hash, signed string) []byte {
+	h := hash.New()
+	h.Write([]byte(signed))
+	return h.Sum(nil)
+}
@@ -0,0 +1,222 @@
+package rbac
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func signJWT(t *testing.T, alg string, key any, payload map[string]any) string {
+	t.Helper()
+
+	header, err := json.Marshal(JWTHeader{Alg: alg, Typ: "JWT"})
+	require.NoError(t, err)
+	body, err := json.Marshal(payload)
+	require.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+
+	var signature []byte
+	sum := sha256.Sum256([]byte(signed))
+	switch key := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, sum[:])
+		require.NoError(t, err)
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(key, []byte(signed))
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func staticKey(key any) JWTKeyfunc {
+	return func(context.Context, JWTHeader) (any, error) {
+		return key, nil
+	}
+}
+
+func TestClaimsFromJWT(t *testing.T) {
+	secret := []byte("secret")
+	payload := map[string]any{
+		"sub":          "u1",
+		"realm_access": map[string]any{"roles": []any{"admin", "user"}},
+		"exp":          time.Now().Add(time.Hour).Unix(),
+	}
+
+	claims, err := ClaimsFromJWT(context.Background(), signJWT(t, "HS256", secret, payload), staticKey(secret), "realm_access.roles")
+	require.NoError(t, err)
+	assert.Equal(t, "u1", SubjectID(claims.Subject))
+	assert.Equal(t, []string{"admin", "user"}, claims.Subject.Roles())
+	assert.Equal(t, "u1", claims.Metadata["sub"])
+
+	_, err = ClaimsFromJWT(context.Background(), signJWT(t, "HS256", []byte("other"), payload), staticKey(secret), "roles")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = ClaimsFromJWT(context.Background(), signJWT(t, "none", secret, payload), staticKey(secret), "roles")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = ClaimsFromJWT(context.Background(), "a.b", staticKey(secret), "roles")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	expired := map[string]any{"sub": "u1", "exp": time.Now().Add(-time.Minute).Unix()}
+	_, err = ClaimsFromJWT(context.Background(), signJWT(t, "HS256", secret, expired), staticKey(secret), "roles")
+	assert.ErrorIs(t, err, ErrTokenExpired)
+
+	early := map[string]any{"sub": "u1", "nbf": time.Now().Add(time.Hour).Unix()}
+	_, err = ClaimsFromJWT(context.Background(), signJWT(t, "HS256", secret, early), staticKey(secret), "roles")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	keyErr := errors.New("unknown kid")
+	_, err = ClaimsFromJWT(context.Background(), signJWT(t, "HS256", secret, payload), func(context.Context, JWTHeader) (any, error) {
+		return nil, keyErr
+	}, "roles")
+	assert.ErrorIs(t, err, keyErr)
+}
+
+func TestClaimsFromJWT_Algorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	payload := map[string]any{"sub": "u1", "roles": "admin"}
+	for _, tc := range []struct {
+		alg         string
+		signKey     any
+		verifyKey   any
+		confusedKey any
+	}{
+		{"RS256", rsaKey, &rsaKey.PublicKey, []byte("secret")},
+		{"ES256", ecKey, &ecKey.PublicKey, &rsaKey.PublicKey},
+		{"EdDSA", edKey, edPub, &ecKey.PublicKey},
+	} {
+		t.Run(tc.alg, func(t *testing.T) {
+			token := signJWT(t, tc.alg, tc.signKey, payload)
+
+			claims, err := ClaimsFromJWT(context.Background(), token, staticKey(tc.verifyKey), "roles")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"admin"}, claims.Subject.Roles())
+
+			_, err = ClaimsFromJWT(context.Background(), token, staticKey(tc.confusedKey), "roles")
+			assert.ErrorIs(t, err, ErrInvalidCredentials)
+		})
+	}
+}
+
+func TestClaimsFromJWT_Options(t *testing.T) {
+	secret := []byte("secret")
+	ctx := context.Background()
+	payload := map[string]any{"sub": "u1", "iss": "https://issuer.example", "aud": []any{"api", "web"}}
+	token := signJWT(t, "HS256", secret, payload)
+
+	_, err := ClaimsFromJWT(ctx, token, staticKey(secret), "roles",
+		WithJWTAlgorithms("RS256", "HS256"), WithJWTIssuer("https://issuer.example"), WithJWTAudience("web"))
+	require.NoError(t, err)
+
+	_, err = ClaimsFromJWT(ctx, token, staticKey(secret), "roles", WithJWTAlgorithms("RS256"))
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	assert.ErrorContains(t, err, "not allowed")
+
+	_, err = ClaimsFromJWT(ctx, token, staticKey(secret), "roles", WithJWTIssuer("https://evil.example"))
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = ClaimsFromJWT(ctx, token, staticKey(secret), "roles", WithJWTAudience("admin"))
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	noAud := signJWT(t, "HS256", secret, map[string]any{"sub": "u1"})
+	_, err = ClaimsFromJWT(ctx, noAud, staticKey(secret), "roles", WithJWTAudience("web"))
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestClaimsFromJWT_NonNumericDates(t *testing.T) {
+	secret := []byte("secret")
+	for _, payload := range []map[string]any{
+		{"sub": "u1", "exp": "2000-01-01T00:00:00Z"},
+		{"sub": "u1", "exp": true},
+		{"sub": "u1", "nbf": "later"},
+	} {
+		_, err := ClaimsFromJWT(context.Background(), signJWT(t, "HS256", secret, payload), staticKey(secret), "roles")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		assert.ErrorContains(t, err, "not a number")
+	}
+}
+
+func TestClaimsFromJWT_ECDSACurve(t *testing.T) {
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	payload := map[string]any{"sub": "u1"}
+	token := signJWT(t, "ES256", p256, payload)
+	_, err = ClaimsFromJWT(context.Background(), token, staticKey(&p256.PublicKey), "roles")
+	require.NoError(t, err)
+
+	// a P-384 key does not verify ES256
+	_, err = ClaimsFromJWT(context.Background(), token, staticKey(&p384.PublicKey), "roles")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	// the signature must be exactly twice the curve size
+	parts := strings.Split(token, ".")
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	half := len(signature) / 2
+	padded := append(append([]byte{0}, signature[:half]...), append([]byte{0}, signature[half:]...)...)
+	parts[2] = base64.RawURLEncoding.EncodeToString(padded)
+	_, err = ClaimsFromJWT(context.Background(), strings.Join(parts, "."), staticKey(&p256.PublicKey), "roles")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	// ES384 names a curve other than the key's
+	header, _ := json.Marshal(JWTHeader{Alg: "ES384"})
+	parts = strings.Split(token, ".")
+	parts[0] = base64.RawURLEncoding.EncodeToString(header)
+	_, err = ClaimsFromJWT(context.Background(), strings.Join(parts, "."), staticKey(&p256.PublicKey), "roles")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestJWTMiddleware(t *testing.T) {
+	secret := []byte("secret")
+	var claims *Claims
+	h := JWTMiddleware(staticKey(secret), "roles")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims = CtxClaims(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+signJWT(t, "HS256", secret, map[string]any{"sub": "u1", "roles": []any{"user"}}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, claims)
+	assert.Equal(t, []string{"user"}, claims.Subject.Roles())
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer invalid")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
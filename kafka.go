@@ -0,0 +1,147 @@
+package rbac
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	KafkaTopic = "topic"
+	KafkaGroup = "group"
+)
+
+const (
+	KafkaRead     = "read"
+	KafkaWrite    = "write"
+	KafkaCreate   = "create"
+	KafkaDelete   = "delete"
+	KafkaAlter    = "alter"
+	KafkaDescribe = "describe"
+)
+
+const (
+	KafkaPatternLiteral  = "LITERAL"
+	KafkaPatternPrefixed = "PREFIXED"
+)
+
+// KafkaActions returns "kafka:operation:type:name" for the resource itself
+// and every prefix wildcard covering it, e.g. "kafka:write:topic:orders-*".
+// They are meant to be checked with Target.Literal, so the trailing "*" is a
+// prefix wildcard rather than a regular expression.
+func KafkaActions(operation, resourceType, name string) []string {
+	if name == "" {
+		return nil
+	}
+
+	prefix := fmt.Sprintf("kafka:%s:%s:", operation, resourceType)
+	actions := make([]string, 0, len(name)+1)
+	actions = append(actions, prefix+name)
+	for i := len(name) - 1; i >= 0; i-- {
+		actions = append(actions, prefix+name[:i]+"*")
+	}
+	return actions
+}
+
+type KafkaAuthorizer struct {
+	authorizer Authorizer
+}
+
+func NewKafkaAuthorizer(authorizer Authorizer) *KafkaAuthorizer {
+	return &KafkaAuthorizer{authorizer: authorizer}
+}
+
+// Authorize allows if a permission equal to one of the KafkaActions of the
+// resource is granted.
+func (k *KafkaAuthorizer) Authorize(ctx context.Context, claims *Claims, operation, resourceType, name string) Decision {
+	target := &Target{Literal: true}
+	for _, action := range KafkaActions(operation, resourceType, name) {
+		target.Action = action
+		if d := k.authorizer.Authorize(ctx, claims, target); d.Allowed() {
+			return d
+		}
+	}
+	return DecisionDeny
+}
+
+func (k *KafkaAuthorizer) CanProduce(ctx context.Context, claims *Claims, topic string) bool {
+	return k.Authorize(ctx, claims, KafkaWrite, KafkaTopic, topic).Allowed()
+}
+
+func (k *KafkaAuthorizer) CanConsume(ctx context.Context, claims *Claims, topic, group string) bool {
+	return k.Authorize(ctx, claims, KafkaRead, KafkaTopic, topic).Allowed() &&
+		k.Authorize(ctx, claims, KafkaRead, KafkaGroup, group).Allowed()
+}
+
+func (k *KafkaAuthorizer) CanAlter(ctx context.Context, claims *Claims, resourceType, name string) bool {
+	return k.Authorize(ctx, claims, KafkaAlter, resourceType, name).Allowed()
+}
+
+type KafkaACL struct {
+	Principal    string
+	Operation    string
+	ResourceType string
+	ResourceName string
+	PatternType  string
+}
+
+func (acl KafkaACL) String() string {
+	return fmt.Sprintf("%s ALLOW %s on %s:%s:%s", acl.Principal, acl.Operation, acl.ResourceType, acl.PatternType, acl.ResourceName)
+}
+
+// KafkaACLs renders the kafka permissions of every role, including inherited
+// ones, as Kafka ACL definitions with "Role:<name>" principals.
+func KafkaACLs(rbac *RBAC) []KafkaACL {
+	var acls []KafkaACL
+	for role := range rbac.Roles() {
+		seen := map[KafkaACL]struct{}{}
+		for permission := range role.Permissions(true) {
+			acl, ok := parseKafkaPermission(permission)
+			if !ok {
+				continue
+			}
+			acl.Principal = "Role:" + role.Name()
+			if _, ok = seen[acl]; !ok {
+				seen[acl] = struct{}{}
+				acls = append(acls, acl)
+			}
+		}
+	}
+
+	slices.SortFunc(acls, func(a, b KafkaACL) int {
+		return cmp.Or(
+			cmp.Compare(a.Principal, b.Principal),
+			cmp.Compare(a.ResourceType, b.ResourceType),
+			cmp.Compare(a.ResourceName, b.ResourceName),
+			cmp.Compare(a.Operation, b.Operation),
+		)
+	})
+	return acls
+}
+
+func parseKafkaPermission(permission string) (acl KafkaACL, ok bool) {
+	parts := strings.SplitN(permission, ":", 4)
+	if len(parts) != 4 || parts[0] != "kafka" || parts[3] == "" {
+		return acl, false
+	}
+
+	acl.Operation = kafkaTitle(parts[1])
+	acl.ResourceType = kafkaTitle(parts[2])
+	acl.ResourceName = parts[3]
+	acl.PatternType = KafkaPatternLiteral
+
+	if name, found := strings.CutSuffix(acl.ResourceName, "*"); found && name != "" {
+		acl.ResourceName = name
+		acl.PatternType = KafkaPatternPrefixed
+	}
+	return acl, true
+}
+
+func kafkaTitle(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,66 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKafkaActions(t *testing.T) {
+	assert.Nil(t, KafkaActions(KafkaRead, KafkaTopic, ""))
+	assert.Equal(t, []string{
+		"kafka:read:topic:abc",
+		"kafka:read:topic:ab*",
+		"kafka:read:topic:a*",
+		"kafka:read:topic:*",
+	}, KafkaActions(KafkaRead, KafkaTopic, "abc"))
+}
+
+func TestKafkaAuthorizer(t *testing.T) {
+	rbac := New()
+	producer := NewRole("producer")
+	producer.AddPermissions("kafka:write:topic:orders-*")
+	consumer := NewRole("consumer")
+	consumer.AddPermissions("kafka:read:topic:orders-eu", "kafka:read:group:billing")
+	assert.NoError(t, rbac.AddRole(producer))
+	assert.NoError(t, rbac.AddRole(consumer))
+
+	k := NewKafkaAuthorizer(NewDefaultAuthorizer(rbac))
+	ctx := context.Background()
+
+	p := &Claims{Subject: &testSubject{roles: []string{"producer"}}}
+	assert.True(t, k.CanProduce(ctx, p, "orders-eu"))
+	assert.False(t, k.CanProduce(ctx, p, "payments"))
+	assert.False(t, k.CanProduce(ctx, p, "orders"))
+	assert.False(t, k.CanProduce(ctx, p, "ordersevil"))
+	assert.False(t, k.CanProduce(ctx, p, "orders_eu"))
+	assert.False(t, k.CanProduce(ctx, p, "eu-orders-eu"))
+	assert.False(t, k.CanConsume(ctx, p, "orders-eu", "billing"))
+
+	c := &Claims{Subject: &testSubject{roles: []string{"consumer"}}}
+	assert.True(t, k.CanConsume(ctx, c, "orders-eu", "billing"))
+	assert.False(t, k.CanConsume(ctx, c, "orders-eu", "other"))
+	assert.False(t, k.CanConsume(ctx, c, "orders-eux", "billing"))
+	assert.False(t, k.CanConsume(ctx, c, "orders-eu", "billing2"))
+	assert.False(t, k.CanAlter(ctx, c, KafkaTopic, "orders-eu"))
+}
+
+func TestKafkaACLs(t *testing.T) {
+	rbac := New()
+	admin := NewRole("admin")
+	admin.AddPermissions("kafka:alter:topic:*", "posts:read")
+	producer := NewRole("producer")
+	producer.AddPermissions("kafka:write:topic:orders-*")
+	assert.NoError(t, rbac.AddRole(producer))
+	assert.NoError(t, rbac.AddRole(admin))
+	assert.NoError(t, admin.AddChild(producer))
+
+	acls := KafkaACLs(rbac)
+	assert.Equal(t, []KafkaACL{
+		{Principal: "Role:admin", Operation: "Alter", ResourceType: "Topic", ResourceName: "*", PatternType: KafkaPatternLiteral},
+		{Principal: "Role:admin", Operation: "Write", ResourceType: "Topic", ResourceName: "orders-", PatternType: KafkaPatternPrefixed},
+		{Principal: "Role:producer", Operation: "Write", ResourceType: "Topic", ResourceName: "orders-", PatternType: KafkaPatternPrefixed},
+	}, acls)
+	assert.Equal(t, "Role:producer ALLOW Write on Topic:PREFIXED:orders-", acls[2].String())
+}
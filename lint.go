@@ -0,0 +1,220 @@
+package rbac
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	LintRedundantPermission     = "redundant-permission"
+	LintRedundantEdge           = "redundant-edge"
+	LintContradictoryConditions = "contradictory-conditions"
+)
+
+type LintIssue struct {
+	Kind       string
+	Role       string
+	Permission string
+	Child      string
+	Message    string
+}
+
+// Lint reports permissions already granted by a broader pattern on the same
+// role or one of its descendants, hierarchy edges whose child grants nothing
+// the parent does not already have, and access entries whose conditions
+// cannot pass together. A grant only covers another one when its assertions
+// and conditions are among those of the other. Configurations only allow,
+// so the one contradiction they can hold is such an entry, which denies
+// whatever it appears to allow. The options build the policy as for
+// NewConfigWatcher, e.g. on top of WithBaseRBAC.
+func Lint(cfg Config, opts ...ConfigOption) ([]LintIssue, error) {
+	rbac, err := newConfigOptions(opts).build(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []LintIssue
+
+	for _, access := range cfg.AccessControl {
+		if a, b, ok := contradictoryConditions(access.Conditions); ok {
+			issues = append(issues, LintIssue{
+				Kind:       LintContradictoryConditions,
+				Role:       access.Role,
+				Permission: strings.Join(access.Permissions, ","),
+				Message:    fmt.Sprintf(`conditions of role "%s" on "%s" never pass: "%s" cannot be in both %q and %q`, access.Role, strings.Join(access.Permissions, `", "`), a.Attribute, equalityValues(a), equalityValues(b)),
+			})
+		}
+	}
+
+	roles := slices.SortedFunc(rbac.Roles(), func(a, b *Role) int {
+		return cmp.Compare(a.Name(), b.Name())
+	})
+
+	for _, role := range roles {
+		for _, permission := range slices.Sorted(role.Permissions(false)) {
+			if by, owner, ok := coveringPermission(role, role, permission, role.conditions[permission]); ok {
+				issues = append(issues, LintIssue{
+					Kind:       LintRedundantPermission,
+					Role:       role.Name(),
+					Permission: permission,
+					Message:    fmt.Sprintf(`permission "%s" of role "%s" is already granted by "%s" of role "%s"`, permission, role.Name(), by, owner.Name()),
+				})
+			}
+		}
+
+		children := slices.SortedFunc(role.Children(), func(a, b *Role) int {
+			return cmp.Compare(a.Name(), b.Name())
+		})
+		redundant := map[*Role]struct{}{}
+		for _, child := range children {
+			if edgeAddsNothing(role, child, redundant) {
+				redundant[child] = struct{}{}
+				issues = append(issues, LintIssue{
+					Kind:    LintRedundantEdge,
+					Role:    role.Name(),
+					Child:   child.Name(),
+					Message: fmt.Sprintf(`role "%s" gains no permission from child "%s"`, role.Name(), child.Name()),
+				})
+			}
+		}
+	}
+
+	return issues, nil
+}
+
+// coveringPermission looks for another grant on role or its descendants that
+// matches permission held under the conditions, skipping the permission
+// itself on origin.
+func coveringPermission(origin, role *Role, permission string, conditions []Assertion) (string, *Role, bool) {
+	for _, pattern := range slices.Sorted(role.Permissions(false)) {
+		if role == origin && pattern == permission {
+			continue
+		}
+		if role.grants(pattern, permission) && coversConditions(role.conditions[pattern], conditions) {
+			return pattern, role, true
+		}
+	}
+	for _, child := range slices.SortedFunc(role.Children(), func(a, b *Role) int { return cmp.Compare(a.Name(), b.Name()) }) {
+		if by, owner, ok := coveringPermission(origin, child, permission, conditions); ok {
+			return by, owner, true
+		}
+	}
+	return "", nil, false
+}
+
+// coversConditions reports whether a grant under the covering assertions
+// passes whenever one under the covered assertions does.
+func coversConditions(covering, covered []Assertion) bool {
+	for _, assertion := range covering {
+		key := assertionKey(assertion)
+		if !slices.ContainsFunc(covered, func(a Assertion) bool { return assertionKey(a) == key }) {
+			return false
+		}
+	}
+	return true
+}
+
+func assertionKey(assertion Assertion) string {
+	if condition, ok := assertion.(*ConditionAssertion); ok {
+		return conditionsKey([]ConditionConfig{condition.Config()})
+	}
+	return AssertionName(assertion)
+}
+
+// edgeAddsNothing reports whether every permission of child and its
+// descendants is covered by the parent's own grants or by a sibling not
+// already reported as redundant, so siblings covering each other are
+// reported once.
+func edgeAddsNothing(parent, child *Role, redundant map[*Role]struct{}) bool {
+	return walkRoles(child, map[*Role]struct{}{}, func(r *Role) bool {
+		for permission := range r.Permissions(false) {
+			conditions := r.conditions[permission]
+			if coveringGrant(parent, permission, conditions) {
+				continue
+			}
+			covered := false
+			for sibling := range parent.Children() {
+				if _, skip := redundant[sibling]; skip || sibling == child {
+					continue
+				}
+				if _, _, covered = coveringPermission(nil, sibling, permission, conditions); covered {
+					break
+				}
+			}
+			if !covered {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// coveringGrant reports whether a grant of the role itself covers permission
+// held under the conditions.
+func coveringGrant(role *Role, permission string, conditions []Assertion) bool {
+	for pattern := range role.Permissions(false) {
+		if role.grants(pattern, permission) && coversConditions(role.conditions[pattern], conditions) {
+			return true
+		}
+	}
+	return false
+}
+
+// walkRoles calls fn for the role and its descendants until fn returns false,
+// reporting whether it did.
+func walkRoles(role *Role, seen map[*Role]struct{}, fn func(*Role) bool) bool {
+	if _, ok := seen[role]; ok {
+		return true
+	}
+	seen[role] = struct{}{}
+	if !fn(role) {
+		return false
+	}
+	for child := range role.Children() {
+		if !walkRoles(child, seen, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// contradictoryConditions returns two Equals or In conditions on the same
+// single valued attribute without a value in common.
+func contradictoryConditions(conditions []ConditionConfig) (ConditionConfig, ConditionConfig, bool) {
+	for i, a := range conditions {
+		if !singleValued(a.Attribute) || len(equalityValues(a)) == 0 {
+			continue
+		}
+		for _, b := range conditions[i+1:] {
+			values := equalityValues(b)
+			if b.Attribute == a.Attribute && len(values) > 0 && !slices.ContainsFunc(equalityValues(a), func(v string) bool { return slices.Contains(values, v) }) {
+				return a, b, true
+			}
+		}
+	}
+	return ConditionConfig{}, ConditionConfig{}, false
+}
+
+// equalityValues returns the values an Equals or In condition accepts.
+func equalityValues(condition ConditionConfig) []string {
+	if condition.Equals != "" {
+		return []string{condition.Equals}
+	}
+	return condition.In
+}
+
+// singleValued reports whether the attribute resolves to at most one value,
+// unlike e.g. "subject.roles", headers or metadata lists.
+func singleValued(attribute string) bool {
+	source, key, _ := strings.Cut(attribute, ".")
+	field, _, _ := strings.Cut(key, ".")
+	switch source {
+	case "subject":
+		return key == "id"
+	case "request":
+		return field != "header"
+	}
+	return false
+}
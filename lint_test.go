@@ -0,0 +1,131 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	issues, err := Lint(Config{
+		RoleHierarchy: []RoleConfig{
+			{Role: "admin", Children: []string{"editor", "viewer", "nobody"}},
+			{Role: "editor", Children: []string{"viewer"}},
+			{Role: "viewer"},
+			{Role: "nobody"},
+		},
+		AccessControl: []AccessConfig{
+			{Role: "admin", Permissions: []string{"posts:.*", "users:delete"}},
+			{Role: "editor", Permissions: []string{"posts:write", "posts:read"}},
+			{Role: "viewer", Permissions: []string{"posts:read"}},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []LintIssue{
+		{
+			Kind:    LintRedundantEdge,
+			Role:    "admin",
+			Child:   "editor",
+			Message: `role "admin" gains no permission from child "editor"`,
+		},
+		{
+			Kind:    LintRedundantEdge,
+			Role:    "admin",
+			Child:   "nobody",
+			Message: `role "admin" gains no permission from child "nobody"`,
+		},
+		{
+			Kind:    LintRedundantEdge,
+			Role:    "admin",
+			Child:   "viewer",
+			Message: `role "admin" gains no permission from child "viewer"`,
+		},
+		{
+			Kind:       LintRedundantPermission,
+			Role:       "editor",
+			Permission: "posts:read",
+			Message:    `permission "posts:read" of role "editor" is already granted by "posts:read" of role "viewer"`,
+		},
+		{
+			Kind:    LintRedundantEdge,
+			Role:    "editor",
+			Child:   "viewer",
+			Message: `role "editor" gains no permission from child "viewer"`,
+		},
+	}, issues)
+
+	_, err = Lint(Config{AccessControl: []AccessConfig{{Role: "missing"}}})
+	assert.ErrorIs(t, err, ErrRoleNotFound)
+}
+
+func TestLint_MutualSiblings(t *testing.T) {
+	issues, err := Lint(Config{
+		RoleHierarchy: []RoleConfig{
+			{Role: "admin", Children: []string{"reader", "viewer"}},
+			{Role: "reader"},
+			{Role: "viewer"},
+		},
+		AccessControl: []AccessConfig{
+			{Role: "reader", Permissions: []string{"posts:read"}},
+			{Role: "viewer", Permissions: []string{"posts:read"}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []LintIssue{{
+		Kind:    LintRedundantEdge,
+		Role:    "admin",
+		Child:   "reader",
+		Message: `role "admin" gains no permission from child "reader"`,
+	}}, issues)
+}
+
+func TestLint_Conditions(t *testing.T) {
+	tenant := []ConditionConfig{{Attribute: "claims.tenant", Equals: "acme"}}
+	issues, err := Lint(Config{
+		RoleHierarchy: []RoleConfig{
+			{Role: "admin", Children: []string{"owner"}},
+			{Role: "editor", Children: []string{"viewer"}},
+			{Role: "owner"},
+			{Role: "viewer"},
+		},
+		AccessControl: []AccessConfig{
+			{Role: "admin", Permissions: []string{"posts:.*"}},
+			{Role: "owner", Permissions: []string{"posts:edit"}, Assertions: []string{"owner"}},
+			{Role: "editor", Permissions: []string{"posts:.*"}, Conditions: tenant},
+			{Role: "editor", Permissions: []string{"posts:edit"}, Conditions: tenant},
+			{Role: "viewer", Permissions: []string{"posts:read"}},
+			{Role: "viewer", Permissions: []string{"deploy"}, Conditions: []ConditionConfig{
+				{Attribute: "request.method", Equals: "POST"},
+				{Attribute: "request.method", In: []string{"GET", "HEAD"}},
+				{Attribute: "subject.roles", Equals: "ops"},
+				{Attribute: "subject.roles", Equals: "dev"},
+			}},
+		},
+	}, WithBaseRBAC(New().SetAssertionRegistry(NewAssertionRegistry())))
+	require.NoError(t, err)
+	assert.Equal(t, []LintIssue{
+		{
+			Kind:       LintContradictoryConditions,
+			Role:       "viewer",
+			Permission: "deploy",
+			Message:    `conditions of role "viewer" on "deploy" never pass: "request.method" cannot be in both ["POST"] and ["GET" "HEAD"]`,
+		},
+		{
+			Kind:    LintRedundantEdge,
+			Role:    "admin",
+			Child:   "owner",
+			Message: `role "admin" gains no permission from child "owner"`,
+		},
+		{
+			Kind:       LintRedundantPermission,
+			Role:       "editor",
+			Permission: "posts:edit",
+			Message:    `permission "posts:edit" of role "editor" is already granted by "posts:.*" of role "editor"`,
+		},
+	}, issues)
+
+	_, err = Lint(Config{RoleHierarchy: []RoleConfig{{Role: "owner"}}, AccessControl: []AccessConfig{{Role: "owner", Permissions: []string{"posts:edit"}, Assertions: []string{"owner"}}}})
+	assert.ErrorIs(t, err, ErrUnknownAssertion)
+}
@@ -0,0 +1,107 @@
+package rbac
+
+import (
+	"context"
+	"strings"
+)
+
+const (
+	MessagePublish   = "pub"
+	MessageSubscribe = "sub"
+)
+
+// maxMessageWildcardTokens bounds single-token wildcard expansion, which
+// grows as 2^n with the number of subject tokens.
+const maxMessageWildcardTokens = 10
+
+type MessageSyntax struct {
+	Separator string
+	Single    string
+	Full      string
+	// FullMatchesEmpty reports whether the full wildcard also matches zero tokens.
+	FullMatchesEmpty bool
+}
+
+var (
+	NATSSyntax = MessageSyntax{Separator: ".", Single: "*", Full: ">"}
+	AMQPSyntax = MessageSyntax{Separator: ".", Single: "*", Full: "#", FullMatchesEmpty: true}
+)
+
+type MessageAuthorizer struct {
+	authorizer Authorizer
+	syntax     MessageSyntax
+}
+
+func NewMessageAuthorizer(authorizer Authorizer, syntax MessageSyntax) *MessageAuthorizer {
+	return &MessageAuthorizer{authorizer: authorizer, syntax: syntax}
+}
+
+func (m *MessageAuthorizer) CanPublish(ctx context.Context, claims *Claims, subject string) bool {
+	return m.Authorize(ctx, claims, MessagePublish, subject).Allowed()
+}
+
+func (m *MessageAuthorizer) CanSubscribe(ctx context.Context, claims *Claims, subject string) bool {
+	return m.Authorize(ctx, claims, MessageSubscribe, subject).Allowed()
+}
+
+// Authorize allows if a permission equal to one of the Actions of the subject
+// is granted, so wildcards match token by token: a grant of "pub:orders.*"
+// covers "orders.created" but neither "orders.a.b" nor "ordersevil".
+func (m *MessageAuthorizer) Authorize(ctx context.Context, claims *Claims, op, subject string) Decision {
+	target := &Target{Literal: true}
+	for _, action := range m.Actions(op, subject) {
+		target.Action = action
+		if d := m.authorizer.Authorize(ctx, claims, target); d.Allowed() {
+			return d
+		}
+	}
+	return DecisionDeny
+}
+
+// Actions returns "op:pattern" for the subject itself and every wildcard
+// pattern that covers it, so a grant of "sub:billing.>" allows subscribing to
+// "billing.invoices" as well as to "billing.>". Subjects of more than
+// maxMessageWildcardTokens tokens are only covered by full wildcards.
+func (m *MessageAuthorizer) Actions(op, subject string) []string {
+	if subject == "" {
+		return nil
+	}
+
+	tokens := strings.Split(subject, m.syntax.Separator)
+	patterns := m.patterns(tokens, len(tokens) <= maxMessageWildcardTokens)
+
+	actions := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		actions = append(actions, op+":"+strings.Join(pattern, m.syntax.Separator))
+	}
+	return actions
+}
+
+func (m *MessageAuthorizer) patterns(tokens []string, single bool) [][]string {
+	if len(tokens) == 0 {
+		if m.syntax.FullMatchesEmpty {
+			return [][]string{nil, {m.syntax.Full}}
+		}
+		return [][]string{nil}
+	}
+
+	var patterns [][]string
+
+	token := tokens[0]
+	if token != m.syntax.Full {
+		var heads []string
+		if token != m.syntax.Single {
+			heads = append(heads, token)
+		}
+		if single || token == m.syntax.Single {
+			heads = append(heads, m.syntax.Single)
+		}
+		for _, tail := range m.patterns(tokens[1:], single) {
+			for _, head := range heads {
+				patterns = append(patterns, append([]string{head}, tail...))
+			}
+		}
+	}
+
+	return append(patterns, []string{m.syntax.Full})
+}
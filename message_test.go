@@ -0,0 +1,68 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageAuthorizer_Actions(t *testing.T) {
+	m := NewMessageAuthorizer(nil, NATSSyntax)
+
+	assert.Nil(t, m.Actions(MessagePublish, ""))
+	assert.ElementsMatch(t, []string{
+		"pub:orders.created",
+		"pub:orders.*",
+		"pub:orders.>",
+		"pub:*.created",
+		"pub:*.*",
+		"pub:*.>",
+		"pub:>",
+	}, m.Actions(MessagePublish, "orders.created"))
+	assert.ElementsMatch(t, []string{"sub:billing.>", "sub:*.>", "sub:>"}, m.Actions(MessageSubscribe, "billing.>"))
+	assert.ElementsMatch(t, []string{"sub:*.x", "sub:*.*", "sub:*.>", "sub:>"}, m.Actions(MessageSubscribe, "*.x"))
+
+	amqp := NewMessageAuthorizer(nil, AMQPSyntax)
+	assert.ElementsMatch(t, []string{"sub:logs", "sub:logs.#", "sub:*", "sub:*.#", "sub:#"}, amqp.Actions(MessageSubscribe, "logs"))
+}
+
+func TestMessageAuthorizer_Authorize(t *testing.T) {
+	rbac := New()
+	role := NewRole("billing")
+	role.AddPermissions("sub:billing.>", "pub:orders.created")
+	assert.NoError(t, rbac.AddRole(role))
+
+	m := NewMessageAuthorizer(NewDefaultAuthorizer(rbac), NATSSyntax)
+	claims := &Claims{Subject: &testSubject{roles: []string{"billing"}}}
+	ctx := context.Background()
+
+	assert.True(t, m.CanSubscribe(ctx, claims, "billing.invoices"))
+	assert.True(t, m.CanSubscribe(ctx, claims, "billing.invoices.paid"))
+	assert.True(t, m.CanSubscribe(ctx, claims, "billing.>"))
+	assert.False(t, m.CanSubscribe(ctx, claims, ">"))
+	assert.False(t, m.CanSubscribe(ctx, claims, "orders.created"))
+	assert.True(t, m.CanPublish(ctx, claims, "orders.created"))
+	assert.False(t, m.CanPublish(ctx, claims, "orders.deleted"))
+	assert.False(t, m.CanPublish(ctx, claims, "billing.invoices"))
+	assert.False(t, m.CanSubscribe(ctx, claims, "billing"))
+	assert.False(t, m.CanSubscribe(ctx, claims, "billingevil.invoices"))
+}
+
+func TestMessageAuthorizer_Authorize_Tokens(t *testing.T) {
+	rbac := New()
+	role := NewRole("orders")
+	role.AddPermissions("pub:orders.*")
+	assert.NoError(t, rbac.AddRole(role))
+
+	m := NewMessageAuthorizer(NewDefaultAuthorizer(rbac), NATSSyntax)
+	claims := &Claims{Subject: &testSubject{roles: []string{"orders"}}}
+	ctx := context.Background()
+
+	assert.True(t, m.CanPublish(ctx, claims, "orders.created"))
+	assert.True(t, m.CanPublish(ctx, claims, "orders.*"))
+	assert.False(t, m.CanPublish(ctx, claims, "orders.a.b"))
+	assert.False(t, m.CanPublish(ctx, claims, "orders.>"))
+	assert.False(t, m.CanPublish(ctx, claims, "orders"))
+	assert.False(t, m.CanPublish(ctx, claims, "ordersevil"))
+}
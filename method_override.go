@@ -0,0 +1,76 @@
+package rbac
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const (
+	HeaderMethodOverride = "X-HTTP-Method-Override"
+	FormMethodOverride   = "_method"
+)
+
+// MethodOverride rewrites the request method from the override header or
+// form field. It must run before routing so that the mux and
+// RequestAuthorizer see the same method. By default only POST requests are
+// overridden and the form field is ignored.
+type MethodOverride struct {
+	form     bool
+	postOnly bool
+	methods  []string
+}
+
+func NewMethodOverride() *MethodOverride {
+	return &MethodOverride{
+		postOnly: true,
+		methods:  []string{http.MethodPut, http.MethodPatch, http.MethodDelete},
+	}
+}
+
+// SetForm enables the _method form field of url-encoded POST bodies.
+func (m *MethodOverride) SetForm(form bool) *MethodOverride {
+	m.form = form
+	return m
+}
+
+func (m *MethodOverride) SetPostOnly(postOnly bool) *MethodOverride {
+	m.postOnly = postOnly
+	return m
+}
+
+// SetMethods sets the methods a request may be overridden to.
+func (m *MethodOverride) SetMethods(methods ...string) *MethodOverride {
+	m.methods = methods
+	return m
+}
+
+// Method returns the effective method of r.
+func (m *MethodOverride) Method(r *http.Request) string {
+	if m.postOnly && r.Method != http.MethodPost {
+		return r.Method
+	}
+
+	method := r.Header.Get(HeaderMethodOverride)
+	if method == "" && m.form && r.Method == http.MethodPost {
+		method = r.PostFormValue(FormMethodOverride)
+	}
+	if method = strings.ToUpper(strings.TrimSpace(method)); method == "" {
+		return r.Method
+	}
+
+	if slices.Contains(m.methods, method) {
+		return method
+	}
+	return r.Method
+}
+
+func (m *MethodOverride) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if method := m.Method(r); method != r.Method {
+			r = r.Clone(r.Context())
+			r.Method = method
+		}
+		next.ServeHTTP(w, r)
+	})
+}
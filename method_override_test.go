@@ -0,0 +1,62 @@
+package rbac
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMethodOverride_Method(t *testing.T) {
+	m := NewMethodOverride()
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	assert.Equal(t, http.MethodPost, m.Method(r))
+
+	r.Header.Set(HeaderMethodOverride, "delete")
+	assert.Equal(t, http.MethodDelete, m.Method(r))
+
+	r.Header.Set(HeaderMethodOverride, "CONNECT")
+	assert.Equal(t, http.MethodPost, m.Method(r))
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(HeaderMethodOverride, "DELETE")
+	assert.Equal(t, http.MethodGet, m.Method(r))
+
+	m.SetPostOnly(false)
+	assert.Equal(t, http.MethodDelete, m.Method(r))
+
+	form := url.Values{FormMethodOverride: {"PUT"}}.Encode()
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	assert.Equal(t, http.MethodPost, NewMethodOverride().Method(r))
+	assert.Equal(t, http.MethodPut, NewMethodOverride().SetForm(true).Method(r))
+	assert.Equal(t, http.MethodPost, NewMethodOverride().SetForm(true).SetMethods(http.MethodPatch).Method(r))
+}
+
+func TestMethodOverride_Middleware(t *testing.T) {
+	rbac := New()
+	role := NewRole("editor")
+	role.AddPermissions("DELETE /posts/1")
+	_ = rbac.AddRole(role)
+
+	authorize := RequestAuthorizer(NewDefaultAuthorizer(rbac))
+	var decision Decision
+	handler := NewMethodOverride().Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		decision = authorize(r)
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/posts/1", nil)
+	r = r.WithContext(WithClaims(r.Context(), &Claims{Subject: NewSubject("1", "editor")}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, DecisionDeny, decision)
+
+	r.Header.Set(HeaderMethodOverride, http.MethodDelete)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, DecisionAllow, decision)
+	assert.Equal(t, http.MethodPost, r.Method)
+}
@@ -0,0 +1,392 @@
+// Package oidc resolves rbac claims from OpenID Connect ID tokens.
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gowool/rbac"
+)
+
+var (
+	ErrDiscovery  = errors.New("oidc discovery failed")
+	ErrUnknownKey = errors.New("oidc signing key not found")
+	ErrExchange   = errors.New("oidc code exchange failed")
+)
+
+// DefaultMinRefreshInterval is the minimum interval between JWKS fetches.
+const DefaultMinRefreshInterval = time.Minute
+
+type GroupRoleConfig struct {
+	Group string   `env:"GROUP" json:"group,omitempty" yaml:"group,omitempty"`
+	Roles []string `env:"ROLES" json:"roles,omitempty" yaml:"roles,omitempty"`
+}
+
+type Config struct {
+	Issuer       string `env:"ISSUER" json:"issuer,omitempty" yaml:"issuer,omitempty"`
+	ClientID     string `env:"CLIENT_ID" json:"clientID,omitempty" yaml:"clientID,omitempty"`
+	ClientSecret string `env:"CLIENT_SECRET" json:"clientSecret,omitempty" yaml:"clientSecret,omitempty"`
+	// RolesClaim is a dot separated path to roles used as is, e.g. "realm_access.roles".
+	RolesClaim string `env:"ROLES_CLAIM" json:"rolesClaim,omitempty" yaml:"rolesClaim,omitempty"`
+	// GroupsClaim defaults to "groups".
+	GroupsClaim string `env:"GROUPS_CLAIM" json:"groupsClaim,omitempty" yaml:"groupsClaim,omitempty"`
+	// GroupRoles maps groups to roles, unmapped groups grant no role.
+	GroupRoles []GroupRoleConfig `envPrefix:"GROUP_ROLES_" json:"groupRoles,omitempty" yaml:"groupRoles,omitempty"`
+}
+
+// Discovery is the subset of the provider metadata used by Provider.
+type Discovery struct {
+	Issuer        string `json:"issuer"`
+	JWKSURI       string `json:"jwks_uri"`
+	TokenEndpoint string `json:"token_endpoint"`
+}
+
+// Provider verifies ID tokens issued by Config.Issuer and maps them to
+// claims. Signing keys are fetched from the discovered JWKS endpoint and
+// refreshed when a token names an unknown key, at most once per
+// MinRefreshInterval.
+type Provider struct {
+	cfg        Config
+	client     *http.Client
+	discovery  Discovery
+	groups     map[string][]string
+	minRefresh time.Duration
+
+	mu         sync.RWMutex
+	keys       map[string]any
+	missing    map[string]struct{}
+	refreshed  time.Time
+	refreshing chan struct{}
+	refreshErr error
+}
+
+// NewProvider fetches the discovery document of cfg.Issuer. A nil client
+// uses http.DefaultClient.
+func NewProvider(ctx context.Context, cfg Config, client *http.Client) (*Provider, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+
+	p := &Provider{cfg: cfg, client: client, groups: map[string][]string{}, minRefresh: DefaultMinRefreshInterval}
+	for _, mapping := range cfg.GroupRoles {
+		p.groups[mapping.Group] = append(p.groups[mapping.Group], mapping.Roles...)
+	}
+
+	wellKnown := strings.TrimSuffix(cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.get(ctx, wellKnown, &p.discovery); err != nil {
+		return nil, err
+	}
+	if p.discovery.Issuer != cfg.Issuer {
+		return nil, fmt.Errorf(`%w: issuer "%s" does not match "%s"`, ErrDiscovery, p.discovery.Issuer, cfg.Issuer)
+	}
+	return p, nil
+}
+
+func (p *Provider) Discovery() Discovery {
+	return p.discovery
+}
+
+// SetMinRefreshInterval sets the minimum interval between JWKS fetches,
+// DefaultMinRefreshInterval unless set.
+func (p *Provider) SetMinRefreshInterval(d time.Duration) *Provider {
+	p.minRefresh = d
+	return p
+}
+
+func (p *Provider) MinRefreshInterval() time.Duration {
+	return p.minRefresh
+}
+
+type nonceKey struct{}
+
+// WithNonce makes Verify and Exchange require ID tokens whose nonce claim
+// equals nonce, the value sent with the authentication request.
+func WithNonce(ctx context.Context, nonce string) context.Context {
+	return context.WithValue(ctx, nonceKey{}, nonce)
+}
+
+// Verify validates a raw ID token and maps it to claims. The subject holds
+// the roles of RolesClaim followed by the roles mapped from GroupsClaim, the
+// metadata holds every token claim.
+func (p *Provider) Verify(ctx context.Context, rawIDToken string) (*rbac.Claims, error) {
+	claims, err := rbac.ClaimsFromJWT(ctx, rawIDToken, p.Keyfunc, p.cfg.RolesClaim)
+	if err != nil {
+		return nil, err
+	}
+
+	if iss, _ := claims.Metadata["iss"].(string); iss != p.cfg.Issuer {
+		return nil, fmt.Errorf(`%w: unexpected issuer "%s"`, rbac.ErrInvalidCredentials, iss)
+	}
+	if !slices.Contains(stringsClaim(claims.Metadata["aud"]), p.cfg.ClientID) {
+		return nil, fmt.Errorf(`%w: token not issued for "%s"`, rbac.ErrInvalidCredentials, p.cfg.ClientID)
+	}
+	if expected, ok := ctx.Value(nonceKey{}).(string); ok {
+		nonce, _ := claims.Metadata["nonce"].(string)
+		if expected == "" || subtle.ConstantTimeCompare([]byte(nonce), []byte(expected)) != 1 {
+			return nil, fmt.Errorf("%w: nonce mismatch", rbac.ErrInvalidCredentials)
+		}
+	}
+
+	var roles []string
+	if p.cfg.RolesClaim != "" {
+		roles = claims.Subject.Roles()
+	}
+	for _, group := range stringsClaim(claims.Metadata[p.cfg.GroupsClaim]) {
+		roles = append(roles, p.groups[group]...)
+	}
+
+	id := rbac.SubjectID(claims.Subject)
+	claims.Subject = rbac.NewSubject(id, roles...)
+	return claims, nil
+}
+
+// Exchange redeems an authorization code at the token endpoint and verifies
+// the returned ID token.
+func (p *Provider) Exchange(ctx context.Context, code, redirectURI string) (*rbac.Claims, error) {
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {redirectURI},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExchange, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExchange, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: token endpoint responded %d", ErrExchange, res.StatusCode)
+	}
+
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExchange, err)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id_token in response", ErrExchange)
+	}
+	return p.Verify(ctx, token.IDToken)
+}
+
+// ClaimsExtractor verifies ID tokens sent as bearer tokens.
+func (p *Provider) ClaimsExtractor() *rbac.HeaderClaimsExtractor {
+	return &rbac.HeaderClaimsExtractor{VerifyToken: p.Verify}
+}
+
+// Keyfunc is a rbac.JWTKeyfunc resolving keys from the provider's JWKS.
+// Concurrent refreshes for unknown keys share one fetch, and keys still
+// unknown after it fail without refetching until MinRefreshInterval passed.
+func (p *Provider) Keyfunc(ctx context.Context, header rbac.JWTHeader) (any, error) {
+	p.mu.RLock()
+	key, ok := p.keys[header.Kid]
+	_, missing := p.missing[header.Kid]
+	missing = missing && time.Since(p.refreshed) < p.minRefresh
+	p.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+	if !missing {
+		if err := p.refreshKeys(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok = p.keys[header.Kid]; !ok {
+		if p.missing == nil {
+			p.missing = map[string]struct{}{}
+		}
+		p.missing[header.Kid] = struct{}{}
+		return nil, fmt.Errorf(`%w: kid "%s"`, ErrUnknownKey, header.Kid)
+	}
+	return key, nil
+}
+
+// refreshKeys fetches the JWKS unless it was fetched within the minimum
+// refresh interval, waiting for a fetch in progress instead of starting one.
+func (p *Provider) refreshKeys(ctx context.Context) error {
+	p.mu.Lock()
+	if wait := p.refreshing; wait != nil {
+		p.mu.Unlock()
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+		return p.refreshErr
+	}
+	if !p.refreshed.IsZero() && time.Since(p.refreshed) < p.minRefresh {
+		p.mu.Unlock()
+		return nil
+	}
+	wait := make(chan struct{})
+	p.refreshing = wait
+	p.mu.Unlock()
+
+	keys, err := p.fetchKeys(ctx)
+
+	p.mu.Lock()
+	if err == nil {
+		p.keys = keys
+	}
+	p.missing = nil
+	p.refreshed = time.Now()
+	p.refreshErr = err
+	p.refreshing = nil
+	p.mu.Unlock()
+	close(wait)
+	return err
+}
+
+func (p *Provider) fetchKeys(ctx context.Context) (map[string]any, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := p.get(ctx, p.discovery.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if key, err := k.publicKey(); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+func (p *Provider) get(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrDiscovery, err)
+	}
+	res, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrDiscovery, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s responded %d", ErrDiscovery, u, res.StatusCode)
+	}
+	if err = json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("%w: %w", ErrDiscovery, err)
+	}
+	return nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jwk) publicKey() (any, error) {
+	dec := base64.RawURLEncoding.DecodeString
+	switch k.Kty {
+	case "RSA":
+		n, err := dec(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := dec(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf(`unsupported curve "%s"`, k.Crv)
+		}
+		x, err := dec(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := dec(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		point := append([]byte{4}, append(leftPad(x, size), leftPad(y, size)...)...)
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+	case "OKP":
+		x, err := dec(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf(`unsupported curve "%s"`, k.Crv)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf(`unsupported key type "%s"`, k.Kty)
+	}
+}
+
+func leftPad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
+
+func stringsClaim(value any) []string {
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []any:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			if v, ok := v.(string); ok {
+				values = append(values, v)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,212 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gowool/rbac"
+)
+
+type testIssuer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	iss := &testIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Discovery{
+			Issuer:        iss.URL,
+			JWKSURI:       iss.URL + "/jwks",
+			TokenEndpoint: iss.URL + "/token",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "app" || secret != "s3cret" || r.FormValue("code") != "c1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": iss.sign(t, "k1", iss.claims())})
+	})
+	iss.Server = httptest.NewServer(mux)
+	t.Cleanup(iss.Close)
+	return iss
+}
+
+func (iss *testIssuer) claims() map[string]any {
+	return map[string]any{
+		"iss":    iss.URL,
+		"aud":    "app",
+		"sub":    "u1",
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"groups": []any{"staff", "unmapped"},
+		"roles":  []any{"user"},
+	}
+}
+
+func (iss *testIssuer) sign(t *testing.T, kid string, claims map[string]any) string {
+	t.Helper()
+
+	header, _ := json.Marshal(rbac.JWTHeader{Alg: "RS256", Kid: kid})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, iss.key, crypto.SHA256, sum[:])
+	require.NoError(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func newTestProvider(t *testing.T, iss *testIssuer) *Provider {
+	t.Helper()
+
+	p, err := NewProvider(context.Background(), Config{
+		Issuer:       iss.URL,
+		ClientID:     "app",
+		ClientSecret: "s3cret",
+		RolesClaim:   "roles",
+		GroupRoles:   []GroupRoleConfig{{Group: "staff", Roles: []string{"editor", "viewer"}}},
+	}, iss.Client())
+	require.NoError(t, err)
+	return p
+}
+
+func TestProvider_Verify(t *testing.T) {
+	iss := newTestIssuer(t)
+	p := newTestProvider(t, iss)
+	assert.Equal(t, iss.URL+"/jwks", p.Discovery().JWKSURI)
+
+	claims, err := p.Verify(context.Background(), iss.sign(t, "k1", iss.claims()))
+	require.NoError(t, err)
+	assert.Equal(t, "u1", rbac.SubjectID(claims.Subject))
+	assert.Equal(t, []string{"user", "editor", "viewer"}, claims.Subject.Roles())
+	assert.Equal(t, iss.URL, claims.Metadata["iss"])
+
+	wrongAud := iss.claims()
+	wrongAud["aud"] = []any{"other"}
+	_, err = p.Verify(context.Background(), iss.sign(t, "k1", wrongAud))
+	assert.ErrorIs(t, err, rbac.ErrInvalidCredentials)
+
+	wrongIss := iss.claims()
+	wrongIss["iss"] = "https://evil.example"
+	_, err = p.Verify(context.Background(), iss.sign(t, "k1", wrongIss))
+	assert.ErrorIs(t, err, rbac.ErrInvalidCredentials)
+
+	_, err = p.Verify(context.Background(), iss.sign(t, "k2", iss.claims()))
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	iss := newTestIssuer(t)
+	p := newTestProvider(t, iss)
+
+	claims, err := p.Exchange(context.Background(), "c1", "https://app.example/callback")
+	require.NoError(t, err)
+	assert.Equal(t, "u1", rbac.SubjectID(claims.Subject))
+
+	_, err = p.Exchange(context.Background(), "bad", "https://app.example/callback")
+	assert.ErrorIs(t, err, ErrExchange)
+}
+
+func TestProvider_ClaimsExtractor(t *testing.T) {
+	iss := newTestIssuer(t)
+	p := newTestProvider(t, iss)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+iss.sign(t, "k1", iss.claims()))
+	claims, err := p.ClaimsExtractor().ExtractClaims(r)
+	require.NoError(t, err)
+	assert.Equal(t, "u1", rbac.SubjectID(claims.Subject))
+}
+
+func TestNewProvider_IssuerMismatch(t *testing.T) {
+	iss := newTestIssuer(t)
+	_, err := NewProvider(context.Background(), Config{Issuer: iss.URL + "/"}, iss.Client())
+	assert.ErrorIs(t, err, ErrDiscovery)
+}
+
+func TestProvider_KeyfuncThrottle(t *testing.T) {
+	iss := newTestIssuer(t)
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	handler := iss.Config.Handler
+	iss.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/jwks" {
+			fetches.Add(1)
+			<-release
+		}
+		handler.ServeHTTP(w, r)
+	})
+	p := newTestProvider(t, iss)
+	assert.Equal(t, DefaultMinRefreshInterval, p.MinRefreshInterval())
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			_, err := p.Keyfunc(context.Background(), rbac.JWTHeader{Kid: "k1"})
+			assert.NoError(t, err)
+		})
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), fetches.Load())
+
+	for range 10 {
+		_, err := p.Keyfunc(context.Background(), rbac.JWTHeader{Kid: "unknown"})
+		assert.ErrorIs(t, err, ErrUnknownKey)
+	}
+	assert.Equal(t, int32(1), fetches.Load())
+
+	p.SetMinRefreshInterval(0)
+	_, err := p.Keyfunc(context.Background(), rbac.JWTHeader{Kid: "unknown"})
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestProvider_VerifyNonce(t *testing.T) {
+	iss := newTestIssuer(t)
+	p := newTestProvider(t, iss)
+
+	claims := iss.claims()
+	claims["nonce"] = "n1"
+	token := iss.sign(t, "k1", claims)
+
+	_, err := p.Verify(WithNonce(context.Background(), "n1"), token)
+	require.NoError(t, err)
+	_, err = p.Verify(WithNonce(context.Background(), "n2"), token)
+	assert.ErrorIs(t, err, rbac.ErrInvalidCredentials)
+	_, err = p.Verify(WithNonce(context.Background(), "n1"), iss.sign(t, "k1", iss.claims()))
+	assert.ErrorIs(t, err, rbac.ErrInvalidCredentials)
+	_, err = p.Verify(WithNonce(context.Background(), ""), iss.sign(t, "k1", iss.claims()))
+	assert.ErrorIs(t, err, rbac.ErrInvalidCredentials)
+}
@@ -0,0 +1,148 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	_ Authorizer = (*OPAAuthorizer)(nil)
+
+	ErrOPAResult = errors.New("unexpected opa result")
+)
+
+// RegoEvaluator evaluates a prepared Rego query against an input document and
+// returns the value of its first expression, nil if it is undefined. With the
+// OPA SDK it wraps rego.PreparedEvalQuery:
+//
+//	RegoEvaluatorFunc(func(ctx context.Context, input map[string]any) (any, error) {
+//		rs, err := query.Eval(ctx, rego.EvalInput(input))
+//		if err != nil || len(rs) == 0 {
+//			return nil, err
+//		}
+//		return rs[0].Expressions[0].Value, nil
+//	})
+type RegoEvaluator interface {
+	Eval(ctx context.Context, input map[string]any) (any, error)
+}
+
+type RegoEvaluatorFunc func(ctx context.Context, input map[string]any) (any, error)
+
+func (f RegoEvaluatorFunc) Eval(ctx context.Context, input map[string]any) (any, error) {
+	return f(ctx, input)
+}
+
+// OPAAuthorizer delegates decisions to a Rego policy evaluated with
+// OPAInput. The policy may yield a boolean, or an object with a boolean
+// "allow" or a "decision" such as "warn" or "abstain". Undefined results
+// deny. Target assertions cannot be expressed in Rego, they are evaluated
+// locally, with a nil role, once the policy allows.
+type OPAAuthorizer struct {
+	eval RegoEvaluator
+}
+
+func NewOPAAuthorizer(eval RegoEvaluator) *OPAAuthorizer {
+	return &OPAAuthorizer{eval: eval}
+}
+
+func (a *OPAAuthorizer) Authorize(ctx context.Context, claims *Claims, target *Target) Decision {
+	d, _ := a.AuthorizeE(ctx, claims, target)
+	return d
+}
+
+func (a *OPAAuthorizer) AuthorizeE(ctx context.Context, claims *Claims, target *Target) (Decision, error) {
+	if target == nil || target.Action == "" {
+		return DecisionDeny, NewAuthzError(AuthzPolicyError, "", &ReasonError{Reason: ReasonInvalidTarget{}})
+	}
+
+	result, err := a.eval.Eval(ctx, OPAInput(ctx, claims, target))
+	if err != nil {
+		return DecisionDeny, NewAuthzError(AuthzPolicyError, target.Action, err)
+	}
+
+	d, err := opaDecision(result)
+	if err != nil {
+		return DecisionDeny, NewAuthzError(AuthzPolicyError, target.Action, err)
+	}
+	if !d.Allowed() {
+		if claims == nil || claims.Subject == nil {
+			return d, NewAuthzError(AuthzUnauthenticated, target.Action, &ReasonError{Reason: ReasonUnauthenticated{}})
+		}
+		return d, NewAuthzError(AuthzForbidden, target.Action)
+	}
+	return assertTarget(ctx, d, target)
+}
+
+// OPAInput builds the input document of a decision:
+//
+//	{"subject": {"id": ..., "roles": [...]}, "actor": {...}, "claims": {...},
+//	 "action": ..., "target": {...}, "request": {"method": ..., "host": ..., ...}}
+//
+// actor and request are only present for impersonated claims and requests
+// carrying RequestInfo.
+func OPAInput(ctx context.Context, claims *Claims, target *Target) map[string]any {
+	input := map[string]any{"action": target.Action}
+	if target.Metadata != nil {
+		input["target"] = target.Metadata
+	}
+	if claims != nil {
+		if claims.Subject != nil {
+			input["subject"] = opaSubject(claims.Subject)
+		}
+		if claims.Actor != nil {
+			input["actor"] = opaSubject(claims.Actor)
+		}
+		if claims.Metadata != nil {
+			input["claims"] = claims.Metadata
+		}
+	}
+	if info := CtxRequestInfo(ctx); info.Method != "" {
+		request := map[string]any{
+			"method":      info.Method,
+			"host":        info.Host,
+			"uri":         info.RequestURI,
+			"pattern":     info.Pattern,
+			"remote_addr": info.RemoteAddr,
+			"tls":         info.IsTLS,
+		}
+		if info.URL != nil {
+			request["path"] = info.URL.Path
+		}
+		if info.Header != nil {
+			request["headers"] = map[string][]string(info.Header)
+		}
+		if info.PathValues != nil {
+			request["path_values"] = info.PathValues
+		}
+		input["request"] = request
+	}
+	return input
+}
+
+func opaSubject(subject Subject) map[string]any {
+	return map[string]any{"id": SubjectID(subject), "roles": subject.Roles()}
+}
+
+func opaDecision(result any) (Decision, error) {
+	switch result := result.(type) {
+	case nil:
+		return DecisionDeny, nil
+	case bool:
+		if result {
+			return DecisionAllow, nil
+		}
+		return DecisionDeny, nil
+	case map[string]any:
+		if s, ok := result["decision"].(string); ok {
+			return ParseDecision(s)
+		}
+		if allow, ok := result["allow"].(bool); ok {
+			return opaDecision(allow)
+		}
+		if _, ok := result["allow"]; !ok {
+			return DecisionDeny, nil
+		}
+	}
+	return DecisionDeny, fmt.Errorf("%w: %T", ErrOPAResult, result)
+}
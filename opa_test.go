@@ -0,0 +1,128 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOPAInput(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/posts/1", nil)
+	ctx := WithRequestInfo(context.Background(), RequestInfo{
+		Method:     r.Method,
+		Host:       r.Host,
+		RequestURI: r.RequestURI,
+		Pattern:    "GET /api/posts/{id}",
+		URL:        r.URL,
+		Header:     http.Header{"X-Tenant": {"t1"}},
+		PathValues: map[string]string{"id": "1"},
+	})
+	claims := &Claims{
+		Subject:  NewSubject("u1", "user"),
+		Actor:    NewSubject("admin1", "admin"),
+		Metadata: map[string]any{"tenant": "t1"},
+	}
+
+	input := OPAInput(ctx, claims, &Target{Action: "posts.read", Metadata: map[string]any{"owner": "u1"}})
+	assert.Equal(t, "posts.read", input["action"])
+	assert.Equal(t, map[string]any{"id": "u1", "roles": []string{"user"}}, input["subject"])
+	assert.Equal(t, map[string]any{"id": "admin1", "roles": []string{"admin"}}, input["actor"])
+	assert.Equal(t, map[string]any{"tenant": "t1"}, input["claims"])
+	assert.Equal(t, map[string]any{"owner": "u1"}, input["target"])
+
+	request := input["request"].(map[string]any)
+	assert.Equal(t, "/api/posts/1", request["path"])
+	assert.Equal(t, "GET /api/posts/{id}", request["pattern"])
+	assert.Equal(t, map[string]string{"id": "1"}, request["path_values"])
+
+	input = OPAInput(context.Background(), nil, &Target{Action: "posts.read"})
+	assert.NotContains(t, input, "subject")
+	assert.NotContains(t, input, "request")
+}
+
+func TestOPAAuthorizer(t *testing.T) {
+	// stands in for: allow if input.action in data.roles[input.subject.roles[_]]
+	policy := RegoEvaluatorFunc(func(_ context.Context, input map[string]any) (any, error) {
+		subject, ok := input["subject"].(map[string]any)
+		if !ok {
+			return nil, nil
+		}
+		switch input["action"] {
+		case "posts.read":
+			return slices.Contains(subject["roles"].([]string), "user"), nil
+		case "posts.beta":
+			return map[string]any{"decision": "warn"}, nil
+		case "posts.other":
+			return map[string]any{"decision": "abstain"}, nil
+		case "posts.object":
+			return map[string]any{"allow": true, "reason": "ok"}, nil
+		case "posts.odd":
+			return 42, nil
+		default:
+			return false, nil
+		}
+	})
+	a := NewOPAAuthorizer(policy)
+	claims := &Claims{Subject: NewSubject("u1", "user")}
+
+	for action, want := range map[string]Decision{
+		"posts.read":   DecisionAllow,
+		"posts.write":  DecisionDeny,
+		"posts.beta":   DecisionWarn,
+		"posts.other":  DecisionAbstain,
+		"posts.object": DecisionAllow,
+		"posts.odd":    DecisionDeny,
+	} {
+		assert.Equal(t, want, a.Authorize(context.Background(), claims, &Target{Action: action}), action)
+	}
+
+	_, err := a.AuthorizeE(context.Background(), claims, &Target{Action: "posts.odd"})
+	assert.ErrorIs(t, err, ErrOPAResult)
+
+	d, err := a.AuthorizeE(context.Background(), nil, &Target{Action: "posts.read"})
+	assert.Equal(t, DecisionDeny, d)
+	assert.Equal(t, []Reason{ReasonUnauthenticated{}}, Reasons(err))
+
+	evalErr := errors.New("opa: undefined function")
+	d, err = NewOPAAuthorizer(RegoEvaluatorFunc(func(context.Context, map[string]any) (any, error) {
+		return nil, evalErr
+	})).AuthorizeE(context.Background(), claims, &Target{Action: "posts.read"})
+	assert.Equal(t, DecisionDeny, d)
+	assert.ErrorIs(t, err, evalErr)
+	var authzErr *AuthzError
+	require.ErrorAs(t, err, &authzErr)
+	assert.Equal(t, AuthzPolicyError, authzErr.Kind)
+
+	assert.Equal(t, DecisionDeny, a.Authorize(context.Background(), claims, nil))
+}
+
+func TestOPAAuthorizer_Assertions(t *testing.T) {
+	a := NewOPAAuthorizer(RegoEvaluatorFunc(func(context.Context, map[string]any) (any, error) {
+		return true, nil
+	}))
+	claims := &Claims{Subject: NewSubject("u1", "user")}
+
+	d, err := a.AuthorizeE(context.Background(), claims, &Target{
+		Action:     "posts.edit",
+		Assertions: []Assertion{&testAssertion{shouldPass: true}, &testAssertion{shouldPass: false}},
+	})
+	assert.Equal(t, DecisionDeny, d)
+	assert.ErrorIs(t, err, ErrDeny)
+
+	d, err = a.AuthorizeE(context.Background(), claims, &Target{
+		Action:     "posts.edit",
+		Assertions: []Assertion{Warn(&testAssertion{shouldPass: false}, "legacy")},
+	})
+	assert.Equal(t, DecisionWarn, d)
+	assert.ErrorIs(t, err, ErrWarn)
+
+	panicking := AssertionFunc(func(context.Context, *Role, string) bool { panic("boom") })
+	assert.Equal(t, DecisionDeny, a.Authorize(context.Background(), claims, &Target{Action: "posts.edit", Assertions: []Assertion{panicking}}))
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), claims, &Target{Action: "posts.edit", Assertions: []Assertion{&testAssertion{shouldPass: true}}}))
+}
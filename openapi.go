@@ -0,0 +1,151 @@
+package rbac
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrOpenAPIOperationID = errors.New("openapi: operation without operationId")
+	ErrActionNameConflict = errors.New("openapi: actions with the same constant name")
+)
+
+type openAPIOperation struct {
+	OperationID string `yaml:"operationId"`
+}
+
+type openAPIPathItem struct {
+	Get     *openAPIOperation `yaml:"get"`
+	Put     *openAPIOperation `yaml:"put"`
+	Post    *openAPIOperation `yaml:"post"`
+	Delete  *openAPIOperation `yaml:"delete"`
+	Options *openAPIOperation `yaml:"options"`
+	Head    *openAPIOperation `yaml:"head"`
+	Patch   *openAPIOperation `yaml:"patch"`
+	Trace   *openAPIOperation `yaml:"trace"`
+}
+
+func (item openAPIPathItem) operations() map[string]*openAPIOperation {
+	return map[string]*openAPIOperation{
+		http.MethodGet:     item.Get,
+		http.MethodPut:     item.Put,
+		http.MethodPost:    item.Post,
+		http.MethodDelete:  item.Delete,
+		http.MethodOptions: item.Options,
+		http.MethodHead:    item.Head,
+		http.MethodPatch:   item.Patch,
+		http.MethodTrace:   item.Trace,
+	}
+}
+
+// ParseOpenAPI reads a JSON or YAML OpenAPI document and maps every operation
+// onto its operationId.
+func ParseOpenAPI(r io.Reader) (ActionMap, error) {
+	var spec struct {
+		Paths map[string]openAPIPathItem `yaml:"paths"`
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if err = yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+
+	m := ActionMap{}
+	for path, item := range spec.Paths {
+		for method, operation := range item.operations() {
+			if operation == nil {
+				continue
+			}
+			if operation.OperationID == "" {
+				return nil, fmt.Errorf(`%w: %s %s`, ErrOpenAPIOperationID, method, path)
+			}
+			m[method+" "+path] = operation.OperationID
+		}
+	}
+	return m, nil
+}
+
+// GenerateActions writes a Go source file declaring a constant per action and
+// an ActionMap variable named Actions. Actions whose constant names collide,
+// e.g. "get-user" and "getUser", or are empty fail with
+// ErrActionNameConflict.
+func GenerateActions(w io.Writer, pkg string, m ActionMap) error {
+	names := map[string]string{}
+	for _, action := range slices.Sorted(maps.Keys(invert(m))) {
+		name := actionConstName(action)
+		if name == "Action" {
+			return fmt.Errorf(`%w: "%s" has no letters or digits`, ErrActionNameConflict, action)
+		}
+		if other, ok := names[name]; ok {
+			return fmt.Errorf(`%w: "%s" and "%s" are both %s`, ErrActionNameConflict, other, action, name)
+		}
+		names[name] = action
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "// Code generated by rbac-openapi. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	fmt.Fprintf(&buf, "import \"github.com/gowool/rbac\"\n\n")
+
+	actions := slices.Sorted(maps.Keys(invert(m)))
+	routes := slices.Sorted(maps.Keys(m))
+
+	buf.WriteString("const (\n")
+	for _, action := range actions {
+		fmt.Fprintf(&buf, "%s = %q\n", actionConstName(action), action)
+	}
+	buf.WriteString(")\n\nvar Actions = rbac.ActionMap{\n")
+	for _, route := range routes {
+		fmt.Fprintf(&buf, "%q: %s,\n", route, actionConstName(m[route]))
+	}
+	buf.WriteString("}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+func invert(m ActionMap) map[string]string {
+	inverted := make(map[string]string, len(m))
+	for route, action := range m {
+		inverted[action] = route
+	}
+	return inverted
+}
+
+func actionConstName(action string) string {
+	return "Action" + exportedIdent(action)
+}
+
+func exportedIdent(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
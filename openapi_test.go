@@ -0,0 +1,79 @@
+package rbac
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOpenAPI = `
+openapi: 3.0.0
+paths:
+  /users:
+    get:
+      operationId: listUsers
+    post:
+      operationId: create-user
+  /users/{id}:
+    parameters: []
+    get:
+      operationId: getUser
+`
+
+func TestParseOpenAPI(t *testing.T) {
+	m, err := ParseOpenAPI(strings.NewReader(testOpenAPI))
+	require.NoError(t, err)
+	assert.Equal(t, ActionMap{
+		"GET /users":      "listUsers",
+		"POST /users":     "create-user",
+		"GET /users/{id}": "getUser",
+	}, m)
+
+	m, err = ParseOpenAPI(strings.NewReader(`{"paths": {"/a": {"get": {"operationId": "getA"}}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, ActionMap{"GET /a": "getA"}, m)
+
+	_, err = ParseOpenAPI(strings.NewReader(`{"paths": {"/a": {"get": {}}}}`))
+	assert.ErrorIs(t, err, ErrOpenAPIOperationID)
+}
+
+func TestGenerateActions(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, GenerateActions(&buf, "api", ActionMap{
+		"GET /users":  "listUsers",
+		"POST /users": "create-user",
+	}))
+
+	assert.Equal(t, `// Code generated by rbac-openapi. DO NOT EDIT.
+
+package api
+
+import "github.com/gowool/rbac"
+
+const (
+	ActionCreateUser = "create-user"
+	ActionListUsers  = "listUsers"
+)
+
+var Actions = rbac.ActionMap{
+	"GET /users":  ActionListUsers,
+	"POST /users": ActionCreateUser,
+}
+`, buf.String())
+}
+
+func TestGenerateActions_NameConflict(t *testing.T) {
+	err := GenerateActions(io.Discard, "api", ActionMap{
+		"GET /users/{id}":    "get-user",
+		"GET /v2/users/{id}": "getUser",
+	})
+	assert.ErrorIs(t, err, ErrActionNameConflict)
+	assert.ErrorContains(t, err, `"get-user" and "getUser" are both ActionGetUser`)
+
+	err = GenerateActions(io.Discard, "api", ActionMap{"GET /": "--"})
+	assert.ErrorIs(t, err, ErrActionNameConflict)
+}
@@ -0,0 +1,162 @@
+package rbac
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchPath treats permissions as resource paths such as "org/42" or
+// "org/42/projects/*". A path grants itself and everything below it, "*"
+// matches any single segment. Paths are resolved like path.Clean, so
+// "org/42/../43" is checked as "org/43".
+const MatchPath PermissionMatching = "path"
+
+// PathTrie holds resource path grants. Check walks the ancestors of a path
+// instead of matching every grant.
+type PathTrie struct {
+	root  pathNode
+	paths map[string]struct{}
+}
+
+type pathNode struct {
+	children map[string]*pathNode
+	granted  bool
+}
+
+func NewPathTrie() *PathTrie {
+	return &PathTrie{paths: map[string]struct{}{}}
+}
+
+// Grant grants the path and everything below it.
+func (t *PathTrie) Grant(paths ...string) *PathTrie {
+	for _, path := range paths {
+		node := &t.root
+		for _, segment := range pathSegments(path) {
+			child, ok := node.children[segment]
+			if !ok {
+				if node.children == nil {
+					node.children = map[string]*pathNode{}
+				}
+				child = &pathNode{}
+				node.children[segment] = child
+			}
+			node = child
+		}
+		node.granted = true
+		t.paths[path] = struct{}{}
+	}
+	return t
+}
+
+// Revoke removes the grant at the path, grants above and below it are not
+// affected.
+func (t *PathTrie) Revoke(paths ...string) *PathTrie {
+	for _, path := range paths {
+		if _, ok := t.paths[path]; !ok {
+			continue
+		}
+		delete(t.paths, path)
+		t.root.revoke(pathSegments(path))
+	}
+	return t
+}
+
+// revoke clears the grant and reports whether the node became empty.
+func (n *pathNode) revoke(segments []string) bool {
+	if len(segments) == 0 {
+		n.granted = false
+	} else if child, ok := n.children[segments[0]]; ok && child.revoke(segments[1:]) {
+		delete(n.children, segments[0])
+	}
+	return !n.granted && len(n.children) == 0
+}
+
+// Check reports whether the path or one of its ancestors is granted.
+func (t *PathTrie) Check(path string) bool {
+	return t.root.check(pathSegments(path))
+}
+
+func (n *pathNode) check(segments []string) bool {
+	if n.granted {
+		return true
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if child, ok := n.children[segments[0]]; ok && child.check(segments[1:]) {
+		return true
+	}
+	if child, ok := n.children["*"]; ok && segments[0] != "" && child.check(segments[1:]) {
+		return true
+	}
+	return false
+}
+
+// Paths returns the granted paths in order.
+func (t *PathTrie) Paths() []string {
+	return sortedKeys(t.paths)
+}
+
+func (t *PathTrie) Len() int {
+	return len(t.paths)
+}
+
+// pathSegments resolves "." and ".." segments, which cannot climb above the
+// root, and drops empty ones.
+func pathSegments(p string) []string {
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// pathPermission marks permissions of the role's PathTrie.
+type pathPermission string
+
+func (p pathPermission) MatchString(s string) bool {
+	grant, path := pathSegments(string(p)), pathSegments(s)
+	if len(path) < len(grant) {
+		return false
+	}
+	for i, segment := range grant {
+		if segment != path[i] && (segment != "*" || path[i] == "") {
+			return false
+		}
+	}
+	return true
+}
+
+// AddPathPermissions adds resource path permissions, see MatchPath.
+func (r *Role) AddPathPermissions(paths ...string) error {
+	return r.addPermissions(paths, func(path string) (permissionMatcher, error) {
+		return pathPermission(path), nil
+	})
+}
+
+// PathTrie returns the role's own resource path permissions, nil if it has
+// none. It must not be modified.
+func (r *Role) PathTrie() *PathTrie {
+	return r.paths
+}
+
+// indexPaths keeps the PathTrie in sync with the permissions.
+func (r *Role) indexPaths(added, removed []string) {
+	for _, permission := range added {
+		if _, ok := r.permissions[permission].(pathPermission); !ok {
+			removed = append(removed, permission)
+			continue
+		}
+		if r.paths == nil {
+			r.paths = NewPathTrie()
+		}
+		r.paths.Grant(permission)
+	}
+	if r.paths == nil {
+		return
+	}
+	r.paths.Revoke(removed...)
+	if r.paths.Len() == 0 {
+		r.paths = nil
+	}
+}
@@ -0,0 +1,94 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathTrie(t *testing.T) {
+	trie := NewPathTrie().Grant("org/42/projects/*", "org/7", "/shared/")
+
+	assert.True(t, trie.Check("org/7"))
+	assert.True(t, trie.Check("org/7/projects/1/tasks/3"))
+	assert.True(t, trie.Check("org/42/projects/1"))
+	assert.True(t, trie.Check("/org/42/projects/1/tasks/3/"))
+	assert.True(t, trie.Check("shared/docs"))
+	assert.False(t, trie.Check("org/42"))
+	assert.False(t, trie.Check("org/42/projects"))
+	assert.False(t, trie.Check("org/42/users/1"))
+	assert.False(t, trie.Check("org/70"))
+	assert.False(t, trie.Check(""))
+	assert.Equal(t, []string{"/shared/", "org/42/projects/*", "org/7"}, trie.Paths())
+
+	trie.Grant("org/42/projects/9/secret").Revoke("org/42/projects/*", "missing")
+	assert.False(t, trie.Check("org/42/projects/1"))
+	assert.True(t, trie.Check("org/42/projects/9/secret/x"))
+	assert.Equal(t, 3, trie.Len())
+
+	assert.True(t, NewPathTrie().Grant("").Check("anything"))
+
+	// dot segments are resolved before checking
+	trie = NewPathTrie().Grant("org/42")
+	assert.False(t, trie.Check("org/42/../43"))
+	assert.False(t, trie.Check("org/42/../../org/43"))
+	assert.True(t, trie.Check("org/43/../42/./projects//1"))
+	assert.False(t, NewPathTrie().Grant("org/*/public").Check("org/1/../public"))
+}
+
+func TestRole_AddPathPermissions(t *testing.T) {
+	rbac := New()
+	member, admin := NewRole("member"), NewRole("admin")
+	require.NoError(t, rbac.AddRole(member))
+	require.NoError(t, rbac.AddRole(admin))
+	require.NoError(t, admin.AddChild(member))
+
+	require.NoError(t, member.AddPathPermissions("org/42/projects/*"))
+	require.NoError(t, admin.SetPermissionMatching(MatchPath).AddPermissionsE("org/42"))
+
+	assert.True(t, member.HasPermission("org/42/projects/1/tasks/2"))
+	assert.False(t, member.HasPermission("org/42/billing"))
+	assert.True(t, admin.HasPermission("org/42/billing"))
+	assert.True(t, admin.HasPermission("org/42/projects/1"))
+	assert.True(t, rbac.IsGranted(context.Background(), "admin", "org/42/users/3"))
+	assert.True(t, admin.grants("org/42", "org/42/users/3"))
+	assert.False(t, admin.grants("org/42", "org/42/../43"))
+	assert.False(t, rbac.IsGranted(context.Background(), "admin", "org/42/../43"))
+
+	matching, ok := member.PermissionMatchingOf("org/42/projects/*")
+	assert.True(t, ok)
+	assert.Equal(t, MatchPath, matching)
+	assert.Equal(t, []string{"org/42/projects/*"}, member.PathTrie().Paths())
+
+	clone := rbac.clone()
+	c, err := clone.Role("member")
+	require.NoError(t, err)
+	member.RemovePermissions("org/42/projects/*")
+	assert.False(t, member.HasPermission("org/42/projects/1"))
+	assert.Nil(t, member.PathTrie())
+	assert.True(t, c.HasPermission("org/42/projects/1"))
+
+	require.NoError(t, member.AddPathPermissions("org/1"))
+	require.NoError(t, member.AddLiteralPermissions("org/1"))
+	assert.False(t, member.HasPermission("org/1/projects"))
+
+	cfg := rbac.Export()
+	assert.Contains(t, cfg.AccessControl, AccessConfig{Role: "admin", Permissions: []string{"org/42"}})
+	applied, err := NewWithConfig(cfg)
+	require.NoError(t, err)
+	assert.True(t, applied.IsGranted(context.Background(), "admin", "org/42/users/3"))
+}
+
+func BenchmarkRole_HasPermission_Path(b *testing.B) {
+	role := NewRole("member")
+	for i := range 1000 {
+		_ = role.AddPathPermissions(fmt.Sprintf("org/%d/projects/*", i))
+	}
+	b.ResetTimer()
+	for b.Loop() {
+		role.HasPermission("org/999/projects/1/tasks/2")
+	}
+}
@@ -0,0 +1,88 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"regexp/syntax"
+	"strings"
+)
+
+var (
+	ErrInvalidPermission  = errors.New("invalid permission")
+	ErrTooManyPermissions = errors.New("too many permissions")
+)
+
+// PermissionLimits bounds what AddPermissions accepts. Zero values disable
+// the respective limit.
+type PermissionLimits struct {
+	MaxLength        int      `env:"MAX_LENGTH" json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	MaxRepeat        int      `env:"MAX_REPEAT" json:"maxRepeat,omitempty" yaml:"maxRepeat,omitempty"`
+	MaxPerRole       int      `env:"MAX_PER_ROLE" json:"maxPerRole,omitempty" yaml:"maxPerRole,omitempty"`
+	BannedConstructs []string `env:"BANNED_CONSTRUCTS" json:"bannedConstructs,omitempty" yaml:"bannedConstructs,omitempty"`
+}
+
+func (l PermissionLimits) isZero() bool {
+	return l.MaxLength == 0 && l.MaxRepeat == 0 && l.MaxPerRole == 0 && len(l.BannedConstructs) == 0
+}
+
+func ValidatePermission(permission string) error {
+	return PermissionLimits{}.Validate(permission)
+}
+
+func (l PermissionLimits) Validate(permission string) error {
+	if permission == "" {
+		return fmt.Errorf("%w: permission is empty", ErrInvalidPermission)
+	}
+
+	if l.MaxLength > 0 && len(permission) > l.MaxLength {
+		return fmt.Errorf(`%w: "%s" is longer than %d characters`, ErrInvalidPermission, permission, l.MaxLength)
+	}
+
+	for _, construct := range l.BannedConstructs {
+		if strings.Contains(permission, construct) {
+			return fmt.Errorf(`%w: "%s" contains banned construct "%s"`, ErrInvalidPermission, permission, construct)
+		}
+	}
+
+	if l.MaxRepeat > 0 {
+		// permissions that are not valid regular expressions match literally
+		if re, err := syntax.Parse(permission, syntax.Perl); err == nil && maxRepeat(re) > l.MaxRepeat {
+			return fmt.Errorf(`%w: "%s" repeats more than %d times`, ErrInvalidPermission, permission, l.MaxRepeat)
+		}
+	}
+
+	return nil
+}
+
+// validateRole checks the permissions a role was given before being
+// registered under the limits.
+func (l PermissionLimits) validateRole(r *Role) error {
+	var errs []error
+	for _, permission := range sortedKeys(r.permissions) {
+		errs = append(errs, l.Validate(permission))
+	}
+	if l.MaxPerRole > 0 && len(r.permissions) > l.MaxPerRole {
+		errs = append(errs, fmt.Errorf(`%w: role "%s" may hold at most %d permissions`, ErrTooManyPermissions, r.Name(), l.MaxPerRole))
+	}
+	return errors.Join(errs...)
+}
+
+func maxRepeat(re *syntax.Regexp) int {
+	n := max(re.Min, re.Max)
+	for _, sub := range re.Sub {
+		n = max(n, maxRepeat(sub))
+	}
+	return n
+}
+
+func (rbac *RBAC) SetPermissionLimits(limits PermissionLimits) *RBAC {
+	rbac.limits = limits
+	for _, role := range rbac.roles {
+		role.limits = limits
+	}
+	return rbac
+}
+
+func (rbac *RBAC) PermissionLimits() PermissionLimits {
+	return rbac.limits
+}
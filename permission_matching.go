@@ -0,0 +1,100 @@
+package rbac
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// PermissionMatching selects how AddPermissions interprets permissions.
+type PermissionMatching string
+
+const (
+	// MatchRegex treats every permission that compiles as a regular
+	// expression as a pattern. It is the default.
+	MatchRegex PermissionMatching = "regex"
+	// MatchLiteral only grants the exact permission string.
+	MatchLiteral PermissionMatching = "literal"
+	// MatchGlob treats "*", "**" and "?" as wildcards, e.g. "posts:*" or
+	// "GET /api/users/**". "*" and "?" do not match "/".
+	MatchGlob PermissionMatching = "glob"
+)
+
+// actionMatching selects which permissions grant a checked action, see
+// Target.Literal and Target.Anchored.
+type actionMatching int8
+
+const (
+	// matchPartial lets regular expressions match any part of the action.
+	matchPartial actionMatching = iota
+	// matchAnchored lets patterns only match the whole action.
+	matchAnchored
+	// matchEqual only lets permissions equal to the action grant it.
+	matchEqual
+)
+
+// match reports whether the pattern m of a permission grants the action.
+func (mode actionMatching) match(m permissionMatcher, action string) bool {
+	if m == nil || mode == matchEqual {
+		return false
+	}
+	if re, ok := m.(*regexp.Regexp); ok && mode == matchAnchored {
+		return anchorPermission(re).MatchString(action)
+	}
+	return m.MatchString(action)
+}
+
+func (m PermissionMatching) valid() bool {
+	switch m {
+	case "", MatchRegex, MatchLiteral, MatchGlob, MatchPath:
+		return true
+	default:
+		return false
+	}
+}
+
+// SetPermissionMatching sets the matching of the role, overriding the one of
+// the RBAC it is registered with. It applies to permissions added afterwards.
+func (r *Role) SetPermissionMatching(matching PermissionMatching) *Role {
+	r.matching = matching
+	return r
+}
+
+func (r *Role) PermissionMatching() PermissionMatching {
+	switch {
+	case r.matching != "":
+		return r.matching
+	case r.inherited != "":
+		return r.inherited
+	default:
+		return MatchRegex
+	}
+}
+
+// SetPermissionMatching sets the matching of all registered roles that do not
+// set their own. It applies to permissions added afterwards.
+func (rbac *RBAC) SetPermissionMatching(matching PermissionMatching) *RBAC {
+	rbac.matching = matching
+	for _, role := range rbac.roles {
+		role.inherited = matching
+	}
+	return rbac
+}
+
+func (rbac *RBAC) PermissionMatching() PermissionMatching {
+	if rbac.matching == "" {
+		return MatchRegex
+	}
+	return rbac.matching
+}
+
+// applyPermissionMatching keeps the matching set in code when the
+// configuration does not set one.
+func (rbac *RBAC) applyPermissionMatching(matching PermissionMatching) error {
+	if !matching.valid() {
+		return fmt.Errorf(`%w: unknown permission matching "%s"`, ErrInvalidPermission, matching)
+	}
+	if matching != "" {
+		rbac.SetPermissionMatching(matching)
+	}
+	return nil
+}
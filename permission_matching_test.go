@@ -0,0 +1,97 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRole_AddLiteralPermissions(t *testing.T) {
+	role := NewRole("foo")
+	require.NoError(t, role.AddLiteralPermissions("a.b"))
+	assert.True(t, role.HasPermission("a.b"))
+	assert.False(t, role.HasPermission("aXb"))
+
+	require.NoError(t, role.AddPermissionsE("c.d"))
+	assert.True(t, role.HasPermission("cXd"))
+}
+
+func TestRole_AddRegexPermissions(t *testing.T) {
+	role := NewRole("foo")
+	require.NoError(t, role.AddRegexPermissions(`post:\d+`))
+	assert.True(t, role.HasPermission("post:1"))
+
+	err := role.AddRegexPermissions("post:ok", "post:(")
+	assert.ErrorIs(t, err, ErrInvalidPermission)
+	assert.False(t, role.HasPermission("post:ok"))
+
+	require.NoError(t, role.AddPermissionsE("post:("))
+	assert.True(t, role.HasPermission("post:("))
+	assert.ErrorIs(t, role.AddRegexPermissions("post:("), ErrInvalidPermission)
+}
+
+func TestRole_SetPermissionMatching(t *testing.T) {
+	role := NewRole("foo")
+	assert.Equal(t, MatchRegex, role.PermissionMatching())
+
+	require.NoError(t, role.SetPermissionMatching(MatchLiteral).AddPermissionsE("a.b"))
+	assert.False(t, role.HasPermission("aXb"))
+
+	rbac := New().SetPermissionMatching(MatchRegex)
+	require.NoError(t, rbac.AddRole(role))
+	assert.Equal(t, MatchLiteral, role.PermissionMatching())
+}
+
+func TestRBAC_SetPermissionMatching(t *testing.T) {
+	rbac := New()
+	assert.Equal(t, MatchRegex, rbac.PermissionMatching())
+	require.NoError(t, rbac.AddRole("before"))
+
+	rbac.SetPermissionMatching(MatchLiteral)
+	assert.Equal(t, MatchLiteral, rbac.PermissionMatching())
+	require.NoError(t, rbac.AddRole("after"))
+
+	for _, name := range []string{"before", "after"} {
+		role, err := rbac.Role(name)
+		require.NoError(t, err)
+		assert.Equal(t, MatchLiteral, role.PermissionMatching())
+		require.NoError(t, role.AddPermissionsE("a.b"))
+		assert.False(t, rbac.IsGranted(context.Background(), name, "aXb"))
+	}
+}
+
+func TestConfig_PermissionMatching(t *testing.T) {
+	rbac, err := NewWithConfig(Config{
+		PermissionMatching: MatchLiteral,
+		RoleHierarchy:      []RoleConfig{{Role: "user"}},
+		AccessControl:      []AccessConfig{{Role: "user", Permissions: []string{"post.read"}}},
+	})
+	require.NoError(t, err)
+	assert.True(t, rbac.IsGranted(context.Background(), "user", "post.read"))
+	assert.False(t, rbac.IsGranted(context.Background(), "user", "postXread"))
+
+	rbac, err = NewWithConfig(Config{
+		PermissionMatching: MatchGlob,
+		RoleHierarchy:      []RoleConfig{{Role: "user"}},
+		AccessControl:      []AccessConfig{{Role: "user", Permissions: []string{"GET /posts/*"}}},
+	})
+	require.NoError(t, err)
+	assert.True(t, rbac.IsGranted(context.Background(), "user", "GET /posts/1"))
+	assert.False(t, rbac.IsGranted(context.Background(), "user", "GET /posts/1/comments"))
+
+	_, err = NewWithConfig(Config{PermissionMatching: "fuzzy"})
+	assert.ErrorIs(t, err, ErrInvalidPermission)
+}
+
+func TestConfig_PermissionMatchingUnset(t *testing.T) {
+	rbac := New().SetPermissionMatching(MatchLiteral)
+	require.NoError(t, rbac.Apply(Config{
+		RoleHierarchy: []RoleConfig{{Role: "user"}},
+		AccessControl: []AccessConfig{{Role: "user", Permissions: []string{"a.b"}}},
+	}))
+	assert.Equal(t, MatchLiteral, rbac.PermissionMatching())
+	assert.True(t, rbac.IsGranted(context.Background(), "user", "a.b"))
+	assert.False(t, rbac.IsGranted(context.Background(), "user", "aXb"))
+}
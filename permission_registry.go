@@ -0,0 +1,141 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+var ErrUndeclaredPermission = errors.New("undeclared permission")
+
+// PermissionMode selects how undeclared permissions are treated. Checked
+// actions are subject to it like granted permissions, so every action
+// RequestAuthorizer tries has to be declared, e.g. "*", the method and the
+// path of its default actions. Undeclared ones are reported on every request
+// in flag mode and never granted in strict mode; declare them or pick the
+// actions with WithActions.
+type PermissionMode int
+
+const (
+	// PermissionModeOff accepts any permission.
+	PermissionModeOff PermissionMode = iota
+	// PermissionModeFlag accepts undeclared permissions but reports them to
+	// the OnUndeclaredPermission callback.
+	PermissionModeFlag
+	// PermissionModeStrict rejects undeclared permissions in AddPermissions,
+	// Apply and IsGrantedE.
+	PermissionModeStrict
+)
+
+type permissionRegistry struct {
+	mu           sync.RWMutex
+	declared     map[string]struct{}
+	mode         PermissionMode
+	onUndeclared func(permission string)
+}
+
+func newPermissionRegistry() *permissionRegistry {
+	return &permissionRegistry{declared: map[string]struct{}{}}
+}
+
+// clone copies the registry for staging, see RBAC.Apply.
+func (r *permissionRegistry) clone() *permissionRegistry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return &permissionRegistry{declared: maps.Clone(r.declared), mode: r.mode}
+}
+
+// check validates a granted permission, which may be a pattern matching at
+// least one declared permission, or a checked action, which has to be
+// declared literally and is passed without pattern.
+func (r *permissionRegistry) check(permission string, pattern permissionMatcher) error {
+	if r == nil {
+		return nil
+	}
+
+	mode, onUndeclared, declared := r.lookup(permission, pattern)
+	switch {
+	case declared || mode == PermissionModeOff:
+		return nil
+	case mode == PermissionModeStrict:
+		return fmt.Errorf(`%w: "%s"`, ErrUndeclaredPermission, permission)
+	}
+	// called without the lock, so the callback may declare the permission
+	if onUndeclared != nil {
+		onUndeclared(permission)
+	}
+	return nil
+}
+
+func (r *permissionRegistry) lookup(permission string, pattern permissionMatcher) (PermissionMode, func(string), bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.mode == PermissionModeOff {
+		return r.mode, nil, false
+	}
+	if _, ok := r.declared[permission]; ok {
+		return r.mode, nil, true
+	}
+	if pattern != nil {
+		for declared := range r.declared {
+			if pattern.MatchString(declared) {
+				return r.mode, nil, true
+			}
+		}
+	}
+	return r.mode, r.onUndeclared, false
+}
+
+// checkRole validates the permissions a role was given before being
+// registered with the registry.
+func (r *permissionRegistry) checkRole(role *Role) error {
+	if role.registry == r {
+		return nil
+	}
+	var errs []error
+	for _, permission := range sortedKeys(role.permissions) {
+		errs = append(errs, r.check(permission, role.permissions[permission]))
+	}
+	return errors.Join(errs...)
+}
+
+// DeclarePermissions adds permissions to the taxonomy checked by
+// SetPermissionMode.
+func (rbac *RBAC) DeclarePermissions(permissions ...string) *RBAC {
+	rbac.registry.mu.Lock()
+	defer rbac.registry.mu.Unlock()
+
+	for _, permission := range permissions {
+		rbac.registry.declared[permission] = struct{}{}
+	}
+	return rbac
+}
+
+func (rbac *RBAC) DeclaredPermissions() []string {
+	rbac.registry.mu.RLock()
+	defer rbac.registry.mu.RUnlock()
+	return slices.Sorted(maps.Keys(rbac.registry.declared))
+}
+
+func (rbac *RBAC) SetPermissionMode(mode PermissionMode) *RBAC {
+	rbac.registry.mu.Lock()
+	defer rbac.registry.mu.Unlock()
+	rbac.registry.mode = mode
+	return rbac
+}
+
+func (rbac *RBAC) PermissionMode() PermissionMode {
+	rbac.registry.mu.RLock()
+	defer rbac.registry.mu.RUnlock()
+	return rbac.registry.mode
+}
+
+func (rbac *RBAC) OnUndeclaredPermission(fn func(permission string)) *RBAC {
+	rbac.registry.mu.Lock()
+	defer rbac.registry.mu.Unlock()
+	rbac.registry.onUndeclared = fn
+	return rbac
+}
@@ -0,0 +1,89 @@
+package rbac
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRBAC_PermissionModeStrict(t *testing.T) {
+	rbac := New().DeclarePermissions("post:read", "post:edit").SetPermissionMode(PermissionModeStrict)
+	assert.Equal(t, PermissionModeStrict, rbac.PermissionMode())
+	assert.Equal(t, []string{"post:edit", "post:read"}, rbac.DeclaredPermissions())
+
+	require.NoError(t, rbac.AddRole("editor"))
+	editor, _ := rbac.Role("editor")
+
+	assert.NoError(t, editor.AddPermissionsE("post:read", `post:(read|edit)`))
+	err := editor.AddPermissionsE("post:edit", "post:edot")
+	assert.ErrorIs(t, err, ErrUndeclaredPermission)
+	assert.ErrorContains(t, err, "post:edot")
+	assert.NotContains(t, slices.Collect(editor.Permissions(false)), "post:edit")
+	assert.ErrorIs(t, editor.AddPermissionsE(`comment:.*`), ErrUndeclaredPermission)
+
+	ok, err := rbac.IsGrantedE(context.Background(), "editor", "post:edit")
+	assert.True(t, ok)
+	assert.NoError(t, err)
+
+	ok, err = rbac.IsGrantedE(context.Background(), "editor", "post:edot")
+	assert.False(t, ok)
+	assert.ErrorIs(t, err, ErrUndeclaredPermission)
+
+	unregistered := NewRole("free")
+	assert.NoError(t, unregistered.AddPermissionsE("anything", "post:read"))
+	err = rbac.AddRole(unregistered)
+	assert.ErrorIs(t, err, ErrUndeclaredPermission)
+	assert.ErrorContains(t, err, "anything")
+	ok, _ = rbac.HasRole("free")
+	assert.False(t, ok)
+
+	declared := NewRole("declared")
+	declared.AddPermissions("post:read", `post:(read|edit)`)
+	assert.NoError(t, rbac.AddRole(declared))
+	assert.NoError(t, rbac.AddRole(declared))
+}
+
+func TestRBAC_PermissionModeFlag(t *testing.T) {
+	var flagged []string
+	rbac := New().
+		DeclarePermissions("post:read").
+		SetPermissionMode(PermissionModeFlag).
+		OnUndeclaredPermission(func(permission string) { flagged = append(flagged, permission) })
+
+	require.NoError(t, rbac.Apply(Config{
+		RoleHierarchy: []RoleConfig{{Role: "viewer"}},
+		AccessControl: []AccessConfig{{Role: "viewer", Permissions: []string{"post:read", "post:raed"}}},
+	}))
+	assert.Equal(t, []string{"post:raed"}, flagged)
+
+	assert.True(t, rbac.IsGranted(context.Background(), "viewer", "post:raed"))
+	assert.Equal(t, []string{"post:raed", "post:raed"}, flagged)
+
+	// the callback may declare what it is reported
+	rbac.OnUndeclaredPermission(func(permission string) {
+		flagged = append(flagged, permission)
+		rbac.DeclarePermissions(permission)
+	})
+	assert.True(t, rbac.IsGranted(context.Background(), "viewer", "post:raed"))
+	assert.True(t, rbac.IsGranted(context.Background(), "viewer", "post:raed"))
+	assert.Equal(t, []string{"post:raed", "post:raed", "post:raed"}, flagged)
+}
+
+func TestRBAC_ApplyDeclaredPermissions(t *testing.T) {
+	rbac := New().SetPermissionMode(PermissionModeStrict)
+
+	cfg := Config{
+		Permissions:   []string{"post:read"},
+		RoleHierarchy: []RoleConfig{{Role: "viewer"}},
+		AccessControl: []AccessConfig{{Role: "viewer", Permissions: []string{"post:read", "post:list"}}},
+	}
+	assert.ErrorIs(t, rbac.Apply(cfg), ErrUndeclaredPermission)
+	assert.Empty(t, rbac.DeclaredPermissions())
+
+	cfg.Permissions = append(cfg.Permissions, "post:list")
+	require.NoError(t, rbac.Apply(cfg))
+	assert.True(t, rbac.IsGranted(context.Background(), "viewer", "post:list"))
+}
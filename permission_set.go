@@ -0,0 +1,87 @@
+package rbac
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// PermissionSetConfig names a bundle of permissions granted as a unit, e.g.
+// "content-editing" for posts:create, posts:update and media:upload.
+type PermissionSetConfig struct {
+	Name        string   `env:"NAME" json:"name,omitempty" yaml:"name,omitempty"`
+	Permissions []string `env:"PERMISSIONS" json:"permissions,omitempty" yaml:"permissions,omitempty"`
+}
+
+// SetPermissionSet defines or replaces a permission set. Roles granted the
+// set before keep the permissions it expanded to then.
+func (rbac *RBAC) SetPermissionSet(name string, permissions ...string) *RBAC {
+	if rbac.permissionSets == nil {
+		rbac.permissionSets = map[string][]string{}
+	}
+	rbac.permissionSets[name] = unite(permissions)
+	return rbac
+}
+
+func (rbac *RBAC) PermissionSet(name string) ([]string, bool) {
+	permissions, ok := rbac.permissionSets[name]
+	return slices.Clone(permissions), ok
+}
+
+// PermissionSets returns the names of the defined permission sets sorted.
+func (rbac *RBAC) PermissionSets() []string {
+	return sortedKeys(rbac.permissionSets)
+}
+
+// GrantPermissionSets adds the permissions of the sets to the role with its
+// PermissionMatching and records which set granted them.
+func (rbac *RBAC) GrantPermissionSets(role *Role, sets ...string) error {
+	return rbac.grantPermissionSets(role, sets, role.AddPermissionsE)
+}
+
+func (rbac *RBAC) grantPermissionSets(role *Role, sets []string, add func(...string) error) error {
+	for _, name := range sets {
+		if _, ok := rbac.permissionSets[name]; !ok {
+			return fmt.Errorf(`%w: unknown permission set "%s"`, ErrInvalidPermission, name)
+		}
+	}
+	for _, name := range sets {
+		permissions := rbac.permissionSets[name]
+		if err := add(permissions...); err != nil {
+			return fmt.Errorf(`permission set "%s": %w`, name, err)
+		}
+		if role.permissionSets == nil {
+			role.permissionSets = map[string][]string{}
+		}
+		role.permissionSets[name] = unite(role.permissionSets[name], permissions)
+	}
+	return nil
+}
+
+// PermissionSets returns the names of the permission sets granted to the role
+// itself sorted.
+func (r *Role) PermissionSets() []string {
+	return sortedKeys(r.permissionSets)
+}
+
+// PermissionSetsOf returns the sets that granted the permission, held by the
+// role itself, sorted.
+func (r *Role) PermissionSetsOf(permission string) []string {
+	if _, ok := r.permissions[permission]; !ok {
+		return nil
+	}
+	var sets []string
+	for _, name := range sortedKeys(r.permissionSets) {
+		if slices.Contains(r.permissionSets[name], permission) {
+			sets = append(sets, name)
+		}
+	}
+	return sets
+}
+
+func clonePermissionSets(sets map[string][]string) map[string][]string {
+	if sets == nil {
+		return nil
+	}
+	return maps.Clone(sets)
+}
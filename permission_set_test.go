@@ -0,0 +1,84 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRBAC_GrantPermissionSets(t *testing.T) {
+	rbac := New().
+		SetPermissionSet("content-editing", "posts:create", "posts:update", "media:upload").
+		SetPermissionSet("media", "media:upload", "media:delete")
+	editor := NewRole("editor")
+	require.NoError(t, rbac.AddRole(editor))
+
+	require.NoError(t, rbac.GrantPermissionSets(editor, "content-editing", "media"))
+	assert.True(t, rbac.IsGranted(context.Background(), "editor", "posts:update"))
+	assert.True(t, rbac.IsGranted(context.Background(), "editor", "media:delete"))
+	assert.Equal(t, []string{"content-editing", "media"}, editor.PermissionSets())
+	assert.Equal(t, []string{"content-editing", "media"}, editor.PermissionSetsOf("media:upload"))
+	assert.Equal(t, []string{"content-editing"}, editor.PermissionSetsOf("posts:create"))
+
+	editor.RemovePermissions("posts:create")
+	assert.Empty(t, editor.PermissionSetsOf("posts:create"))
+
+	permissions, ok := rbac.PermissionSet("media")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"media:upload", "media:delete"}, permissions)
+	assert.Equal(t, []string{"content-editing", "media"}, rbac.PermissionSets())
+
+	err := rbac.GrantPermissionSets(editor, "media", "missing")
+	assert.ErrorIs(t, err, ErrInvalidPermission)
+	assert.ErrorContains(t, err, `unknown permission set "missing"`)
+}
+
+const permissionSetConfig = `
+createMissingRoles: true
+permissionSets:
+  - name: content-editing
+    permissions: [posts:create, posts:update, media:upload]
+accessControl:
+  - role: editor
+    permissions: [posts:read]
+    permissionSets: [content-editing]
+`
+
+func TestConfig_PermissionSets(t *testing.T) {
+	var cfg Config
+	require.NoError(t, yaml.Unmarshal([]byte(permissionSetConfig), &cfg))
+	require.NoError(t, cfg.Validate())
+
+	rbac, err := NewWithConfig(cfg)
+	require.NoError(t, err)
+	editor, err := rbac.Role("editor")
+	require.NoError(t, err)
+	assert.True(t, editor.HasPermission("media:upload"))
+	assert.True(t, editor.HasPermission("posts:read"))
+	assert.Equal(t, []string{"content-editing"}, editor.PermissionSetsOf("posts:update"))
+
+	exported := rbac.Export()
+	assert.Equal(t, cfg.PermissionSets, exported.PermissionSets)
+	assert.Contains(t, exported.AccessControl, AccessConfig{Role: "editor", PermissionSets: []string{"content-editing"}})
+	applied, err := NewWithConfig(exported)
+	require.NoError(t, err)
+	r, err := applied.Role("editor")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"content-editing"}, r.PermissionSetsOf("posts:update"))
+
+	merged, err := cfg.Merge(Config{
+		PermissionSets: []PermissionSetConfig{{Name: "content-editing", Permissions: []string{"media:delete"}}},
+		AccessControl:  []AccessConfig{{Role: "editor", PermissionSets: []string{"review"}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"posts:create", "posts:update", "media:upload", "media:delete"}, merged.PermissionSets[0].Permissions)
+	assert.Equal(t, []string{"content-editing", "review"}, merged.AccessControl[0].PermissionSets)
+	assert.ErrorContains(t, merged.Validate(), `unknown permission set "review" in accessControl[0]`)
+	assert.ErrorIs(t, New().Apply(merged), ErrInvalidPermission)
+
+	cfg.PermissionSets = append(cfg.PermissionSets, PermissionSetConfig{Permissions: []string{"x"}})
+	assert.ErrorContains(t, cfg.Validate(), "permissionSets[1] has no name")
+}
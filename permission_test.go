@@ -0,0 +1,78 @@
+package rbac
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePermission(t *testing.T) {
+	assert.NoError(t, ValidatePermission("posts:read"))
+	assert.NoError(t, ValidatePermission("*"))
+	assert.ErrorIs(t, ValidatePermission(""), ErrInvalidPermission)
+}
+
+func TestPermissionLimits_Validate(t *testing.T) {
+	limits := PermissionLimits{MaxLength: 16, MaxRepeat: 10, BannedConstructs: []string{".*"}}
+
+	assert.NoError(t, limits.Validate("posts:\\d{1,10}"))
+	assert.ErrorIs(t, limits.Validate("posts:read:everything"), ErrInvalidPermission)
+	assert.ErrorIs(t, limits.Validate("posts:.*"), ErrInvalidPermission)
+	assert.ErrorIs(t, limits.Validate("a{1000}"), ErrInvalidPermission)
+	assert.ErrorIs(t, limits.Validate("(a{11})"), ErrInvalidPermission)
+	assert.NoError(t, limits.Validate("*"))
+}
+
+func TestRole_AddPermissionsWithLimits(t *testing.T) {
+	rbac := New().SetPermissionLimits(PermissionLimits{MaxPerRole: 2})
+	role := NewRole("user")
+	assert.NoError(t, rbac.AddRole(role))
+
+	assert.NoError(t, role.AddPermissionsE("a", "b", "a"))
+	assert.ErrorIs(t, role.AddPermissionsE("c"), ErrTooManyPermissions)
+	assert.NoError(t, role.AddPermissionsE("b"))
+	assert.ErrorIs(t, role.AddPermissionsE(""), ErrInvalidPermission)
+	assert.False(t, role.HasPermission("c"))
+
+	rbac.SetPermissionLimits(PermissionLimits{})
+	assert.NoError(t, role.AddPermissionsE("c"))
+}
+
+func TestRole_AddPermissionsSkipsRejected(t *testing.T) {
+	rbac := New().SetPermissionLimits(PermissionLimits{BannedConstructs: []string{".*"}})
+	role := NewRole("user")
+	require.NoError(t, rbac.AddRole(role))
+
+	role.AddPermissions("posts:read", ".*", "posts:list")
+	assert.ElementsMatch(t, []string{"posts:read", "posts:list"}, slices.Collect(role.Permissions(false)))
+}
+
+func TestRBAC_AddRoleWithLimits(t *testing.T) {
+	rbac := New().SetPermissionLimits(PermissionLimits{MaxLength: 10, MaxPerRole: 1, BannedConstructs: []string{".*"}})
+
+	evil := NewRole("evil")
+	evil.AddPermissions(".*", strings.Repeat("a", 50), "b")
+	err := rbac.AddRole(evil)
+	assert.ErrorIs(t, err, ErrInvalidPermission)
+	assert.ErrorIs(t, err, ErrTooManyPermissions)
+	ok, _ := rbac.HasRole("evil")
+	assert.False(t, ok)
+	assert.False(t, rbac.IsGranted(context.Background(), "evil", "admin:delete"))
+
+	fine := NewRole("fine")
+	fine.AddPermissions("b")
+	assert.NoError(t, rbac.AddRole(fine))
+}
+
+func TestApply_PermissionLimits(t *testing.T) {
+	_, err := NewWithConfig(Config{
+		PermissionLimits: PermissionLimits{BannedConstructs: []string{".*"}},
+		RoleHierarchy:    []RoleConfig{{Role: "admin"}},
+		AccessControl:    []AccessConfig{{Role: "admin", Permissions: []string{".*"}}},
+	})
+	assert.ErrorIs(t, err, ErrInvalidPermission)
+}
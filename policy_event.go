@@ -0,0 +1,102 @@
+package rbac
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+type PolicyEventType string
+
+const (
+	PolicyRoleAdded          PolicyEventType = "role.added"
+	PolicyRoleRemoved        PolicyEventType = "role.removed"
+	PolicyRoleRenamed        PolicyEventType = "role.renamed"
+	PolicyPermissionsAdded   PolicyEventType = "permissions.added"
+	PolicyPermissionsRemoved PolicyEventType = "permissions.removed"
+	PolicyChildAdded         PolicyEventType = "child.added"
+	PolicyChildRemoved       PolicyEventType = "child.removed"
+)
+
+type PolicyEvent struct {
+	// Seq is assigned by an EventStore.
+	Seq  uint64          `json:"seq,omitempty"`
+	Type PolicyEventType `json:"type"`
+	Time time.Time       `json:"time"`
+	Role string          `json:"role"`
+	// Previous is the former name of a renamed role.
+	Previous string `json:"previous,omitempty"`
+	// Child is the role added below or removed from Role.
+	Child       string   `json:"child,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// OnChange registers a function called synchronously after every mutation of
+// the policy made through the RBAC or its registered roles. Dry runs of Apply
+// do not emit events.
+func (rbac *RBAC) OnChange(fn func(PolicyEvent)) *RBAC {
+	rbac.onChange = fn
+	return rbac.rewire()
+}
+
+// Watch adds fn to the functions called after every mutation, next to the
+// OnChange callback, e.g. CachingAuthorizer.Watch.
+func (rbac *RBAC) Watch(fn func(PolicyEvent)) *RBAC {
+	rbac.watchers = append(rbac.watchers, fn)
+	return rbac.rewire()
+}
+
+// rewire hands the OnChange callback and the watchers to the registered
+// roles as a single function.
+func (rbac *RBAC) rewire() *RBAC {
+	onChange, watchers := rbac.onChange, slices.Clone(rbac.watchers)
+	switch {
+	case len(watchers) == 0:
+		rbac.notify = onChange
+	case onChange == nil && len(watchers) == 1:
+		rbac.notify = watchers[0]
+	default:
+		rbac.notify = func(event PolicyEvent) {
+			if onChange != nil {
+				onChange(event)
+			}
+			for _, fn := range watchers {
+				fn(event)
+			}
+		}
+	}
+	for _, role := range rbac.roles {
+		role.notify = rbac.notify
+	}
+	return rbac
+}
+
+func (rbac *RBAC) emit(event PolicyEvent) {
+	if rbac.notify != nil {
+		event.Time = time.Now()
+		rbac.notify(event)
+	}
+}
+
+func (r *Role) emit(event PolicyEvent) {
+	if r.notify != nil {
+		event.Time = time.Now()
+		r.notify(event)
+	}
+}
+
+// roleEdges describes the hierarchy a role brings along when registered.
+func roleEdges(r *Role) []PolicyEvent {
+	var edges []PolicyEvent
+	for _, parent := range sortedKeys(r.parents) {
+		edges = append(edges, PolicyEvent{Type: PolicyChildAdded, Role: parent, Child: r.Name()})
+	}
+	for _, child := range sortedKeys(r.children) {
+		edges = append(edges, PolicyEvent{Type: PolicyChildAdded, Role: r.Name(), Child: child})
+	}
+	return edges
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	return slices.Sorted(maps.Keys(m))
+}
@@ -0,0 +1,67 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRBAC_OnChange(t *testing.T) {
+	rbac := New()
+	require.NoError(t, rbac.AddRole("admin"))
+
+	var events []PolicyEvent
+	rbac.OnChange(func(event PolicyEvent) {
+		assert.False(t, event.Time.IsZero())
+		events = append(events, PolicyEvent{Type: event.Type, Role: event.Role, Previous: event.Previous, Child: event.Child, Permissions: event.Permissions})
+	})
+
+	admin, err := rbac.Role("admin")
+	require.NoError(t, err)
+	require.NoError(t, admin.AddPermissionsE("write", "read", "write"))
+	require.NoError(t, admin.AddPermissionsE("read"))
+
+	editor := NewRole("editor")
+	editor.AddTags("generated")
+	require.NoError(t, rbac.AddRole(editor, "admin"))
+	require.NoError(t, rbac.RenameRole("editor", "author"))
+	author, err := rbac.Role("author")
+	require.NoError(t, err)
+	admin.RemoveChild(author)
+	assert.Equal(t, 1, rbac.RemoveByTag("generated"))
+
+	assert.Equal(t, []PolicyEvent{
+		{Type: PolicyPermissionsAdded, Role: "admin", Permissions: []string{"read", "write"}},
+		{Type: PolicyChildAdded, Role: "admin", Child: "editor"},
+		{Type: PolicyRoleAdded, Role: "editor"},
+		{Type: PolicyRoleRenamed, Role: "author", Previous: "editor"},
+		{Type: PolicyChildRemoved, Role: "admin", Child: "author"},
+		{Type: PolicyRoleRemoved, Role: "author"},
+	}, events)
+
+	events = nil
+	err = rbac.Apply(Config{RoleHierarchy: []RoleConfig{{Role: "viewer", Parents: []string{"missing"}}}})
+	assert.ErrorIs(t, err, ErrRoleNotFound)
+	assert.Empty(t, events)
+
+	require.NoError(t, rbac.Apply(Config{AccessControl: []AccessConfig{{Role: "admin", Permissions: []string{"delete"}}}}))
+	assert.Equal(t, []PolicyEvent{{Type: PolicyPermissionsAdded, Role: "admin", Permissions: []string{"delete"}}}, events)
+}
+
+func TestRBAC_Watch(t *testing.T) {
+	rbac := New()
+	var changes, watched, more int
+	rbac.OnChange(func(PolicyEvent) { changes++ })
+	rbac.Watch(func(PolicyEvent) { watched++ })
+	require.NoError(t, rbac.AddRole("user"))
+	rbac.Watch(func(PolicyEvent) { more++ })
+	mustRole(t, rbac, "user").AddPermissions("read")
+
+	rbac.OnChange(nil)
+	require.NoError(t, rbac.AddRole("admin"))
+
+	assert.Equal(t, 2, changes)
+	assert.Equal(t, 3, watched)
+	assert.Equal(t, 2, more)
+}
@@ -0,0 +1,60 @@
+package rbac
+
+import (
+	"context"
+	"runtime/pprof"
+	"strconv"
+)
+
+const (
+	LabelAuthorizer = "rbac.authorizer"
+	LabelAction     = "rbac.action"
+	LabelRole       = "rbac.role"
+	LabelRoles      = "rbac.roles"
+)
+
+var _ Authorizer = (*ProfiledAuthorizer)(nil)
+
+// SetProfileLabels runs IsGrantedE under pprof labels with the action and
+// role, so CPU profiles attribute time to policies.
+func (rbac *RBAC) SetProfileLabels(enabled bool) *RBAC {
+	rbac.profile = enabled
+	return rbac
+}
+
+func (rbac *RBAC) ProfileLabels() bool {
+	return rbac.profile
+}
+
+func (rbac *RBAC) profileDo(ctx context.Context, role any, permission string, fn func(ctx context.Context)) {
+	name, _ := rbac.roleName(role)
+	pprof.Do(ctx, pprof.Labels(LabelAction, permission, LabelRole, name), fn)
+}
+
+// ProfiledAuthorizer runs the wrapped authorizer under pprof labels with its
+// name, the action and the number of subject roles.
+type ProfiledAuthorizer struct {
+	name       string
+	authorizer Authorizer
+}
+
+func NewProfiledAuthorizer(name string, authorizer Authorizer) *ProfiledAuthorizer {
+	return &ProfiledAuthorizer{name: name, authorizer: authorizer}
+}
+
+func (a *ProfiledAuthorizer) Authorize(ctx context.Context, claims *Claims, target *Target) (d Decision) {
+	var action string
+	if target != nil {
+		action = target.Action
+	}
+	var roles int
+	if claims != nil && claims.Subject != nil {
+		roles = len(claims.Subject.Roles())
+	}
+
+	labels := pprof.Labels(LabelAuthorizer, a.name, LabelAction, action, LabelRoles, strconv.Itoa(roles))
+	pprof.Do(ctx, labels, func(ctx context.Context) {
+		d = a.authorizer.Authorize(ctx, claims, target)
+	})
+	return d
+}
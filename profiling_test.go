@@ -0,0 +1,121 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type labelAssertion struct {
+	labels map[string]string
+}
+
+func (a *labelAssertion) Assert(ctx context.Context, _ *Role, _ string) bool {
+	pprof.ForLabels(ctx, func(key, value string) bool {
+		a.labels[key] = value
+		return true
+	})
+	return true
+}
+
+func TestRBAC_SetProfileLabels(t *testing.T) {
+	rbac := New()
+	role := NewRole("admin")
+	role.AddPermissions("post.edit")
+	_ = rbac.AddRole(role)
+
+	a := &labelAssertion{labels: map[string]string{}}
+	assert.True(t, rbac.IsGranted(context.Background(), "admin", "post.edit", a))
+	assert.Empty(t, a.labels)
+
+	assert.True(t, rbac.SetProfileLabels(true).ProfileLabels())
+	assert.True(t, rbac.IsGranted(context.Background(), "admin", "post.edit", a))
+	assert.Equal(t, map[string]string{LabelAction: "post.edit", LabelRole: "admin"}, a.labels)
+}
+
+func TestProfiledAuthorizer_Authorize(t *testing.T) {
+	var labels map[string]string
+	a := NewProfiledAuthorizer("api", authorizerFunc(func(ctx context.Context, _ *Claims, _ *Target) Decision {
+		labels = map[string]string{}
+		pprof.ForLabels(ctx, func(key, value string) bool {
+			labels[key] = value
+			return true
+		})
+		return DecisionAllow
+	}))
+
+	d := a.Authorize(context.Background(), &Claims{Subject: NewSubject("1", "user", "editor")}, &Target{Action: "post.edit"})
+	assert.Equal(t, DecisionAllow, d)
+	assert.Equal(t, map[string]string{LabelAuthorizer: "api", LabelAction: "post.edit", LabelRoles: "2"}, labels)
+
+	a.Authorize(context.Background(), nil, nil)
+	assert.Equal(t, map[string]string{LabelAuthorizer: "api", LabelAction: "", LabelRoles: "0"}, labels)
+}
+
+func benchmarkHierarchy(depth, patterns int) *RBAC {
+	rbac := New()
+	var parent *Role
+	for i := range depth {
+		role := NewRole(fmt.Sprintf("role-%d", i))
+		for j := range patterns {
+			role.AddPermissions(fmt.Sprintf(`res%d-%d:\d+:(read|write)`, i, j))
+		}
+		role.AddPermissions(fmt.Sprintf("res%d.view", i))
+		if parent == nil {
+			_ = rbac.AddRole(role)
+		} else {
+			_ = rbac.AddRole(role, parent)
+		}
+		parent = role
+	}
+	return rbac
+}
+
+func BenchmarkIsGranted(b *testing.B) {
+	for _, bc := range []struct {
+		depth, patterns int
+	}{{1, 0}, {10, 0}, {50, 0}, {10, 10}, {50, 10}} {
+		rbac := benchmarkHierarchy(bc.depth, bc.patterns)
+		ctx := context.Background()
+
+		b.Run(fmt.Sprintf("depth=%d/patterns=%d/literal", bc.depth, bc.patterns), func(b *testing.B) {
+			for b.Loop() {
+				rbac.IsGranted(ctx, "role-0", fmt.Sprintf("res%d.view", bc.depth-1))
+			}
+		})
+		b.Run(fmt.Sprintf("depth=%d/patterns=%d/miss", bc.depth, bc.patterns), func(b *testing.B) {
+			for b.Loop() {
+				rbac.IsGranted(ctx, "role-0", "missing")
+			}
+		})
+		b.Run(fmt.Sprintf("depth=%d/patterns=%d/profiled", bc.depth, bc.patterns), func(b *testing.B) {
+			profiled := rbac.clone().SetProfileLabels(true)
+			for b.Loop() {
+				profiled.IsGranted(ctx, "role-0", "missing")
+			}
+		})
+	}
+}
+
+func BenchmarkAuthorize(b *testing.B) {
+	rbac := benchmarkHierarchy(20, 5)
+	claims := &Claims{Subject: NewSubject("1", "role-0", "role-5", "role-10")}
+	target := &Target{Action: `res19-4:42:write`}
+	ctx := context.Background()
+
+	b.Run("default", func(b *testing.B) {
+		a := NewDefaultAuthorizer(rbac)
+		for b.Loop() {
+			a.Authorize(ctx, claims, target)
+		}
+	})
+	b.Run("profiled", func(b *testing.B) {
+		a := NewProfiledAuthorizer("default", NewDefaultAuthorizer(rbac))
+		for b.Loop() {
+			a.Authorize(ctx, claims, target)
+		}
+	})
+}
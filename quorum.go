@@ -0,0 +1,128 @@
+package rbac
+
+import (
+	"context"
+	"time"
+)
+
+var _ Authorizer = (*QuorumAuthorizer)(nil)
+
+// QuorumFailure decides how a voter that timed out or panicked is counted.
+type QuorumFailure int
+
+const (
+	// QuorumFailDeny counts a failed voter as a denial.
+	QuorumFailDeny QuorumFailure = iota
+	// QuorumFailAbstain drops a failed voter, lowering the threshold to the
+	// number of voters that answered. All voters failing still denies.
+	QuorumFailAbstain
+)
+
+// QuorumAuthorizer allows when at least threshold of the wrapped authorizers
+// allow. Voters run concurrently and evaluation stops as soon as the outcome
+// is known. A threshold below one is treated as one. Abstaining voters count
+// as not approving, so they cannot lower the threshold; if all abstain the
+// quorum abstains.
+type QuorumAuthorizer struct {
+	authorizers []Authorizer
+	threshold   int
+	timeout     time.Duration
+	failure     QuorumFailure
+}
+
+func NewQuorumAuthorizer(threshold int, authorizers ...Authorizer) *QuorumAuthorizer {
+	return &QuorumAuthorizer{authorizers: authorizers, threshold: max(threshold, 1)}
+}
+
+// SetTimeout bounds each voter; zero waits for the caller's context only.
+func (a *QuorumAuthorizer) SetTimeout(timeout time.Duration) *QuorumAuthorizer {
+	a.timeout = timeout
+	return a
+}
+
+func (a *QuorumAuthorizer) SetFailurePolicy(failure QuorumFailure) *QuorumAuthorizer {
+	a.failure = failure
+	return a
+}
+
+type quorumVote struct {
+	decision Decision
+	failed   bool
+}
+
+func (a *QuorumAuthorizer) Authorize(ctx context.Context, claims *Claims, target *Target) Decision {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	votes := make(chan quorumVote, len(a.authorizers))
+	for _, authorizer := range a.authorizers {
+		go func() {
+			votes <- a.vote(ctx, authorizer, claims, target)
+		}()
+	}
+
+	var allowed, failed, abstained int
+	warn := false
+	for received := 1; received <= len(a.authorizers); received++ {
+		v := <-votes
+		switch {
+		case v.failed:
+			failed++
+		case v.decision == DecisionAbstain:
+			abstained++
+		case v.decision.Allowed():
+			allowed++
+			warn = warn || v.decision == DecisionWarn
+		}
+		if abstained == len(a.authorizers) {
+			return DecisionAbstain
+		}
+
+		threshold := a.threshold
+		if a.failure == QuorumFailAbstain {
+			threshold = max(min(threshold, len(a.authorizers)-failed), 1)
+		}
+		if allowed >= threshold {
+			if warn {
+				return DecisionWarn
+			}
+			return DecisionAllow
+		}
+		if allowed+len(a.authorizers)-received < threshold {
+			return DecisionDeny
+		}
+	}
+	return DecisionDeny
+}
+
+func (a *QuorumAuthorizer) vote(ctx context.Context, authorizer Authorizer, claims *Claims, target *Target) (v quorumVote) {
+	if a.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.timeout)
+		defer cancel()
+	}
+
+	// voters may outlive Authorize, so they get their own copy of a possibly
+	// pooled target
+	if target != nil {
+		t := *target
+		target = &t
+	}
+
+	result := make(chan Decision, 1)
+	go func() {
+		defer func() {
+			if recover() != nil {
+				close(result)
+			}
+		}()
+		result <- authorizer.Authorize(ctx, claims, target)
+	}()
+
+	select {
+	case d, ok := <-result:
+		return quorumVote{decision: d, failed: !ok}
+	case <-ctx.Done():
+		return quorumVote{failed: true}
+	}
+}
@@ -0,0 +1,81 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type slowAuthorizer struct {
+	delay    time.Duration
+	decision Decision
+}
+
+func (a slowAuthorizer) Authorize(ctx context.Context, _ *Claims, _ *Target) Decision {
+	select {
+	case <-time.After(a.delay):
+		return a.decision
+	case <-ctx.Done():
+		return DecisionAllow
+	}
+}
+
+type panicAuthorizer struct{}
+
+func (panicAuthorizer) Authorize(context.Context, *Claims, *Target) Decision {
+	panic("boom")
+}
+
+func TestQuorumAuthorizer(t *testing.T) {
+	allow := &mockAuthorizer{decision: DecisionAllow}
+	warn := &mockAuthorizer{decision: DecisionWarn}
+	deny := &mockAuthorizer{decision: DecisionDeny}
+	abstain := &mockAuthorizer{decision: DecisionAbstain}
+	claims := &Claims{Subject: NewSubject("u1", "user")}
+	target := &Target{Action: "read"}
+
+	tests := []struct {
+		name       string
+		authorizer *QuorumAuthorizer
+		expected   Decision
+	}{
+		{"2 of 3", NewQuorumAuthorizer(2, allow, deny, allow), DecisionAllow},
+		{"1 of 3", NewQuorumAuthorizer(2, allow, deny, deny), DecisionDeny},
+		{"warn", NewQuorumAuthorizer(2, allow, warn), DecisionWarn},
+		{"threshold above voters", NewQuorumAuthorizer(3, allow, allow), DecisionDeny},
+		{"zero threshold", NewQuorumAuthorizer(0, deny, deny), DecisionDeny},
+		{"no voters", NewQuorumAuthorizer(1), DecisionDeny},
+		{"panic denies", NewQuorumAuthorizer(2, allow, panicAuthorizer{}), DecisionDeny},
+		{"panic abstains", NewQuorumAuthorizer(2, allow, panicAuthorizer{}).SetFailurePolicy(QuorumFailAbstain), DecisionAllow},
+		{"all fail", NewQuorumAuthorizer(1, panicAuthorizer{}).SetFailurePolicy(QuorumFailAbstain), DecisionDeny},
+		{"abstain keeps denials", NewQuorumAuthorizer(2, allow, deny, panicAuthorizer{}).SetFailurePolicy(QuorumFailAbstain), DecisionDeny},
+		{"abstaining voter", NewQuorumAuthorizer(2, allow, abstain), DecisionDeny},
+		{"abstentions do not lower the threshold", NewQuorumAuthorizer(2, allow, abstain, abstain), DecisionDeny},
+		{"abstentions with a quorum", NewQuorumAuthorizer(2, allow, abstain, allow), DecisionAllow},
+		{"abstaining voter keeps denials", NewQuorumAuthorizer(2, allow, deny, abstain), DecisionDeny},
+		{"all abstain", NewQuorumAuthorizer(1, abstain, abstain), DecisionAbstain},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.authorizer.Authorize(context.Background(), claims, target))
+		})
+	}
+}
+
+func TestQuorumAuthorizer_Timeout(t *testing.T) {
+	slow := slowAuthorizer{delay: time.Second, decision: DecisionAllow}
+	allow := &mockAuthorizer{decision: DecisionAllow}
+
+	a := NewQuorumAuthorizer(2, allow, slow).SetTimeout(10 * time.Millisecond)
+	assert.Equal(t, DecisionDeny, a.Authorize(context.Background(), nil, &Target{Action: "read"}))
+
+	a.SetFailurePolicy(QuorumFailAbstain)
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), nil, &Target{Action: "read"}))
+
+	start := time.Now()
+	a = NewQuorumAuthorizer(1, allow, slow)
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), nil, &Target{Action: "read"}))
+	assert.Less(t, time.Since(start), time.Second)
+}
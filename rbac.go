@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"iter"
 	"maps"
+	"slices"
+	"time"
 )
 
 var (
@@ -13,6 +15,8 @@ var (
 	ErrRoleNil      = errors.New("role is nil")
 	ErrRoleNotFound = errors.New("role not found")
 	ErrInvalidRole  = errors.New("role must be a string or implement the Role interface")
+	ErrRoleExists   = errors.New("role already exists")
+	ErrWarn         = errors.New("granted with warning")
 )
 
 type Assertion interface {
@@ -25,17 +29,37 @@ func (f AssertionFunc) Assert(ctx context.Context, role *Role, permission string
 	return f(ctx, role, permission)
 }
 
+type ErrorAssertion interface {
+	AssertE(ctx context.Context, role *Role, permission string) error
+}
+
 type AuthorizationChecker interface {
 	IsGranted(ctx context.Context, role any, permission string, assertions ...Assertion) bool
 }
 
 type RBAC struct {
-	roles              map[string]*Role
-	createMissingRoles bool
+	roles               map[string]*Role
+	createMissingRoles  bool
+	usage               *roleUsage
+	trackUsage          bool
+	limits              PermissionLimits
+	observer            AssertionObserver
+	notify              func(PolicyEvent)
+	onChange            func(PolicyEvent)
+	watchers            []func(PolicyEvent)
+	registry            *permissionRegistry
+	profile             bool
+	matching            PermissionMatching
+	exclusive           []ExclusiveRolesConfig
+	assertions          *AssertionRegistry
+	implications        *implications
+	permissionSets      map[string][]string
+	superusers          []string
+	superuserAssertions bool
 }
 
 func New() *RBAC {
-	return &RBAC{roles: map[string]*Role{}}
+	return &RBAC{roles: map[string]*Role{}, usage: newRoleUsage(), registry: newPermissionRegistry(), implications: &implications{}}
 }
 
 func (rbac *RBAC) SetCreateMissingRoles(createMissingRoles bool) *RBAC {
@@ -51,6 +75,58 @@ func (rbac *RBAC) Roles() iter.Seq[*Role] {
 	return maps.Values(rbac.roles)
 }
 
+func (rbac *RBAC) RolesByTag(tag string) iter.Seq[*Role] {
+	return func(yield func(*Role) bool) {
+		for _, role := range rbac.roles {
+			if role.HasTag(tag) && !yield(role) {
+				return
+			}
+		}
+	}
+}
+
+// RemoveByTag detaches and removes every role carrying the tag and returns
+// the number of removed roles.
+func (rbac *RBAC) RemoveByTag(tag string) int {
+	var n int
+	for name, role := range rbac.roles {
+		if role.HasTag(tag) {
+			rbac.removeRole(name)
+			n++
+		}
+	}
+	return n
+}
+
+// RemoveRole detaches the role from its parents and children and removes it.
+func (rbac *RBAC) RemoveRole(name string) error {
+	if _, err := rbac.Role(name); err != nil {
+		return err
+	}
+	rbac.removeRole(name)
+	return nil
+}
+
+func (rbac *RBAC) removeRole(name string) {
+	if role, ok := rbac.roles[name]; ok {
+		role.detach()
+		role.notify = nil
+		delete(rbac.roles, name)
+		rbac.usage.remove(name)
+		rbac.emit(PolicyEvent{Type: PolicyRoleRemoved, Role: name})
+	}
+}
+
+// ApplyToTag calls fn for every role carrying the tag and stops at the first error.
+func (rbac *RBAC) ApplyToTag(tag string, fn func(*Role) error) error {
+	for role := range rbac.RolesByTag(tag) {
+		if err := fn(role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (rbac *RBAC) Role(name string) (*Role, error) {
 	if role, ok := rbac.roles[name]; ok {
 		return role, nil
@@ -80,6 +156,21 @@ func (rbac *RBAC) AddRole(role any, parents ...any) error {
 		return ErrInvalidRole
 	}
 
+	if err := errors.Join(rbac.limits.validateRole(r), rbac.registry.checkRole(r)); err != nil {
+		return err
+	}
+
+	r.limits = rbac.limits
+	r.notify = rbac.notify
+	r.registry = rbac.registry
+	r.implications = rbac.implications
+	r.inherited = rbac.matching
+
+	var edges []PolicyEvent
+	if rbac.notify != nil {
+		edges = roleEdges(r)
+	}
+
 	for _, parent := range parents {
 		if rbac.createMissingRoles {
 			ok, err := rbac.HasRole(parent)
@@ -98,52 +189,287 @@ func (rbac *RBAC) AddRole(role any, parents ...any) error {
 			return err
 		}
 
+		if err = rbac.checkInherit(parentRole, r); err != nil {
+			return err
+		}
+
 		if err = parentRole.AddChild(r); err != nil {
 			return err
 		}
 	}
 
 	rbac.roles[r.Name()] = r
+	if rbac.notify != nil {
+		rbac.emit(PolicyEvent{Type: PolicyRoleAdded, Role: r.Name(), Permissions: sortedKeys(r.permissions)})
+		for _, edge := range edges {
+			rbac.emit(edge)
+		}
+	}
+
+	return nil
+}
+
+// RenameRole renames a registered role and rewrites every edge referencing it.
+func (rbac *RBAC) RenameRole(oldName, newName string) error {
+	r, err := rbac.Role(oldName)
+	if err != nil {
+		return err
+	}
+	if oldName == newName {
+		return nil
+	}
+	if _, ok := rbac.roles[newName]; ok {
+		return fmt.Errorf(`%w: a role with name "%s" is already registered`, ErrRoleExists, newName)
+	}
+
+	for _, parent := range r.parents {
+		delete(parent.children, oldName)
+		parent.children[newName] = r
+	}
+	for _, child := range r.children {
+		delete(child.parents, oldName)
+		child.parents[newName] = r
+	}
+
+	r.name = newName
+	delete(rbac.roles, oldName)
+	rbac.roles[newName] = r
+	rbac.usage.rename(oldName, newName)
+	for i, c := range rbac.exclusive {
+		rbac.exclusive[i].Roles = renameIn(c.Roles, oldName, newName)
+	}
+	rbac.superusers = renameIn(rbac.superusers, oldName, newName)
+	rbac.emit(PolicyEvent{Type: PolicyRoleRenamed, Role: newName, Previous: oldName})
 
 	return nil
 }
 
 func (rbac *RBAC) IsGranted(ctx context.Context, role any, permission string, assertions ...Assertion) bool {
 	granted, err := rbac.IsGrantedE(ctx, role, permission, assertions...)
-	return granted && err == nil
+	return granted && (err == nil || errors.Is(err, ErrWarn))
 }
 
 func (rbac *RBAC) IsGrantedE(ctx context.Context, role any, permission string, assertions ...Assertion) (granted bool, err error) {
-	defer func() {
-		if rec := recover(); rec != nil {
-			var ok bool
-			if err, ok = rec.(error); !ok {
-				err = fmt.Errorf("%v", rec)
+	if rbac.profile {
+		rbac.profileDo(ctx, role, permission, func(ctx context.Context) {
+			granted, _, err = rbac.evaluate(ctx, role, permission, matchPartial, assertions...)
+		})
+		return
+	}
+	granted, _, err = rbac.evaluate(ctx, role, permission, matchPartial, assertions...)
+	return
+}
+
+// IsGrantedAny reports whether the role is granted at least one of the
+// permissions, looking the role up once. It is false without permissions.
+func (rbac *RBAC) IsGrantedAny(ctx context.Context, role any, permissions ...string) bool {
+	return rbac.isGrantedBatch(ctx, role, permissions, true)
+}
+
+// IsGrantedAll reports whether the role is granted every permission, looking
+// the role up once. It is false without permissions.
+func (rbac *RBAC) IsGrantedAll(ctx context.Context, role any, permissions ...string) bool {
+	return rbac.isGrantedBatch(ctx, role, permissions, false)
+}
+
+// EffectivePermissions returns the permissions and patterns held by the roles,
+// directly, through their children or implications, deduplicated and sorted.
+// Unknown roles are skipped. Permissions granted under assertions are
+// included, those of superuser roles are not expanded, see IsSuperuser.
+func (rbac *RBAC) EffectivePermissions(roles ...string) []string {
+	permissions := map[string]struct{}{}
+	for _, name := range roles {
+		r, ok := rbac.roles[name]
+		if !ok {
+			continue
+		}
+		for permission := range r.Permissions(true) {
+			permissions[permission] = struct{}{}
+		}
+		for from, implied := range rbac.implications.rules {
+			if r.HasPermission(from) {
+				for _, permission := range implied {
+					permissions[permission] = struct{}{}
+				}
 			}
 		}
-	}()
+	}
+	return sortedKeys(permissions)
+}
 
+// isGrantedBatch stops at the first permission whose grant equals stop.
+func (rbac *RBAC) isGrantedBatch(ctx context.Context, role any, permissions []string, stop bool) bool {
+	if len(permissions) == 0 {
+		return false
+	}
 	name, err := rbac.roleName(role)
 	if err != nil {
-		return false, err
+		return false
+	}
+	r, ok := rbac.roles[name]
+	if !ok {
+		return false
+	}
+
+	if rbac.trackUsage {
+		rbac.usage.touch(name)
+	}
+
+	for _, permission := range permissions {
+		if rbac.grantedTo(ctx, r, permission) == stop {
+			return stop
+		}
+	}
+	return !stop
+}
+
+func (rbac *RBAC) grantedTo(ctx context.Context, r *Role, permission string) (granted bool) {
+	if rbac.registry.check(permission, nil) != nil {
+		return false
+	}
+	eval := func(ctx context.Context) {
+		ok, _, err := rbac.evaluateRole(ctx, r, permission, matchPartial)
+		granted = ok && (err == nil || errors.Is(err, ErrWarn))
+	}
+	if rbac.profile {
+		rbac.profileDo(ctx, r, permission, eval)
+	} else {
+		eval(ctx)
+	}
+	return
+}
+
+// evaluate is IsGrantedE additionally reporting why the permission was not
+// granted. Patterns match the permission as selected by mode.
+func (rbac *RBAC) evaluate(ctx context.Context, role any, permission string, mode actionMatching, assertions ...Assertion) (granted bool, reason Reason, err error) {
+	name, err := rbac.roleName(role)
+	if err != nil {
+		return false, ReasonRoleMissing{}, err
+	}
+
+	if err = rbac.registry.check(permission, nil); err != nil {
+		return false, ReasonPermissionMissing{Role: name, Action: permission}, err
 	}
 
 	r, ok := rbac.roles[name]
 	if !ok {
-		return false, fmt.Errorf(`%w: no role with name "%s" could be found`, ErrRoleNotFound, role)
+		return false, ReasonRoleMissing{Role: name}, fmt.Errorf(`%w: no role with name "%s" could be found`, ErrRoleNotFound, role)
 	}
 
-	if !r.HasPermission(permission) {
-		return false, nil
+	if rbac.trackUsage {
+		rbac.usage.touch(name)
 	}
 
+	return rbac.evaluateRole(ctx, r, permission, mode, assertions...)
+}
+
+// evaluateRole is evaluate for a role already looked up.
+func (rbac *RBAC) evaluateRole(ctx context.Context, r *Role, permission string, mode actionMatching, assertions ...Assertion) (granted bool, reason Reason, err error) {
+	var (
+		current Assertion
+		started time.Time
+	)
+	defer func() {
+		if rec := recover(); rec != nil {
+			var ok bool
+			if err, ok = rec.(error); !ok {
+				err = fmt.Errorf("%v", rec)
+			}
+			granted, reason = false, ReasonAssertionFailed{Name: AssertionName(current)}
+			rbac.observe(ctx, current, r, permission, started, AssertionPanicked, err)
+		}
+	}()
+
+	name := r.Name()
+	switch {
+	case len(rbac.superusers) > 0 && rbac.superuser(r):
+		if !rbac.superuserAssertions {
+			return true, nil, nil
+		}
+	case !r.hasPermission(permission, mode):
+		return false, ReasonPermissionMissing{Role: name, Action: permission}, nil
+	default:
+		if conditions := r.permissionAssertions(permission, mode); len(conditions) > 0 {
+			assertions = slices.Concat(conditions, assertions)
+		}
+	}
+
+	var warn error
+	timed := len(assertions) > 0 && (rbac.observer != nil || ctxExplanation(ctx) != nil)
 	for _, assertion := range assertions {
-		if ok = assertion.Assert(ctx, r, permission); !ok {
-			return false, nil
+		current = assertion
+		if timed {
+			started = time.Now()
 		}
+		if assertion, ok := assertion.(ErrorAssertion); ok {
+			if err = assertion.AssertE(ctx, r, permission); errors.Is(err, ErrWarn) {
+				rbac.observe(ctx, current, r, permission, started, AssertionWarned, err)
+				warn = errors.Join(warn, err)
+			} else if err != nil {
+				rbac.observe(ctx, current, r, permission, started, AssertionErrored, err)
+				return false, errorReason(err, ReasonAssertionFailed{Name: AssertionName(current)}), err
+			} else {
+				rbac.observe(ctx, current, r, permission, started, AssertionPassed, nil)
+			}
+			continue
+		}
+		if !assertion.Assert(ctx, r, permission) {
+			rbac.observe(ctx, current, r, permission, started, AssertionFailed, nil)
+			return false, ReasonAssertionFailed{Name: AssertionName(current)}, nil
+		}
+		rbac.observe(ctx, current, r, permission, started, AssertionPassed, nil)
+	}
+
+	return true, nil, warn
+}
+
+func (rbac *RBAC) observe(ctx context.Context, assertion Assertion, role *Role, permission string, started time.Time, outcome AssertionOutcome, err error) {
+	if assertion == nil {
+		return
 	}
+	explanation := ctxExplanation(ctx)
+	if rbac.observer == nil && explanation == nil {
+		return
+	}
+	event := AssertionEvent{
+		Name:       AssertionName(assertion),
+		Role:       role.Name(),
+		Permission: permission,
+		Duration:   time.Since(started),
+		Outcome:    outcome,
+		Err:        err,
+	}
+	if rbac.observer != nil {
+		rbac.observer(ctx, event)
+	}
+	if explanation != nil {
+		explanation.assertions = append(explanation.assertions, event)
+	}
+}
+
+// clone returns a deep copy of the role graph sharing compiled permissions.
+func (rbac *RBAC) clone() *RBAC {
+	c := New()
+	c.createMissingRoles = rbac.createMissingRoles
+	c.limits = rbac.limits
+	c.observer = rbac.observer
+	c.profile = rbac.profile
+	c.trackUsage = rbac.trackUsage
+	c.matching = rbac.matching
+	c.exclusive = rbac.ExclusiveRoles()
+	c.assertions = rbac.assertions
+	c.registry = rbac.registry.clone()
+	c.implications = rbac.implications.clone()
+	c.permissionSets = clonePermissionSets(rbac.permissionSets)
+	c.superusers, c.superuserAssertions = rbac.SuperuserRoles(), rbac.superuserAssertions
 
-	return true, nil
+	copies := map[*Role]*Role{}
+	for name, role := range rbac.roles {
+		c.roles[name] = role.clone(copies)
+		c.roles[name].registry = c.registry
+		c.roles[name].implications = c.implications
+	}
+	return c
 }
 
 func (rbac *RBAC) roleName(role any) (string, error) {
@@ -0,0 +1,51 @@
+package rbac
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// RBACHolder shares a policy between readers and a reloader. Swapping in a
+// new RBAC is atomic, requests in flight keep evaluating the one they
+// loaded. A swapped in RBAC must not be mutated afterwards.
+type RBACHolder struct {
+	mu   sync.Mutex
+	rbac atomic.Pointer[RBAC]
+}
+
+func NewRBACHolder(rbac *RBAC) *RBACHolder {
+	h := &RBACHolder{}
+	h.rbac.Store(rbac)
+	return h
+}
+
+func (h *RBACHolder) Load() *RBAC {
+	return h.rbac.Load()
+}
+
+// Swap replaces the policy and returns the previous one.
+func (h *RBACHolder) Swap(rbac *RBAC) *RBAC {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.rbac.Swap(rbac)
+}
+
+// Apply applies cfg to a copy of the current policy and swaps the copy in
+// only if every step succeeds, so readers never observe a partially applied
+// configuration. The copy keeps the OnChange callback and usage of the
+// current policy.
+func (h *RBACHolder) Apply(cfg Config) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	current := h.rbac.Load()
+	staged := current.clone()
+	staged.usage = current.usage
+	staged.OnChange(current.notify)
+	if err := staged.Apply(cfg); err != nil {
+		return err
+	}
+	h.rbac.Store(staged)
+	return nil
+}
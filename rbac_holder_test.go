@@ -0,0 +1,83 @@
+package rbac
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRBACHolder(t *testing.T) {
+	first, second := New(), New()
+	h := NewRBACHolder(first)
+	assert.Same(t, first, h.Load())
+	assert.Same(t, first, h.Swap(second))
+	assert.Same(t, second, h.Load())
+}
+
+func TestHeldAuthorizer_Swap(t *testing.T) {
+	newPolicy := func(permission string) *RBAC {
+		rbac := New()
+		role := NewRole("user")
+		require.NoError(t, role.AddPermissionsE(permission))
+		require.NoError(t, rbac.AddRole(role))
+		return rbac
+	}
+
+	a := NewDefaultAuthorizer(newPolicy("posts.read"))
+	claims := &Claims{Subject: NewSubject("u1", "user")}
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), claims, &Target{Action: "posts.read"}))
+
+	a.Holder().Swap(newPolicy("posts.write"))
+	assert.Equal(t, DecisionDeny, a.Authorize(context.Background(), claims, &Target{Action: "posts.read"}))
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), claims, &Target{Action: "posts.write"}))
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for range 100 {
+				a.Authorize(context.Background(), claims, &Target{Action: "posts.write"})
+			}
+		})
+	}
+	for range 10 {
+		a.Holder().Swap(newPolicy("posts.write"))
+	}
+	wg.Wait()
+}
+
+func TestConfigWatcher_Holder(t *testing.T) {
+	w := &ConfigWatcher{holder: NewRBACHolder(New())}
+	a := NewHeldAuthorizer(w.Holder())
+	assert.Same(t, w.RBAC(), a.Holder().Load())
+}
+
+func TestRBACHolder_Apply(t *testing.T) {
+	rbac := New()
+	require.NoError(t, rbac.Apply(Config{
+		RoleHierarchy: []RoleConfig{{Role: "user"}},
+		AccessControl: []AccessConfig{{Role: "user", Permissions: []string{"posts.read"}}},
+	}))
+	var events []PolicyEvent
+	rbac.OnChange(func(event PolicyEvent) { events = append(events, event) })
+	h := NewRBACHolder(rbac)
+
+	err := h.Apply(Config{AccessControl: []AccessConfig{
+		{Role: "user", Permissions: []string{"posts.write"}},
+		{Role: "missing", Permissions: []string{"x"}},
+	}})
+	assert.ErrorIs(t, err, ErrRoleNotFound)
+	assert.Same(t, rbac, h.Load())
+	assert.Empty(t, events)
+
+	require.NoError(t, h.Apply(Config{AccessControl: []AccessConfig{{Role: "user", Permissions: []string{"posts.write"}}}}))
+	staged := h.Load()
+	assert.NotSame(t, rbac, staged)
+	assert.True(t, staged.IsGranted(context.Background(), "user", "posts.read"))
+	assert.True(t, staged.IsGranted(context.Background(), "user", "posts.write"))
+	assert.False(t, rbac.IsGranted(context.Background(), "user", "posts.write"))
+	require.Len(t, events, 1)
+	assert.Equal(t, PolicyPermissionsAdded, events[0].Type)
+}
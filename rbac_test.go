@@ -107,6 +107,44 @@ func (s *rbacSuit) TestIsGrantedWithInvalidRole() {
 	s.ErrorIs(err, ErrRoleNotFound)
 }
 
+func (s *rbacSuit) TestIsGrantedAnyAll() {
+	foo := NewRole("foo")
+	s.NoError(foo.AddPermissionsE("can.foo", "can.bar"))
+	s.NoError(s.rbac.AddRole(foo))
+	s.NoError(s.rbac.AddRole("warned"))
+	warned, _ := s.rbac.Role("warned")
+	s.NoError(warned.AddPermissionsE("can.warn"))
+	warned.SetPermissionAssertions("can.warn", Warn(&testAssertion{shouldPass: false}, "legacy"))
+
+	ctx := context.Background()
+	s.True(s.rbac.IsGrantedAny(ctx, "foo", "can.baz", "can.bar"))
+	s.False(s.rbac.IsGrantedAny(ctx, "foo", "can.baz", "can.qux"))
+	s.True(s.rbac.IsGrantedAll(ctx, foo, "can.foo", "can.bar"))
+	s.False(s.rbac.IsGrantedAll(ctx, foo, "can.foo", "can.baz"))
+	s.True(s.rbac.IsGrantedAll(ctx, "warned", "can.warn"))
+
+	s.False(s.rbac.IsGrantedAny(ctx, "foo"))
+	s.False(s.rbac.IsGrantedAll(ctx, "foo"))
+	s.False(s.rbac.IsGrantedAny(ctx, "ghost", "can.foo"))
+	s.False(s.rbac.IsGrantedAll(ctx, 42, "can.foo"))
+}
+
+func (s *rbacSuit) TestEffectivePermissions() {
+	s.NoError(s.rbac.AddRole("admin"))
+	s.NoError(s.rbac.AddRole("editor", "admin"))
+	s.NoError(s.rbac.AddRole("viewer", "editor"))
+	viewer, editor := mustRole(s.T(), s.rbac, "viewer"), mustRole(s.T(), s.rbac, "editor")
+	s.NoError(viewer.AddPermissionsE("posts:list"))
+	s.NoError(editor.AddPermissionsE("posts:write", "posts:list"))
+	s.NoError(editor.AddRegexPermissions(`^media:.+$`))
+	s.rbac.AddImplication("posts:write", "posts:read", "drafts:*")
+
+	s.Equal([]string{"posts:list"}, s.rbac.EffectivePermissions("viewer"))
+	s.Equal([]string{"^media:.+$", "drafts:*", "posts:list", "posts:read", "posts:write"}, s.rbac.EffectivePermissions("editor"))
+	s.Equal(s.rbac.EffectivePermissions("editor"), s.rbac.EffectivePermissions("admin", "viewer", "ghost"))
+	s.Empty(s.rbac.EffectivePermissions())
+}
+
 func (s *rbacSuit) TestHasRole() {
 	foo := NewRole("foo")
 	snafu := testRole{Role: NewRole("snafu")}
@@ -226,3 +264,86 @@ func (s *rbacSuit) TestAddParentRole() {
 	s.False(s.rbac.IsGranted(context.Background(), "Editor", "user.manage"))
 	s.False(s.rbac.IsGranted(context.Background(), "Editor", "post.publish"))
 }
+
+func (s *rbacSuit) TestTags() {
+	acmeAdmin := NewRole("acme:admin")
+	acmeAdmin.AddTags("tenant:acme")
+	acmeUser := NewRole("acme:user")
+	acmeUser.AddTags("tenant:acme")
+	global := NewRole("global")
+
+	s.Nil(s.rbac.AddRole(global))
+	s.Nil(s.rbac.AddRole(acmeAdmin, "global"))
+	s.Nil(s.rbac.AddRole(acmeUser, "acme:admin"))
+
+	s.ElementsMatch([]*Role{acmeAdmin, acmeUser}, slices.Collect(s.rbac.RolesByTag("tenant:acme")))
+
+	s.NoError(s.rbac.ApplyToTag("tenant:acme", func(r *Role) error {
+		r.AddPermissions("acme:read")
+		return nil
+	}))
+	s.True(acmeUser.HasPermission("acme:read"))
+
+	s.ErrorIs(s.rbac.ApplyToTag("tenant:acme", func(*Role) error { return ErrDeny }), ErrDeny)
+
+	s.Equal(2, s.rbac.RemoveByTag("tenant:acme"))
+	s.Equal([]*Role{global}, slices.Collect(s.rbac.Roles()))
+	s.Empty(slices.Collect(global.Children()))
+	s.False(global.HasPermission("acme:read"))
+}
+
+func (s *rbacSuit) TestRemoveRole() {
+	admin := NewRole("admin")
+	editor := NewRole("editor")
+	editor.AddPermissions("posts:write")
+	viewer := NewRole("viewer")
+
+	s.Nil(s.rbac.AddRole(admin))
+	s.Nil(s.rbac.AddRole(editor, "admin"))
+	s.Nil(s.rbac.AddRole(viewer, "editor"))
+	s.True(s.rbac.IsGranted(context.Background(), "admin", "posts:write"))
+
+	s.NoError(s.rbac.RemoveRole("editor"))
+	s.ErrorIs(s.rbac.RemoveRole("editor"), ErrRoleNotFound)
+
+	ok, err := s.rbac.HasRole("editor")
+	s.NoError(err)
+	s.False(ok)
+	s.Empty(slices.Collect(admin.Children()))
+	s.Empty(slices.Collect(viewer.Parents()))
+	s.False(s.rbac.IsGranted(context.Background(), "admin", "posts:write"))
+}
+
+func (s *rbacSuit) TestRenameRole() {
+	s.rbac.SetUsageTracking(true)
+	admin := NewRole("admin")
+	editor := NewRole("editor")
+	editor.AddPermissions("posts:write")
+	viewer := NewRole("viewer")
+
+	s.Nil(s.rbac.AddRole(admin))
+	s.Nil(s.rbac.AddRole(editor, "admin"))
+	s.Nil(s.rbac.AddRole(viewer, "editor"))
+	s.True(s.rbac.IsGranted(context.Background(), "editor", "posts:write"))
+
+	s.NoError(s.rbac.RenameRole("editor", "author"))
+	s.Equal("author", editor.Name())
+
+	ok, err := s.rbac.HasRole("editor")
+	s.NoError(err)
+	s.False(ok)
+
+	r, err := s.rbac.Role("author")
+	s.NoError(err)
+	s.Same(editor, r)
+	s.Equal([]*Role{editor}, slices.Collect(admin.Children()))
+	s.Equal([]*Role{editor}, slices.Collect(viewer.Parents()))
+	s.True(admin.HasDescendant(NewRole("author")))
+	s.False(admin.HasDescendant(NewRole("editor")))
+	s.True(s.rbac.IsGranted(context.Background(), "admin", "posts:write"))
+	s.NotContains(s.rbac.Report().Unused, "author")
+
+	s.NoError(s.rbac.RenameRole("author", "author"))
+	s.ErrorIs(s.rbac.RenameRole("author", "admin"), ErrRoleExists)
+	s.ErrorIs(s.rbac.RenameRole("missing", "other"), ErrRoleNotFound)
+}
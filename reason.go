@@ -0,0 +1,204 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Reason is a structured, localizable cause of a denial.
+type Reason interface {
+	Code() string
+	Args() map[string]string
+}
+
+const (
+	ReasonCodeUnauthenticated     = "unauthenticated"
+	ReasonCodeInvalidTarget       = "invalid_target"
+	ReasonCodeRoleMissing         = "role_missing"
+	ReasonCodePermissionMissing   = "permission_missing"
+	ReasonCodeAssertionFailed     = "assertion_failed"
+	ReasonCodeImpersonationDenied = "impersonation_denied"
+	ReasonCodeInsufficientScope   = "insufficient_scope"
+	ReasonCodeStepUpRequired      = "step_up_required"
+	ReasonCodeGrantExpired        = "grant_expired"
+	ReasonCodeExclusiveRole       = "exclusive_role"
+)
+
+type ReasonUnauthenticated struct{}
+
+func (ReasonUnauthenticated) Code() string            { return ReasonCodeUnauthenticated }
+func (ReasonUnauthenticated) Args() map[string]string { return nil }
+
+type ReasonInvalidTarget struct{}
+
+func (ReasonInvalidTarget) Code() string            { return ReasonCodeInvalidTarget }
+func (ReasonInvalidTarget) Args() map[string]string { return nil }
+
+type ReasonRoleMissing struct {
+	Role string
+}
+
+func (ReasonRoleMissing) Code() string { return ReasonCodeRoleMissing }
+func (r ReasonRoleMissing) Args() map[string]string {
+	return map[string]string{"role": r.Role}
+}
+
+type ReasonPermissionMissing struct {
+	Role   string
+	Action string
+}
+
+func (ReasonPermissionMissing) Code() string { return ReasonCodePermissionMissing }
+func (r ReasonPermissionMissing) Args() map[string]string {
+	return map[string]string{"role": r.Role, "action": r.Action}
+}
+
+type ReasonAssertionFailed struct {
+	Name string
+}
+
+func (ReasonAssertionFailed) Code() string { return ReasonCodeAssertionFailed }
+func (r ReasonAssertionFailed) Args() map[string]string {
+	return map[string]string{"name": r.Name}
+}
+
+type ReasonImpersonationDenied struct {
+	Actor   string
+	Subject string
+}
+
+func (ReasonImpersonationDenied) Code() string { return ReasonCodeImpersonationDenied }
+func (r ReasonImpersonationDenied) Args() map[string]string {
+	return map[string]string{"actor": r.Actor, "subject": r.Subject}
+}
+
+type ReasonInsufficientScope struct {
+	Action string
+}
+
+func (ReasonInsufficientScope) Code() string { return ReasonCodeInsufficientScope }
+func (r ReasonInsufficientScope) Args() map[string]string {
+	return map[string]string{"action": r.Action}
+}
+
+type ReasonStepUpRequired struct {
+	Required AuthLevel
+}
+
+func (ReasonStepUpRequired) Code() string { return ReasonCodeStepUpRequired }
+func (r ReasonStepUpRequired) Args() map[string]string {
+	return map[string]string{"required": r.Required.String()}
+}
+
+type ReasonGrantExpired struct {
+	Role string
+}
+
+func (ReasonGrantExpired) Code() string { return ReasonCodeGrantExpired }
+func (r ReasonGrantExpired) Args() map[string]string {
+	return map[string]string{"role": r.Role}
+}
+
+type ReasonExclusiveRole struct {
+	Role      string
+	Exercised string
+}
+
+func (ReasonExclusiveRole) Code() string { return ReasonCodeExclusiveRole }
+func (r ReasonExclusiveRole) Args() map[string]string {
+	return map[string]string{"role": r.Role, "exercised": r.Exercised}
+}
+
+// ReasonError attaches a Reason to an error. Err may be nil when the denial
+// is not caused by a failure, e.g. a missing permission.
+type ReasonError struct {
+	Reason Reason
+	Err    error
+}
+
+func (e *ReasonError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return e.Reason.Code()
+}
+
+func (e *ReasonError) Unwrap() error {
+	return e.Err
+}
+
+// Reasons collects every Reason attached to err or the errors it wraps.
+func Reasons(err error) []Reason {
+	var reasons []Reason
+	var walk func(error)
+	walk = func(err error) {
+		switch e := err.(type) {
+		case nil:
+			return
+		case *ReasonError:
+			reasons = append(reasons, e.Reason)
+			walk(e.Err)
+		case interface{ Unwrap() []error }:
+			for _, err := range e.Unwrap() {
+				walk(err)
+			}
+		case interface{ Unwrap() error }:
+			walk(e.Unwrap())
+		}
+	}
+	walk(err)
+	return reasons
+}
+
+func errorReason(err error, fallback Reason) Reason {
+	var r interface{ Reason() Reason }
+	if errors.As(err, &r) {
+		return r.Reason()
+	}
+	return fallback
+}
+
+// AssertionName returns Name() of assertions implementing it and the type
+// name otherwise.
+func AssertionName(assertion Assertion) string {
+	if named, ok := assertion.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%T", assertion), "*")
+}
+
+// MessageCatalog maps languages onto reason codes and message templates
+// referencing reason arguments as {name}.
+type MessageCatalog map[string]map[string]string
+
+var DefaultMessageCatalog = MessageCatalog{
+	"en": {
+		ReasonCodeUnauthenticated:     "Authentication is required.",
+		ReasonCodeInvalidTarget:       "The request cannot be authorized.",
+		ReasonCodeRoleMissing:         "Your account has no valid role for this request.",
+		ReasonCodePermissionMissing:   "You are not allowed to perform {action}.",
+		ReasonCodeAssertionFailed:     "The request does not satisfy the access conditions.",
+		ReasonCodeImpersonationDenied: "You are not allowed to act on behalf of this user.",
+		ReasonCodeInsufficientScope:   "Your access token does not allow {action}.",
+		ReasonCodeStepUpRequired:      "Please confirm your identity with additional authentication.",
+		ReasonCodeGrantExpired:        "Your access as {role} has expired.",
+		ReasonCodeExclusiveRole:       "You cannot act as {role} after acting as {exercised}.",
+	},
+}
+
+// Message renders the reason in the first language available out of lang,
+// its base language ("de" for "de-AT") and English. The reason code is
+// returned when no template is found.
+func (c MessageCatalog) Message(lang string, reason Reason) string {
+	base, _, _ := strings.Cut(lang, "-")
+	for _, l := range []string{lang, base, "en"} {
+		if template, ok := c[l][reason.Code()]; ok {
+			for key, value := range reason.Args() {
+				template = strings.ReplaceAll(template, "{"+key+"}", value)
+			}
+			return template
+		}
+	}
+	return reason.Code()
+}
@@ -0,0 +1,73 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type namedAssertion struct {
+	testAssertion
+}
+
+func (*namedAssertion) Name() string {
+	return "owner"
+}
+
+func TestReasons(t *testing.T) {
+	rbac := New()
+	user := NewRole("user")
+	user.AddPermissions("posts:read", "posts:edit")
+	assert.NoError(t, rbac.AddRole(user))
+
+	authorizer := NewDefaultAuthorizer(rbac)
+	claims := &Claims{Subject: &testSubject{roles: []string{"user", "ghost"}}}
+
+	_, err := authorizer.AuthorizeE(context.Background(), claims, &Target{Action: "posts:delete"})
+	assert.Equal(t, []Reason{
+		ReasonPermissionMissing{Role: "user", Action: "posts:delete"},
+		ReasonRoleMissing{Role: "ghost"},
+	}, Reasons(err))
+	assert.ErrorIs(t, err, ErrRoleNotFound)
+
+	_, err = authorizer.AuthorizeE(context.Background(), claims, &Target{
+		Action:     "posts:edit",
+		Assertions: []Assertion{&testAssertion{shouldPass: true}, &namedAssertion{}},
+	})
+	assert.Equal(t, ReasonAssertionFailed{Name: "owner"}, Reasons(err)[0])
+
+	_, err = authorizer.AuthorizeE(context.Background(), claims, &Target{
+		Action:     "posts:edit",
+		Assertions: []Assertion{&panicAssertion{}},
+	})
+	assert.Equal(t, ReasonAssertionFailed{Name: "rbac.panicAssertion"}, Reasons(err)[0])
+
+	_, err = authorizer.AuthorizeE(context.Background(), claims, &Target{
+		Action:     "posts:edit",
+		Assertions: []Assertion{AuthStrengthAssertion(AuthLevelMultiFactor)},
+	})
+	assert.Equal(t, ReasonStepUpRequired{Required: AuthLevelMultiFactor}, Reasons(err)[0])
+
+	_, err = authorizer.AuthorizeE(context.Background(), nil, &Target{Action: "posts:edit"})
+	assert.Equal(t, []Reason{ReasonUnauthenticated{}}, Reasons(err))
+
+	_, err = authorizer.AuthorizeE(context.Background(), claims, nil)
+	assert.Equal(t, []Reason{ReasonInvalidTarget{}}, Reasons(err))
+
+	assert.Nil(t, Reasons(errors.New("plain")))
+}
+
+func TestMessageCatalog_Message(t *testing.T) {
+	catalog := MessageCatalog{
+		"en": DefaultMessageCatalog["en"],
+		"de": {ReasonCodePermissionMissing: "Sie dürfen {action} nicht ausführen."},
+	}
+
+	reason := ReasonPermissionMissing{Role: "user", Action: "posts:delete"}
+	assert.Equal(t, "Sie dürfen posts:delete nicht ausführen.", catalog.Message("de-AT", reason))
+	assert.Equal(t, "You are not allowed to perform posts:delete.", catalog.Message("fr", reason))
+	assert.Equal(t, "Authentication is required.", catalog.Message("de", ReasonUnauthenticated{}))
+	assert.Equal(t, "role_missing", MessageCatalog{}.Message("en", ReasonRoleMissing{}))
+}
@@ -0,0 +1,199 @@
+package rbac
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	mrand "math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var _ Authorizer = (*RecordingAuthorizer)(nil)
+
+// DecisionRecorder writes anonymized authorization inputs as a corpus
+// readable by ReadDecisionRecords. Subject identifiers are replaced by salted
+// hashes unless SetKeepSubjects opts out, and only allowlisted claims and
+// target metadata keys are kept.
+type DecisionRecorder struct {
+	mu                 sync.Mutex
+	enc                *json.Encoder
+	rate               float64
+	salt               string
+	keepSubjects       bool
+	keepRemoteAddr     bool
+	requestHeaders     []string
+	metadataKeys       []string
+	targetMetadataKeys []string
+	random             func() float64
+	now                func() time.Time
+}
+
+// NewDecisionRecorder anonymizes subjects with a random salt, so hashes are
+// only comparable within the records of the recorder. Set a salt with
+// SetSalt to compare corpora recorded by several recorders or processes.
+func NewDecisionRecorder(w io.Writer) *DecisionRecorder {
+	return &DecisionRecorder{
+		enc:    json.NewEncoder(w),
+		rate:   1,
+		salt:   rand.Text(),
+		random: mrand.Float64,
+		now:    time.Now,
+	}
+}
+
+// SetSampleRate sets the fraction of decisions recorded, from 0 to 1.
+func (r *DecisionRecorder) SetSampleRate(rate float64) *DecisionRecorder {
+	r.rate = rate
+	return r
+}
+
+// SetSalt sets the secret salt of subject hashes. It must stay the same
+// between recordings whose subjects are compared, and an empty salt makes
+// hashes of guessable identifiers such as emails reversible.
+func (r *DecisionRecorder) SetSalt(salt string) *DecisionRecorder {
+	r.salt = salt
+	return r
+}
+
+// SetKeepSubjects records subject identifiers as they are, so replaying the
+// corpus can evaluate ownership assertions. Only use it for corpora that may
+// hold personal data.
+func (r *DecisionRecorder) SetKeepSubjects(keep bool) *DecisionRecorder {
+	r.keepSubjects = keep
+	return r
+}
+
+// SetMetadataKeys allowlists keys of the claims metadata.
+func (r *DecisionRecorder) SetMetadataKeys(keys ...string) *DecisionRecorder {
+	r.metadataKeys = keys
+	return r
+}
+
+// SetTargetMetadataKeys allowlists keys of the target metadata, e.g.
+// MetadataOwner.
+func (r *DecisionRecorder) SetTargetMetadataKeys(keys ...string) *DecisionRecorder {
+	r.targetMetadataKeys = keys
+	return r
+}
+
+// SetKeepRemoteAddr records the remote address of requests, so replaying the
+// corpus can evaluate IP assertions and conditions.
+func (r *DecisionRecorder) SetKeepRemoteAddr(keep bool) *DecisionRecorder {
+	r.keepRemoteAddr = keep
+	return r
+}
+
+// SetRequestHeaders allowlists request headers.
+func (r *DecisionRecorder) SetRequestHeaders(names ...string) *DecisionRecorder {
+	r.requestHeaders = names
+	return r
+}
+
+// Record writes the decision with the request info of ctx, if any, and the
+// time of WithDecisionTime or the current one.
+func (r *DecisionRecorder) Record(ctx context.Context, claims *Claims, target *Target, d Decision) error {
+	if r.rate <= 0 || (r.rate < 1 && r.random() >= r.rate) {
+		return nil
+	}
+
+	record := DecisionRecord{Time: decisionTime(ctx, r.now).UTC(), Decision: d}
+	if target != nil {
+		record.Action = target.Action
+		record.Literal, record.Anchored = target.Literal, target.Anchored
+		record.TargetMetadata = allowlisted(target.Metadata, r.targetMetadataKeys)
+	}
+	if claims != nil {
+		if claims.Subject != nil {
+			record.Subject, record.Anonymized = r.subject(claims.Subject)
+			record.Roles = claims.Subject.Roles()
+		}
+		if claims.Actor != nil {
+			record.Actor, _ = r.subject(claims.Actor)
+			record.ActorRoles = claims.Actor.Roles()
+		}
+		record.Metadata = allowlisted(claims.Metadata, r.metadataKeys)
+	}
+	record.Request = r.request(CtxRequestInfo(ctx))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enc.Encode(record)
+}
+
+// subject returns the identifier of the subject, anonymized unless subjects
+// are kept.
+func (r *DecisionRecorder) subject(subject Subject) (id string, anonymized bool) {
+	id = SubjectID(subject)
+	if r.keepSubjects || id == "" {
+		return id, false
+	}
+	return r.anonymize(id), true
+}
+
+func (r *DecisionRecorder) request(info RequestInfo) *RecordedRequest {
+	if info.Method == "" && info.URL == nil {
+		return nil
+	}
+	request := &RecordedRequest{
+		Method:     info.Method,
+		Host:       info.Host,
+		Pattern:    info.Pattern,
+		PathValues: info.PathValues,
+	}
+	if info.URL != nil {
+		request.Path = info.URL.Path
+	}
+	if r.keepRemoteAddr {
+		request.RemoteAddr = info.RemoteAddr
+	}
+	for _, name := range r.requestHeaders {
+		if values := info.Header.Values(name); len(values) > 0 {
+			if request.Header == nil {
+				request.Header = http.Header{}
+			}
+			request.Header[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	return request
+}
+
+func (r *DecisionRecorder) anonymize(id string) string {
+	if id == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(r.salt + id))
+	return hex.EncodeToString(sum[:8])
+}
+
+func allowlisted(metadata map[string]any, keys []string) map[string]any {
+	var kept map[string]any
+	for _, key := range keys {
+		if value, ok := metadata[key]; ok {
+			if kept == nil {
+				kept = map[string]any{}
+			}
+			kept[key] = value
+		}
+	}
+	return kept
+}
+
+type RecordingAuthorizer struct {
+	authorizer Authorizer
+	recorder   *DecisionRecorder
+}
+
+func NewRecordingAuthorizer(authorizer Authorizer, recorder *DecisionRecorder) *RecordingAuthorizer {
+	return &RecordingAuthorizer{authorizer: authorizer, recorder: recorder}
+}
+
+func (a *RecordingAuthorizer) Authorize(ctx context.Context, claims *Claims, target *Target) Decision {
+	d := a.authorizer.Authorize(ctx, claims, target)
+	_ = a.recorder.Record(ctx, claims, target, d)
+	return d
+}
@@ -0,0 +1,136 @@
+package rbac
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecisionRecorder(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	recorder := NewDecisionRecorder(&buf).SetSalt("salt").SetMetadataKeys("tenant").SetTargetMetadataKeys(MetadataOwner)
+	recorder.now = func() time.Time { return now }
+
+	a := NewRecordingAuthorizer(&mockAuthorizer{decision: DecisionAllow}, recorder)
+	claims := &Claims{
+		Subject:  &testIdentifiedSubject{id: "alice@example.com", roles: []string{"admin"}},
+		Metadata: map[string]any{"tenant": "acme", "email": "alice@example.com"},
+	}
+	target := &Target{Action: "posts:read", Metadata: map[string]any{MetadataOwner: "bob", "title": "draft"}}
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), claims, target))
+
+	records, err := ReadDecisionRecords(&buf)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	record := records[0]
+	assert.Equal(t, now, record.Time)
+	assert.Equal(t, "posts:read", record.Action)
+	assert.Equal(t, []string{"admin"}, record.Roles)
+	assert.Equal(t, DecisionAllow, record.Decision)
+	assert.Equal(t, map[string]any{"tenant": "acme"}, record.Metadata)
+	assert.Equal(t, map[string]any{MetadataOwner: "bob"}, record.TargetMetadata)
+	assert.Len(t, record.Subject, 16)
+	assert.NotContains(t, record.Subject, "alice")
+	assert.Equal(t, recorder.anonymize("alice@example.com"), record.Subject)
+	assert.True(t, record.Anonymized)
+
+	recorder.SetKeepSubjects(true)
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), claims, target))
+	records, err = ReadDecisionRecords(&buf)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "alice@example.com", records[0].Subject)
+	assert.False(t, records[0].Anonymized)
+}
+
+func TestDecisionRecorder_Salt(t *testing.T) {
+	a, b := NewDecisionRecorder(io.Discard), NewDecisionRecorder(io.Discard)
+	assert.NotEmpty(t, a.salt)
+	assert.NotEqual(t, a.anonymize("alice"), b.anonymize("alice"))
+
+	a.SetSalt("salt")
+	b.SetSalt("salt")
+	assert.Equal(t, a.anonymize("alice"), b.anonymize("alice"))
+}
+
+func TestDecisionRecorder_Request(t *testing.T) {
+	var buf bytes.Buffer
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	recorder := NewDecisionRecorder(&buf).SetSalt("salt").SetRequestHeaders("x-tenant")
+
+	ctx := WithDecisionTime(context.Background(), at)
+	ctx = WithRequestInfo(ctx, RequestInfo{
+		Method:     "GET",
+		Host:       "api.example.com",
+		Pattern:    "GET /posts/{id}",
+		RemoteAddr: "10.1.2.3:5000",
+		Header:     http.Header{"X-Tenant": {"acme"}, "Authorization": {"Bearer secret"}},
+		URL:        &url.URL{Path: "/posts/42"},
+		PathValues: map[string]string{"id": "42"},
+	})
+	claims := &Claims{
+		Subject: &testIdentifiedSubject{id: "bob", roles: []string{"user"}},
+		Actor:   &testIdentifiedSubject{id: "alice", roles: []string{"support"}},
+	}
+	require.NoError(t, recorder.Record(ctx, claims, &Target{Action: "topics:orders-*", Literal: true}, DecisionAllow))
+
+	records, err := ReadDecisionRecords(&buf)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	record := records[0]
+	assert.Equal(t, at, record.Time)
+	assert.True(t, record.Literal)
+	assert.False(t, record.Anchored)
+	assert.Equal(t, recorder.anonymize("alice"), record.Actor)
+	assert.Equal(t, []string{"support"}, record.ActorRoles)
+	assert.Equal(t, &RecordedRequest{
+		Method:     "GET",
+		Host:       "api.example.com",
+		Path:       "/posts/42",
+		Pattern:    "GET /posts/{id}",
+		Header:     http.Header{"X-Tenant": {"acme"}},
+		PathValues: map[string]string{"id": "42"},
+	}, record.Request)
+
+	recorder.SetKeepRemoteAddr(true)
+	require.NoError(t, recorder.Record(ctx, claims, &Target{Action: "a"}, DecisionAllow))
+	records, err = ReadDecisionRecords(&buf)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "10.1.2.3:5000", records[0].Request.RemoteAddr)
+
+	require.NoError(t, recorder.Record(context.Background(), nil, &Target{Action: "a"}, DecisionAllow))
+	records, err = ReadDecisionRecords(&buf)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Nil(t, records[0].Request)
+}
+
+func TestDecisionRecorder_Sampling(t *testing.T) {
+	var buf bytes.Buffer
+	recorder := NewDecisionRecorder(&buf).SetSampleRate(0.5)
+
+	recorder.random = func() float64 { return 0.7 }
+	assert.NoError(t, recorder.Record(context.Background(), nil, &Target{Action: "a"}, DecisionDeny))
+	assert.Empty(t, buf.String())
+
+	recorder.random = func() float64 { return 0.2 }
+	assert.NoError(t, recorder.Record(context.Background(), nil, &Target{Action: "a"}, DecisionDeny))
+	assert.NotEmpty(t, buf.String())
+
+	buf.Reset()
+	recorder.SetSampleRate(0)
+	assert.NoError(t, recorder.Record(context.Background(), nil, &Target{Action: "a"}, DecisionDeny))
+	assert.Empty(t, buf.String())
+}
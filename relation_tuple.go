@@ -0,0 +1,241 @@
+package rbac
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// MetadataResource is the Target.Metadata key of the object a
+// RelationAuthorizer checks, e.g. "doc:7".
+const MetadataResource = "resource"
+
+var (
+	_ TupleChecker = (*TupleStore)(nil)
+	_ Authorizer   = (*RelationAuthorizer)(nil)
+
+	ErrInvalidTuple = errors.New("invalid relation tuple")
+)
+
+// RelationTuple states that Subject has Relation on Object, written
+// "doc:7#viewer@user:1". The subject may be a userset such as
+// "group:eng#member", granting the relation to every member of the group.
+type RelationTuple struct {
+	Object   string
+	Relation string
+	Subject  string
+}
+
+func ParseRelationTuple(s string) (RelationTuple, error) {
+	objectRelation, subject, ok := strings.Cut(s, "@")
+	if !ok {
+		return RelationTuple{}, fmt.Errorf(`%w: "%s" lacks "@subject"`, ErrInvalidTuple, s)
+	}
+	object, relation, _ := strings.Cut(objectRelation, "#")
+	t := RelationTuple{Object: object, Relation: relation, Subject: subject}
+	return t, t.validate()
+}
+
+func (t RelationTuple) String() string {
+	return t.Object + "#" + t.Relation + "@" + t.Subject
+}
+
+func (t RelationTuple) validate() error {
+	if _, id, ok := strings.Cut(t.Object, ":"); !ok || id == "" || t.Relation == "" || t.Subject == "" {
+		return fmt.Errorf(`%w: "%s" is not "type:id#relation@subject"`, ErrInvalidTuple, t)
+	}
+	return nil
+}
+
+// TupleChecker answers "does subject have relation on object", e.g. backed
+// by a TupleStore or a Zanzibar style service.
+type TupleChecker interface {
+	Check(ctx context.Context, object, relation, subject string) (bool, error)
+}
+
+// TupleStore is an in-memory TupleChecker. Checks follow usersets and
+// relations implied by others, see SetImplied, up to a fixed depth.
+type TupleStore struct {
+	mu      sync.RWMutex
+	tuples  map[objectRelation]map[string]struct{}
+	implied map[objectRelation][]string
+}
+
+// objectRelation keys tuples by object and implications by object type.
+type objectRelation struct {
+	object   string
+	relation string
+}
+
+const maxTupleDepth = 16
+
+func NewTupleStore() *TupleStore {
+	return &TupleStore{tuples: map[objectRelation]map[string]struct{}{}, implied: map[objectRelation][]string{}}
+}
+
+// SetImplied declares that the given relations imply relation on objects of
+// the type, e.g. SetImplied("doc", "viewer", "editor", "owner").
+func (s *TupleStore) SetImplied(objectType, relation string, by ...string) *TupleStore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.implied[objectRelation{objectType, relation}] = by
+	return s
+}
+
+func (s *TupleStore) Write(tuples ...RelationTuple) error {
+	for _, t := range tuples {
+		if err := t.validate(); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range tuples {
+		key := objectRelation{t.Object, t.Relation}
+		if s.tuples[key] == nil {
+			s.tuples[key] = map[string]struct{}{}
+		}
+		s.tuples[key][t.Subject] = struct{}{}
+	}
+	return nil
+}
+
+func (s *TupleStore) Delete(tuples ...RelationTuple) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range tuples {
+		key := objectRelation{t.Object, t.Relation}
+		delete(s.tuples[key], t.Subject)
+		if len(s.tuples[key]) == 0 {
+			delete(s.tuples, key)
+		}
+	}
+}
+
+// Tuples returns all stored tuples sorted by object, relation and subject.
+func (s *TupleStore) Tuples() []RelationTuple {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tuples []RelationTuple
+	for key, subjects := range s.tuples {
+		for subject := range subjects {
+			tuples = append(tuples, RelationTuple{Object: key.object, Relation: key.relation, Subject: subject})
+		}
+	}
+	slices.SortFunc(tuples, func(a, b RelationTuple) int {
+		return cmp.Or(cmp.Compare(a.Object, b.Object), cmp.Compare(a.Relation, b.Relation), cmp.Compare(a.Subject, b.Subject))
+	})
+	return tuples
+}
+
+func (s *TupleStore) Check(_ context.Context, object, relation, subject string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.check(object, relation, subject, map[objectRelation]struct{}{}), nil
+}
+
+func (s *TupleStore) check(object, relation, subject string, visited map[objectRelation]struct{}) bool {
+	key := objectRelation{object, relation}
+	if _, ok := visited[key]; ok || len(visited) >= maxTupleDepth {
+		return false
+	}
+	visited[key] = struct{}{}
+	defer delete(visited, key)
+
+	subjects := s.tuples[key]
+	if _, ok := subjects[subject]; ok {
+		return true
+	}
+	for userset := range subjects {
+		if object, relation, ok := strings.Cut(userset, "#"); ok && s.check(object, relation, subject, visited) {
+			return true
+		}
+	}
+
+	objectType, _, _ := strings.Cut(object, ":")
+	for _, by := range s.implied[objectRelation{objectType, relation}] {
+		if s.check(object, by, subject, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// RelationAuthorizer grants actions on the object referenced by
+// Target.Metadata[MetadataResource] through relation tuples. The action is
+// the relation and the subject is "user:<identifier>" unless configured
+// otherwise. Targets without a resource reference are left to other
+// authorizers with DecisionAbstain, e.g. through FirstApplicableAuthorizer.
+type RelationAuthorizer struct {
+	checker  TupleChecker
+	relation func(action string) string
+	subject  func(claims *Claims) string
+}
+
+func NewRelationAuthorizer(checker TupleChecker) *RelationAuthorizer {
+	return &RelationAuthorizer{
+		checker:  checker,
+		relation: func(action string) string { return action },
+		subject: func(claims *Claims) string {
+			if id := SubjectID(claims.Subject); id != "" {
+				return "user:" + id
+			}
+			return ""
+		},
+	}
+}
+
+func (a *RelationAuthorizer) SetRelation(relation func(action string) string) *RelationAuthorizer {
+	a.relation = relation
+	return a
+}
+
+func (a *RelationAuthorizer) SetSubject(subject func(claims *Claims) string) *RelationAuthorizer {
+	a.subject = subject
+	return a
+}
+
+func (a *RelationAuthorizer) Authorize(ctx context.Context, claims *Claims, target *Target) Decision {
+	d, _ := a.AuthorizeE(ctx, claims, target)
+	return d
+}
+
+func (a *RelationAuthorizer) AuthorizeE(ctx context.Context, claims *Claims, target *Target) (Decision, error) {
+	if target == nil || target.Action == "" {
+		return DecisionDeny, NewAuthzError(AuthzPolicyError, "", &ReasonError{Reason: ReasonInvalidTarget{}})
+	}
+	object, _ := target.Metadata[MetadataResource].(string)
+	if object == "" {
+		return DecisionAbstain, nil
+	}
+
+	var subject string
+	if claims != nil && claims.Subject != nil {
+		subject = a.subject(claims)
+	}
+	if subject == "" {
+		return DecisionDeny, NewAuthzError(AuthzUnauthenticated, target.Action, &ReasonError{Reason: ReasonUnauthenticated{}})
+	}
+
+	relation := a.relation(target.Action)
+	ok, err := a.checker.Check(ctx, object, relation, subject)
+	switch {
+	case err != nil:
+		return DecisionDeny, NewAuthzError(AuthzPolicyError, target.Action, err)
+	case !ok:
+		return DecisionDeny, NewAuthzError(AuthzForbidden, target.Action,
+			fmt.Errorf(`no relation tuple grants "%s"`, RelationTuple{Object: object, Relation: relation, Subject: subject}))
+	default:
+		return DecisionAllow, nil
+	}
+}
@@ -0,0 +1,124 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustTuples(t *testing.T, specs ...string) []RelationTuple {
+	t.Helper()
+
+	tuples := make([]RelationTuple, len(specs))
+	for i, spec := range specs {
+		var err error
+		tuples[i], err = ParseRelationTuple(spec)
+		require.NoError(t, err)
+	}
+	return tuples
+}
+
+func TestParseRelationTuple(t *testing.T) {
+	tuple, err := ParseRelationTuple("doc:7#viewer@group:eng#member")
+	require.NoError(t, err)
+	assert.Equal(t, RelationTuple{Object: "doc:7", Relation: "viewer", Subject: "group:eng#member"}, tuple)
+	assert.Equal(t, "doc:7#viewer@group:eng#member", tuple.String())
+
+	for _, invalid := range []string{"doc:7#viewer", "doc#viewer@user:1", "doc:7@user:1", "doc:7#viewer@"} {
+		_, err = ParseRelationTuple(invalid)
+		assert.ErrorIs(t, err, ErrInvalidTuple, invalid)
+	}
+}
+
+func TestTupleStore_Check(t *testing.T) {
+	s := NewTupleStore().SetImplied("doc", "viewer", "editor")
+	require.NoError(t, s.Write(mustTuples(t,
+		"doc:7#editor@user:1",
+		"doc:7#viewer@group:eng#member",
+		"group:eng#member@user:2",
+		"group:eng#member@group:sre#member",
+		"group:sre#member@user:3",
+		// cycles terminate
+		"group:a#member@group:b#member",
+		"group:b#member@group:a#member",
+	)...))
+
+	check := func(object, relation, subject string) bool {
+		ok, err := s.Check(context.Background(), object, relation, subject)
+		require.NoError(t, err)
+		return ok
+	}
+	assert.True(t, check("doc:7", "editor", "user:1"))
+	assert.True(t, check("doc:7", "viewer", "user:1"))
+	assert.True(t, check("doc:7", "viewer", "user:2"))
+	assert.True(t, check("doc:7", "viewer", "user:3"))
+	assert.False(t, check("doc:7", "editor", "user:2"))
+	assert.False(t, check("doc:8", "viewer", "user:1"))
+	assert.False(t, check("group:a", "member", "user:1"))
+
+	s.Delete(mustTuples(t, "group:eng#member@group:sre#member")...)
+	assert.False(t, check("doc:7", "viewer", "user:3"))
+	assert.Len(t, s.Tuples(), 6)
+	assert.Equal(t, "doc:7#editor@user:1", s.Tuples()[0].String())
+
+	assert.ErrorIs(t, s.Write(RelationTuple{Object: "doc:7"}), ErrInvalidTuple)
+}
+
+func TestRelationAuthorizer(t *testing.T) {
+	s := NewTupleStore()
+	require.NoError(t, s.Write(mustTuples(t, "doc:7#view@user:1")...))
+	a := NewRelationAuthorizer(s)
+	claims := &Claims{Subject: NewSubject("1")}
+	doc := map[string]any{MetadataResource: "doc:7"}
+
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), claims, &Target{Action: "view", Metadata: doc}))
+
+	d, err := a.AuthorizeE(context.Background(), claims, &Target{Action: "edit", Metadata: doc})
+	assert.Equal(t, DecisionDeny, d)
+	assert.ErrorContains(t, err, `"doc:7#edit@user:1"`)
+
+	d, err = a.AuthorizeE(context.Background(), claims, &Target{Action: "view"})
+	assert.Equal(t, DecisionAbstain, d)
+	assert.NoError(t, err)
+
+	d, err = a.AuthorizeE(context.Background(), &Claims{Subject: NewSubject("", "user")}, &Target{Action: "view", Metadata: doc})
+	assert.Equal(t, DecisionDeny, d)
+	assert.Equal(t, []Reason{ReasonUnauthenticated{}}, Reasons(err))
+
+	a.SetRelation(func(action string) string { return map[string]string{"docs.read": "view"}[action] }).
+		SetSubject(func(claims *Claims) string { return "service:" + SubjectID(claims.Subject) })
+	require.NoError(t, s.Write(mustTuples(t, "doc:7#view@service:1")...))
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), claims, &Target{Action: "docs.read", Metadata: doc}))
+
+	checkErr := errors.New("spicedb unavailable")
+	failing := NewRelationAuthorizer(tupleCheckerFunc(func(context.Context, string, string, string) (bool, error) {
+		return false, checkErr
+	}))
+	_, err = failing.AuthorizeE(context.Background(), claims, &Target{Action: "view", Metadata: doc})
+	assert.ErrorIs(t, err, checkErr)
+}
+
+func TestRelationAuthorizer_FirstApplicable(t *testing.T) {
+	rbac := New()
+	role := NewRole("user")
+	require.NoError(t, role.AddPermissionsE("docs.list"))
+	require.NoError(t, rbac.AddRole(role))
+
+	s := NewTupleStore()
+	require.NoError(t, s.Write(mustTuples(t, "doc:7#docs.read@user:1")...))
+
+	a := NewFirstApplicableAuthorizer(NewRelationAuthorizer(s), NewDefaultAuthorizer(rbac))
+	claims := &Claims{Subject: NewSubject("1", "user")}
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), claims, &Target{Action: "docs.list"}))
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), claims, &Target{Action: "docs.read", Metadata: map[string]any{MetadataResource: "doc:7"}}))
+	assert.Equal(t, DecisionDeny, a.Authorize(context.Background(), claims, &Target{Action: "docs.read", Metadata: map[string]any{MetadataResource: "doc:8"}}))
+}
+
+type tupleCheckerFunc func(ctx context.Context, object, relation, subject string) (bool, error)
+
+func (f tupleCheckerFunc) Check(ctx context.Context, object, relation, subject string) (bool, error) {
+	return f(ctx, object, relation, subject)
+}
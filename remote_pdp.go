@@ -0,0 +1,267 @@
+package rbac
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	_ Authorizer   = (*RemoteAuthorizer)(nil)
+	_ http.Handler = (*PDPServer)(nil)
+
+	ErrRemotePDP = errors.New("remote pdp unavailable")
+)
+
+// PDPRequest is the body a RemoteAuthorizer posts to a policy decision point.
+type PDPRequest struct {
+	Subject *PDPSubject `json:"subject,omitempty"`
+	Actor   *PDPSubject `json:"actor,omitempty"`
+	// Metadata is the claims metadata.
+	Metadata map[string]any `json:"claims,omitempty"`
+	Action   string         `json:"action"`
+	Target   map[string]any `json:"target,omitempty"`
+}
+
+type PDPSubject struct {
+	ID    string   `json:"id,omitempty"`
+	Roles []string `json:"roles"`
+}
+
+type PDPResponse struct {
+	Decision Decision `json:"decision"`
+	// Code is the reason code of a denial, see AuthzError.
+	Code string `json:"code,omitempty"`
+}
+
+// NewPDPRequest describes a decision for the wire. Target assertions cannot
+// be transmitted, RemoteAuthorizer evaluates them locally.
+func NewPDPRequest(claims *Claims, target *Target) PDPRequest {
+	req := PDPRequest{}
+	if target != nil {
+		req.Action, req.Target = target.Action, target.Metadata
+	}
+	if claims != nil {
+		req.Subject, req.Actor, req.Metadata = pdpSubject(claims.Subject), pdpSubject(claims.Actor), claims.Metadata
+	}
+	return req
+}
+
+// Claims rebuilds the claims of the request, nil for anonymous requests.
+func (r PDPRequest) Claims() *Claims {
+	if r.Subject == nil {
+		return nil
+	}
+	claims := &Claims{Subject: NewSubject(r.Subject.ID, r.Subject.Roles...), Metadata: r.Metadata}
+	if r.Actor != nil {
+		claims.Actor = NewSubject(r.Actor.ID, r.Actor.Roles...)
+	}
+	return claims
+}
+
+func pdpSubject(subject Subject) *PDPSubject {
+	if subject == nil {
+		return nil
+	}
+	return &PDPSubject{ID: SubjectID(subject), Roles: subject.Roles()}
+}
+
+// RemoteAuthorizer asks a remote policy decision point, by default a
+// PDPServer over HTTP. Failed attempts are retried on transport errors, 429
+// and 5xx. When the PDP stays unreachable the fallback decision is returned,
+// DecisionDeny (fail-closed) unless configured otherwise. Target assertions
+// are evaluated locally, with a nil role, for allowed decisions.
+type RemoteAuthorizer struct {
+	decide   func(ctx context.Context, req PDPRequest) (PDPResponse, error)
+	client   *http.Client
+	timeout  time.Duration
+	retries  int
+	backoff  time.Duration
+	fallback Decision
+}
+
+func NewRemoteAuthorizer(url string) *RemoteAuthorizer {
+	a := NewRemoteAuthorizerFunc(nil)
+	a.decide = func(ctx context.Context, req PDPRequest) (PDPResponse, error) {
+		return a.post(ctx, url, req)
+	}
+	return a
+}
+
+// NewRemoteAuthorizerFunc uses decide as transport, e.g. a gRPC client, with
+// the same timeouts, retries and fallback. Errors wrapping ErrRemotePDP are
+// retried.
+func NewRemoteAuthorizerFunc(decide func(ctx context.Context, req PDPRequest) (PDPResponse, error)) *RemoteAuthorizer {
+	return &RemoteAuthorizer{
+		decide:   decide,
+		client:   http.DefaultClient,
+		timeout:  time.Second,
+		retries:  2,
+		backoff:  50 * time.Millisecond,
+		fallback: DecisionDeny,
+	}
+}
+
+func (a *RemoteAuthorizer) SetClient(client *http.Client) *RemoteAuthorizer {
+	a.client = client
+	return a
+}
+
+// SetTimeout bounds every attempt, zero disables the timeout.
+func (a *RemoteAuthorizer) SetTimeout(timeout time.Duration) *RemoteAuthorizer {
+	a.timeout = timeout
+	return a
+}
+
+func (a *RemoteAuthorizer) SetRetries(retries int, backoff time.Duration) *RemoteAuthorizer {
+	a.retries, a.backoff = retries, backoff
+	return a
+}
+
+// SetFallback sets the decision returned while the PDP is unavailable, e.g.
+// DecisionAllow to fail open.
+func (a *RemoteAuthorizer) SetFallback(fallback Decision) *RemoteAuthorizer {
+	a.fallback = fallback
+	return a
+}
+
+func (a *RemoteAuthorizer) Authorize(ctx context.Context, claims *Claims, target *Target) Decision {
+	d, _ := a.AuthorizeE(ctx, claims, target)
+	return d
+}
+
+func (a *RemoteAuthorizer) AuthorizeE(ctx context.Context, claims *Claims, target *Target) (Decision, error) {
+	if target == nil || target.Action == "" {
+		return DecisionDeny, NewAuthzError(AuthzPolicyError, "", &ReasonError{Reason: ReasonInvalidTarget{}})
+	}
+	req := NewPDPRequest(claims, target)
+
+	backoff := a.backoff
+	for attempt := 0; ; attempt++ {
+		res, err := a.attempt(ctx, req)
+		if err == nil {
+			d, err := pdpDecision(res, target.Action)
+			if d.Allowed() {
+				return assertTarget(ctx, d, target)
+			}
+			return d, err
+		}
+		if !errors.Is(err, ErrRemotePDP) || attempt >= a.retries {
+			return a.fallbackE(ctx, target, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return a.fallbackE(ctx, target, err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// fallbackE returns the fallback decision, checking the target assertions
+// when it allows.
+func (a *RemoteAuthorizer) fallbackE(ctx context.Context, target *Target, errs ...error) (Decision, error) {
+	err := NewAuthzError(AuthzPolicyError, target.Action, errs...)
+	if !a.fallback.Allowed() {
+		return a.fallback, err
+	}
+	if d, assertErr := assertTarget(ctx, a.fallback, target); !d.Allowed() {
+		return d, assertErr
+	}
+	return a.fallback, err
+}
+
+func (a *RemoteAuthorizer) attempt(ctx context.Context, req PDPRequest) (PDPResponse, error) {
+	if a.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.timeout)
+		defer cancel()
+	}
+	return a.decide(ctx, req)
+}
+
+func (a *RemoteAuthorizer) post(ctx context.Context, url string, pdpReq PDPRequest) (PDPResponse, error) {
+	body, err := json.Marshal(pdpReq)
+	if err != nil {
+		return PDPResponse{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return PDPResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return PDPResponse{}, fmt.Errorf("%w: %w", ErrRemotePDP, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500 {
+		return PDPResponse{}, fmt.Errorf("%w: status %d", ErrRemotePDP, res.StatusCode)
+	}
+	if res.StatusCode != http.StatusOK {
+		return PDPResponse{}, fmt.Errorf("remote pdp: status %d", res.StatusCode)
+	}
+
+	var pdpRes PDPResponse
+	if err = json.NewDecoder(res.Body).Decode(&pdpRes); err != nil {
+		return PDPResponse{}, fmt.Errorf("remote pdp: %w", err)
+	}
+	return pdpRes, nil
+}
+
+func pdpDecision(res PDPResponse, action string) (Decision, error) {
+	if res.Decision.Allowed() {
+		return res.Decision, nil
+	}
+	err := NewAuthzError(AuthzForbidden, action)
+	err.Code = res.Code
+	return res.Decision, err
+}
+
+// PDPServer exposes a local Authorizer as a policy decision point for
+// RemoteAuthorizer. Decide backs a gRPC service registered by the caller,
+// ServeHTTP serves the JSON protocol. Authenticating callers is left to
+// middleware.
+type PDPServer struct {
+	authorizer Authorizer
+}
+
+func NewPDPServer(authorizer Authorizer) *PDPServer {
+	return &PDPServer{authorizer: authorizer}
+}
+
+func (s *PDPServer) Decide(ctx context.Context, req PDPRequest) PDPResponse {
+	target := &Target{Action: req.Action, Metadata: req.Target}
+	result := Explain(ctx, s.authorizer, req.Claims(), target)
+
+	res := PDPResponse{Decision: result.Decision}
+	var authzErr *AuthzError
+	if errors.As(result.Err, &authzErr) {
+		res.Code = authzErr.Code
+	}
+	return res
+}
+
+func (s *PDPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req PDPRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.Decide(r.Context(), req))
+}
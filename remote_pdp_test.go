@@ -0,0 +1,154 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPDPServer(t *testing.T) *PDPServer {
+	t.Helper()
+
+	rbac := New()
+	user := NewRole("user")
+	require.NoError(t, user.AddPermissionsE("posts.read"))
+	require.NoError(t, rbac.AddRole(user))
+	return NewPDPServer(NewDefaultAuthorizer(rbac))
+}
+
+func TestPDPRequest_Claims(t *testing.T) {
+	claims := &Claims{Subject: NewSubject("u1", "user"), Actor: NewSubject("a1", "admin"), Metadata: map[string]any{"k": "v"}}
+	req := NewPDPRequest(claims, &Target{Action: "posts.read", Metadata: map[string]any{"id": "1"}})
+	assert.Equal(t, "posts.read", req.Action)
+	assert.Equal(t, map[string]any{"id": "1"}, req.Target)
+
+	restored := req.Claims()
+	assert.Equal(t, "u1", SubjectID(restored.Subject))
+	assert.Equal(t, []string{"admin"}, restored.Actor.Roles())
+	assert.Equal(t, "v", restored.Metadata["k"])
+
+	assert.Nil(t, NewPDPRequest(nil, &Target{Action: "x"}).Claims())
+}
+
+func TestRemoteAuthorizer(t *testing.T) {
+	srv := httptest.NewServer(newPDPServer(t))
+	defer srv.Close()
+
+	a := NewRemoteAuthorizer(srv.URL).SetClient(srv.Client())
+	claims := &Claims{Subject: NewSubject("u1", "user")}
+
+	d, err := a.AuthorizeE(context.Background(), claims, &Target{Action: "posts.read"})
+	require.NoError(t, err)
+	assert.Equal(t, DecisionAllow, d)
+
+	d, err = a.AuthorizeE(context.Background(), claims, &Target{Action: "posts.write"})
+	assert.Equal(t, DecisionDeny, d)
+	assert.ErrorIs(t, err, ErrDeny)
+	var authzErr *AuthzError
+	require.ErrorAs(t, err, &authzErr)
+	assert.Equal(t, AuthzForbidden, authzErr.Kind)
+	assert.Equal(t, ReasonPermissionMissing{}.Code(), authzErr.Code)
+
+	assert.Equal(t, DecisionDeny, a.Authorize(context.Background(), claims, nil))
+}
+
+func TestRemoteAuthorizer_RetryAndFallback(t *testing.T) {
+	var calls atomic.Int32
+	pdp := newPDPServer(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		pdp.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	claims := &Claims{Subject: NewSubject("u1", "user")}
+	a := NewRemoteAuthorizer(srv.URL).SetClient(srv.Client()).SetRetries(2, time.Millisecond)
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), claims, &Target{Action: "posts.read"}))
+	assert.Equal(t, int32(3), calls.Load())
+
+	calls.Store(0)
+	a.SetRetries(1, time.Millisecond)
+	d, err := a.AuthorizeE(context.Background(), claims, &Target{Action: "posts.read"})
+	assert.Equal(t, DecisionDeny, d)
+	assert.ErrorIs(t, err, ErrRemotePDP)
+	assert.Equal(t, int32(2), calls.Load())
+
+	calls.Store(0)
+	a.SetFallback(DecisionAllow)
+	d, err = a.AuthorizeE(context.Background(), claims, &Target{Action: "posts.read"})
+	assert.Equal(t, DecisionAllow, d)
+	assert.ErrorIs(t, err, ErrRemotePDP)
+}
+
+func TestRemoteAuthorizer_Timeout(t *testing.T) {
+	a := NewRemoteAuthorizerFunc(func(ctx context.Context, _ PDPRequest) (PDPResponse, error) {
+		<-ctx.Done()
+		return PDPResponse{}, errors.Join(ErrRemotePDP, ctx.Err())
+	}).SetTimeout(time.Millisecond).SetRetries(0, 0)
+
+	d, err := a.AuthorizeE(context.Background(), &Claims{Subject: NewSubject("u1")}, &Target{Action: "x"})
+	assert.Equal(t, DecisionDeny, d)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	permanent := errors.New("bad request")
+	var calls int
+	a = NewRemoteAuthorizerFunc(func(context.Context, PDPRequest) (PDPResponse, error) {
+		calls++
+		return PDPResponse{}, permanent
+	})
+	_, err = a.AuthorizeE(context.Background(), nil, &Target{Action: "x"})
+	assert.ErrorIs(t, err, permanent)
+	assert.Equal(t, 1, calls)
+}
+
+func TestPDPServer_ServeHTTP(t *testing.T) {
+	s := newPDPServer(t)
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"subject":{"roles":["user"]},"action":"posts.read"}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"decision":"allow"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"action":"posts.read"}`)))
+	assert.JSONEq(t, `{"decision":"deny","code":"`+ReasonUnauthenticated{}.Code()+`"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestRemoteAuthorizer_Assertions(t *testing.T) {
+	srv := httptest.NewServer(newPDPServer(t))
+	defer srv.Close()
+
+	a := NewRemoteAuthorizer(srv.URL).SetClient(srv.Client())
+	claims := &Claims{Subject: NewSubject("u1", "user")}
+	denied := &Target{Action: "posts.read", Assertions: []Assertion{&testAssertion{shouldPass: false}}}
+
+	d, err := a.AuthorizeE(context.Background(), claims, denied)
+	assert.Equal(t, DecisionDeny, d)
+	assert.ErrorIs(t, err, ErrDeny)
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), claims, &Target{Action: "posts.read", Assertions: []Assertion{&testAssertion{shouldPass: true}}}))
+
+	unavailable := NewRemoteAuthorizerFunc(func(context.Context, PDPRequest) (PDPResponse, error) {
+		return PDPResponse{}, ErrRemotePDP
+	}).SetRetries(0, 0).SetFallback(DecisionAllow)
+	assert.Equal(t, DecisionDeny, unavailable.Authorize(context.Background(), claims, denied))
+	assert.Equal(t, DecisionAllow, unavailable.Authorize(context.Background(), claims, &Target{Action: "posts.read"}))
+}
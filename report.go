@@ -0,0 +1,121 @@
+package rbac
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type roleUsage struct {
+	since    atomic.Int64
+	lastUsed sync.Map
+}
+
+func newRoleUsage() *roleUsage {
+	u := new(roleUsage)
+	u.since.Store(time.Now().UnixNano())
+	return u
+}
+
+func (u *roleUsage) touch(name string) {
+	now := time.Now().UnixNano()
+	if value, ok := u.lastUsed.Load(name); ok {
+		value.(*atomic.Int64).Store(now)
+		return
+	}
+	value := new(atomic.Int64)
+	value.Store(now)
+	if actual, loaded := u.lastUsed.LoadOrStore(name, value); loaded {
+		actual.(*atomic.Int64).Store(now)
+	}
+}
+
+func (u *roleUsage) rename(oldName, newName string) {
+	if value, ok := u.lastUsed.LoadAndDelete(oldName); ok {
+		u.lastUsed.Store(newName, value)
+	}
+}
+
+func (u *roleUsage) remove(name string) {
+	u.lastUsed.Delete(name)
+}
+
+func (u *roleUsage) used(name string) bool {
+	value, ok := u.lastUsed.Load(name)
+	return ok && value.(*atomic.Int64).Load() >= u.since.Load()
+}
+
+type RoleReport struct {
+	// Since is the start of the observation window.
+	Since time.Time
+	// Unused lists roles no authorization check referenced within the window.
+	Unused []string
+	// Empty lists roles without own or inherited permissions.
+	Empty []string
+	// Unreachable lists roles that are neither used nor a descendant of a used role.
+	Unreachable []string
+}
+
+// SetUsageTracking records when roles are checked, for Report. It is off by
+// default as it costs a clock read and an atomic store per check.
+func (rbac *RBAC) SetUsageTracking(track bool) *RBAC {
+	rbac.trackUsage = track
+	return rbac
+}
+
+func (rbac *RBAC) UsageTracking() bool {
+	return rbac.trackUsage
+}
+
+// Report summarizes role usage observed by IsGrantedE since New or the last
+// ResetUsage, to support periodic access reviews. Without SetUsageTracking
+// every role is reported unused.
+func (rbac *RBAC) Report() RoleReport {
+	report := RoleReport{Since: time.Unix(0, rbac.usage.since.Load())}
+
+	reachable := map[string]struct{}{}
+	var walk func(*Role)
+	walk = func(r *Role) {
+		if _, ok := reachable[r.Name()]; ok {
+			return
+		}
+		reachable[r.Name()] = struct{}{}
+		for child := range r.Children() {
+			walk(child)
+		}
+	}
+
+	for name, role := range rbac.roles {
+		if rbac.usage.used(name) {
+			walk(role)
+		} else {
+			report.Unused = append(report.Unused, name)
+		}
+
+		empty := true
+		for range role.Permissions(true) {
+			empty = false
+			break
+		}
+		if empty {
+			report.Empty = append(report.Empty, name)
+		}
+	}
+
+	for name := range rbac.roles {
+		if _, ok := reachable[name]; !ok {
+			report.Unreachable = append(report.Unreachable, name)
+		}
+	}
+
+	slices.Sort(report.Unused)
+	slices.Sort(report.Empty)
+	slices.Sort(report.Unreachable)
+	return report
+}
+
+// ResetUsage starts a new observation window for Report.
+func (rbac *RBAC) ResetUsage() {
+	rbac.usage.since.Store(time.Now().UnixNano())
+}
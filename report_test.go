@@ -0,0 +1,52 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRBAC_Report(t *testing.T) {
+	rbac := New().SetUsageTracking(true)
+	assert.True(t, rbac.UsageTracking())
+	admin := NewRole("admin")
+	editor := NewRole("editor")
+	editor.AddPermissions("posts:write")
+	legacy := NewRole("legacy")
+	legacy.AddPermissions("old:thing")
+	empty := NewRole("empty")
+
+	assert.NoError(t, rbac.AddRole(admin))
+	assert.NoError(t, rbac.AddRole(editor, "admin"))
+	assert.NoError(t, rbac.AddRole(legacy))
+	assert.NoError(t, rbac.AddRole(empty))
+
+	report := rbac.Report()
+	assert.Equal(t, []string{"admin", "editor", "empty", "legacy"}, report.Unused)
+	assert.Equal(t, []string{"empty"}, report.Empty)
+	assert.Equal(t, []string{"admin", "editor", "empty", "legacy"}, report.Unreachable)
+	assert.WithinDuration(t, time.Now(), report.Since, time.Minute)
+
+	rbac.IsGranted(context.Background(), "admin", "posts:write")
+
+	report = rbac.Report()
+	assert.Equal(t, []string{"editor", "empty", "legacy"}, report.Unused)
+	assert.Equal(t, []string{"empty", "legacy"}, report.Unreachable)
+
+	time.Sleep(time.Millisecond)
+	rbac.ResetUsage()
+
+	report = rbac.Report()
+	assert.Equal(t, []string{"admin", "editor", "empty", "legacy"}, report.Unused)
+}
+
+func TestRBAC_ReportUntracked(t *testing.T) {
+	rbac := New()
+	assert.False(t, rbac.UsageTracking())
+	assert.NoError(t, rbac.AddRole("admin"))
+
+	rbac.IsGranted(context.Background(), "admin", "posts:write")
+	assert.Equal(t, []string{"admin"}, rbac.Report().Unused)
+}
@@ -0,0 +1,133 @@
+package rbac
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var (
+	_ Authorizer        = (*RevocationAuthorizer)(nil)
+	_ RevocationChecker = (*RevocationList)(nil)
+	_ RevocationChecker = (*StoreRevocationChecker)(nil)
+)
+
+const MetadataTokenID = "jti"
+
+type RevocationChecker interface {
+	Revoked(ctx context.Context, claims *Claims) (bool, error)
+}
+
+func revocationKeys(claims *Claims) []string {
+	if claims == nil {
+		return nil
+	}
+
+	var keys []string
+	if claims.Subject != nil {
+		if id := SubjectID(claims.Subject); id != "" {
+			keys = append(keys, "subject:"+id)
+		}
+	}
+	if jti, _ := claims.Metadata[MetadataTokenID].(string); jti != "" {
+		keys = append(keys, "token:"+jti)
+	}
+	return keys
+}
+
+// RevocationList is an in-memory RevocationChecker whose entries expire,
+// typically together with the revoked tokens.
+type RevocationList struct {
+	mu      sync.RWMutex
+	entries map[string]time.Time
+	now     func() time.Time
+}
+
+func NewRevocationList() *RevocationList {
+	return &RevocationList{entries: map[string]time.Time{}, now: time.Now}
+}
+
+func (l *RevocationList) RevokeSubject(id string, until time.Time) {
+	l.revoke("subject:"+id, until)
+}
+
+func (l *RevocationList) RevokeToken(jti string, until time.Time) {
+	l.revoke("token:"+jti, until)
+}
+
+func (l *RevocationList) revoke(key string, until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries[key] = until
+}
+
+func (l *RevocationList) Revoked(_ context.Context, claims *Claims) (bool, error) {
+	now := l.now()
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, key := range revocationKeys(claims) {
+		if until, ok := l.entries[key]; ok && now.Before(until) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Purge drops expired entries.
+func (l *RevocationList) Purge() {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, until := range l.entries {
+		if !now.Before(until) {
+			delete(l.entries, key)
+		}
+	}
+}
+
+// RevocationStore is the lookup a shared store such as Redis has to provide,
+// e.g. an EXISTS on the prefixed key with entries written using SET ... EX.
+type RevocationStore interface {
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+type StoreRevocationChecker struct {
+	store  RevocationStore
+	prefix string
+}
+
+func NewStoreRevocationChecker(store RevocationStore, prefix string) *StoreRevocationChecker {
+	return &StoreRevocationChecker{store: store, prefix: prefix}
+}
+
+func (c *StoreRevocationChecker) Revoked(ctx context.Context, claims *Claims) (bool, error) {
+	for _, key := range revocationKeys(claims) {
+		ok, err := c.store.Exists(ctx, c.prefix+key)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// RevocationAuthorizer denies revoked claims before consulting the wrapped
+// authorizer. Checker errors deny as well.
+type RevocationAuthorizer struct {
+	authorizer Authorizer
+	checker    RevocationChecker
+}
+
+func NewRevocationAuthorizer(authorizer Authorizer, checker RevocationChecker) *RevocationAuthorizer {
+	return &RevocationAuthorizer{authorizer: authorizer, checker: checker}
+}
+
+func (a *RevocationAuthorizer) Authorize(ctx context.Context, claims *Claims, target *Target) Decision {
+	if revoked, err := a.checker.Revoked(ctx, claims); revoked || err != nil {
+		return DecisionDeny
+	}
+	return a.authorizer.Authorize(ctx, claims, target)
+}
@@ -0,0 +1,75 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testRevocationStore map[string]bool
+
+func (s testRevocationStore) Exists(_ context.Context, key string) (bool, error) {
+	if key == "rbac:subject:broken" {
+		return false, errors.New("connection refused")
+	}
+	return s[key], nil
+}
+
+func TestRevocationList(t *testing.T) {
+	now := time.Now()
+	l := NewRevocationList()
+	l.now = func() time.Time { return now }
+
+	alice := &Claims{Subject: &testIdentifiedSubject{id: "alice"}, Metadata: map[string]any{MetadataTokenID: "t1"}}
+	bob := &Claims{Subject: &testIdentifiedSubject{id: "bob"}, Metadata: map[string]any{MetadataTokenID: "t2"}}
+
+	l.RevokeSubject("alice", now.Add(time.Hour))
+	l.RevokeToken("t2", now.Add(time.Minute))
+
+	revoked, err := l.Revoked(context.Background(), alice)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, _ = l.Revoked(context.Background(), bob)
+	assert.True(t, revoked)
+
+	revoked, _ = l.Revoked(context.Background(), nil)
+	assert.False(t, revoked)
+
+	now = now.Add(2 * time.Minute)
+	revoked, _ = l.Revoked(context.Background(), bob)
+	assert.False(t, revoked)
+
+	l.Purge()
+	assert.Len(t, l.entries, 1)
+}
+
+func TestStoreRevocationChecker(t *testing.T) {
+	c := NewStoreRevocationChecker(testRevocationStore{"rbac:token:t1": true}, "rbac:")
+
+	revoked, err := c.Revoked(context.Background(), &Claims{Metadata: map[string]any{MetadataTokenID: "t1"}})
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = c.Revoked(context.Background(), &Claims{Subject: &testIdentifiedSubject{id: "alice"}})
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	_, err = c.Revoked(context.Background(), &Claims{Subject: &testIdentifiedSubject{id: "broken"}})
+	assert.Error(t, err)
+}
+
+func TestRevocationAuthorizer(t *testing.T) {
+	l := NewRevocationList()
+	l.RevokeSubject("alice", time.Now().Add(time.Hour))
+
+	a := NewRevocationAuthorizer(&mockAuthorizer{decision: DecisionAllow}, l)
+	assert.Equal(t, DecisionDeny, a.Authorize(context.Background(), &Claims{Subject: &testIdentifiedSubject{id: "alice"}}, &Target{Action: "a"}))
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), &Claims{Subject: &testIdentifiedSubject{id: "bob"}}, &Target{Action: "a"}))
+
+	broken := NewRevocationAuthorizer(&mockAuthorizer{decision: DecisionAllow}, NewStoreRevocationChecker(testRevocationStore{}, "rbac:"))
+	assert.Equal(t, DecisionDeny, broken.Authorize(context.Background(), &Claims{Subject: &testIdentifiedSubject{id: "broken"}}, &Target{Action: "a"}))
+}
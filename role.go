@@ -10,21 +10,41 @@ import (
 
 var _ fmt.Stringer = (*Role)(nil)
 
-var perms = new(sync.Map)
+var (
+	perms    = new(sync.Map)
+	anchored = new(sync.Map)
+)
+
+// permissionMatcher matches actions against a pattern permission, literal
+// permissions are stored without one.
+type permissionMatcher interface {
+	MatchString(s string) bool
+}
 
 type Role struct {
-	name        string
-	permissions map[string]*regexp.Regexp
-	parents     map[string]*Role
-	children    map[string]*Role
+	name           string
+	permissions    map[string]permissionMatcher
+	parents        map[string]*Role
+	children       map[string]*Role
+	tags           map[string]struct{}
+	limits         PermissionLimits
+	notify         func(PolicyEvent)
+	registry       *permissionRegistry
+	matching       PermissionMatching
+	inherited      PermissionMatching
+	conditions     map[string][]Assertion
+	paths          *PathTrie
+	implications   *implications
+	permissionSets map[string][]string
 }
 
 func NewRole(name string) *Role {
 	return &Role{
 		name:        name,
-		permissions: map[string]*regexp.Regexp{},
+		permissions: map[string]permissionMatcher{},
 		parents:     map[string]*Role{},
 		children:    map[string]*Role{},
+		tags:        map[string]struct{}{},
 	}
 }
 
@@ -36,33 +56,189 @@ func (r *Role) Name() string {
 	return r.name
 }
 
+// AddPermissions adds the permissions with the role's PermissionMatching,
+// skipping those rejected by the permission limits or registry.
+// AddPermissionsE reports them instead.
 func (r *Role) AddPermissions(permissions ...string) {
+	if err := r.AddPermissionsE(permissions...); err == nil {
+		return
+	}
 	for _, permission := range permissions {
-		var re *regexp.Regexp
-		if value, ok := perms.Load(permission); ok {
-			re, _ = value.(*regexp.Regexp)
-		} else {
-			re, _ = regexp.Compile(permission)
-			perms.Store(permission, re)
+		_ = r.AddPermissionsE(permission)
+	}
+}
+
+// AddPermissionsE adds the permissions with the role's PermissionMatching.
+// Nothing is added if one of them is rejected.
+func (r *Role) AddPermissionsE(permissions ...string) error {
+	switch r.PermissionMatching() {
+	case MatchLiteral:
+		return r.AddLiteralPermissions(permissions...)
+	case MatchGlob:
+		return r.AddGlobPermissions(permissions...)
+	case MatchPath:
+		return r.AddPathPermissions(permissions...)
+	default:
+		return r.addPermissions(permissions, func(permission string) (permissionMatcher, error) {
+			// permissions that are not valid regular expressions match literally
+			if re, _ := compilePermission(permission); re != nil {
+				return re, nil
+			}
+			return nil, nil
+		})
+	}
+}
+
+// AddRegexPermissions adds permissions that must be valid regular expressions.
+func (r *Role) AddRegexPermissions(permissions ...string) error {
+	return r.addPermissions(permissions, func(permission string) (permissionMatcher, error) {
+		re, err := compilePermission(permission)
+		if err != nil {
+			return nil, fmt.Errorf(`%w: "%s" is not a regular expression: %w`, ErrInvalidPermission, permission, err)
 		}
+		return re, nil
+	})
+}
+
+// AddLiteralPermissions adds permissions that only match themselves, even if
+// they are valid regular expressions, e.g. "a.b" does not match "aXb".
+func (r *Role) AddLiteralPermissions(permissions ...string) error {
+	return r.addPermissions(permissions, func(string) (permissionMatcher, error) {
+		return nil, nil
+	})
+}
+
+// AddGlobPermissions adds glob permissions, see MatchGlob.
+func (r *Role) AddGlobPermissions(permissions ...string) error {
+	return r.addPermissions(permissions, func(permission string) (permissionMatcher, error) {
+		if g := compileGlob(permission); g != nil {
+			return g, nil
+		}
+		return nil, nil
+	})
+}
+
+func (r *Role) addPermissions(permissions []string, compile func(string) (permissionMatcher, error)) error {
+	added := map[string]struct{}{}
+	compiled := make([]permissionMatcher, len(permissions))
+	for i, permission := range permissions {
+		if err := r.limits.Validate(permission); err != nil {
+			return err
+		}
+		m, err := compile(permission)
+		if err != nil {
+			return err
+		}
+		if err = r.registry.check(permission, m); err != nil {
+			return err
+		}
+		compiled[i] = m
+		if _, ok := r.permissions[permission]; !ok {
+			added[permission] = struct{}{}
+		}
+	}
+
+	if r.limits.MaxPerRole > 0 && len(r.permissions)+len(added) > r.limits.MaxPerRole {
+		return fmt.Errorf(`%w: role "%s" may hold at most %d permissions`, ErrTooManyPermissions, r.Name(), r.limits.MaxPerRole)
+	}
 
-		r.permissions[permission] = re
+	for i, permission := range permissions {
+		r.permissions[permission] = compiled[i]
+	}
+	r.indexPaths(permissions, nil)
+
+	if len(added) > 0 {
+		r.emit(PolicyEvent{Type: PolicyPermissionsAdded, Role: r.Name(), Permissions: sortedKeys(added)})
+	}
+
+	return nil
+}
+
+func compilePermission(permission string) (*regexp.Regexp, error) {
+	if value, ok := perms.Load(permission); ok {
+		if re, _ := value.(*regexp.Regexp); re != nil {
+			return re, nil
+		}
+		// invalid permissions are cached as nil, compile again for the error
+		return regexp.Compile(permission)
+	}
+	re, err := regexp.Compile(permission)
+	perms.Store(permission, re)
+	return re, err
+}
+
+// anchorPermission returns the regular expression of a permission matching
+// whole actions only.
+func anchorPermission(re *regexp.Regexp) *regexp.Regexp {
+	if value, ok := anchored.Load(re); ok {
+		return value.(*regexp.Regexp)
+	}
+	a, err := regexp.Compile(`^(?:` + re.String() + `)$`)
+	if err != nil {
+		return re
+	}
+	anchored.Store(re, a)
+	return a
+}
+
+// RemovePermissions revokes literal and pattern permissions held by the role
+// itself. Permissions inherited from children are not affected.
+func (r *Role) RemovePermissions(permissions ...string) {
+	removed := map[string]struct{}{}
+	for _, permission := range permissions {
+		if _, ok := r.permissions[permission]; ok {
+			delete(r.permissions, permission)
+			delete(r.conditions, permission)
+			removed[permission] = struct{}{}
+		}
+	}
+
+	r.indexPaths(nil, sortedKeys(removed))
+	if len(removed) > 0 {
+		r.emit(PolicyEvent{Type: PolicyPermissionsRemoved, Role: r.Name(), Permissions: sortedKeys(removed)})
 	}
 }
 
+// ClearPermissions revokes all permissions held by the role itself.
+func (r *Role) ClearPermissions() {
+	r.RemovePermissions(sortedKeys(r.permissions)...)
+}
+
+// HasPermission reports whether the role, or one of its children, holds the
+// permission or a permission implying it.
 func (r *Role) HasPermission(permission string) bool {
+	return r.hasPermission(permission, matchPartial)
+}
+
+// HasLiteralPermission is HasPermission for actions carrying their own
+// wildcards, e.g. those of KafkaActions: only permissions equal to the action
+// grant it, patterns are not evaluated.
+func (r *Role) HasLiteralPermission(permission string) bool {
+	return r.hasPermission(permission, matchEqual)
+}
+
+func (r *Role) hasPermission(permission string, mode actionMatching) bool {
+	return r.holds(permission, mode) || r.impliedPermission(permission, mode == matchEqual, nil)
+}
+
+func (r *Role) holds(permission string, mode actionMatching) bool {
 	if _, ok := r.permissions[permission]; ok {
 		return true
 	}
 
-	for _, re := range r.permissions {
-		if re != nil && re.MatchString(permission) {
+	if mode != matchEqual {
+		if r.paths != nil && r.paths.Check(permission) {
 			return true
 		}
+		for _, m := range r.permissions {
+			if _, ok := m.(pathPermission); !ok && mode.match(m, permission) {
+				return true
+			}
+		}
 	}
 
 	for child := range r.Children() {
-		if child.HasPermission(permission) {
+		if child.holds(permission, mode) {
 			return true
 		}
 	}
@@ -70,6 +246,14 @@ func (r *Role) HasPermission(permission string) bool {
 	return false
 }
 
+func (r *Role) grants(pattern, permission string) bool {
+	if pattern == permission {
+		return true
+	}
+	re, ok := r.permissions[pattern]
+	return ok && re != nil && re.MatchString(permission)
+}
+
 func (r *Role) Permissions(children bool) iter.Seq[string] {
 	return func(yield func(string) bool) {
 		_ = iterPermissions(r, children, yield)
@@ -112,6 +296,14 @@ func (r *Role) AddParent(parent *Role) error {
 	return parent.AddChild(r)
 }
 
+// RemoveParent is the inverse of AddParent.
+func (r *Role) RemoveParent(parent *Role) {
+	if parent == nil {
+		panic(ErrRoleNil)
+	}
+	parent.RemoveChild(r)
+}
+
 func (r *Role) Parents() iter.Seq[*Role] {
 	return maps.Values(r.parents)
 }
@@ -130,14 +322,100 @@ func (r *Role) AddChild(child *Role) error {
 	}
 
 	r.children[child.Name()] = child
+	event := PolicyEvent{Type: PolicyChildAdded, Role: r.Name(), Child: child.Name()}
+	if r.notify != nil {
+		r.emit(event)
+	} else {
+		child.emit(event)
+	}
 
 	return child.AddParent(r)
 }
 
+// RemoveChild is the inverse of AddChild. Permissions the child inherited
+// through r are no longer granted.
+func (r *Role) RemoveChild(child *Role) {
+	if child == nil {
+		panic(ErrRoleNil)
+	}
+
+	if _, ok := r.children[child.Name()]; !ok {
+		return
+	}
+
+	delete(r.children, child.Name())
+	delete(child.parents, r.Name())
+
+	event := PolicyEvent{Type: PolicyChildRemoved, Role: r.Name(), Child: child.Name()}
+	if r.notify != nil {
+		r.emit(event)
+	} else {
+		child.emit(event)
+	}
+}
+
 func (r *Role) Children() iter.Seq[*Role] {
 	return maps.Values(r.children)
 }
 
+func (r *Role) clone(copies map[*Role]*Role) *Role {
+	if c, ok := copies[r]; ok {
+		return c
+	}
+
+	c := NewRole(r.name)
+	maps.Copy(c.permissions, r.permissions)
+	c.indexPaths(sortedKeys(r.permissions), nil)
+	c.permissionSets = clonePermissionSets(r.permissionSets)
+	maps.Copy(c.tags, r.tags)
+	if r.conditions != nil {
+		c.conditions = maps.Clone(r.conditions)
+	}
+	c.limits = r.limits
+	c.matching, c.inherited = r.matching, r.inherited
+	copies[r] = c
+
+	for name, parent := range r.parents {
+		c.parents[name] = parent.clone(copies)
+	}
+	for name, child := range r.children {
+		c.children[name] = child.clone(copies)
+	}
+	return c
+}
+
+func (r *Role) detach() {
+	for _, parent := range r.parents {
+		delete(parent.children, r.Name())
+	}
+	for _, child := range r.children {
+		delete(child.parents, r.Name())
+	}
+	clear(r.parents)
+	clear(r.children)
+}
+
+func (r *Role) AddTags(tags ...string) {
+	for _, tag := range tags {
+		r.tags[tag] = struct{}{}
+	}
+}
+
+func (r *Role) RemoveTags(tags ...string) {
+	for _, tag := range tags {
+		delete(r.tags, tag)
+	}
+}
+
+func (r *Role) HasTag(tag string) bool {
+	_, ok := r.tags[tag]
+	return ok
+}
+
+func (r *Role) Tags() iter.Seq[string] {
+	return maps.Keys(r.tags)
+}
+
 func (r *Role) HasAncestor(role *Role) bool {
 	if role == nil {
 		panic(ErrRoleNil)
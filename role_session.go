@@ -0,0 +1,119 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	_ Subject    = (*RoleSession)(nil)
+	_ Identifier = (*RoleSession)(nil)
+
+	ErrRoleNotAssigned = errors.New("role not assigned")
+)
+
+// RoleSession is a subject with a subset of its assigned roles activated, the
+// session of NIST RBAC. As a Subject it reports only the active roles, so
+// authorizers evaluate the session with least privilege. Activations may
+// lapse after a period.
+type RoleSession struct {
+	mu          sync.RWMutex
+	subject     Subject
+	assigned    []string
+	active      map[string]time.Time
+	constraints *ConstraintRegistry
+	hierarchy   *RBAC
+}
+
+// NewRoleSession starts a session of the subject without active roles.
+func NewRoleSession(subject Subject) *RoleSession {
+	return &RoleSession{subject: subject, assigned: subject.Roles(), active: map[string]time.Time{}}
+}
+
+// Activate activates assigned roles until they are deactivated.
+func (s *RoleSession) Activate(roles ...string) error {
+	return s.activate(time.Time{}, roles)
+}
+
+// ActivateFor activates assigned roles for the given period.
+func (s *RoleSession) ActivateFor(period time.Duration, roles ...string) error {
+	return s.activate(time.Now().Add(period), roles)
+}
+
+func (s *RoleSession) activate(until time.Time, roles []string) error {
+	for _, role := range roles {
+		if !slices.Contains(s.assigned, role) {
+			return fmt.Errorf(`%w: role "%s" is not assigned to "%s"`, ErrRoleNotAssigned, role, SubjectID(s.subject))
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.constraints != nil {
+		held := append(s.activeRoles(), roles...)
+		if s.hierarchy != nil {
+			held = sortedKeys(s.hierarchy.heldRoles(held...))
+		}
+		if err := s.constraints.Check(held...); err != nil {
+			return err
+		}
+	}
+	for _, role := range roles {
+		s.active[role] = until
+	}
+	return nil
+}
+
+func (s *RoleSession) Deactivate(roles ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, role := range roles {
+		delete(s.active, role)
+	}
+}
+
+func (s *RoleSession) Identifier() string {
+	return SubjectID(s.subject)
+}
+
+// AssignedRoles returns the roles the session may activate.
+func (s *RoleSession) AssignedRoles() []string {
+	return slices.Clone(s.assigned)
+}
+
+// Roles returns the active roles sorted by name.
+func (s *RoleSession) Roles() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.activeRoles()
+}
+
+// activeRoles returns the active roles, the caller holds s.mu.
+func (s *RoleSession) activeRoles() []string {
+	now := time.Now()
+	roles := make([]string, 0, len(s.active))
+	for _, role := range sortedKeys(s.active) {
+		if until := s.active[role]; until.IsZero() || now.Before(until) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// IsGranted reports whether one of the active roles is granted the
+// permission.
+func (s *RoleSession) IsGranted(ctx context.Context, rbac AuthorizationChecker, permission string, assertions ...Assertion) bool {
+	for _, role := range s.Roles() {
+		if rbac.IsGranted(ctx, role, permission, assertions...) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleSession(t *testing.T) {
+	rbac := New()
+	for name, permission := range map[string]string{"admin": "users.delete", "editor": "posts.edit"} {
+		role := NewRole(name)
+		require.NoError(t, role.AddPermissionsE(permission))
+		require.NoError(t, rbac.AddRole(role))
+	}
+
+	s := NewRoleSession(NewSubject("u1", "admin", "editor"))
+	assert.Equal(t, "u1", s.Identifier())
+	assert.Equal(t, []string{"admin", "editor"}, s.AssignedRoles())
+	assert.Empty(t, s.Roles())
+	assert.False(t, s.IsGranted(context.Background(), rbac, "posts.edit"))
+
+	require.NoError(t, s.Activate("editor"))
+	assert.Equal(t, []string{"editor"}, s.Roles())
+	assert.True(t, s.IsGranted(context.Background(), rbac, "posts.edit"))
+	assert.False(t, s.IsGranted(context.Background(), rbac, "users.delete"))
+
+	assert.ErrorIs(t, s.Activate("editor", "root"), ErrRoleNotAssigned)
+	assert.Equal(t, []string{"editor"}, s.Roles())
+
+	require.NoError(t, s.ActivateFor(-time.Second, "admin"))
+	assert.Equal(t, []string{"editor"}, s.Roles())
+	require.NoError(t, s.ActivateFor(time.Minute, "admin"))
+	assert.Equal(t, []string{"admin", "editor"}, s.Roles())
+
+	s.Deactivate("admin", "editor")
+	assert.Empty(t, s.Roles())
+}
+
+func TestWithRoleSession(t *testing.T) {
+	rbac := New()
+	editor := NewRole("editor")
+	require.NoError(t, editor.AddPermissionsE("posts.edit"))
+	require.NoError(t, rbac.AddRole(editor))
+	authorizer := NewDefaultAuthorizer(rbac)
+
+	s := NewRoleSession(NewSubject("u1", "editor"))
+	ctx := WithClaims(context.Background(), &Claims{Subject: NewSubject("u1", "editor"), Metadata: map[string]any{"tenant": "t1"}})
+	ctx = WithRoleSession(ctx, s)
+
+	assert.Same(t, s, CtxRoleSession(ctx))
+	claims := CtxClaims(ctx)
+	assert.Equal(t, "t1", claims.Metadata["tenant"])
+	assert.Equal(t, DecisionDeny, authorizer.Authorize(ctx, claims, &Target{Action: "posts.edit"}))
+
+	require.NoError(t, s.Activate("editor"))
+	assert.Equal(t, DecisionAllow, authorizer.Authorize(ctx, claims, &Target{Action: "posts.edit"}))
+
+	assert.Nil(t, CtxRoleSession(context.Background()))
+}
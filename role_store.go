@@ -0,0 +1,215 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+var _ RoleStore = (*MemoryRoleStore)(nil)
+
+// StoredRole is the persisted form of a role: its own permissions with their
+// matchings, the names of its children and its tags. Conditions, assertions
+// and granted permission sets are not stored, a loaded role only keeps the
+// permissions they were attached to or expanded to.
+type StoredRole struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions,omitempty"`
+	// PermissionMatching is the matching set on the role itself, empty if it
+	// inherits the one of the RBAC.
+	PermissionMatching PermissionMatching `json:"permissionMatching,omitempty"`
+	// Matching holds the matching each permission was added with.
+	// Permissions without an entry are added with the role's matching.
+	Matching map[string]PermissionMatching `json:"matching,omitempty"`
+	Children []string                      `json:"children,omitempty"`
+	Tags     []string                      `json:"tags,omitempty"`
+}
+
+// RoleStore persists role hierarchies. Every change increments the store's
+// version.
+type RoleStore interface {
+	LoadRoles(ctx context.Context) (roles []StoredRole, version uint64, err error)
+	// SaveRole creates or replaces the role.
+	SaveRole(ctx context.Context, role StoredRole) error
+	// DeleteRole removes the role and detaches it from its parents.
+	DeleteRole(ctx context.Context, name string) error
+	// Wait blocks until the version is greater than the given one.
+	Wait(ctx context.Context, version uint64) error
+}
+
+func StoredRoleOf(r *Role) StoredRole {
+	stored := StoredRole{
+		Name:               r.Name(),
+		Permissions:        sortedKeys(r.permissions),
+		PermissionMatching: r.matching,
+		Children:           sortedKeys(r.children),
+		Tags:               sortedKeys(r.tags),
+	}
+	if len(stored.Permissions) > 0 {
+		stored.Matching = make(map[string]PermissionMatching, len(stored.Permissions))
+		for _, permission := range stored.Permissions {
+			stored.Matching[permission], _ = r.PermissionMatchingOf(permission)
+		}
+	}
+	return stored
+}
+
+// SaveRoles saves every role of rbac to the store.
+func SaveRoles(ctx context.Context, store RoleStore, rbac *RBAC) error {
+	for _, name := range sortedKeys(rbac.roles) {
+		if err := store.SaveRole(ctx, StoredRoleOf(rbac.roles[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadRoles builds a policy from the store. Children that are not stored
+// themselves are created without permissions.
+func LoadRoles(ctx context.Context, store RoleStore) (*RBAC, uint64, error) {
+	roles, version, err := store.LoadRoles(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rbac := New()
+	for _, stored := range roles {
+		r, err := projectRole(rbac, stored.Name)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err = addStoredPermissions(r, stored); err != nil {
+			return nil, 0, err
+		}
+		r.AddTags(stored.Tags...)
+	}
+	for _, stored := range roles {
+		r := rbac.roles[stored.Name]
+		for _, name := range stored.Children {
+			child, err := projectRole(rbac, name)
+			if err == nil {
+				err = r.AddChild(child)
+			}
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+	return rbac, version, nil
+}
+
+// addStoredPermissions restores the role's matching and adds its permissions
+// grouped by the matching they were stored with.
+func addStoredPermissions(r *Role, stored StoredRole) error {
+	if !stored.PermissionMatching.valid() {
+		return fmt.Errorf(`%w: unknown permission matching "%s"`, ErrInvalidPermission, stored.PermissionMatching)
+	}
+	r.SetPermissionMatching(stored.PermissionMatching)
+
+	var matchings []PermissionMatching
+	groups := map[PermissionMatching][]string{}
+	for _, permission := range stored.Permissions {
+		matching := stored.Matching[permission]
+		if _, ok := groups[matching]; !ok {
+			matchings = append(matchings, matching)
+		}
+		groups[matching] = append(groups[matching], permission)
+	}
+	for _, matching := range matchings {
+		if err := addConfigPermissions(r, AccessConfig{Permissions: groups[matching], Matching: matching}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WatchRoles calls fn with a freshly loaded policy initially and after every
+// change of the store until ctx is done or loading fails.
+func WatchRoles(ctx context.Context, store RoleStore, fn func(rbac *RBAC, version uint64)) error {
+	for {
+		rbac, version, err := LoadRoles(ctx, store)
+		if err != nil {
+			return err
+		}
+		fn(rbac, version)
+		if err = store.Wait(ctx, version); err != nil {
+			return err
+		}
+	}
+}
+
+type MemoryRoleStore struct {
+	mu      sync.RWMutex
+	roles   map[string]StoredRole
+	version uint64
+	changed chan struct{}
+}
+
+func NewMemoryRoleStore() *MemoryRoleStore {
+	return &MemoryRoleStore{roles: map[string]StoredRole{}, changed: make(chan struct{})}
+}
+
+func (s *MemoryRoleStore) LoadRoles(context.Context) ([]StoredRole, uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	roles := make([]StoredRole, 0, len(s.roles))
+	for _, name := range sortedKeys(s.roles) {
+		roles = append(roles, s.roles[name])
+	}
+	return roles, s.version, nil
+}
+
+func (s *MemoryRoleStore) SaveRole(_ context.Context, role StoredRole) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.roles[role.Name] = StoredRole{
+		Name:               role.Name,
+		Permissions:        slices.Clone(role.Permissions),
+		PermissionMatching: role.PermissionMatching,
+		Matching:           maps.Clone(role.Matching),
+		Children:           slices.Clone(role.Children),
+		Tags:               slices.Clone(role.Tags),
+	}
+	s.bump()
+	return nil
+}
+
+func (s *MemoryRoleStore) DeleteRole(_ context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.roles, name)
+	for key, role := range s.roles {
+		role.Children = slices.DeleteFunc(role.Children, func(child string) bool { return child == name })
+		s.roles[key] = role
+	}
+	s.bump()
+	return nil
+}
+
+func (s *MemoryRoleStore) Wait(ctx context.Context, version uint64) error {
+	s.mu.RLock()
+	changed := s.changed
+	ready := version < s.version
+	s.mu.RUnlock()
+
+	if ready {
+		return nil
+	}
+	select {
+	case <-changed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *MemoryRoleStore) bump() {
+	s.version++
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
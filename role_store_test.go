@@ -0,0 +1,88 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRoleStoreRoundTrip(t *testing.T, store RoleStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	rbac := New()
+	admin, editor := NewRole("admin"), NewRole("editor")
+	require.NoError(t, admin.AddPermissionsE("users.*"))
+	require.NoError(t, admin.AddLiteralPermissions("audit.log"))
+	require.NoError(t, editor.AddPermissionsE("posts.edit", "posts.publish"))
+	editor.SetPermissionMatching(MatchGlob).AddTags("content")
+	require.NoError(t, rbac.AddRole(editor))
+	require.NoError(t, rbac.AddRole(admin))
+	require.NoError(t, admin.AddChild(editor))
+
+	require.NoError(t, SaveRoles(ctx, store, rbac))
+
+	loaded, version, err := LoadRoles(ctx, store)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), version)
+	assert.True(t, loaded.IsGranted(ctx, "admin", "users.delete"))
+	assert.True(t, loaded.IsGranted(ctx, "admin", "posts.publish"))
+	assert.False(t, loaded.IsGranted(ctx, "editor", "users.delete"))
+	assert.True(t, loaded.IsGranted(ctx, "admin", "audit.log"))
+	assert.False(t, loaded.IsGranted(ctx, "admin", "auditXlog"))
+	r, err := loaded.Role("editor")
+	require.NoError(t, err)
+	assert.True(t, r.HasTag("content"))
+	assert.Equal(t, MatchGlob, r.PermissionMatching())
+	matching, _ := r.PermissionMatchingOf("posts.edit")
+	assert.Equal(t, MatchRegex, matching)
+
+	require.NoError(t, store.DeleteRole(ctx, "editor"))
+	roles, version, err := store.LoadRoles(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), version)
+	require.Len(t, roles, 1)
+	assert.Equal(t, "admin", roles[0].Name)
+	assert.Equal(t, []string{"audit.log", "users.*"}, roles[0].Permissions)
+	assert.Equal(t, map[string]PermissionMatching{"audit.log": MatchLiteral, "users.*": MatchRegex}, roles[0].Matching)
+	assert.Empty(t, roles[0].Children)
+}
+
+func TestMemoryRoleStore(t *testing.T) {
+	testRoleStoreRoundTrip(t, NewMemoryRoleStore())
+}
+
+func TestLoadRoles_MissingChild(t *testing.T) {
+	store := NewMemoryRoleStore()
+	require.NoError(t, store.SaveRole(context.Background(), StoredRole{Name: "admin", Children: []string{"guest"}}))
+
+	rbac, _, err := LoadRoles(context.Background(), store)
+	require.NoError(t, err)
+	ok, err := rbac.HasRole("guest")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestWatchRoles(t *testing.T) {
+	store := NewMemoryRoleStore()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	versions := make(chan uint64)
+	done := make(chan error)
+	go func() {
+		done <- WatchRoles(ctx, store, func(_ *RBAC, version uint64) {
+			versions <- version
+		})
+	}()
+
+	assert.Equal(t, uint64(0), <-versions)
+	require.NoError(t, store.SaveRole(ctx, StoredRole{Name: "admin"}))
+	assert.Equal(t, uint64(1), <-versions)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
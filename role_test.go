@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRole_Name(t *testing.T) {
@@ -53,6 +54,63 @@ func TestRole_AddParent(t *testing.T) {
 	assert.ElementsMatch(t, []*Role{bar, baz}, slices.Collect(foo.Parents()))
 }
 
+func TestRole_RemoveChild(t *testing.T) {
+	foo := NewRole("foo")
+	bar := NewRole("bar")
+	baz := NewRole("baz")
+	baz.AddPermissions("baz.read")
+
+	assert.Nil(t, foo.AddChild(bar))
+	assert.Nil(t, foo.AddChild(baz))
+	assert.True(t, foo.HasPermission("baz.read"))
+
+	foo.RemoveChild(baz)
+	foo.RemoveChild(baz)
+	assert.Equal(t, []*Role{bar}, slices.Collect(foo.Children()))
+	assert.Empty(t, slices.Collect(baz.Parents()))
+	assert.False(t, foo.HasPermission("baz.read"))
+
+	assert.Panics(t, func() { foo.RemoveChild(nil) })
+}
+
+func TestRole_RemoveParent(t *testing.T) {
+	foo := NewRole("foo")
+	bar := NewRole("bar")
+
+	assert.Nil(t, foo.AddParent(bar))
+	foo.RemoveParent(bar)
+	assert.Empty(t, slices.Collect(foo.Parents()))
+	assert.Empty(t, slices.Collect(bar.Children()))
+
+	assert.Panics(t, func() { foo.RemoveParent(nil) })
+}
+
+func TestRole_RemovePermissions(t *testing.T) {
+	role := NewRole("foo")
+	child := NewRole("bar")
+	child.AddPermissions("bar.read")
+	assert.Nil(t, role.AddChild(child))
+	assert.Nil(t, role.AddPermissionsE("foo.read", `foo\.\d+`, "foo.write"))
+
+	var events []PolicyEvent
+	role.notify = func(event PolicyEvent) { events = append(events, event) }
+
+	role.RemovePermissions(`foo\.\d+`, "foo.read", "missing")
+	assert.False(t, role.HasPermission("foo.1"))
+	assert.False(t, role.HasPermission("foo.read"))
+	assert.True(t, role.HasPermission("foo.write"))
+	assert.Len(t, events, 1)
+	assert.Equal(t, []string{"foo.read", `foo\.\d+`}, events[0].Permissions)
+
+	role.ClearPermissions()
+	assert.False(t, role.HasPermission("foo.write"))
+	assert.True(t, role.HasPermission("bar.read"))
+	assert.Empty(t, slices.Collect(role.Permissions(false)))
+
+	role.ClearPermissions()
+	assert.Len(t, events, 2)
+}
+
 func TestRole_PermissionHierarchy(t *testing.T) {
 	foo := NewRole("foo")
 	foo.AddPermissions("foo.permission")
@@ -79,6 +137,22 @@ func TestRole_PermissionHierarchy(t *testing.T) {
 	assert.True(t, baz.HasPermission("baz.permission"))
 }
 
+func TestRole_HasLiteralPermission(t *testing.T) {
+	parent := NewRole("parent")
+	child := NewRole("child")
+	child.AddPermissions("orders-*")
+	require.NoError(t, child.AddGlobPermissions("posts:*"))
+	require.NoError(t, parent.AddChild(child))
+
+	assert.True(t, parent.HasPermission("ordersevil"))
+	assert.False(t, parent.HasLiteralPermission("ordersevil"))
+	assert.True(t, parent.HasLiteralPermission("orders-*"))
+
+	assert.True(t, parent.HasPermission("posts:read"))
+	assert.False(t, parent.HasLiteralPermission("posts:read"))
+	assert.True(t, parent.HasLiteralPermission("posts:*"))
+}
+
 func TestRole_CircleReferenceWithChild(t *testing.T) {
 	foo := NewRole("foo")
 	bar := NewRole("bar")
@@ -138,3 +212,13 @@ func TestRole_AddSameParent(t *testing.T) {
 
 	assert.ElementsMatch(t, []*Role{bar}, slices.Collect(foo.Parents()))
 }
+
+func TestRole_Tags(t *testing.T) {
+	role := NewRole("foo")
+	role.AddTags("tenant:acme", "generated")
+	assert.True(t, role.HasTag("generated"))
+	assert.ElementsMatch(t, []string{"tenant:acme", "generated"}, slices.Collect(role.Tags()))
+
+	role.RemoveTags("generated")
+	assert.False(t, role.HasTag("generated"))
+}
@@ -0,0 +1,123 @@
+package rbac
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync/atomic"
+	"time"
+)
+
+var _ Authorizer = (*RolloutAuthorizer)(nil)
+
+// RolloutAuthorizer serves the candidate policy to a stable percentage of
+// subjects, chosen by hashing the subject identifier, and the current policy
+// to everyone else. Both policies are evaluated so divergences are counted
+// for the whole population; the one not served runs like the candidate of
+// ComparisonAuthorizer. Subjects without an identifier stay on the current
+// policy.
+type RolloutAuthorizer struct {
+	current     Authorizer
+	candidate   Authorizer
+	timeout     time.Duration
+	salt        string
+	basisPoints atomic.Int64
+	onDivergent func(ctx context.Context, d Divergence, served Decision)
+	evaluations atomic.Int64
+	divergences atomic.Int64
+	candidates  atomic.Int64
+}
+
+func NewRolloutAuthorizer(current, candidate Authorizer) *RolloutAuthorizer {
+	return &RolloutAuthorizer{current: current, candidate: candidate}
+}
+
+// SetSalt changes the bucketing so different rollouts select different
+// subjects.
+func (a *RolloutAuthorizer) SetSalt(salt string) *RolloutAuthorizer {
+	a.salt = salt
+	return a
+}
+
+// SetPercentage sets the share of subjects served by the candidate, from 0
+// to 100 with a resolution of 0.01. It is safe to call while serving and
+// increasing it keeps already selected subjects on the candidate.
+func (a *RolloutAuthorizer) SetPercentage(percent float64) *RolloutAuthorizer {
+	a.basisPoints.Store(int64(min(max(percent, 0), 100) * 100))
+	return a
+}
+
+func (a *RolloutAuthorizer) Percentage() float64 {
+	return float64(a.basisPoints.Load()) / 100
+}
+
+// SetTimeout bounds the policy not served, see
+// ComparisonAuthorizer.SetTimeout.
+func (a *RolloutAuthorizer) SetTimeout(timeout time.Duration) *RolloutAuthorizer {
+	a.timeout = timeout
+	return a
+}
+
+// OnDivergence calls fn whenever the decisions differ or the policy not
+// served fails, including warn and abstain changes that do not change access.
+func (a *RolloutAuthorizer) OnDivergence(fn func(ctx context.Context, d Divergence, served Decision)) *RolloutAuthorizer {
+	a.onDivergent = fn
+	return a
+}
+
+func (a *RolloutAuthorizer) Evaluations() int64 {
+	return a.evaluations.Load()
+}
+
+// Divergences counts decisions where the candidate changes access, see
+// Divergence.ChangesAccess.
+func (a *RolloutAuthorizer) Divergences() int64 {
+	return a.divergences.Load()
+}
+
+// CandidateServed counts decisions served by the candidate policy.
+func (a *RolloutAuthorizer) CandidateServed() int64 {
+	return a.candidates.Load()
+}
+
+// InRollout reports whether the subject is served by the candidate.
+func (a *RolloutAuthorizer) InRollout(subject Subject) bool {
+	if subject == nil {
+		return false
+	}
+	id := SubjectID(subject)
+	if id == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(a.salt + "\x00" + id))
+	return int64(binary.BigEndian.Uint64(sum[:8])%10000) < a.basisPoints.Load()
+}
+
+func (a *RolloutAuthorizer) Authorize(ctx context.Context, claims *Claims, target *Target) Decision {
+	var (
+		current, candidate, served Decision
+		err                        error
+	)
+	if claims != nil && a.InRollout(claims.Subject) {
+		candidate = a.candidate.Authorize(ctx, claims, target)
+		current, err = shadowAuthorize(ctx, a.current, a.timeout, claims, target)
+		served = candidate
+		a.candidates.Add(1)
+	} else {
+		current = a.current.Authorize(ctx, claims, target)
+		candidate, err = shadowAuthorize(ctx, a.candidate, a.timeout, claims, target)
+		served = current
+	}
+
+	a.evaluations.Add(1)
+	if current != candidate || err != nil {
+		d := newDivergence(claims, target, current, candidate, err)
+		if d.ChangesAccess() {
+			a.divergences.Add(1)
+		}
+		if a.onDivergent != nil {
+			a.onDivergent(ctx, d, served)
+		}
+	}
+	return served
+}
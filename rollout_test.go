@@ -0,0 +1,101 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRolloutAuthorizer(t *testing.T) {
+	ctx := context.Background()
+	a := NewRolloutAuthorizer(&mockAuthorizer{decision: DecisionDeny}, &mockAuthorizer{decision: DecisionAllow})
+
+	var divergences []Divergence
+	a.OnDivergence(func(_ context.Context, d Divergence, _ Decision) {
+		divergences = append(divergences, d)
+	})
+
+	claims := &Claims{Subject: NewSubject("u1", "user")}
+	assert.Equal(t, DecisionDeny, a.Authorize(ctx, claims, &Target{Action: "read"}))
+	assert.Len(t, divergences, 1)
+	assert.Equal(t, "u1", divergences[0].Subject)
+	assert.Equal(t, "read", divergences[0].Action)
+
+	a.SetPercentage(100)
+	assert.Equal(t, 100.0, a.Percentage())
+	assert.Equal(t, DecisionAllow, a.Authorize(ctx, claims, &Target{Action: "read"}))
+	assert.Equal(t, DecisionDeny, a.Authorize(ctx, &Claims{Subject: NewSubject("", "user")}, &Target{Action: "read"}))
+	assert.Equal(t, DecisionDeny, a.Authorize(ctx, nil, &Target{Action: "read"}))
+
+	assert.Equal(t, int64(4), a.Evaluations())
+	assert.Equal(t, int64(4), a.Divergences())
+	assert.Equal(t, int64(1), a.CandidateServed())
+
+	a.SetPercentage(150)
+	assert.Equal(t, 100.0, a.Percentage())
+
+	a = NewRolloutAuthorizer(&mockAuthorizer{decision: DecisionAllow}, &mockAuthorizer{decision: DecisionWarn})
+	a.OnDivergence(func(_ context.Context, d Divergence, _ Decision) {
+		divergences = append(divergences, d)
+	})
+	assert.Equal(t, DecisionAllow, a.Authorize(ctx, claims, &Target{Action: "read"}))
+	assert.Equal(t, "flagged", divergences[len(divergences)-1].Direction())
+	assert.Equal(t, int64(0), a.Divergences())
+}
+
+func TestRolloutAuthorizer_Shadow(t *testing.T) {
+	var divergences []Divergence
+	current, candidate := &ctxAuthorizer{}, &ctxAuthorizer{}
+	a := NewRolloutAuthorizer(current, candidate).OnDivergence(func(_ context.Context, d Divergence, _ Decision) {
+		divergences = append(divergences, d)
+	})
+
+	ctx := WithRoleTransaction(context.Background())
+	claims := &Claims{Subject: NewSubject("u1", "user")}
+	assert.Equal(t, DecisionAllow, a.Authorize(ctx, claims, &Target{Action: "read"}))
+	assert.NotNil(t, CtxRoleTransaction(current.ctx))
+	assert.Nil(t, CtxRoleTransaction(candidate.ctx))
+
+	a.SetPercentage(100)
+	assert.Equal(t, DecisionAllow, a.Authorize(ctx, claims, &Target{Action: "read"}))
+	assert.Nil(t, CtxRoleTransaction(current.ctx))
+	assert.NotNil(t, CtxRoleTransaction(candidate.ctx))
+	assert.Empty(t, divergences)
+
+	a = NewRolloutAuthorizer(panicAuthorizer{}, &mockAuthorizer{decision: DecisionAllow}).SetPercentage(100).OnDivergence(func(_ context.Context, d Divergence, _ Decision) {
+		divergences = append(divergences, d)
+	})
+	assert.Equal(t, DecisionAllow, a.Authorize(ctx, claims, &Target{Action: "read"}))
+	require.Len(t, divergences, 1)
+	assert.ErrorIs(t, divergences[0].Err, ErrShadowFailed)
+}
+
+func TestRolloutAuthorizer_StableBuckets(t *testing.T) {
+	a := NewRolloutAuthorizer(&mockAuthorizer{}, &mockAuthorizer{}).SetPercentage(20)
+
+	var selected []string
+	for i := range 1000 {
+		id := fmt.Sprintf("user-%d", i)
+		if a.InRollout(NewSubject(id)) {
+			selected = append(selected, id)
+		}
+	}
+	assert.InDelta(t, 200, len(selected), 50)
+
+	a.SetPercentage(50)
+	for _, id := range selected {
+		assert.True(t, a.InRollout(NewSubject(id)))
+	}
+
+	a.SetPercentage(20).SetSalt("other")
+	var moved int
+	for _, id := range selected {
+		if !a.InRollout(NewSubject(id)) {
+			moved++
+		}
+	}
+	assert.Positive(t, moved)
+}
@@ -0,0 +1,219 @@
+package rbac
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+)
+
+var ErrInvalidRoute = errors.New("invalid route")
+
+// Route maps requests onto permissions independent of the URL structure.
+type Route struct {
+	// Pattern is a path optionally preceded by a method, e.g.
+	// "PUT /api/posts/{id}". Segments may be parameters such as "{id}", "*"
+	// for any single segment and, as the last one, "{path...}" or "**" for
+	// the rest of the path.
+	Pattern     string         `json:"pattern" yaml:"pattern"`
+	Permissions []string       `json:"permissions,omitempty" yaml:"permissions,omitempty"`
+	Metadata    map[string]any `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+}
+
+// RouteMatcher finds the route of requests. When several routes match, the
+// most specific one wins: literal segments before parameters before the rest
+// wildcard, then routes with a method, then the route added first. ActionMap
+// matches its routes the same way.
+type RouteMatcher struct {
+	routes []compiledRoute
+}
+
+type compiledRoute struct {
+	Route
+	method   string
+	segments []routeSegment
+}
+
+type segmentKind int8
+
+const (
+	segmentLiteral segmentKind = iota
+	segmentParam
+	segmentRest
+)
+
+type routeSegment struct {
+	kind  segmentKind
+	value string
+}
+
+func NewRouteMatcher(routes ...Route) (*RouteMatcher, error) {
+	m := &RouteMatcher{}
+	var errs []error
+	for _, route := range routes {
+		if err := m.Add(route); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return m, errors.Join(errs...)
+}
+
+func (m *RouteMatcher) Add(route Route) error {
+	c, err := compileRoute(route)
+	if err != nil {
+		return err
+	}
+	m.routes = append(m.routes, c)
+	return nil
+}
+
+func compileRoute(route Route) (compiledRoute, error) {
+	method, path, ok := strings.Cut(route.Pattern, " ")
+	if !ok {
+		method, path = "", route.Pattern
+	}
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "/") {
+		return compiledRoute{}, fmt.Errorf(`%w: "%s" does not start with "/"`, ErrInvalidRoute, route.Pattern)
+	}
+
+	c := compiledRoute{Route: route, method: method}
+	parts := strings.Split(path[1:], "/")
+	for i, part := range parts {
+		segment := routeSegment{value: part}
+		switch {
+		case part == "**":
+			segment = routeSegment{kind: segmentRest}
+		case isPathParam(part) && strings.HasSuffix(part, "...}"):
+			segment = routeSegment{kind: segmentRest, value: part[1 : len(part)-4]}
+		case part == "*":
+			segment = routeSegment{kind: segmentParam}
+		case isPathParam(part):
+			segment = routeSegment{kind: segmentParam, value: part[1 : len(part)-1]}
+		}
+		if segment.kind == segmentRest && i != len(parts)-1 {
+			return compiledRoute{}, fmt.Errorf(`%w: "%s" has a wildcard before its last segment`, ErrInvalidRoute, route.Pattern)
+		}
+		c.segments = append(c.segments, segment)
+	}
+	return c, nil
+}
+
+// Routes returns the routes in the order they were added.
+func (m *RouteMatcher) Routes() []Route {
+	routes := make([]Route, 0, len(m.routes))
+	for _, route := range m.routes {
+		routes = append(routes, route.Route)
+	}
+	return routes
+}
+
+// Match returns the route of the request and the values of its named
+// parameters.
+func (m *RouteMatcher) Match(r *http.Request) (Route, map[string]string, bool) {
+	var (
+		best   *compiledRoute
+		params map[string]string
+	)
+	path := requestPath(r)
+	for i := range m.routes {
+		route := &m.routes[i]
+		if route.method != "" && route.method != r.Method {
+			continue
+		}
+		values, ok := route.match(path)
+		if ok && (best == nil || route.compare(best) < 0) {
+			best, params = route, values
+		}
+	}
+	if best == nil {
+		return Route{}, nil, false
+	}
+	return best.Route, params, true
+}
+
+// Actions returns the permissions of the request's route, nil if there is
+// none. Pass it to WithActions.
+func (m *RouteMatcher) Actions(r *http.Request) []string {
+	route, _, ok := m.Match(r)
+	if !ok {
+		return nil
+	}
+	return route.Permissions
+}
+
+// BuildTarget copies the metadata and the named parameters of the request's
+// route into the target, the metadata taking precedence. Pass it to
+// WithTargetBuilder.
+func (m *RouteMatcher) BuildTarget(r *http.Request, target *Target) {
+	route, params, ok := m.Match(r)
+	if !ok {
+		return
+	}
+	if len(route.Metadata) > 0 {
+		if target.Metadata == nil {
+			target.Metadata = make(map[string]any, len(route.Metadata)+len(params))
+		}
+		maps.Copy(target.Metadata, route.Metadata)
+	}
+	target.addParams(params)
+}
+
+func (c *compiledRoute) match(path string) (map[string]string, bool) {
+	var params map[string]string
+	set := func(name, value string) {
+		if name == "" {
+			return
+		}
+		if params == nil {
+			params = map[string]string{}
+		}
+		params[name] = value
+	}
+
+	rest := path[1:]
+	for i, segment := range c.segments {
+		if segment.kind == segmentRest {
+			set(segment.value, rest)
+			return params, true
+		}
+		part, next, more := strings.Cut(rest, "/")
+		if more != (i < len(c.segments)-1) {
+			return nil, false
+		}
+		switch segment.kind {
+		case segmentLiteral:
+			if part != segment.value {
+				return nil, false
+			}
+		case segmentParam:
+			if part == "" {
+				return nil, false
+			}
+			set(segment.value, part)
+		}
+		rest = next
+	}
+	return params, true
+}
+
+// compare orders more specific routes first.
+func (c *compiledRoute) compare(other *compiledRoute) int {
+	for i := range min(len(c.segments), len(other.segments)) {
+		if n := cmp.Compare(c.segments[i].kind, other.segments[i].kind); n != 0 {
+			return n
+		}
+	}
+	if n := cmp.Compare(len(other.segments), len(c.segments)); n != 0 {
+		return n
+	}
+	switch {
+	case c.method != "" && other.method == "":
+		return -1
+	case c.method == "" && other.method != "":
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,82 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteMatcher(t *testing.T) {
+	m, err := NewRouteMatcher(
+		Route{Pattern: "/api/posts/{id}", Permissions: []string{"posts:read"}},
+		Route{Pattern: "PUT /api/posts/{id}", Permissions: []string{"posts:update"}, Metadata: map[string]any{"resource": "post"}},
+		Route{Pattern: "GET /api/posts/drafts", Permissions: []string{"posts:drafts"}},
+		Route{Pattern: "/api/*/{id}/comments", Permissions: []string{"comments:read"}},
+		Route{Pattern: "/files/{path...}", Permissions: []string{"files:read"}},
+		Route{Pattern: "/**", Permissions: []string{"any"}},
+	)
+	require.NoError(t, err)
+	assert.Len(t, m.Routes(), 6)
+
+	for _, tt := range []struct {
+		method, path string
+		want         []string
+		params       map[string]string
+	}{
+		{"GET", "/api/posts/7", []string{"posts:read"}, map[string]string{"id": "7"}},
+		{"PUT", "/api/posts/7", []string{"posts:update"}, map[string]string{"id": "7"}},
+		{"GET", "/api/posts/drafts", []string{"posts:drafts"}, nil},
+		{"PUT", "/api/posts/drafts", []string{"posts:update"}, map[string]string{"id": "drafts"}},
+		{"GET", "/api/users/3/comments", []string{"comments:read"}, map[string]string{"id": "3"}},
+		{"GET", "/files/a/b.txt", []string{"files:read"}, map[string]string{"path": "a/b.txt"}},
+		{"GET", "/api/posts", []string{"any"}, nil},
+	} {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		route, params, ok := m.Match(r)
+		require.True(t, ok, tt.path)
+		assert.Equal(t, tt.want, route.Permissions, tt.method+" "+tt.path)
+		assert.Equal(t, tt.params, params, tt.method+" "+tt.path)
+		assert.Equal(t, tt.want, m.Actions(r))
+	}
+
+	empty, err := NewRouteMatcher(Route{Pattern: "GET /healthz"})
+	require.NoError(t, err)
+	_, _, ok := empty.Match(httptest.NewRequest("POST", "/healthz", nil))
+	assert.False(t, ok)
+	assert.Nil(t, empty.Actions(httptest.NewRequest("GET", "/other", nil)))
+
+	_, err = NewRouteMatcher(Route{Pattern: "GET api"}, Route{Pattern: "/files/**/x"})
+	assert.ErrorIs(t, err, ErrInvalidRoute)
+	assert.ErrorContains(t, err, `"GET api" does not start with "/"`)
+	assert.ErrorContains(t, err, `"/files/**/x" has a wildcard before its last segment`)
+}
+
+func TestRouteMatcher_RequestAuthorizer(t *testing.T) {
+	m, err := NewRouteMatcher(Route{Pattern: "PUT /api/posts/{id}", Permissions: []string{"posts:update"}, Metadata: map[string]any{"resource": "post"}})
+	require.NoError(t, err)
+
+	rbac := New()
+	editor := NewRole("editor")
+	require.NoError(t, editor.AddPermissionsE("posts:update"))
+	require.NoError(t, rbac.AddRole(editor))
+	authorizer := NewDefaultAuthorizer(rbac)
+
+	var metadata map[string]any
+	capture := authorizerFunc(func(ctx context.Context, claims *Claims, target *Target) Decision {
+		metadata = target.Metadata
+		return authorizer.Authorize(ctx, claims, target)
+	})
+	authorize := RequestAuthorizer(capture, WithActions(m.Actions), WithTargetBuilder(m.BuildTarget))
+
+	claims := &Claims{Subject: NewSubject("1", "editor")}
+	r := httptest.NewRequest(http.MethodPut, "/api/posts/7", nil)
+	assert.Equal(t, DecisionAllow, authorize(r.WithContext(WithClaims(r.Context(), claims))))
+	assert.Equal(t, map[string]any{"resource": "post", "id": "7"}, metadata)
+
+	r = httptest.NewRequest(http.MethodDelete, "/api/posts/7", nil)
+	assert.Equal(t, DecisionDeny, authorize(r.WithContext(WithClaims(r.Context(), claims))))
+}
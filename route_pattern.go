@@ -0,0 +1,83 @@
+package rbac
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RoutePatternActions returns an actions function for RequestAuthorizer that
+// uses the declared route template instead of the concrete path, e.g.
+// "GET /users/{id}", so permissions do not depend on IDs. Requests without a
+// pattern fall back to the default actions.
+//
+// For chi, pass a function returning
+// chi.RouteContext(r.Context()).RoutePattern(); the pattern is complete only
+// in middleware registered on the final route, e.g. with r.With.
+func RoutePatternActions(pattern func(*http.Request) string) func(*http.Request) []string {
+	return func(r *http.Request) []string {
+		route := normalizeRoutePattern(pattern(r))
+		if route == "" {
+			return defaultActions(r)
+		}
+		return []string{
+			"*",
+			r.Method,
+			route,
+			fmt.Sprintf("%s %s", r.Method, route),
+		}
+	}
+}
+
+// ServeMuxPattern returns the path of the ServeMux pattern matching r.
+func ServeMuxPattern(r *http.Request) string {
+	return patternPath(r.Pattern)
+}
+
+// normalizeRoutePattern drops regular expressions from parameters, so chi's
+// "/users/{id:[0-9]+}" becomes "/users/{id}".
+func normalizeRoutePattern(pattern string) string {
+	if !strings.Contains(pattern, ":") {
+		return pattern
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(pattern, '{')
+		if start < 0 {
+			b.WriteString(pattern)
+			return b.String()
+		}
+		end := paramEnd(pattern[start:])
+		if end < 0 {
+			b.WriteString(pattern)
+			return b.String()
+		}
+		end += start
+
+		param := pattern[start+1 : end]
+		if name, _, ok := strings.Cut(param, ":"); ok {
+			param = name
+		}
+		b.WriteString(pattern[:start])
+		b.WriteString("{" + param + "}")
+		pattern = pattern[end+1:]
+	}
+}
+
+// paramEnd returns the index of the brace closing the parameter at the start
+// of s, skipping braces nested in its regular expression.
+func paramEnd(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,50 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type routePatternKey struct{}
+
+func TestRoutePatternActions(t *testing.T) {
+	actions := RoutePatternActions(func(r *http.Request) string {
+		pattern, _ := r.Context().Value(routePatternKey{}).(string)
+		return pattern
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	assert.Equal(t, defaultActions(r), actions(r))
+
+	r = r.WithContext(context.WithValue(r.Context(), routePatternKey{}, "/users/{id:[0-9]{1,6}}"))
+	assert.Equal(t, []string{"*", "GET", "/users/{id}", "GET /users/{id}"}, actions(r))
+}
+
+func TestRoutePatternActions_ServeMux(t *testing.T) {
+	rbac := New()
+	role := NewRole("user")
+	_ = role.AddLiteralPermissions("GET /users/{id}")
+	_ = rbac.AddRole(role)
+
+	authorize := RequestAuthorizer(NewDefaultAuthorizer(rbac), WithActions(RoutePatternActions(ServeMuxPattern)))
+
+	var decision Decision
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		decision = authorize(r.WithContext(WithClaims(r.Context(), &Claims{Subject: NewSubject("1", "user")})))
+	})
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	assert.Equal(t, DecisionAllow, decision)
+}
+
+func TestNormalizeRoutePattern(t *testing.T) {
+	assert.Equal(t, "/users/{id}", normalizeRoutePattern("/users/{id}"))
+	assert.Equal(t, "/users/{id}/posts/{slug}", normalizeRoutePattern("/users/{id:\\d+}/posts/{slug:[a-z-]+}"))
+	assert.Equal(t, "/files/*", normalizeRoutePattern("/files/*"))
+	assert.Equal(t, "/a/{b:", normalizeRoutePattern("/a/{b:"))
+}
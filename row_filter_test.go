@@ -0,0 +1,87 @@
+package rbac
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowFilter_Predicate(t *testing.T) {
+	rbac := New()
+	member := NewRole("member")
+	member.AddPermissions("posts:read:tenant:42", "posts:read:tenant:7", "posts:read:own", "posts:write:tenant:1")
+	admin := NewRole("admin")
+	admin.AddPermissions("posts:read")
+	assert.NoError(t, rbac.AddRole(member))
+	assert.NoError(t, rbac.AddRole(admin))
+
+	filter := NewRowFilter("posts:read").Scope("tenant", "tenant_id").Owner("own", "owner_id")
+
+	where, args := filter.Predicate(rbac, &Claims{Subject: &testIdentifiedSubject{id: "u1", roles: []string{"member"}}})
+	assert.Equal(t, "(tenant_id IN (?, ?) OR owner_id = ?)", where)
+	assert.Equal(t, []any{"42", "7", "u1"}, args)
+
+	where, args = filter.Predicate(rbac, &Claims{Subject: &testSubject{roles: []string{"member"}}})
+	assert.Equal(t, "tenant_id IN (?, ?)", where)
+	assert.Equal(t, []any{"42", "7"}, args)
+
+	where, args = filter.Predicate(rbac, &Claims{Subject: &testSubject{roles: []string{"member", "admin"}}})
+	assert.Equal(t, "1 = 1", where)
+	assert.Nil(t, args)
+
+	where, args = filter.Predicate(rbac, &Claims{Subject: &testSubject{roles: []string{"unknown"}}})
+	assert.Equal(t, "1 = 0", where)
+	assert.Nil(t, args)
+
+	where, _ = filter.Predicate(rbac, nil)
+	assert.Equal(t, "1 = 0", where)
+}
+
+func TestRowFilter_EffectivePermissions(t *testing.T) {
+	rbac := New()
+	root, editor, reader, scoped := NewRole("root"), NewRole("editor"), NewRole("reader"), NewRole("scoped")
+	require.NoError(t, editor.AddPermissionsE("posts:.*"))
+	require.NoError(t, reader.AddPermissionsE("posts:manage"))
+	require.NoError(t, scoped.AddPermissionsE("posts:manage:tenant:9"))
+	for _, role := range []*Role{root, editor, reader, scoped} {
+		require.NoError(t, rbac.AddRole(role))
+	}
+	rbac.SetSuperuserRoles("root")
+	rbac.AddImplication("posts:manage", "posts:read")
+	rbac.AddImplication("posts:manage:tenant:9", "posts:read:tenant:9")
+
+	filter := NewRowFilter("posts:read").Scope("tenant", "tenant_id")
+	for _, role := range []string{"root", "editor", "reader"} {
+		where, args := filter.Predicate(rbac, &Claims{Subject: &testSubject{roles: []string{role}}})
+		assert.Equal(t, "1 = 1", where, role)
+		assert.Nil(t, args, role)
+	}
+
+	where, args := filter.Predicate(rbac, &Claims{Subject: &testSubject{roles: []string{"scoped"}}})
+	assert.Equal(t, "tenant_id IN (?)", where)
+	assert.Equal(t, []any{"9"}, args)
+
+	// a permission matching part of the action does not lift restrictions
+	partial := NewRole("partial")
+	require.NoError(t, partial.AddPermissionsE("read", "posts:read:tenant:3"))
+	require.NoError(t, rbac.AddRole(partial))
+	where, args = filter.Predicate(rbac, &Claims{Subject: &testSubject{roles: []string{"partial"}}})
+	assert.Equal(t, "tenant_id IN (?)", where)
+	assert.Equal(t, []any{"3"}, args)
+}
+
+func TestRowFilter_Placeholder(t *testing.T) {
+	rbac := New()
+	member := NewRole("member")
+	member.AddPermissions("posts:read:tenant:1", "posts:read:own")
+	assert.NoError(t, rbac.AddRole(member))
+
+	filter := NewRowFilter("posts:read").Scope("tenant", "tenant_id").Owner("own", "owner_id")
+	filter.Placeholder = func(n int) string { return "$" + strconv.Itoa(n) }
+
+	where, args := filter.Predicate(rbac, &Claims{Subject: &testIdentifiedSubject{id: "u1", roles: []string{"member"}}})
+	assert.Equal(t, "(tenant_id IN ($1) OR owner_id = $2)", where)
+	assert.Equal(t, []any{"1", "u1"}, args)
+}
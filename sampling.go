@@ -0,0 +1,71 @@
+package rbac
+
+import "math/rand/v2"
+
+// DecisionSampler selects which decisions logging decorators emit: all
+// denials and a share of allows by default, plus everything for selected
+// actions and subjects.
+type DecisionSampler struct {
+	allowRate float64
+	denyRate  float64
+	actions   map[string]struct{}
+	subjects  map[string]struct{}
+	random    func() float64
+}
+
+// NewDecisionSampler samples allows at the given rate, from 0 to 1, and
+// keeps every denial.
+func NewDecisionSampler(allowRate float64) *DecisionSampler {
+	return &DecisionSampler{
+		allowRate: allowRate,
+		denyRate:  1,
+		actions:   map[string]struct{}{},
+		subjects:  map[string]struct{}{},
+		random:    rand.Float64,
+	}
+}
+
+func (s *DecisionSampler) SetDenyRate(rate float64) *DecisionSampler {
+	s.denyRate = rate
+	return s
+}
+
+// AlwaysActions keeps every decision for the given actions.
+func (s *DecisionSampler) AlwaysActions(actions ...string) *DecisionSampler {
+	for _, action := range actions {
+		s.actions[action] = struct{}{}
+	}
+	return s
+}
+
+// AlwaysSubjects keeps every decision for the given subject identifiers.
+func (s *DecisionSampler) AlwaysSubjects(ids ...string) *DecisionSampler {
+	for _, id := range ids {
+		s.subjects[id] = struct{}{}
+	}
+	return s
+}
+
+// Sample reports whether the decision should be logged. A nil sampler keeps
+// everything.
+func (s *DecisionSampler) Sample(claims *Claims, target *Target, d Decision) bool {
+	if s == nil {
+		return true
+	}
+	if target != nil {
+		if _, ok := s.actions[target.Action]; ok {
+			return true
+		}
+	}
+	if claims != nil && claims.Subject != nil {
+		if _, ok := s.subjects[SubjectID(claims.Subject)]; ok {
+			return true
+		}
+	}
+
+	rate := s.allowRate
+	if !d.Allowed() {
+		rate = s.denyRate
+	}
+	return rate >= 1 || (rate > 0 && s.random() < rate)
+}
@@ -0,0 +1,28 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecisionSampler(t *testing.T) {
+	s := NewDecisionSampler(0.1).AlwaysActions("admin:delete").AlwaysSubjects("auditor")
+	s.random = func() float64 { return 0.5 }
+
+	claims := &Claims{Subject: NewSubject("u1", "user")}
+	assert.False(t, s.Sample(claims, &Target{Action: "read"}, DecisionAllow))
+	assert.True(t, s.Sample(claims, &Target{Action: "read"}, DecisionDeny))
+	assert.True(t, s.Sample(claims, &Target{Action: "admin:delete"}, DecisionAllow))
+	assert.True(t, s.Sample(&Claims{Subject: NewSubject("auditor")}, &Target{Action: "read"}, DecisionAllow))
+	assert.False(t, s.Sample(nil, nil, DecisionWarn))
+
+	s.random = func() float64 { return 0.05 }
+	assert.True(t, s.Sample(claims, &Target{Action: "read"}, DecisionAllow))
+
+	s.SetDenyRate(0)
+	assert.False(t, s.Sample(claims, &Target{Action: "read"}, DecisionDeny))
+
+	var none *DecisionSampler
+	assert.True(t, none.Sample(nil, nil, DecisionAllow))
+}
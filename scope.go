@@ -0,0 +1,83 @@
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInsufficientScope = errors.New("insufficient scope")
+
+const (
+	MetadataScope = "scope"
+	MetadataSCP   = "scp"
+)
+
+type ScopeMapping struct {
+	scopes map[string]*Role
+}
+
+// NewScopeMapping maps OAuth scopes onto permission patterns. A scope without
+// a mapping grants the permission with the same name.
+func NewScopeMapping(mapping map[string][]string) *ScopeMapping {
+	m := &ScopeMapping{scopes: make(map[string]*Role, len(mapping))}
+	for scope, permissions := range mapping {
+		m.Map(scope, permissions...)
+	}
+	return m
+}
+
+func (m *ScopeMapping) Map(scope string, permissions ...string) *ScopeMapping {
+	role, ok := m.scopes[scope]
+	if !ok {
+		role = NewRole(scope)
+		m.scopes[scope] = role
+	}
+	role.AddPermissions(permissions...)
+	return m
+}
+
+func (m *ScopeMapping) Allows(scopes []string, permission string) bool {
+	for _, scope := range scopes {
+		if role, ok := m.scopes[scope]; ok {
+			if role.HasPermission(permission) {
+				return true
+			}
+		} else if scope == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func ClaimsScopes(claims *Claims) []string {
+	if claims == nil || claims.Metadata == nil {
+		return nil
+	}
+	if scope, ok := claims.Metadata[MetadataScope].(string); ok {
+		return strings.Fields(scope)
+	}
+	if scope, ok := claims.Metadata[MetadataScope]; ok {
+		return metadataStrings(scope)
+	}
+	return metadataStrings(claims.Metadata[MetadataSCP])
+}
+
+func (a *DefaultAuthorizer) SetScopeMapping(scopes *ScopeMapping) *DefaultAuthorizer {
+	a.scopes = scopes
+	return a
+}
+
+func (a *DefaultAuthorizer) ScopeMapping() *ScopeMapping {
+	return a.scopes
+}
+
+func (a *DefaultAuthorizer) authorizeScopes(claims *Claims, action string) error {
+	if a.scopes == nil || a.scopes.Allows(ClaimsScopes(claims), action) {
+		return nil
+	}
+	return &ReasonError{
+		Reason: ReasonInsufficientScope{Action: action},
+		Err:    fmt.Errorf(`%w: no token scope grants "%s"`, ErrInsufficientScope, action),
+	}
+}
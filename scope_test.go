@@ -0,0 +1,57 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeMapping_Allows(t *testing.T) {
+	m := NewScopeMapping(map[string][]string{
+		"posts": {"posts:read", "posts:write"},
+		"admin": {"admin:.*"},
+	})
+
+	assert.True(t, m.Allows([]string{"posts"}, "posts:read"))
+	assert.True(t, m.Allows([]string{"admin"}, "admin:users"))
+	assert.True(t, m.Allows([]string{"comments:read"}, "comments:read"))
+	assert.False(t, m.Allows([]string{"posts"}, "admin:users"))
+	assert.False(t, m.Allows(nil, "posts:read"))
+}
+
+func TestClaimsScopes(t *testing.T) {
+	assert.Nil(t, ClaimsScopes(nil))
+	assert.Equal(t, []string{"a", "b"}, ClaimsScopes(&Claims{Metadata: map[string]any{MetadataScope: "a  b"}}))
+	assert.Equal(t, []string{"a", "b"}, ClaimsScopes(&Claims{Metadata: map[string]any{MetadataScope: []string{"a", "b"}}}))
+	assert.Equal(t, []string{"c"}, ClaimsScopes(&Claims{Metadata: map[string]any{MetadataSCP: []any{"c"}}}))
+}
+
+func TestDefaultAuthorizer_ScopeIntersection(t *testing.T) {
+	rbac := New()
+	role := NewRole("editor")
+	role.AddPermissions("posts:read", "posts:write", "users:read")
+	assert.NoError(t, rbac.AddRole(role))
+
+	authorizer := NewDefaultAuthorizer(rbac).SetScopeMapping(NewScopeMapping(map[string][]string{
+		"posts.readonly": {"posts:read"},
+	}))
+
+	claims := &Claims{
+		Subject:  &testSubject{roles: []string{"editor"}},
+		Metadata: map[string]any{MetadataScope: "posts.readonly"},
+	}
+
+	decision, err := authorizer.AuthorizeE(context.Background(), claims, &Target{Action: "posts:read"})
+	assert.Equal(t, DecisionAllow, decision)
+	assert.NoError(t, err)
+
+	decision, err = authorizer.AuthorizeE(context.Background(), claims, &Target{Action: "posts:write"})
+	assert.Equal(t, DecisionDeny, decision)
+	assert.ErrorIs(t, err, ErrDeny)
+	assert.ErrorIs(t, err, ErrInsufficientScope)
+
+	claims.Metadata[MetadataScope] = "posts.readonly admin:all"
+	decision, _ = authorizer.AuthorizeE(context.Background(), claims, &Target{Action: "admin:all"})
+	assert.Equal(t, DecisionDeny, decision)
+}
@@ -0,0 +1,202 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	_ AssignmentStore = (*ExclusiveAssignmentStore)(nil)
+
+	ErrSoDViolation = errors.New("separation of duty violated")
+)
+
+// AddExclusiveRoles declares roles no subject may hold together, directly or
+// through inheritance, e.g. "payment-approver" and "payment-creator". The
+// constraint is rejected if a registered role already inherits more than one
+// of them. Roles may be registered later, AddRole then rejects parents
+// violating the constraint. Edges added with Role.AddChild or Role.AddParent
+// are not checked.
+func (rbac *RBAC) AddExclusiveRoles(name string, roles ...string) error {
+	constraint := ExclusiveRolesConfig{Name: name, Roles: unite(roles)}
+	if slices.ContainsFunc(rbac.exclusive, constraint.equal) {
+		return nil
+	}
+
+	var errs []error
+	for _, name := range sortedKeys(rbac.roles) {
+		held := map[string]struct{}{}
+		collectHeld(rbac.roles[name], held)
+		errs = append(errs, exclusiveViolations([]ExclusiveRolesConfig{constraint}, held, fmt.Sprintf(`role "%s"`, name))...)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	rbac.exclusive = append(rbac.exclusive, constraint)
+	return nil
+}
+
+// renameIn returns the role names with oldName replaced by newName.
+func renameIn(names []string, oldName, newName string) []string {
+	if !slices.Contains(names, oldName) {
+		return names
+	}
+	renamed := make([]string, len(names))
+	for i, name := range names {
+		if name == oldName {
+			name = newName
+		}
+		renamed[i] = name
+	}
+	return unite(renamed)
+}
+
+func (rbac *RBAC) ExclusiveRoles() []ExclusiveRolesConfig {
+	constraints := make([]ExclusiveRolesConfig, len(rbac.exclusive))
+	for i, c := range rbac.exclusive {
+		constraints[i] = ExclusiveRolesConfig{Name: c.Name, Roles: slices.Clone(c.Roles)}
+	}
+	return constraints
+}
+
+// CheckExclusive reports the constraints violated by holding all roles at
+// once, taking inherited roles into account.
+func (rbac *RBAC) CheckExclusive(roles ...string) error {
+	return errors.Join(exclusiveViolations(rbac.exclusive, rbac.heldRoles(roles...), "")...)
+}
+
+// heldRoles returns the roles along with the ones they inherit, unknown roles
+// as they are.
+func (rbac *RBAC) heldRoles(roles ...string) map[string]struct{} {
+	held := map[string]struct{}{}
+	for _, role := range roles {
+		if r, ok := rbac.roles[role]; ok {
+			collectHeld(r, held)
+		} else {
+			held[role] = struct{}{}
+		}
+	}
+	return held
+}
+
+// checkInherit rejects making child a child of parent if parent or one of
+// its ancestors would then hold exclusive roles.
+func (rbac *RBAC) checkInherit(parent, child *Role) error {
+	if len(rbac.exclusive) == 0 {
+		return nil
+	}
+
+	inherited := map[string]struct{}{}
+	collectHeld(child, inherited)
+
+	visited := map[string]struct{}{}
+	var check func(r *Role) error
+	check = func(r *Role) error {
+		if _, ok := visited[r.Name()]; ok {
+			return nil
+		}
+		visited[r.Name()] = struct{}{}
+
+		held := map[string]struct{}{}
+		collectHeld(r, held)
+		for role := range inherited {
+			held[role] = struct{}{}
+		}
+		if errs := exclusiveViolations(rbac.exclusive, held, fmt.Sprintf(`role "%s"`, r.Name())); len(errs) > 0 {
+			return errors.Join(errs...)
+		}
+		for ancestor := range r.Parents() {
+			if err := check(ancestor); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return check(parent)
+}
+
+// collectHeld adds the role and its descendants to held.
+func collectHeld(r *Role, held map[string]struct{}) {
+	if _, ok := held[r.Name()]; ok {
+		return
+	}
+	held[r.Name()] = struct{}{}
+	for child := range r.Children() {
+		collectHeld(child, held)
+	}
+}
+
+func (c ExclusiveRolesConfig) equal(other ExclusiveRolesConfig) bool {
+	return c.Name == other.Name && slices.Equal(slices.Sorted(slices.Values(c.Roles)), slices.Sorted(slices.Values(other.Roles)))
+}
+
+// exclusiveViolations reports every constraint of which more than one role
+// is held.
+func exclusiveViolations(constraints []ExclusiveRolesConfig, held map[string]struct{}, holder string) []error {
+	var errs []error
+	for _, c := range constraints {
+		var roles []string
+		for _, role := range c.Roles {
+			if _, ok := held[role]; ok {
+				roles = append(roles, `"`+role+`"`)
+			}
+		}
+		if len(roles) < 2 {
+			continue
+		}
+
+		msg := fmt.Sprintf("roles %s are mutually exclusive", strings.Join(roles, ", "))
+		if c.Name != "" {
+			msg += fmt.Sprintf(` by "%s"`, c.Name)
+		}
+		if holder != "" {
+			msg = holder + " inherits " + msg
+		}
+		errs = append(errs, fmt.Errorf("%w: %s", ErrSoDViolation, msg))
+	}
+	return errs
+}
+
+// ExclusiveAssignmentStore rejects grants that would let a subject hold
+// exclusive roles of the policy at the same time. Grants whose validity does
+// not overlap do not conflict.
+type ExclusiveAssignmentStore struct {
+	AssignmentStore
+	holder *RBACHolder
+}
+
+func NewExclusiveAssignmentStore(store AssignmentStore, holder *RBACHolder) *ExclusiveAssignmentStore {
+	return &ExclusiveAssignmentStore{AssignmentStore: store, holder: holder}
+}
+
+func (s *ExclusiveAssignmentStore) AssignRole(ctx context.Context, subject, role string) error {
+	return s.GrantRole(ctx, Assignment{Subject: subject, Role: role})
+}
+
+func (s *ExclusiveAssignmentStore) GrantRole(ctx context.Context, assignment Assignment) error {
+	assignments, err := s.Assignments(ctx, assignment.Subject)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	roles := []string{assignment.Role}
+	for _, a := range assignments {
+		if a.Role != assignment.Role && !a.Expired(now) && a.overlaps(assignment) {
+			roles = append(roles, a.Role)
+		}
+	}
+	if err = s.holder.Load().CheckExclusive(roles...); err != nil {
+		return fmt.Errorf(`grant of role "%s" to "%s": %w`, assignment.Role, assignment.Subject, err)
+	}
+	return s.AssignmentStore.GrantRole(ctx, assignment)
+}
+
+func (a Assignment) overlaps(other Assignment) bool {
+	return (a.ValidUntil.IsZero() || other.ValidFrom.Before(a.ValidUntil)) &&
+		(other.ValidUntil.IsZero() || a.ValidFrom.Before(other.ValidUntil))
+}
@@ -0,0 +1,131 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRBAC_AddExclusiveRoles(t *testing.T) {
+	rbac := New()
+	require.NoError(t, rbac.AddRole("payment-creator"))
+	require.NoError(t, rbac.AddRole("payment-approver"))
+	require.NoError(t, rbac.AddRole("cfo"))
+	require.NoError(t, rbac.AddRole("finance", "cfo"))
+	require.NoError(t, rbac.AddRole("ops", "cfo"))
+	require.NoError(t, mustRole(t, rbac, "finance").AddChild(mustRole(t, rbac, "payment-creator")))
+
+	require.NoError(t, rbac.AddExclusiveRoles("payments", "payment-creator", "payment-approver"))
+	require.NoError(t, rbac.AddExclusiveRoles("payments", "payment-approver", "payment-creator"))
+	assert.Equal(t, []ExclusiveRolesConfig{{Name: "payments", Roles: []string{"payment-creator", "payment-approver"}}}, rbac.ExclusiveRoles())
+
+	err := rbac.AddRole(mustRole(t, rbac, "payment-approver"), "finance")
+	assert.ErrorIs(t, err, ErrSoDViolation)
+	assert.ErrorContains(t, err, `role "finance" inherits roles "payment-creator", "payment-approver" are mutually exclusive by "payments"`)
+
+	// "ops" alone is fine, its parent "cfo" would inherit both
+	err = rbac.AddRole(mustRole(t, rbac, "payment-approver"), "ops")
+	assert.ErrorIs(t, err, ErrSoDViolation)
+	assert.ErrorContains(t, err, `role "cfo" inherits`)
+
+	require.NoError(t, rbac.AddRole("approver-lead", "payment-approver"))
+
+	assert.ErrorIs(t, rbac.CheckExclusive("finance", "payment-approver"), ErrSoDViolation)
+	assert.ErrorIs(t, rbac.CheckExclusive("payment-creator", "unregistered", "payment-approver"), ErrSoDViolation)
+	assert.NoError(t, rbac.CheckExclusive("finance", "ops"))
+
+	other := New()
+	require.NoError(t, other.AddRole("a"))
+	require.NoError(t, other.AddRole("b"))
+	require.NoError(t, other.AddRole("c"))
+	require.NoError(t, mustRole(t, other, "c").AddChild(mustRole(t, other, "a")))
+	require.NoError(t, mustRole(t, other, "c").AddChild(mustRole(t, other, "b")))
+	assert.ErrorIs(t, other.AddExclusiveRoles("", "a", "b"), ErrSoDViolation)
+	assert.Empty(t, other.ExclusiveRoles())
+}
+
+func TestRBAC_RenameExclusiveRole(t *testing.T) {
+	rbac := New()
+	require.NoError(t, rbac.AddRole("creator"))
+	require.NoError(t, rbac.AddRole("approver"))
+	require.NoError(t, rbac.AddExclusiveRoles("payments", "creator", "approver", "auditor"))
+
+	require.NoError(t, rbac.RenameRole("approver", "approver2"))
+	assert.ErrorIs(t, rbac.CheckExclusive("approver2", "creator"), ErrSoDViolation)
+	assert.NoError(t, rbac.CheckExclusive("approver", "creator"))
+	assert.Equal(t, []ExclusiveRolesConfig{{Name: "payments", Roles: []string{"creator", "approver2", "auditor"}}}, rbac.ExclusiveRoles())
+
+	require.NoError(t, rbac.RenameRole("creator", "auditor"))
+	assert.Equal(t, []ExclusiveRolesConfig{{Name: "payments", Roles: []string{"auditor", "approver2"}}}, rbac.ExclusiveRoles())
+}
+
+func mustRole(t *testing.T, rbac *RBAC, name string) *Role {
+	t.Helper()
+	r, err := rbac.Role(name)
+	require.NoError(t, err)
+	return r
+}
+
+func TestConfig_ExclusiveRoles(t *testing.T) {
+	cfg := Config{
+		RoleHierarchy: []RoleConfig{
+			{Role: "creator"},
+			{Role: "approver"},
+			{Role: "finance", Children: []string{"creator"}},
+		},
+		ExclusiveRoles: []ExclusiveRolesConfig{{Name: "payments", Roles: []string{"creator", "approver"}}},
+	}
+	require.NoError(t, cfg.Validate())
+
+	rbac, err := NewWithConfig(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.ExclusiveRoles, rbac.Export().ExclusiveRoles)
+	assert.Equal(t, cfg.ExclusiveRoles, rbac.clone().ExclusiveRoles())
+
+	cfg.RoleHierarchy[2].Children = append(cfg.RoleHierarchy[2].Children, "approver")
+	cfg.ExclusiveRoles = append(cfg.ExclusiveRoles, ExclusiveRolesConfig{Roles: []string{"creator", "missing"}})
+	err = cfg.Validate()
+	assert.ErrorIs(t, err, ErrSoDViolation)
+	assert.ErrorContains(t, err, `role "finance" inherits roles "creator", "approver" are mutually exclusive by "payments"`)
+	assert.ErrorContains(t, err, `role "missing" referenced by exclusiveRoles[1] is not declared`)
+
+	_, err = NewWithConfig(cfg)
+	assert.ErrorIs(t, err, ErrSoDViolation)
+
+	merged, err := Config{ExclusiveRoles: cfg.ExclusiveRoles[:1]}.Merge(Config{ExclusiveRoles: cfg.ExclusiveRoles})
+	require.NoError(t, err)
+	assert.Equal(t, cfg.ExclusiveRoles, merged.ExclusiveRoles)
+}
+
+func TestExclusiveAssignmentStore(t *testing.T) {
+	rbac := New()
+	require.NoError(t, rbac.AddRole("creator"))
+	require.NoError(t, rbac.AddRole("approver"))
+	require.NoError(t, rbac.AddRole("viewer"))
+	require.NoError(t, rbac.AddExclusiveRoles("payments", "creator", "approver"))
+
+	ctx := context.Background()
+	store := NewExclusiveAssignmentStore(NewMemoryAssignmentStore(), NewRBACHolder(rbac))
+	require.NoError(t, store.AssignRole(ctx, "u1", "creator"))
+	require.NoError(t, store.AssignRole(ctx, "u1", "viewer"))
+	require.NoError(t, store.AssignRole(ctx, "u1", "creator"))
+
+	err := store.AssignRole(ctx, "u1", "approver")
+	assert.ErrorIs(t, err, ErrSoDViolation)
+	assert.ErrorContains(t, err, `grant of role "approver" to "u1"`)
+
+	now := time.Now()
+	require.NoError(t, store.GrantRole(ctx, Assignment{Subject: "u2", Role: "creator", ValidUntil: now.Add(time.Hour)}))
+	require.NoError(t, store.GrantRole(ctx, Assignment{Subject: "u2", Role: "approver", ValidFrom: now.Add(time.Hour)}))
+	assert.ErrorIs(t, store.GrantRole(ctx, Assignment{Subject: "u2", Role: "approver", ValidFrom: now.Add(time.Minute)}), ErrSoDViolation)
+
+	require.NoError(t, store.GrantRole(ctx, Assignment{Subject: "u3", Role: "creator", ValidUntil: now.Add(-time.Minute)}))
+	require.NoError(t, store.AssignRole(ctx, "u3", "approver"))
+
+	roles, err := store.RolesOf(ctx, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"creator", "viewer"}, roles)
+}
@@ -0,0 +1,79 @@
+package rbac
+
+import "net/http"
+
+var _ ClaimsExtractor = (*SessionClaimsExtractor)(nil)
+
+const (
+	SessionKeySubject = "rbac.subject"
+	SessionKeyRoles   = "rbac.roles"
+)
+
+// Session reads and writes values of a server-side session.
+//
+// For alexedwards/scs, SessionFuncs call the manager's Get and Put with
+// r.Context(). For gorilla/sessions, both look up store.Get(r, name) and
+// PutFunc finishes with session.Save(r, w).
+type Session interface {
+	Get(r *http.Request, key string) any
+	Put(w http.ResponseWriter, r *http.Request, key string, value any) error
+}
+
+type SessionFuncs struct {
+	GetFunc func(r *http.Request, key string) any
+	PutFunc func(w http.ResponseWriter, r *http.Request, key string, value any) error
+}
+
+func (s SessionFuncs) Get(r *http.Request, key string) any {
+	return s.GetFunc(r, key)
+}
+
+func (s SessionFuncs) Put(w http.ResponseWriter, r *http.Request, key string, value any) error {
+	return s.PutFunc(w, r, key, value)
+}
+
+// SessionClaimsExtractor loads claims from a server-side session. Sessions
+// without roles yield no claims.
+type SessionClaimsExtractor struct {
+	Session    Session
+	SubjectKey string
+	RolesKey   string
+}
+
+func NewSessionClaimsExtractor(session Session) *SessionClaimsExtractor {
+	return &SessionClaimsExtractor{Session: session, SubjectKey: SessionKeySubject, RolesKey: SessionKeyRoles}
+}
+
+func (e *SessionClaimsExtractor) ExtractClaims(r *http.Request) (*Claims, error) {
+	roles := metadataStrings(e.Session.Get(r, e.RolesKey))
+	if len(roles) == 0 {
+		return nil, nil
+	}
+
+	id, _ := e.Session.Get(r, e.SubjectKey).(string)
+
+	return &Claims{Subject: NewSubject(id, roles...), Metadata: map[string]any{}}, nil
+}
+
+// Save writes the subject of claims back to the session, e.g. after login or
+// when roles change mid-session.
+func (e *SessionClaimsExtractor) Save(w http.ResponseWriter, r *http.Request, claims *Claims) error {
+	var (
+		id    string
+		roles []string
+	)
+	if claims != nil && claims.Subject != nil {
+		id = SubjectID(claims.Subject)
+		roles = claims.Subject.Roles()
+	}
+
+	if err := e.Session.Put(w, r, e.SubjectKey, id); err != nil {
+		return err
+	}
+	return e.Session.Put(w, r, e.RolesKey, roles)
+}
+
+// SaveRoles replaces the roles stored in the session and keeps the subject.
+func (e *SessionClaimsExtractor) SaveRoles(w http.ResponseWriter, r *http.Request, roles ...string) error {
+	return e.Session.Put(w, r, e.RolesKey, roles)
+}
@@ -0,0 +1,65 @@
+package rbac
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testSession(values map[string]any) SessionFuncs {
+	return SessionFuncs{
+		GetFunc: func(_ *http.Request, key string) any {
+			return values[key]
+		},
+		PutFunc: func(_ http.ResponseWriter, _ *http.Request, key string, value any) error {
+			values[key] = value
+			return nil
+		},
+	}
+}
+
+func TestSessionClaimsExtractor_ExtractClaims(t *testing.T) {
+	values := map[string]any{}
+	e := NewSessionClaimsExtractor(testSession(values))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	claims, err := e.ExtractClaims(r)
+	assert.NoError(t, err)
+	assert.Nil(t, claims)
+
+	values[SessionKeySubject] = "user-1"
+	values[SessionKeyRoles] = []any{"user", "editor"}
+	claims, err = e.ExtractClaims(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", SubjectID(claims.Subject))
+	assert.Equal(t, []string{"user", "editor"}, claims.Subject.Roles())
+}
+
+func TestSessionClaimsExtractor_Save(t *testing.T) {
+	values := map[string]any{}
+	e := NewSessionClaimsExtractor(testSession(values))
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	assert.NoError(t, e.Save(w, r, &Claims{Subject: NewSubject("user-1", "user")}))
+	assert.Equal(t, "user-1", values[SessionKeySubject])
+
+	assert.NoError(t, e.SaveRoles(w, r, "admin"))
+	claims, err := e.ExtractClaims(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", SubjectID(claims.Subject))
+	assert.Equal(t, []string{"admin"}, claims.Subject.Roles())
+
+	assert.NoError(t, e.Save(w, r, nil))
+	claims, err = e.ExtractClaims(r)
+	assert.NoError(t, err)
+	assert.Nil(t, claims)
+
+	failing := NewSessionClaimsExtractor(SessionFuncs{
+		PutFunc: func(http.ResponseWriter, *http.Request, string, any) error { return errors.New("store down") },
+	})
+	assert.Error(t, failing.Save(w, r, nil))
+}
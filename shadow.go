@@ -0,0 +1,82 @@
+package rbac
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+)
+
+var _ Authorizer = (*ShadowAuthorizer)(nil)
+
+// ShadowAuthorizer logs and counts denials of the wrapped authorizer but lets
+// the request through, so a new policy can be observed before it is enforced.
+type ShadowAuthorizer struct {
+	authorizer Authorizer
+	logger     *slog.Logger
+	enabled    func(ctx context.Context, target *Target) bool
+	sampler    *DecisionSampler
+	denials    atomic.Int64
+}
+
+func NewShadowAuthorizer(authorizer Authorizer, logger *slog.Logger) *ShadowAuthorizer {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &ShadowAuthorizer{authorizer: authorizer, logger: logger}
+}
+
+// SetEnabled restricts shadow mode to matching requests, other requests are
+// enforced. A nil predicate enables shadow mode globally.
+func (a *ShadowAuthorizer) SetEnabled(enabled func(ctx context.Context, target *Target) bool) *ShadowAuthorizer {
+	a.enabled = enabled
+	return a
+}
+
+// SetSampler limits logged denials; Denials still counts all of them.
+func (a *ShadowAuthorizer) SetSampler(sampler *DecisionSampler) *ShadowAuthorizer {
+	a.sampler = sampler
+	return a
+}
+
+func (a *ShadowAuthorizer) Denials() int64 {
+	return a.denials.Load()
+}
+
+func (a *ShadowAuthorizer) Authorize(ctx context.Context, claims *Claims, target *Target) Decision {
+	d := a.authorizer.Authorize(ctx, claims, target)
+	if d.Allowed() || (a.enabled != nil && !a.enabled(ctx, target)) {
+		return d
+	}
+
+	a.denials.Add(1)
+	if !a.sampler.Sample(claims, target, d) {
+		return DecisionAllow
+	}
+
+	var action, subject string
+	if target != nil {
+		action = target.Action
+	}
+	if claims != nil && claims.Subject != nil {
+		subject = SubjectID(claims.Subject)
+	}
+	info := CtxRequestInfo(ctx)
+
+	a.logger.WarnContext(ctx, "rbac: shadow deny",
+		slog.String("action", action),
+		slog.String("subject", subject),
+		slog.String("method", info.Method),
+		slog.String("pattern", info.Pattern),
+	)
+
+	return DecisionAllow
+}
+
+// ShadowPatterns enables shadow mode for requests matched by the given
+// ServeMux patterns only.
+func ShadowPatterns(patterns ...string) func(ctx context.Context, target *Target) bool {
+	return func(ctx context.Context, _ *Target) bool {
+		return slices.Contains(patterns, CtxRequestInfo(ctx).Pattern)
+	}
+}
@@ -0,0 +1,53 @@
+package rbac
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShadowAuthorizer(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	a := NewShadowAuthorizer(&mockAuthorizer{decision: DecisionDeny}, logger)
+	claims := &Claims{Subject: &testIdentifiedSubject{id: "u1"}}
+
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), claims, &Target{Action: "posts:write"}))
+	assert.Equal(t, int64(1), a.Denials())
+	assert.Contains(t, buf.String(), "rbac: shadow deny")
+	assert.Contains(t, buf.String(), "action=posts:write")
+	assert.Contains(t, buf.String(), "subject=u1")
+
+	allow := NewShadowAuthorizer(&mockAuthorizer{decision: DecisionAllow}, logger)
+	assert.Equal(t, DecisionAllow, allow.Authorize(context.Background(), claims, &Target{Action: "posts:write"}))
+	assert.Equal(t, int64(0), allow.Denials())
+}
+
+func TestShadowAuthorizer_Patterns(t *testing.T) {
+	a := NewShadowAuthorizer(&mockAuthorizer{decision: DecisionDeny}, slog.New(slog.DiscardHandler)).
+		SetEnabled(ShadowPatterns("GET /beta/{id}"))
+
+	ctx := WithRequestInfo(context.Background(), RequestInfo{Pattern: "GET /beta/{id}"})
+	assert.Equal(t, DecisionAllow, a.Authorize(ctx, nil, nil))
+
+	ctx = WithRequestInfo(context.Background(), RequestInfo{Pattern: "GET /stable/{id}"})
+	assert.Equal(t, DecisionDeny, a.Authorize(ctx, nil, nil))
+	assert.Equal(t, int64(1), a.Denials())
+}
+
+func TestShadowAuthorizer_Sampler(t *testing.T) {
+	var buf bytes.Buffer
+	a := NewShadowAuthorizer(&mockAuthorizer{decision: DecisionDeny}, slog.New(slog.NewTextHandler(&buf, nil))).
+		SetSampler(NewDecisionSampler(0).SetDenyRate(0).AlwaysActions("posts:delete"))
+
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), nil, &Target{Action: "posts:write"}))
+	assert.Empty(t, buf.String())
+
+	assert.Equal(t, DecisionAllow, a.Authorize(context.Background(), nil, &Target{Action: "posts:delete"}))
+	assert.Contains(t, buf.String(), "action=posts:delete")
+	assert.Equal(t, int64(2), a.Denials())
+}
@@ -0,0 +1,278 @@
+package rbac
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DecisionRecord is a single recorded authorization input, stored one JSON
+// object per line in a corpus file.
+type DecisionRecord struct {
+	Time    time.Time `json:"time,omitzero"`
+	Subject string    `json:"subject,omitempty"`
+	// Anonymized marks Subject as a salted hash rather than the identifier.
+	Anonymized bool     `json:"anonymized,omitempty"`
+	Roles      []string `json:"roles,omitempty"`
+	// Actor and ActorRoles describe an impersonating actor, anonymized like
+	// the subject.
+	Actor      string   `json:"actor,omitempty"`
+	ActorRoles []string `json:"actorRoles,omitempty"`
+	Action     string   `json:"action"`
+	Literal    bool     `json:"literal,omitempty"`
+	Anchored   bool     `json:"anchored,omitempty"`
+	// Metadata holds the claims metadata, TargetMetadata the one of the
+	// target.
+	Metadata       map[string]any   `json:"metadata,omitempty"`
+	TargetMetadata map[string]any   `json:"targetMetadata,omitempty"`
+	Request        *RecordedRequest `json:"request,omitempty"`
+	Decision       Decision         `json:"decision"`
+}
+
+// RecordedRequest is the request info of a DecisionRecord. RemoteAddr and
+// Header are only kept when the recorder allowlists them.
+type RecordedRequest struct {
+	Method     string            `json:"method,omitempty"`
+	Host       string            `json:"host,omitempty"`
+	Path       string            `json:"path,omitempty"`
+	Pattern    string            `json:"pattern,omitempty"`
+	RemoteAddr string            `json:"remoteAddr,omitempty"`
+	Header     http.Header       `json:"header,omitempty"`
+	PathValues map[string]string `json:"pathValues,omitempty"`
+}
+
+func (r *RecordedRequest) info() RequestInfo {
+	return RequestInfo{
+		Method:     r.Method,
+		Host:       r.Host,
+		RequestURI: r.Path,
+		Pattern:    r.Pattern,
+		RemoteAddr: r.RemoteAddr,
+		Header:     r.Header,
+		URL:        &url.URL{Path: r.Path},
+		PathValues: r.PathValues,
+	}
+}
+
+func (r DecisionRecord) key() string {
+	if r.Subject != "" {
+		return r.Subject
+	}
+	return strings.Join(r.Roles, ",")
+}
+
+func ReadDecisionRecords(r io.Reader) ([]DecisionRecord, error) {
+	var records []DecisionRecord
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var record DecisionRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+type SimulationResult struct {
+	Record   DecisionRecord
+	Decision Decision
+	// Unevaluable marks denials of anonymized records by assertions
+	// comparing the subject identifier, which a hashed subject cannot pass.
+	Unevaluable bool
+}
+
+type SimulationDiff struct {
+	Record    DecisionRecord
+	Baseline  Decision
+	Candidate Decision
+}
+
+type Simulation struct {
+	Results     []SimulationResult
+	Allowed     int
+	Denied      int
+	Unevaluable int
+}
+
+// SubjectAssertion is implemented by assertions comparing the subject
+// identifier, e.g. with the owner of the target.
+type SubjectAssertion interface {
+	Assertion
+	ComparesSubject() bool
+}
+
+// Simulate replays the records against a policy built from cfg with the
+// built-in assertions of NewAssertionRegistry, at the recorded time and with
+// the recorded actor and request info. Denials of anonymized records by a
+// SubjectAssertion, e.g. "owner" or a "subject.id" condition, are counted as
+// Unevaluable rather than Denied, record subjects with SetKeepSubjects to
+// evaluate them.
+func Simulate(cfg Config, records []DecisionRecord) (*Simulation, error) {
+	rbac := New().SetAssertionRegistry(NewAssertionRegistry())
+	if err := rbac.Apply(cfg); err != nil {
+		return nil, err
+	}
+
+	authorizer := NewDefaultAuthorizer(rbac)
+	subjectAssertions := rbac.subjectAssertions()
+	simulation := &Simulation{Results: make([]SimulationResult, 0, len(records))}
+
+	for _, record := range records {
+		claims := &Claims{
+			Subject:  NewSubject(record.Subject, record.Roles...),
+			Metadata: record.Metadata,
+		}
+		if record.Actor != "" || len(record.ActorRoles) > 0 {
+			claims.Actor = NewSubject(record.Actor, record.ActorRoles...)
+		}
+		target := &Target{
+			Action:   record.Action,
+			Metadata: record.TargetMetadata,
+			Literal:  record.Literal,
+			Anchored: record.Anchored,
+		}
+
+		ctx := context.Background()
+		if !record.Time.IsZero() {
+			ctx = WithDecisionTime(ctx, record.Time)
+		}
+		if record.Request != nil {
+			ctx = WithRequestInfo(ctx, record.Request.info())
+		}
+
+		d, err := authorizer.AuthorizeE(ctx, claims, target)
+		result := SimulationResult{Record: record, Decision: d}
+		switch {
+		case d.Allowed():
+			simulation.Allowed++
+		case record.Anonymized && slices.ContainsFunc(Reasons(err), subjectAssertions.failed):
+			result.Unevaluable = true
+			simulation.Unevaluable++
+		default:
+			simulation.Denied++
+		}
+		simulation.Results = append(simulation.Results, result)
+	}
+	return simulation, nil
+}
+
+type assertionNames map[string]struct{}
+
+func (names assertionNames) failed(reason Reason) bool {
+	failed, ok := reason.(ReasonAssertionFailed)
+	if ok {
+		_, ok = names[failed.Name]
+	}
+	return ok
+}
+
+// subjectAssertions returns the names of the registered assertions and the
+// permission assertions comparing the subject identifier.
+func (rbac *RBAC) subjectAssertions() assertionNames {
+	names := assertionNames{}
+	add := func(assertion Assertion) {
+		if comparesSubject(assertion) {
+			names[AssertionName(assertion)] = struct{}{}
+		}
+	}
+	if rbac.assertions != nil {
+		for _, name := range rbac.assertions.Names() {
+			if assertion, err := rbac.assertions.Assertion(name); err == nil {
+				add(assertion)
+			}
+		}
+	}
+	for _, role := range rbac.roles {
+		for _, assertions := range role.conditions {
+			for _, assertion := range assertions {
+				add(assertion)
+			}
+		}
+	}
+	return names
+}
+
+func comparesSubject(assertion Assertion) bool {
+	switch assertion := assertion.(type) {
+	case *registeredAssertion:
+		return comparesSubject(assertion.Assertion)
+	case *registeredErrorAssertion:
+		return comparesSubject(assertion.Assertion)
+	case SubjectAssertion:
+		return assertion.ComparesSubject()
+	}
+	return false
+}
+
+// Matrix returns decisions indexed by action and then by subject, or by the
+// joined roles for anonymous records.
+func (s *Simulation) Matrix() map[string]map[string]Decision {
+	matrix := map[string]map[string]Decision{}
+	for _, result := range s.Results {
+		row, ok := matrix[result.Record.Action]
+		if !ok {
+			row = map[string]Decision{}
+			matrix[result.Record.Action] = row
+		}
+		row[result.Record.key()] = result.Decision
+	}
+	return matrix
+}
+
+// Diff compares the simulation with a baseline simulated from the same
+// records, skipping results unevaluable in either.
+func (s *Simulation) Diff(baseline *Simulation) []SimulationDiff {
+	var diffs []SimulationDiff
+	for i, result := range s.Results {
+		if i >= len(baseline.Results) {
+			break
+		}
+		if result.Unevaluable || baseline.Results[i].Unevaluable {
+			continue
+		}
+		if b := baseline.Results[i].Decision; b != result.Decision {
+			diffs = append(diffs, SimulationDiff{Record: result.Record, Baseline: b, Candidate: result.Decision})
+		}
+	}
+	return diffs
+}
+
+// DiffRecorded compares the simulation with the decisions stored in the
+// records, skipping unevaluable results.
+func (s *Simulation) DiffRecorded() []SimulationDiff {
+	var diffs []SimulationDiff
+	for _, result := range s.Results {
+		if !result.Unevaluable && result.Record.Decision != result.Decision {
+			diffs = append(diffs, SimulationDiff{Record: result.Record, Baseline: result.Record.Decision, Candidate: result.Decision})
+		}
+	}
+	return diffs
+}
+
+// LostAccess lists subjects that are allowed by the baseline and denied by
+// the candidate in at least one of the diffs.
+func LostAccess(diffs []SimulationDiff) []string {
+	var subjects []string
+	for _, diff := range diffs {
+		if diff.Baseline.Allowed() && !diff.Candidate.Allowed() {
+			if key := diff.Record.key(); !slices.Contains(subjects, key) {
+				subjects = append(subjects, key)
+			}
+		}
+	}
+	slices.Sort(subjects)
+	return subjects
+}
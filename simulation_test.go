@@ -0,0 +1,123 @@
+package rbac
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCorpus = `{"subject":"alice","roles":["admin"],"action":"posts:delete","decision":"allow"}
+{"subject":"bob","roles":["user"],"action":"posts:read","decision":1}
+
+{"roles":["user"],"action":"posts:delete","decision":"deny"}
+`
+
+func TestReadDecisionRecords(t *testing.T) {
+	records, err := ReadDecisionRecords(strings.NewReader(testCorpus))
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, DecisionRecord{Subject: "bob", Roles: []string{"user"}, Action: "posts:read", Decision: DecisionAllow}, records[1])
+
+	_, err = ReadDecisionRecords(strings.NewReader("{"))
+	assert.Error(t, err)
+}
+
+func TestSimulate(t *testing.T) {
+	records, err := ReadDecisionRecords(strings.NewReader(testCorpus))
+	require.NoError(t, err)
+
+	baseline, err := Simulate(Config{
+		RoleHierarchy: []RoleConfig{{Role: "admin", Children: []string{"user"}}, {Role: "user"}},
+		AccessControl: []AccessConfig{
+			{Role: "admin", Permissions: []string{"posts:delete"}},
+			{Role: "user", Permissions: []string{"posts:read"}},
+		},
+	}, records)
+	require.NoError(t, err)
+	assert.Equal(t, 2, baseline.Allowed)
+	assert.Equal(t, 1, baseline.Denied)
+	assert.Empty(t, baseline.DiffRecorded())
+	assert.Equal(t, map[string]map[string]Decision{
+		"posts:delete": {"alice": DecisionAllow, "user": DecisionDeny},
+		"posts:read":   {"bob": DecisionAllow},
+	}, baseline.Matrix())
+
+	candidate, err := Simulate(Config{
+		RoleHierarchy: []RoleConfig{{Role: "admin"}, {Role: "user"}},
+		AccessControl: []AccessConfig{{Role: "user", Permissions: []string{"posts:read", "posts:delete"}}},
+	}, records)
+	require.NoError(t, err)
+
+	diffs := candidate.Diff(baseline)
+	assert.Len(t, diffs, 2)
+	assert.Equal(t, []string{"alice"}, LostAccess(diffs))
+	assert.Equal(t, diffs, candidate.DiffRecorded())
+
+	_, err = Simulate(Config{AccessControl: []AccessConfig{{Role: "missing"}}}, records)
+	assert.ErrorIs(t, err, ErrRoleNotFound)
+}
+
+func TestSimulate_Ownership(t *testing.T) {
+	cfg := Config{
+		RoleHierarchy: []RoleConfig{{Role: "user"}},
+		AccessControl: []AccessConfig{{Role: "user", Permissions: []string{"posts:edit"}, Assertions: []string{"owner"}}},
+	}
+	owned := map[string]any{MetadataOwner: "alice"}
+	records := []DecisionRecord{
+		{Subject: "alice", Roles: []string{"user"}, Action: "posts:edit", TargetMetadata: owned, Metadata: map[string]any{MetadataOwner: "bob"}, Decision: DecisionAllow},
+		{Subject: "bob", Roles: []string{"user"}, Action: "posts:edit", TargetMetadata: owned, Decision: DecisionDeny},
+		{Subject: "5f1e0b6c9a2d4e73", Anonymized: true, Roles: []string{"user"}, Action: "posts:edit", TargetMetadata: owned, Decision: DecisionAllow},
+	}
+
+	simulation, err := Simulate(cfg, records)
+	require.NoError(t, err)
+	assert.Equal(t, 1, simulation.Allowed)
+	assert.Equal(t, 1, simulation.Denied)
+	assert.Equal(t, 1, simulation.Unevaluable)
+	assert.True(t, simulation.Results[2].Unevaluable)
+	assert.Empty(t, simulation.DiffRecorded())
+
+	candidate, err := Simulate(Config{RoleHierarchy: cfg.RoleHierarchy}, records)
+	require.NoError(t, err)
+	assert.Len(t, candidate.Diff(simulation), 1)
+}
+
+func TestSimulate_Replay(t *testing.T) {
+	cfg := Config{
+		RoleHierarchy: []RoleConfig{{Role: "ops"}, {Role: "support"}, {Role: "user"}},
+		AccessControl: []AccessConfig{
+			{Role: "ops", Permissions: []string{"deploy"}, Conditions: []ConditionConfig{
+				{Time: &TimeCondition{Location: "UTC", Start: "09:00", End: "17:00"}},
+				{Attribute: "request.remoteAddr", CIDR: []string{"10.0.0.0/8"}},
+			}},
+			{Role: "ops", Permissions: []string{"topics:orders-*"}},
+			{Role: "support", Permissions: []string{ActionImpersonate}},
+			{Role: "user", Permissions: []string{"profile:edit"}, Conditions: []ConditionConfig{
+				{Attribute: "subject.id", Equals: "alice"},
+			}},
+		},
+	}
+	noon := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+	office := &RecordedRequest{Method: "POST", Path: "/deploy", RemoteAddr: "10.1.2.3:5000"}
+	records := []DecisionRecord{
+		{Time: noon, Roles: []string{"ops"}, Action: "deploy", Request: office, Decision: DecisionAllow},
+		{Time: noon.Add(8 * time.Hour), Roles: []string{"ops"}, Action: "deploy", Request: office, Decision: DecisionDeny},
+		{Time: noon, Roles: []string{"ops"}, Action: "deploy", Request: &RecordedRequest{RemoteAddr: "192.0.2.1:5000"}, Decision: DecisionDeny},
+		{Roles: []string{"ops"}, Action: "topics:orders-*", Literal: true, Decision: DecisionAllow},
+		{Roles: []string{"ops"}, Action: "topics:*", Literal: true, Decision: DecisionDeny},
+		{Subject: "bob", Roles: []string{"ops"}, ActorRoles: []string{"support"}, Action: "topics:orders-*", Literal: true, Decision: DecisionAllow},
+		{Subject: "bob", Roles: []string{"ops"}, ActorRoles: []string{"user"}, Action: "topics:orders-*", Literal: true, Decision: DecisionDeny},
+		{Subject: "5f1e0b6c9a2d4e73", Anonymized: true, Roles: []string{"user"}, Action: "profile:edit", Decision: DecisionAllow},
+	}
+
+	simulation, err := Simulate(cfg, records)
+	require.NoError(t, err)
+	assert.Empty(t, simulation.DiffRecorded())
+	assert.Equal(t, 3, simulation.Allowed)
+	assert.Equal(t, 4, simulation.Denied)
+	assert.Equal(t, 1, simulation.Unevaluable)
+	assert.True(t, simulation.Results[7].Unevaluable)
+}
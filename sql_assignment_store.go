@@ -0,0 +1,95 @@
+package rbac
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+)
+
+var _ AssignmentStore = (*SQLAssignmentStore)(nil)
+
+// SQLAssignmentStore is an AssignmentStore sharing the schema of
+// SQLRoleStore. Call Migrate before first use. Grant bounds are stored as
+// seconds since the Unix epoch, 0 meaning unbounded.
+type SQLAssignmentStore struct {
+	store *SQLRoleStore
+}
+
+func NewSQLAssignmentStore(db *sql.DB, dialect SQLDialect) *SQLAssignmentStore {
+	return &SQLAssignmentStore{store: NewSQLRoleStore(db, dialect)}
+}
+
+// Migrate creates or upgrades the schema shared with SQLRoleStore.
+func (s *SQLAssignmentStore) Migrate(ctx context.Context) error {
+	return s.store.Migrate(ctx)
+}
+
+func (s *SQLAssignmentStore) AssignRole(ctx context.Context, subject, role string) error {
+	return s.GrantRole(ctx, Assignment{Subject: subject, Role: role})
+}
+
+func (s *SQLAssignmentStore) GrantRole(ctx context.Context, assignment Assignment) error {
+	if err := assignment.validate(); err != nil {
+		return err
+	}
+	return s.store.tx(ctx, func(tx *sql.Tx) error {
+		if err := s.store.exec(ctx, tx, `DELETE FROM rbac_assignments WHERE subject = ? AND role = ?`, assignment.Subject, assignment.Role); err != nil {
+			return err
+		}
+		return s.store.exec(ctx, tx, `INSERT INTO rbac_assignments (subject, role, valid_from, valid_until) VALUES (?, ?, ?, ?)`,
+			assignment.Subject, assignment.Role, unixSeconds(assignment.ValidFrom), unixSeconds(assignment.ValidUntil))
+	})
+}
+
+func (s *SQLAssignmentStore) RevokeRole(ctx context.Context, subject, role string) error {
+	return s.store.tx(ctx, func(tx *sql.Tx) error {
+		return s.store.exec(ctx, tx, `DELETE FROM rbac_assignments WHERE subject = ? AND role = ?`, subject, role)
+	})
+}
+
+func (s *SQLAssignmentStore) RolesOf(ctx context.Context, subject string) ([]string, error) {
+	assignments, err := s.Assignments(ctx, subject)
+	return activeRoles(assignments, time.Now()), err
+}
+
+func (s *SQLAssignmentStore) Assignments(ctx context.Context, subject string) (assignments []Assignment, err error) {
+	err = s.store.tx(ctx, func(tx *sql.Tx) error {
+		return s.store.scan(ctx, tx, `SELECT role, valid_from, valid_until FROM rbac_assignments WHERE subject = ? ORDER BY role`, func(values ...string) {
+			assignments = append(assignments, Assignment{
+				Subject:    subject,
+				Role:       values[0],
+				ValidFrom:  fromUnixSeconds(values[1]),
+				ValidUntil: fromUnixSeconds(values[2]),
+			})
+		}, subject)
+	})
+	return assignments, err
+}
+
+func (s *SQLAssignmentStore) Sweep(ctx context.Context, now time.Time) (n int, err error) {
+	err = s.store.tx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, s.store.rebind(`DELETE FROM rbac_assignments WHERE valid_until > 0 AND valid_until <= ?`), now.Unix())
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		n = int(affected)
+		return err
+	})
+	return n, err
+}
+
+func unixSeconds(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func fromUnixSeconds(s string) time.Time {
+	if n, _ := strconv.ParseInt(s, 10, 64); n != 0 {
+		return time.Unix(n, 0)
+	}
+	return time.Time{}
+}
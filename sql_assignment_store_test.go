@@ -0,0 +1,29 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLAssignmentStore(t *testing.T) {
+	_, db := newFakeSQL()
+	store := NewSQLAssignmentStore(db, SQLMySQL)
+	require.NoError(t, store.Migrate(context.Background()))
+	require.NoError(t, NewSQLRoleStore(db, SQLMySQL).Migrate(context.Background()))
+
+	testAssignmentStore(t, store)
+}
+
+func TestSQLAssignmentStore_Postgres(t *testing.T) {
+	fake, db := newFakeSQL()
+	store := NewSQLAssignmentStore(db, SQLPostgres)
+	require.NoError(t, store.Migrate(context.Background()))
+
+	testAssignmentStore(t, store)
+	assert.Contains(t, fake.queries, `DELETE FROM rbac_assignments WHERE subject = $1 AND role = $2`)
+	assert.Contains(t, fake.queries, `DELETE FROM rbac_assignments WHERE valid_until > 0 AND valid_until <= $1`)
+	assert.Contains(t, fake.queries, `SELECT role, valid_from, valid_until FROM rbac_assignments WHERE subject = $1 ORDER BY role`)
+}
@@ -0,0 +1,275 @@
+package rbac
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var _ RoleStore = (*SQLRoleStore)(nil)
+
+// SQLDialect selects the placeholder syntax of the database.
+type SQLDialect int
+
+const (
+	// SQLMySQL uses "?" placeholders, which also suits SQLite.
+	SQLMySQL SQLDialect = iota
+	// SQLPostgres uses "$1" placeholders.
+	SQLPostgres
+)
+
+// sqlMigrations holds the schema, one entry per version. Applied versions
+// must never change, append new ones instead.
+var sqlMigrations = [][]string{
+	{
+		`CREATE TABLE rbac_roles (name VARCHAR(255) NOT NULL PRIMARY KEY)`,
+		`CREATE TABLE rbac_role_permissions (role VARCHAR(255) NOT NULL, permission VARCHAR(1024) NOT NULL)`,
+		`CREATE INDEX rbac_role_permissions_role ON rbac_role_permissions (role)`,
+		`CREATE TABLE rbac_role_children (role VARCHAR(255) NOT NULL, child VARCHAR(255) NOT NULL, PRIMARY KEY (role, child))`,
+		`CREATE TABLE rbac_role_tags (role VARCHAR(255) NOT NULL, tag VARCHAR(255) NOT NULL, PRIMARY KEY (role, tag))`,
+		`CREATE TABLE rbac_version (version BIGINT NOT NULL)`,
+		`INSERT INTO rbac_version (version) VALUES (0)`,
+	},
+	{
+		`CREATE TABLE rbac_assignments (subject VARCHAR(255) NOT NULL, role VARCHAR(255) NOT NULL, PRIMARY KEY (subject, role))`,
+	},
+	{
+		`ALTER TABLE rbac_assignments ADD COLUMN valid_from BIGINT NOT NULL DEFAULT 0`,
+		`ALTER TABLE rbac_assignments ADD COLUMN valid_until BIGINT NOT NULL DEFAULT 0`,
+	},
+	{
+		`ALTER TABLE rbac_roles ADD COLUMN matching VARCHAR(16) NOT NULL DEFAULT ''`,
+		`ALTER TABLE rbac_role_permissions ADD COLUMN matching VARCHAR(16) NOT NULL DEFAULT ''`,
+	},
+}
+
+// SQLRoleStore is a RoleStore backed by database/sql, e.g. Postgres or
+// MySQL. Call Migrate before first use. Wait polls the version table.
+type SQLRoleStore struct {
+	db      *sql.DB
+	dialect SQLDialect
+	poll    time.Duration
+}
+
+func NewSQLRoleStore(db *sql.DB, dialect SQLDialect) *SQLRoleStore {
+	return &SQLRoleStore{db: db, dialect: dialect, poll: 5 * time.Second}
+}
+
+func (s *SQLRoleStore) SetPollInterval(poll time.Duration) *SQLRoleStore {
+	s.poll = poll
+	return s
+}
+
+// Migrate creates or upgrades the schema. Applied migrations are recorded in
+// rbac_schema_migrations.
+func (s *SQLRoleStore) Migrate(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS rbac_schema_migrations (version INTEGER NOT NULL PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("rbac: migrate: %w", err)
+	}
+
+	var current int
+	if err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM rbac_schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("rbac: migrate: %w", err)
+	}
+
+	for i := current; i < len(sqlMigrations); i++ {
+		err := s.tx(ctx, func(tx *sql.Tx) error {
+			for _, stmt := range sqlMigrations[i] {
+				if _, err := tx.ExecContext(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			_, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO rbac_schema_migrations (version) VALUES (?)`), i+1)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("rbac: migration %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLRoleStore) LoadRoles(ctx context.Context) (roles []StoredRole, version uint64, err error) {
+	err = s.tx(ctx, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx, `SELECT version FROM rbac_version`).Scan(&version); err != nil {
+			return err
+		}
+
+		index := map[string]int{}
+		if err := s.scan(ctx, tx, `SELECT name, matching FROM rbac_roles ORDER BY name`, func(values ...string) {
+			index[values[0]] = len(roles)
+			roles = append(roles, StoredRole{Name: values[0], PermissionMatching: PermissionMatching(values[1])})
+		}); err != nil {
+			return err
+		}
+
+		for _, link := range []struct {
+			query string
+			add   func(r *StoredRole, values ...string)
+		}{
+			{`SELECT role, permission, matching FROM rbac_role_permissions ORDER BY role, permission`, addStoredPermission},
+			{`SELECT role, child FROM rbac_role_children ORDER BY role, child`, func(r *StoredRole, values ...string) { r.Children = append(r.Children, values[0]) }},
+			{`SELECT role, tag FROM rbac_role_tags ORDER BY role, tag`, func(r *StoredRole, values ...string) { r.Tags = append(r.Tags, values[0]) }},
+		} {
+			if err := s.scan(ctx, tx, link.query, func(values ...string) {
+				if i, ok := index[values[0]]; ok {
+					link.add(&roles[i], values[1:]...)
+				}
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return roles, version, err
+}
+
+func (s *SQLRoleStore) SaveRole(ctx context.Context, role StoredRole) error {
+	return s.tx(ctx, func(tx *sql.Tx) error {
+		if err := s.deleteRole(ctx, tx, role.Name, false); err != nil {
+			return err
+		}
+		if err := s.exec(ctx, tx, `INSERT INTO rbac_roles (name, matching) VALUES (?, ?)`, role.Name, string(role.PermissionMatching)); err != nil {
+			return err
+		}
+		for _, permission := range role.Permissions {
+			if err := s.exec(ctx, tx, `INSERT INTO rbac_role_permissions (role, permission, matching) VALUES (?, ?, ?)`, role.Name, permission, string(role.Matching[permission])); err != nil {
+				return err
+			}
+		}
+		for _, child := range role.Children {
+			if err := s.exec(ctx, tx, `INSERT INTO rbac_role_children (role, child) VALUES (?, ?)`, role.Name, child); err != nil {
+				return err
+			}
+		}
+		for _, tag := range role.Tags {
+			if err := s.exec(ctx, tx, `INSERT INTO rbac_role_tags (role, tag) VALUES (?, ?)`, role.Name, tag); err != nil {
+				return err
+			}
+		}
+		return s.exec(ctx, tx, `UPDATE rbac_version SET version = version + 1`)
+	})
+}
+
+// addStoredPermission adds a permission row, an empty matching meaning the
+// one of the role.
+func addStoredPermission(r *StoredRole, values ...string) {
+	r.Permissions = append(r.Permissions, values[0])
+	if values[1] == "" {
+		return
+	}
+	if r.Matching == nil {
+		r.Matching = map[string]PermissionMatching{}
+	}
+	r.Matching[values[0]] = PermissionMatching(values[1])
+}
+
+func (s *SQLRoleStore) DeleteRole(ctx context.Context, name string) error {
+	return s.tx(ctx, func(tx *sql.Tx) error {
+		if err := s.deleteRole(ctx, tx, name, true); err != nil {
+			return err
+		}
+		return s.exec(ctx, tx, `UPDATE rbac_version SET version = version + 1`)
+	})
+}
+
+func (s *SQLRoleStore) Wait(ctx context.Context, version uint64) error {
+	ticker := time.NewTicker(s.poll)
+	defer ticker.Stop()
+
+	for {
+		var current uint64
+		if err := s.db.QueryRowContext(ctx, `SELECT version FROM rbac_version`).Scan(&current); err != nil {
+			return err
+		}
+		if current > version {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *SQLRoleStore) deleteRole(ctx context.Context, tx *sql.Tx, name string, detach bool) error {
+	stmts := []string{
+		`DELETE FROM rbac_roles WHERE name = ?`,
+		`DELETE FROM rbac_role_permissions WHERE role = ?`,
+		`DELETE FROM rbac_role_children WHERE role = ?`,
+		`DELETE FROM rbac_role_tags WHERE role = ?`,
+	}
+	if detach {
+		stmts = append(stmts, `DELETE FROM rbac_role_children WHERE child = ?`)
+	}
+	for _, stmt := range stmts {
+		if err := s.exec(ctx, tx, stmt, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLRoleStore) exec(ctx context.Context, tx *sql.Tx, query string, args ...any) error {
+	_, err := tx.ExecContext(ctx, s.rebind(query), args...)
+	return err
+}
+
+func (s *SQLRoleStore) scan(ctx context.Context, tx *sql.Tx, query string, fn func(values ...string), args ...any) error {
+	rows, err := tx.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	values := make([]string, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err = rows.Scan(dest...); err != nil {
+			return err
+		}
+		fn(values...)
+	}
+	return rows.Err()
+}
+
+func (s *SQLRoleStore) tx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// rebind rewrites "?" placeholders for the dialect.
+func (s *SQLRoleStore) rebind(query string) string {
+	if s.dialect != SQLPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
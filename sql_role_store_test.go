@@ -0,0 +1,272 @@
+package rbac
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSQL is a database/sql driver understanding just the statements issued
+// by SQLRoleStore.
+type fakeSQL struct {
+	mu      sync.Mutex
+	tables  map[string]*fakeTable
+	queries []string
+}
+
+type fakeTable struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+var (
+	fakeCreate = regexp.MustCompile(`^CREATE TABLE (?:IF NOT EXISTS )?(\w+) \((.*)\)$`)
+	fakeInsert = regexp.MustCompile(`^INSERT INTO (\w+) \(([^)]*)\) VALUES \(([^)]*)\)$`)
+	fakeAlter  = regexp.MustCompile(`^ALTER TABLE (\w+) ADD COLUMN (\w+) .* DEFAULT (\d+|'[^']*')$`)
+	fakeDelete = regexp.MustCompile(`^DELETE FROM (\w+) WHERE (.*)$`)
+	fakeSelect = regexp.MustCompile(`^SELECT (.*?) FROM (\w+)(?: WHERE (.*?))?(?: ORDER BY .*)?$`)
+)
+
+func newFakeSQL() (*fakeSQL, *sql.DB) {
+	f := &fakeSQL{tables: map[string]*fakeTable{}}
+	return f, sql.OpenDB(f)
+}
+
+func (f *fakeSQL) Connect(context.Context) (driver.Conn, error) { return f, nil }
+func (f *fakeSQL) Driver() driver.Driver                        { return nil }
+func (f *fakeSQL) Prepare(string) (driver.Stmt, error)          { return nil, driver.ErrSkip }
+func (f *fakeSQL) Close() error                                 { return nil }
+func (f *fakeSQL) Begin() (driver.Tx, error)                    { return f, nil }
+func (f *fakeSQL) Commit() error                                { return nil }
+func (f *fakeSQL) Rollback() error                              { return nil }
+
+func (f *fakeSQL) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries = append(f.queries, query)
+
+	switch {
+	case strings.HasPrefix(query, "CREATE INDEX"):
+	case fakeCreate.MatchString(query):
+		m := fakeCreate.FindStringSubmatch(query)
+		if _, ok := f.tables[m[1]]; ok {
+			if strings.Contains(query, "IF NOT EXISTS") {
+				break
+			}
+			return nil, errors.New("table exists: " + m[1])
+		}
+		table := &fakeTable{}
+		for def := range strings.SplitSeq(m[2], ", ") {
+			name := strings.Fields(def)[0]
+			if name == "PRIMARY" {
+				break
+			}
+			table.columns = append(table.columns, name)
+		}
+		f.tables[m[1]] = table
+	case fakeAlter.MatchString(query):
+		m := fakeAlter.FindStringSubmatch(query)
+		table := f.tables[m[1]]
+		table.columns = append(table.columns, m[2])
+		var value driver.Value = strings.Trim(m[3], "'")
+		if n, err := strconv.ParseInt(m[3], 10, 64); err == nil {
+			value = n
+		}
+		for i := range table.rows {
+			table.rows[i] = append(table.rows[i], value)
+		}
+	case fakeInsert.MatchString(query):
+		m := fakeInsert.FindStringSubmatch(query)
+		var row []driver.Value
+		next := 0
+		for token := range strings.SplitSeq(m[3], ", ") {
+			switch {
+			case token == "?":
+				row = append(row, args[next].Value)
+				next++
+			case strings.HasPrefix(token, "$"):
+				n, _ := strconv.Atoi(token[1:])
+				row = append(row, args[n-1].Value)
+			default:
+				n, _ := strconv.ParseInt(token, 10, 64)
+				row = append(row, n)
+			}
+		}
+		f.tables[m[1]].rows = append(f.tables[m[1]].rows, row)
+	case fakeDelete.MatchString(query):
+		m := fakeDelete.FindStringSubmatch(query)
+		table := f.tables[m[1]]
+		n := len(table.rows)
+		table.rows = slices.DeleteFunc(table.rows, table.where(m[2], args))
+		return driver.RowsAffected(n - len(table.rows)), nil
+	case query == `UPDATE rbac_version SET version = version + 1`:
+		row := f.tables["rbac_version"].rows[0]
+		row[0] = row[0].(int64) + 1
+	default:
+		return nil, errors.New("unsupported statement: " + query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (f *fakeSQL) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries = append(f.queries, query)
+
+	if query == `SELECT COALESCE(MAX(version), 0) FROM rbac_schema_migrations` {
+		var max int64
+		for _, row := range f.tables["rbac_schema_migrations"].rows {
+			max = row[0].(int64)
+		}
+		return &fakeRows{columns: []string{"max"}, rows: [][]driver.Value{{max}}}, nil
+	}
+
+	m := fakeSelect.FindStringSubmatch(query)
+	if m == nil {
+		return nil, errors.New("unsupported query: " + query)
+	}
+	table, ok := f.tables[m[2]]
+	if !ok {
+		return nil, errors.New("no such table: " + m[2])
+	}
+	columns := strings.Split(m[1], ", ")
+	match := func([]driver.Value) bool { return true }
+	if m[3] != "" {
+		match = table.where(m[3], args)
+	}
+	rows := make([][]driver.Value, 0, len(table.rows))
+	for _, row := range table.rows {
+		if !match(row) {
+			continue
+		}
+		projected := make([]driver.Value, len(columns))
+		for i, column := range columns {
+			projected[i] = row[slices.Index(table.columns, column)]
+		}
+		rows = append(rows, projected)
+	}
+	slices.SortStableFunc(rows, func(a, b []driver.Value) int {
+		for i := range a {
+			if c := strings.Compare(toString(a[i]), toString(b[i])); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	return &fakeRows{columns: columns, rows: rows}, nil
+}
+
+// where matches rows against "column = ? AND ..." conditions, also
+// understanding "<=" and ">" as well as integer literals.
+func (t *fakeTable) where(clause string, args []driver.NamedValue) func([]driver.Value) bool {
+	type condition struct {
+		column int
+		op     string
+		value  driver.Value
+	}
+	var conditions []condition
+	next := 0
+	for cond := range strings.SplitSeq(clause, " AND ") {
+		fields := strings.Fields(cond)
+		c := condition{column: slices.Index(t.columns, fields[0]), op: fields[1]}
+		switch token := fields[2]; {
+		case token == "?":
+			c.value = args[next].Value
+			next++
+		case strings.HasPrefix(token, "$"):
+			n, _ := strconv.Atoi(token[1:])
+			c.value = args[n-1].Value
+		default:
+			c.value, _ = strconv.ParseInt(token, 10, 64)
+		}
+		conditions = append(conditions, c)
+	}
+	return func(row []driver.Value) bool {
+		for _, c := range conditions {
+			switch c.op {
+			case "=":
+				if row[c.column] != c.value {
+					return false
+				}
+			case "<=":
+				if row[c.column].(int64) > c.value.(int64) {
+					return false
+				}
+			case ">":
+				if row[c.column].(int64) <= c.value.(int64) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+}
+
+func toString(v driver.Value) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return strconv.FormatInt(v.(int64), 10)
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestSQLRoleStore(t *testing.T) {
+	fake, db := newFakeSQL()
+	store := NewSQLRoleStore(db, SQLMySQL)
+	require.NoError(t, store.Migrate(context.Background()))
+	require.NoError(t, store.Migrate(context.Background()))
+
+	testRoleStoreRoundTrip(t, store)
+	assert.Contains(t, fake.queries, `DELETE FROM rbac_role_children WHERE child = ?`)
+}
+
+func TestSQLRoleStore_Postgres(t *testing.T) {
+	fake, db := newFakeSQL()
+	store := NewSQLRoleStore(db, SQLPostgres)
+	require.NoError(t, store.Migrate(context.Background()))
+	require.NoError(t, store.SaveRole(context.Background(), StoredRole{Name: "admin", Permissions: []string{"*"}}))
+
+	assert.Contains(t, fake.queries, `INSERT INTO rbac_role_permissions (role, permission, matching) VALUES ($1, $2, $3)`)
+	assert.Contains(t, fake.queries, `INSERT INTO rbac_schema_migrations (version) VALUES ($1)`)
+}
+
+func TestSQLRoleStore_Wait(t *testing.T) {
+	_, db := newFakeSQL()
+	store := NewSQLRoleStore(db, SQLMySQL).SetPollInterval(time.Millisecond)
+	require.NoError(t, store.Migrate(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, store.Wait(ctx, 0), context.DeadlineExceeded)
+
+	require.NoError(t, store.SaveRole(context.Background(), StoredRole{Name: "admin"}))
+	assert.NoError(t, store.Wait(context.Background(), 0))
+}
@@ -0,0 +1,39 @@
+package rbac
+
+import "slices"
+
+var (
+	_ Subject    = (*StaticSubject)(nil)
+	_ Identifier = (*StaticSubject)(nil)
+	_ Subject    = SubjectFunc(nil)
+)
+
+// StaticSubject is a Subject with a fixed identifier and roles.
+type StaticSubject struct {
+	id    string
+	roles []string
+}
+
+func NewSubject(id string, roles ...string) *StaticSubject {
+	return &StaticSubject{id: id, roles: roles}
+}
+
+func (s *StaticSubject) Identifier() string {
+	return s.id
+}
+
+func (s *StaticSubject) Roles() []string {
+	return s.roles
+}
+
+// WithRoles returns a copy of the subject with additional roles.
+func (s *StaticSubject) WithRoles(roles ...string) *StaticSubject {
+	return &StaticSubject{id: s.id, roles: append(slices.Clip(s.roles), roles...)}
+}
+
+// SubjectFunc adapts a function resolving roles lazily to the Subject interface.
+type SubjectFunc func() []string
+
+func (f SubjectFunc) Roles() []string {
+	return f()
+}
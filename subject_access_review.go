@@ -0,0 +1,164 @@
+package rbac
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+var _ http.Handler = (*SubjectAccessReviewHandler)(nil)
+
+// SubjectAccessReview is the authorization.k8s.io/v1 object exchanged with
+// the Kubernetes API server in webhook authorization mode.
+type SubjectAccessReview struct {
+	APIVersion string                    `json:"apiVersion"`
+	Kind       string                    `json:"kind"`
+	Spec       SubjectAccessReviewSpec   `json:"spec"`
+	Status     SubjectAccessReviewStatus `json:"status"`
+}
+
+type SubjectAccessReviewSpec struct {
+	ResourceAttributes    *ResourceAttributes    `json:"resourceAttributes,omitempty"`
+	NonResourceAttributes *NonResourceAttributes `json:"nonResourceAttributes,omitempty"`
+	User                  string                 `json:"user,omitempty"`
+	Groups                []string               `json:"groups,omitempty"`
+	Extra                 map[string][]string    `json:"extra,omitempty"`
+	UID                   string                 `json:"uid,omitempty"`
+}
+
+type ResourceAttributes struct {
+	Namespace   string `json:"namespace,omitempty"`
+	Verb        string `json:"verb,omitempty"`
+	Group       string `json:"group,omitempty"`
+	Version     string `json:"version,omitempty"`
+	Resource    string `json:"resource,omitempty"`
+	Subresource string `json:"subresource,omitempty"`
+	Name        string `json:"name,omitempty"`
+}
+
+type NonResourceAttributes struct {
+	Path string `json:"path,omitempty"`
+	Verb string `json:"verb,omitempty"`
+}
+
+type SubjectAccessReviewStatus struct {
+	Allowed         bool   `json:"allowed"`
+	Denied          bool   `json:"denied,omitempty"`
+	Reason          string `json:"reason,omitempty"`
+	EvaluationError string `json:"evaluationError,omitempty"`
+}
+
+// SubjectAccessReviewHandler answers SubjectAccessReviews with an
+// Authorizer. Groups become roles unless a mapping is set.
+type SubjectAccessReviewHandler struct {
+	authorizer Authorizer
+	roles      func(user string, groups []string) []string
+	actions    func(spec SubjectAccessReviewSpec) []string
+	deny       bool
+}
+
+func NewSubjectAccessReviewHandler(authorizer Authorizer) *SubjectAccessReviewHandler {
+	return &SubjectAccessReviewHandler{
+		authorizer: authorizer,
+		roles: func(_ string, groups []string) []string {
+			return groups
+		},
+		actions: SubjectAccessReviewActions,
+	}
+}
+
+func (h *SubjectAccessReviewHandler) SetRoles(roles func(user string, groups []string) []string) *SubjectAccessReviewHandler {
+	h.roles = roles
+	return h
+}
+
+// SetGroupRoles maps groups onto roles, unmapped groups grant no role.
+func (h *SubjectAccessReviewHandler) SetGroupRoles(mapping map[string][]string) *SubjectAccessReviewHandler {
+	return h.SetRoles(func(_ string, groups []string) []string {
+		var roles []string
+		for _, group := range groups {
+			roles = append(roles, mapping[group]...)
+		}
+		return roles
+	})
+}
+
+func (h *SubjectAccessReviewHandler) SetActions(actions func(spec SubjectAccessReviewSpec) []string) *SubjectAccessReviewHandler {
+	h.actions = actions
+	return h
+}
+
+// SetDeny reports denials as explicit, which stops the API server from
+// consulting further authorizers. Abstentions never deny explicitly.
+func (h *SubjectAccessReviewHandler) SetDeny(deny bool) *SubjectAccessReviewHandler {
+	h.deny = deny
+	return h
+}
+
+func (h *SubjectAccessReviewHandler) Review(r *http.Request, review SubjectAccessReview) SubjectAccessReview {
+	spec := review.Spec
+	review.Status = SubjectAccessReviewStatus{}
+
+	claims := &Claims{
+		Subject:  NewSubject(spec.User, h.roles(spec.User, spec.Groups)...),
+		Metadata: map[string]any{"groups": spec.Groups, "extra": spec.Extra, "uid": spec.UID},
+	}
+	ctx := WithClaims(r.Context(), claims)
+
+	d := DecisionDeny
+	target := &Target{}
+	for _, action := range h.actions(spec) {
+		target.Action = action
+		if d = h.authorizer.Authorize(ctx, claims, target); d.Allowed() {
+			review.Status.Allowed = true
+			review.Status.Reason = "allowed by rbac policy"
+			return review
+		}
+	}
+
+	review.Status.Denied = h.deny && d != DecisionAbstain
+	review.Status.Reason = "no rbac policy grants the request"
+	return review
+}
+
+func (h *SubjectAccessReviewHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var review SubjectAccessReview
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	review = h.Review(r, review)
+	if review.APIVersion == "" {
+		review.APIVersion = "authorization.k8s.io/v1"
+	}
+	review.Kind = "SubjectAccessReview"
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(review)
+}
+
+// SubjectAccessReviewActions maps resource requests onto
+// "verb group/resource/subresource" and "verb namespace:group/resource/subresource",
+// with the group omitted for the core API group, and non-resource requests
+// onto "verb /path".
+func SubjectAccessReviewActions(spec SubjectAccessReviewSpec) []string {
+	if attrs := spec.ResourceAttributes; attrs != nil {
+		resource := attrs.Resource
+		if attrs.Group != "" {
+			resource = attrs.Group + "/" + resource
+		}
+		if attrs.Subresource != "" {
+			resource += "/" + attrs.Subresource
+		}
+		actions := []string{attrs.Verb + " " + resource}
+		if attrs.Namespace != "" {
+			actions = append(actions, attrs.Verb+" "+attrs.Namespace+":"+resource)
+		}
+		return actions
+	}
+	if attrs := spec.NonResourceAttributes; attrs != nil {
+		return []string{attrs.Verb + " " + strings.TrimSpace(attrs.Path)}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package rbac
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubjectAccessReviewActions(t *testing.T) {
+	assert.Equal(t, []string{"get apps/deployments/scale", "get prod:apps/deployments/scale"}, SubjectAccessReviewActions(SubjectAccessReviewSpec{
+		ResourceAttributes: &ResourceAttributes{Namespace: "prod", Verb: "get", Group: "apps", Resource: "deployments", Subresource: "scale"},
+	}))
+	assert.Equal(t, []string{"list pods"}, SubjectAccessReviewActions(SubjectAccessReviewSpec{
+		ResourceAttributes: &ResourceAttributes{Verb: "list", Resource: "pods"},
+	}))
+	assert.Equal(t, []string{"get /healthz"}, SubjectAccessReviewActions(SubjectAccessReviewSpec{
+		NonResourceAttributes: &NonResourceAttributes{Verb: "get", Path: "/healthz"},
+	}))
+	assert.Nil(t, SubjectAccessReviewActions(SubjectAccessReviewSpec{}))
+}
+
+func TestSubjectAccessReviewHandler(t *testing.T) {
+	rbac := New()
+	ops := NewRole("ops")
+	require.NoError(t, ops.AddPermissionsE("get prod:apps/deployments", "get /healthz"))
+	require.NoError(t, rbac.AddRole(ops))
+
+	h := NewSubjectAccessReviewHandler(NewDefaultAuthorizer(rbac))
+
+	review := func(body string) SubjectAccessReview {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/authorize", strings.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code)
+		var res SubjectAccessReview
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		return res
+	}
+
+	res := review(`{"apiVersion":"authorization.k8s.io/v1","kind":"SubjectAccessReview","spec":{
+		"resourceAttributes":{"namespace":"prod","verb":"get","group":"apps","resource":"deployments"},
+		"user":"jane","groups":["ops"]}}`)
+	assert.Equal(t, "authorization.k8s.io/v1", res.APIVersion)
+	assert.Equal(t, "SubjectAccessReview", res.Kind)
+	assert.True(t, res.Status.Allowed)
+
+	deniedBody := `{"spec":{"resourceAttributes":{"namespace":"dev","verb":"delete","resource":"pods"},"user":"jane","groups":["ops"]}}`
+	res = review(deniedBody)
+	assert.False(t, res.Status.Allowed)
+	assert.False(t, res.Status.Denied)
+
+	h.SetDeny(true)
+	res = review(deniedBody)
+	assert.True(t, res.Status.Denied)
+
+	h.SetGroupRoles(map[string][]string{"system:masters": {"ops"}})
+	res = review(`{"spec":{"nonResourceAttributes":{"path":"/healthz","verb":"get"},"user":"admin","groups":["system:masters"]}}`)
+	assert.True(t, res.Status.Allowed)
+	res = review(`{"spec":{"nonResourceAttributes":{"path":"/healthz","verb":"get"},"user":"jane","groups":["ops"]}}`)
+	assert.False(t, res.Status.Allowed)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/authorize", strings.NewReader("{")))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSubjectAccessReviewHandler_Abstain(t *testing.T) {
+	h := NewSubjectAccessReviewHandler(&mockAuthorizer{decision: DecisionAbstain}).SetDeny(true)
+	res := h.Review(httptest.NewRequest(http.MethodPost, "/", nil), SubjectAccessReview{Spec: SubjectAccessReviewSpec{
+		NonResourceAttributes: &NonResourceAttributes{Path: "/metrics", Verb: "get"},
+	}})
+	assert.False(t, res.Status.Allowed)
+	assert.False(t, res.Status.Denied)
+}
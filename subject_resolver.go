@@ -0,0 +1,112 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+var ErrSubjectNotFound = errors.New("subject not found")
+
+// SubjectResolver looks up the subject, i.e. its roles, behind an
+// identifier in an external source such as a database or an IdP.
+type SubjectResolver interface {
+	Resolve(ctx context.Context, identifier string) (Subject, error)
+}
+
+type SubjectResolverFunc func(ctx context.Context, identifier string) (Subject, error)
+
+func (f SubjectResolverFunc) Resolve(ctx context.Context, identifier string) (Subject, error) {
+	return f(ctx, identifier)
+}
+
+type subjectCacheKey struct{}
+
+type subjectCache struct {
+	mu       sync.Mutex
+	subjects map[string]Subject
+}
+
+// WithSubjectCache opts the request into caching resolved subjects, so every
+// ResolveSubject call of the request hits the resolver once per identifier.
+// A cache already present in ctx is kept.
+func WithSubjectCache(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(subjectCacheKey{}).(*subjectCache); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, subjectCacheKey{}, &subjectCache{subjects: map[string]Subject{}})
+}
+
+// ResolveSubject resolves the identifier, consulting the request's subject
+// cache if there is one. The cache is keyed by identifier alone, a request
+// is expected to use a single resolver. Errors are not cached.
+func ResolveSubject(ctx context.Context, resolver SubjectResolver, identifier string) (Subject, error) {
+	cache, _ := ctx.Value(subjectCacheKey{}).(*subjectCache)
+	if cache == nil {
+		return resolver.Resolve(ctx, identifier)
+	}
+	cache.mu.Lock()
+	subject, ok := cache.subjects[identifier]
+	cache.mu.Unlock()
+	if ok {
+		return subject, nil
+	}
+
+	subject, err := resolver.Resolve(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.mu.Lock()
+	cache.subjects[identifier] = subject
+	cache.mu.Unlock()
+
+	return subject, nil
+}
+
+// SubjectResolverMiddleware replaces the subject and actor of the request
+// claims with the ones resolved from their identifiers. Requests without
+// claims or identifiers pass through, unknown subjects are rejected with 401
+// and resolver failures with 500.
+func SubjectResolverMiddleware(resolver SubjectResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := CtxClaims(r.Context())
+			if claims == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			resolved := &Claims{Subject: claims.Subject, Actor: claims.Actor, Metadata: claims.Metadata}
+			err := resolveClaimsSubject(r.Context(), resolver, &resolved.Subject)
+			if err == nil {
+				err = resolveClaimsSubject(r.Context(), resolver, &resolved.Actor)
+			}
+			switch {
+			case errors.Is(err, ErrSubjectNotFound):
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			case err != nil:
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			default:
+				next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), resolved)))
+			}
+		})
+	}
+}
+
+func resolveClaimsSubject(ctx context.Context, resolver SubjectResolver, subject *Subject) error {
+	if *subject == nil {
+		return nil
+	}
+	id := SubjectID(*subject)
+	if id == "" {
+		return nil
+	}
+	resolved, err := ResolveSubject(ctx, resolver, id)
+	if err != nil {
+		return err
+	}
+	*subject = resolved
+	return nil
+}
@@ -0,0 +1,86 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestResolver(calls *int) SubjectResolver {
+	return SubjectResolverFunc(func(_ context.Context, id string) (Subject, error) {
+		*calls++
+		switch id {
+		case "u1":
+			return NewSubject(id, "admin"), nil
+		case "boom":
+			return nil, errors.New("db down")
+		default:
+			return nil, fmt.Errorf(`%w: "%s"`, ErrSubjectNotFound, id)
+		}
+	})
+}
+
+func TestResolveSubject_Cache(t *testing.T) {
+	var calls int
+	resolver := newTestResolver(&calls)
+
+	for range 2 {
+		subject, err := ResolveSubject(context.Background(), resolver, "u1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admin"}, subject.Roles())
+	}
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+	ctx := WithSubjectCache(context.Background())
+	assert.Equal(t, ctx, WithSubjectCache(ctx))
+	for range 2 {
+		_, err := ResolveSubject(ctx, resolver, "u1")
+		require.NoError(t, err)
+		_, err = ResolveSubject(ctx, resolver, "missing")
+		assert.ErrorIs(t, err, ErrSubjectNotFound)
+	}
+	assert.Equal(t, 3, calls)
+}
+
+func TestSubjectResolverMiddleware(t *testing.T) {
+	var calls int
+	var claims *Claims
+	h := SubjectResolverMiddleware(newTestResolver(&calls))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims = CtxClaims(r.Context())
+	}))
+
+	serve := func(c *Claims) int {
+		claims = nil
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if c != nil {
+			r = r.WithContext(WithClaims(r.Context(), c))
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	original := &Claims{Subject: NewSubject("u1"), Actor: NewSubject("u1"), Metadata: map[string]any{"k": "v"}}
+	assert.Equal(t, http.StatusOK, serve(original))
+	require.NotNil(t, claims)
+	assert.Equal(t, []string{"admin"}, claims.Subject.Roles())
+	assert.Equal(t, []string{"admin"}, claims.Actor.Roles())
+	assert.Equal(t, "v", claims.Metadata["k"])
+	assert.Empty(t, original.Subject.Roles())
+
+	assert.Equal(t, http.StatusOK, serve(nil))
+	assert.Nil(t, claims)
+
+	assert.Equal(t, http.StatusOK, serve(&Claims{Subject: NewSubject("", "guest")}))
+	assert.Equal(t, []string{"guest"}, claims.Subject.Roles())
+
+	assert.Equal(t, http.StatusUnauthorized, serve(&Claims{Subject: NewSubject("missing")}))
+	assert.Equal(t, http.StatusInternalServerError, serve(&Claims{Subject: NewSubject("boom")}))
+}
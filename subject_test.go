@@ -0,0 +1,32 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSubject(t *testing.T) {
+	s := NewSubject("u1", "admin", "user")
+	assert.Equal(t, "u1", s.Identifier())
+	assert.Equal(t, []string{"admin", "user"}, s.Roles())
+	assert.Equal(t, "u1", SubjectID(s))
+
+	more := s.WithRoles("ops")
+	assert.Equal(t, []string{"admin", "user", "ops"}, more.Roles())
+	assert.Equal(t, []string{"admin", "user"}, s.Roles())
+	assert.Equal(t, "u1", more.Identifier())
+
+	assert.Empty(t, NewSubject("anonymous").Roles())
+}
+
+func TestSubjectFunc(t *testing.T) {
+	calls := 0
+	s := SubjectFunc(func() []string {
+		calls++
+		return []string{"user"}
+	})
+	assert.Equal(t, []string{"user"}, s.Roles())
+	assert.Equal(t, 1, calls)
+	assert.Empty(t, SubjectID(s))
+}
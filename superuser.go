@@ -0,0 +1,44 @@
+package rbac
+
+import "slices"
+
+// SetSuperuserRoles designates break-glass roles granted every action, also
+// to roles inheriting them. Undeclared actions are still rejected in
+// PermissionModeStrict.
+func (rbac *RBAC) SetSuperuserRoles(roles ...string) *RBAC {
+	rbac.superusers = unite(roles)
+	return rbac
+}
+
+func (rbac *RBAC) SuperuserRoles() []string {
+	return slices.Clone(rbac.superusers)
+}
+
+// SetSuperuserAssertions makes superuser roles run the assertions of the
+// check instead of skipping them.
+func (rbac *RBAC) SetSuperuserAssertions(run bool) *RBAC {
+	rbac.superuserAssertions = run
+	return rbac
+}
+
+func (rbac *RBAC) SuperuserAssertions() bool {
+	return rbac.superuserAssertions
+}
+
+// IsSuperuser reports whether the role is a superuser role or inherits one.
+func (rbac *RBAC) IsSuperuser(role string) bool {
+	r, ok := rbac.roles[role]
+	return ok && rbac.superuser(r)
+}
+
+func (rbac *RBAC) superuser(r *Role) bool {
+	for _, name := range rbac.superusers {
+		if name == r.Name() {
+			return true
+		}
+		if su, ok := rbac.roles[name]; ok && r.HasDescendant(su) {
+			return true
+		}
+	}
+	return false
+}
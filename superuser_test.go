@@ -0,0 +1,75 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRBAC_SetSuperuserRoles(t *testing.T) {
+	rbac := New().SetCreateMissingRoles(true)
+	require.NoError(t, rbac.AddRole("root"))
+	require.NoError(t, rbac.AddRole("ops"))
+	ops, root := mustRole(t, rbac, "ops"), mustRole(t, rbac, "root")
+	require.NoError(t, ops.AddChild(root))
+	require.NoError(t, rbac.AddRole("user"))
+	rbac.SetSuperuserRoles("root", "root")
+
+	ctx := context.Background()
+	assert.Equal(t, []string{"root"}, rbac.SuperuserRoles())
+	assert.True(t, rbac.IsSuperuser("root"))
+	assert.True(t, rbac.IsSuperuser("ops"))
+	assert.False(t, rbac.IsSuperuser("user"))
+	assert.False(t, rbac.IsSuperuser("missing"))
+	assert.True(t, rbac.IsGranted(ctx, "root", "anything:at:all"))
+	assert.False(t, rbac.IsGranted(ctx, "user", "anything:at:all"))
+
+	deny := AssertionFunc(func(context.Context, *Role, string) bool { return false })
+	assert.True(t, rbac.IsGranted(ctx, "root", "posts:delete", deny))
+	rbac.SetSuperuserAssertions(true)
+	assert.True(t, rbac.SuperuserAssertions())
+	assert.False(t, rbac.IsGranted(ctx, "root", "posts:delete", deny))
+	assert.True(t, rbac.IsGranted(ctx, "root", "posts:delete"))
+
+	authorizer := NewDefaultAuthorizer(rbac)
+	assert.Equal(t, DecisionAllow, authorizer.Authorize(ctx, &Claims{Subject: NewSubject("1", "user", "root")}, &Target{Action: "users:delete"}))
+
+	rbac.SetPermissionMode(PermissionModeStrict)
+	assert.False(t, rbac.IsGranted(ctx, "root", "undeclared"))
+}
+
+func TestRBAC_RenameSuperuserRole(t *testing.T) {
+	rbac := New()
+	require.NoError(t, rbac.AddRole("root"))
+	rbac.SetSuperuserRoles("root")
+
+	require.NoError(t, rbac.RenameRole("root", "admin"))
+	assert.True(t, rbac.IsSuperuser("admin"))
+	assert.Equal(t, []string{"admin"}, rbac.SuperuserRoles())
+
+	require.NoError(t, rbac.AddRole("root"))
+	assert.False(t, rbac.IsSuperuser("root"))
+	assert.False(t, rbac.IsGranted(context.Background(), "root", "anything"))
+}
+
+func TestConfig_SuperuserRoles(t *testing.T) {
+	cfg := Config{CreateMissingRoles: true, SuperuserRoles: []string{"root"}, SuperuserAssertions: true}
+	require.NoError(t, cfg.Validate())
+
+	rbac, err := NewWithConfig(cfg)
+	require.NoError(t, err)
+	assert.True(t, rbac.IsGranted(context.Background(), "root", "anything"))
+
+	exported := rbac.Export()
+	assert.Equal(t, []string{"root"}, exported.SuperuserRoles)
+	assert.True(t, exported.SuperuserAssertions)
+
+	merged, err := cfg.Merge(Config{SuperuserRoles: []string{"breakglass", "root"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"root", "breakglass"}, merged.SuperuserRoles)
+
+	assert.ErrorIs(t, Config{SuperuserRoles: []string{"root"}}.Validate(), ErrRoleNotFound)
+	assert.ErrorIs(t, New().Apply(Config{SuperuserRoles: []string{"root"}}), ErrRoleNotFound)
+}
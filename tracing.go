@@ -0,0 +1,85 @@
+package rbac
+
+import (
+	"context"
+	"time"
+)
+
+var _ Authorizer = (*TracingAuthorizer)(nil)
+
+// Tracer starts spans. An OpenTelemetry trace.Tracer fits through a thin
+// adapter, attribute values are strings, ints, bools and durations.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+type Span interface {
+	SetAttributes(attributes map[string]any)
+	AddEvent(name string, attributes map[string]any)
+	End()
+}
+
+type spanKey struct{}
+
+// TracingAuthorizer records a span per decision of the wrapped authorizer.
+// Install TraceAssertions as assertion observer to add assertions as span
+// events.
+type TracingAuthorizer struct {
+	authorizer Authorizer
+	tracer     Tracer
+	name       string
+}
+
+func NewTracingAuthorizer(authorizer Authorizer, tracer Tracer) *TracingAuthorizer {
+	return &TracingAuthorizer{authorizer: authorizer, tracer: tracer, name: "rbac.Authorize"}
+}
+
+func (a *TracingAuthorizer) SetSpanName(name string) *TracingAuthorizer {
+	a.name = name
+	return a
+}
+
+func (a *TracingAuthorizer) Authorize(ctx context.Context, claims *Claims, target *Target) Decision {
+	ctx, span := a.tracer.Start(ctx, a.name)
+	defer span.End()
+
+	var action string
+	if target != nil {
+		action = target.Action
+	}
+	var roles int
+	if claims != nil && claims.Subject != nil {
+		roles = len(claims.Subject.Roles())
+	}
+
+	started := time.Now()
+	d := a.authorizer.Authorize(context.WithValue(ctx, spanKey{}, span), claims, target)
+
+	span.SetAttributes(map[string]any{
+		"rbac.action":     action,
+		"rbac.decision":   d.String(),
+		"rbac.role_count": roles,
+		"rbac.latency":    time.Since(started),
+	})
+	return d
+}
+
+// TraceAssertions adds an event for every evaluated assertion to the span of
+// the surrounding TracingAuthorizer.
+func TraceAssertions(ctx context.Context, event AssertionEvent) {
+	span, ok := ctx.Value(spanKey{}).(Span)
+	if !ok {
+		return
+	}
+	attributes := map[string]any{
+		"rbac.assertion":  event.Name,
+		"rbac.role":       event.Role,
+		"rbac.permission": event.Permission,
+		"rbac.outcome":    event.Outcome.String(),
+		"rbac.duration":   event.Duration,
+	}
+	if event.Err != nil {
+		attributes["rbac.error"] = event.Err.Error()
+	}
+	span.AddEvent("rbac.assertion", attributes)
+}
@@ -0,0 +1,74 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSpan struct {
+	name       string
+	attributes map[string]any
+	events     []string
+	ended      bool
+}
+
+func (s *testSpan) SetAttributes(attributes map[string]any) {
+	for k, v := range attributes {
+		s.attributes[k] = v
+	}
+}
+
+func (s *testSpan) AddEvent(name string, attributes map[string]any) {
+	s.events = append(s.events, name+":"+attributes["rbac.outcome"].(string))
+}
+
+func (s *testSpan) End() {
+	s.ended = true
+}
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &testSpan{name: name, attributes: map[string]any{}}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func TestTracingAuthorizer(t *testing.T) {
+	rbac := New().SetAssertionObserver(TraceAssertions)
+	role := NewRole("editor")
+	require.NoError(t, role.AddPermissionsE("post.edit"))
+	require.NoError(t, rbac.AddRole(role))
+
+	tracer := &testTracer{}
+	a := NewTracingAuthorizer(NewDefaultAuthorizer(rbac), tracer)
+
+	d := a.Authorize(context.Background(), &Claims{Subject: NewSubject("1", "editor", "viewer")}, &Target{
+		Action:     "post.edit",
+		Assertions: []Assertion{&testAssertion{shouldPass: true}},
+	})
+	assert.Equal(t, DecisionAllow, d)
+
+	require.Len(t, tracer.spans, 1)
+	span := tracer.spans[0]
+	assert.Equal(t, "rbac.Authorize", span.name)
+	assert.True(t, span.ended)
+	assert.Equal(t, "post.edit", span.attributes["rbac.action"])
+	assert.Equal(t, "allow", span.attributes["rbac.decision"])
+	assert.Equal(t, 2, span.attributes["rbac.role_count"])
+	assert.Contains(t, span.attributes, "rbac.latency")
+	assert.Equal(t, []string{"rbac.assertion:passed"}, span.events)
+
+	a.SetSpanName("authz").Authorize(context.Background(), nil, nil)
+	require.Len(t, tracer.spans, 2)
+	assert.Equal(t, "authz", tracer.spans[1].name)
+	assert.Equal(t, "deny", tracer.spans[1].attributes["rbac.decision"])
+
+	// outside of a traced call the observer is a no-op
+	assert.True(t, rbac.IsGranted(context.Background(), "editor", "post.edit", &testAssertion{shouldPass: true}))
+}
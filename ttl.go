@@ -0,0 +1,111 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	MetadataExpiry = "exp"
+	// MetadataRoleExpiry maps roles onto the end of their grant, as
+	// time.Time or seconds since the Unix epoch.
+	MetadataRoleExpiry = "role_exp"
+	HeaderDecisionTTL  = "X-Authz-Ttl"
+)
+
+// TTLAuthorizer is implemented by authorizers able to tell how long a
+// decision stays valid. A zero duration means no hint is available.
+type TTLAuthorizer interface {
+	Authorizer
+	DecisionTTL(ctx context.Context, claims *Claims, target *Target) time.Duration
+}
+
+var _ TTLAuthorizer = (*DefaultAuthorizer)(nil)
+
+// SetMaxTTL caps decision TTL hints, e.g. to the cache policy of the service.
+func (a *DefaultAuthorizer) SetMaxTTL(ttl time.Duration) *DefaultAuthorizer {
+	a.maxTTL = ttl
+	return a
+}
+
+func (a *DefaultAuthorizer) MaxTTL() time.Duration {
+	return a.maxTTL
+}
+
+// DecisionTTL returns the time until the claims or the first role grant
+// expire, capped by MaxTTL.
+func (a *DefaultAuthorizer) DecisionTTL(_ context.Context, claims *Claims, _ *Target) time.Duration {
+	ttl := a.maxTTL
+	limit := func(exp time.Time) {
+		remaining := max(time.Until(exp), 0)
+		if ttl == 0 || remaining < ttl {
+			ttl = remaining
+		}
+	}
+	if exp, ok := ClaimsExpiry(claims); ok {
+		limit(exp)
+	}
+	if claims != nil && claims.Subject != nil {
+		for _, role := range claims.Subject.Roles() {
+			if exp, ok := RoleGrantExpiry(claims, role); ok {
+				limit(exp)
+			}
+		}
+	}
+	return ttl
+}
+
+// ClaimsExpiry reads the "exp" metadata as a time.Time or as seconds since
+// the Unix epoch.
+func ClaimsExpiry(claims *Claims) (time.Time, bool) {
+	if claims == nil {
+		return time.Time{}, false
+	}
+	return expiryTime(claims.Metadata[MetadataExpiry])
+}
+
+// RoleGrantExpiry reads the end of the role's grant from the
+// MetadataRoleExpiry metadata.
+func RoleGrantExpiry(claims *Claims, role string) (time.Time, bool) {
+	if claims == nil {
+		return time.Time{}, false
+	}
+
+	switch expiry := claims.Metadata[MetadataRoleExpiry].(type) {
+	case map[string]time.Time:
+		exp, ok := expiry[role]
+		return exp, ok
+	case map[string]any:
+		return expiryTime(expiry[role])
+	}
+	return time.Time{}, false
+}
+
+func expiryTime(v any) (time.Time, bool) {
+	switch exp := v.(type) {
+	case time.Time:
+		return exp, true
+	case int64:
+		return time.Unix(exp, 0), true
+	case int:
+		return time.Unix(int64(exp), 0), true
+	case float64:
+		return time.Unix(int64(exp), 0), true
+	case json.Number:
+		if n, err := exp.Int64(); err == nil {
+			return time.Unix(n, 0), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// WriteDecisionTTL exposes a TTL hint to clients in whole seconds.
+func WriteDecisionTTL(header http.Header, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	header.Set(HeaderDecisionTTL, strconv.FormatInt(int64(ttl/time.Second), 10))
+}
@@ -0,0 +1,52 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaimsExpiry(t *testing.T) {
+	exp := time.Unix(1700000000, 0)
+
+	for _, value := range []any{exp, int64(1700000000), 1700000000, float64(1700000000), json.Number("1700000000")} {
+		got, ok := ClaimsExpiry(&Claims{Metadata: map[string]any{MetadataExpiry: value}})
+		assert.True(t, ok)
+		assert.True(t, exp.Equal(got))
+	}
+
+	_, ok := ClaimsExpiry(&Claims{Metadata: map[string]any{MetadataExpiry: "soon"}})
+	assert.False(t, ok)
+	_, ok = ClaimsExpiry(nil)
+	assert.False(t, ok)
+}
+
+func TestDefaultAuthorizer_DecisionTTL(t *testing.T) {
+	a := NewDefaultAuthorizer(New())
+	ctx := context.Background()
+
+	assert.Zero(t, a.DecisionTTL(ctx, &Claims{}, nil))
+
+	claims := &Claims{Metadata: map[string]any{MetadataExpiry: time.Now().Add(time.Hour)}}
+	assert.InDelta(t, time.Hour, a.DecisionTTL(ctx, claims, nil), float64(time.Second))
+
+	a.SetMaxTTL(5 * time.Minute)
+	assert.Equal(t, 5*time.Minute, a.DecisionTTL(ctx, claims, nil))
+	assert.Equal(t, 5*time.Minute, a.DecisionTTL(ctx, &Claims{}, nil))
+
+	claims.Metadata[MetadataExpiry] = time.Now().Add(-time.Minute)
+	assert.Zero(t, a.DecisionTTL(ctx, claims, nil))
+}
+
+func TestWriteDecisionTTL(t *testing.T) {
+	header := http.Header{}
+	WriteDecisionTTL(header, 0)
+	assert.Empty(t, header.Get(HeaderDecisionTTL))
+
+	WriteDecisionTTL(header, 90*time.Second+time.Millisecond)
+	assert.Equal(t, "90", header.Get(HeaderDecisionTTL))
+}
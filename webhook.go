@@ -0,0 +1,168 @@
+package rbac
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	HeaderWebhookID        = "X-Rbac-Webhook-Id"
+	HeaderWebhookTimestamp = "X-Rbac-Webhook-Timestamp"
+	HeaderWebhookSignature = "X-Rbac-Webhook-Signature"
+
+	WebhookSchema = "rbac.policy.v1"
+)
+
+var ErrWebhookDelivery = errors.New("webhook delivery failed")
+
+// WebhookPayload is the JSON body posted for every policy event.
+type WebhookPayload struct {
+	Schema string `json:"schema"`
+	ID     string `json:"id"`
+	PolicyEvent
+}
+
+// WebhookDispatcher posts policy events to an endpoint. Bodies are signed
+// with HMAC-SHA256 over "<timestamp>.<body>", see VerifyWebhookSignature.
+// Pass Notify to RBAC.OnChange and run Run in its own goroutine.
+type WebhookDispatcher struct {
+	url     string
+	secret  []byte
+	client  *http.Client
+	retries int
+	backoff time.Duration
+	queue   chan PolicyEvent
+	onError func(event PolicyEvent, err error)
+	dropped atomic.Int64
+}
+
+func NewWebhookDispatcher(url string, secret []byte, queueSize int) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		url:     url,
+		secret:  secret,
+		client:  http.DefaultClient,
+		retries: 3,
+		backoff: 500 * time.Millisecond,
+		queue:   make(chan PolicyEvent, queueSize),
+	}
+}
+
+func (d *WebhookDispatcher) SetClient(client *http.Client) *WebhookDispatcher {
+	d.client = client
+	return d
+}
+
+// SetRetries sets the number of retries after a failed delivery and the
+// initial backoff, doubled after every attempt.
+func (d *WebhookDispatcher) SetRetries(retries int, backoff time.Duration) *WebhookDispatcher {
+	d.retries, d.backoff = retries, backoff
+	return d
+}
+
+func (d *WebhookDispatcher) OnError(fn func(event PolicyEvent, err error)) *WebhookDispatcher {
+	d.onError = fn
+	return d
+}
+
+// Notify queues an event without blocking; events are dropped when the queue
+// is full.
+func (d *WebhookDispatcher) Notify(event PolicyEvent) {
+	select {
+	case d.queue <- event:
+	default:
+		d.dropped.Add(1)
+	}
+}
+
+func (d *WebhookDispatcher) Dropped() int64 {
+	return d.dropped.Load()
+}
+
+// Run delivers queued events in order until ctx is done.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-d.queue:
+			if err := d.Send(ctx, event); err != nil && d.onError != nil {
+				d.onError(event, err)
+			}
+		}
+	}
+}
+
+// Send delivers a single event, retrying on transport errors, 429 and 5xx.
+func (d *WebhookDispatcher) Send(ctx context.Context, event PolicyEvent) error {
+	payload := WebhookPayload{Schema: WebhookSchema, ID: rand.Text(), PolicyEvent: event}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	backoff := d.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := d.post(ctx, payload.ID, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= d.retries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (d *WebhookDispatcher) post(ctx context.Context, id string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookID, id)
+	req.Header.Set(HeaderWebhookTimestamp, timestamp)
+	req.Header.Set(HeaderWebhookSignature, SignWebhook(d.secret, timestamp, body))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("%w: %w", ErrWebhookDelivery, err)
+	}
+	_ = res.Body.Close()
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return false, nil
+	}
+	retry = res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+	return retry, fmt.Errorf("%w: status %d", ErrWebhookDelivery, res.StatusCode)
+}
+
+func SignWebhook(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func VerifyWebhookSignature(secret []byte, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhook(secret, timestamp, body)), []byte(signature))
+}
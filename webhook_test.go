@@ -0,0 +1,90 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookDispatcher_Send(t *testing.T) {
+	secret := []byte("s3cret")
+	var attempts atomic.Int32
+	var payload WebhookPayload
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		assert.True(t, VerifyWebhookSignature(secret, r.Header.Get(HeaderWebhookTimestamp), body, r.Header.Get(HeaderWebhookSignature)))
+		assert.False(t, VerifyWebhookSignature([]byte("other"), r.Header.Get(HeaderWebhookTimestamp), body, r.Header.Get(HeaderWebhookSignature)))
+		assert.NoError(t, json.Unmarshal(body, &payload))
+		assert.Equal(t, payload.ID, r.Header.Get(HeaderWebhookID))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	d := NewWebhookDispatcher(srv.URL, secret, 1).SetRetries(2, time.Millisecond)
+	event := PolicyEvent{Type: PolicyRoleAdded, Role: "admin", Time: time.Unix(1700000000, 0).UTC()}
+	require.NoError(t, d.Send(context.Background(), event))
+
+	assert.Equal(t, int32(2), attempts.Load())
+	assert.Equal(t, WebhookSchema, payload.Schema)
+	assert.NotEmpty(t, payload.ID)
+	assert.Equal(t, event, payload.PolicyEvent)
+}
+
+func TestWebhookDispatcher_SendFailure(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		if r.URL.Path == "/bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	d := NewWebhookDispatcher(srv.URL, nil, 1).SetRetries(2, time.Millisecond)
+	assert.ErrorIs(t, d.Send(context.Background(), PolicyEvent{}), ErrWebhookDelivery)
+	assert.Equal(t, int32(3), attempts.Load())
+
+	attempts.Store(0)
+	d = NewWebhookDispatcher(srv.URL+"/bad", nil, 1).SetRetries(2, time.Millisecond)
+	assert.ErrorIs(t, d.Send(context.Background(), PolicyEvent{}), ErrWebhookDelivery)
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestWebhookDispatcher_Run(t *testing.T) {
+	received := make(chan WebhookPayload, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload WebhookPayload
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload
+	}))
+	defer srv.Close()
+
+	d := NewWebhookDispatcher(srv.URL, []byte("k"), 2)
+	rbac := New().OnChange(d.Notify)
+	require.NoError(t, rbac.AddRole("admin"))
+	require.NoError(t, rbac.AddRole("editor", "admin"))
+	require.NoError(t, rbac.AddRole("viewer"))
+	assert.Equal(t, int64(2), d.Dropped())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	assert.Equal(t, PolicyRoleAdded, (<-received).Type)
+	assert.Equal(t, PolicyChildAdded, (<-received).Type)
+}